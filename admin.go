@@ -0,0 +1,374 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"maps"
+	"net/http"
+	"slices"
+	"time"
+)
+
+// adminStatus is the operational state shown on the dashboard.
+type adminStatus struct {
+	Running    int             `json:"running"`
+	Done24h    int             `json:"done_24h"`
+	Failed24h  int             `json:"failed_24h"`
+	StageAvg   []stageAverage  `json:"stage_avg"`
+	Jobs       []job           `json:"jobs"`
+	Failures   []job           `json:"failures"`
+	Review     []job           `json:"review"`    // held for review
+	Scheduled  []queuedCommit  `json:"scheduled"` // to be published later
+	Queued     []queuedCommit  `json:"queued"`    // commits to be retried
+	Suggested  []suggestion    `json:"suggested"` // awaiting moderation
+	Today      usageSummary    `json:"today"`
+	Month      usageSummary    `json:"month"`
+	Budget     int             `json:"budget,omitempty"` // monthly token budget
+	BudgetUsed float64         `json:"budget_used,omitempty"`
+	Funnel     lifecycleFunnel `json:"funnel"`
+	Generated  time.Time       `json:"generated"`
+}
+
+type stageAverage struct {
+	Name    string        `json:"name"`
+	Average time.Duration `json:"average"`
+}
+
+// stageOrder is the order in which job stages are listed. Publishing
+// records its hooks and its steps slug, commit, and index separately.
+var stageOrder = []string{"wait", "detect-lang", "moderate", "fetch", "title", "translate", "augment", "tag", "link-check", "pre-publish", "slug", "commit", "index", "post-publish", "crosspost"}
+
+func (s *service) adminStatus() adminStatus {
+	now := time.Now()
+	st := adminStatus{
+		Today:     s.usage.summary(now.Format(time.DateOnly)),
+		Month:     s.usage.summary(now.Format("2006-01")),
+		Budget:    s.tokenBudget,
+		Generated: now,
+	}
+	if st.Budget > 0 {
+		st.BudgetUsed = float64(st.Month.Total.Tokens()) / float64(st.Budget)
+	}
+	st.Funnel, _ = s.lifecycle.funnel()
+	st.Suggested = s.suggestions.pending()
+	if s.commits != nil {
+		for _, c := range s.commits.list() {
+			c.Markdown = ""
+			if c.Attempts == 0 {
+				st.Scheduled = append(st.Scheduled, c)
+			} else {
+				st.Queued = append(st.Queued, c)
+			}
+		}
+	}
+
+	stageSum := map[string]time.Duration{}
+	stageN := map[string]int{}
+	for _, j := range s.jobs.recent() {
+		recent := now.Sub(j.Started) < 24*time.Hour
+		switch j.Status {
+		case jobRunning:
+			st.Running++
+		case jobReview:
+			st.Review = append(st.Review, j)
+		case jobDone:
+			if recent {
+				st.Done24h++
+			}
+			for _, stg := range j.Stages {
+				stageSum[stg.Name] += stg.Duration
+				stageN[stg.Name]++
+			}
+		case jobFailed:
+			if recent {
+				st.Failed24h++
+			}
+			if !j.Retried {
+				st.Failures = append(st.Failures, j)
+			}
+		}
+		if len(st.Jobs) < 50 {
+			st.Jobs = append(st.Jobs, j)
+		}
+	}
+	for _, name := range stageOrder {
+		if n := stageN[name]; n > 0 {
+			st.StageAvg = append(st.StageAvg, stageAverage{Name: name, Average: stageSum[name] / time.Duration(n)})
+		}
+	}
+	return st
+}
+
+// handleAdmin serves the operational dashboard, or its data as JSON
+// with ?format=json.
+func (s *service) handleAdmin(w http.ResponseWriter, r *http.Request) {
+	st := s.adminStatus()
+	if r.URL.Query().Get("format") == "json" {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(st)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := adminTmpl.Execute(w, st); err != nil {
+		s.log.Printf("cannot render dashboard: %v", err)
+	}
+}
+
+// requestedJob returns the job of the path of r, reporting false if
+// there is none or the caller may not see it.
+func (s *service) requestedJob(r *http.Request) (job, bool) {
+	j, ok := s.jobs.get(r.PathValue("id"))
+	return j, ok && s.canSeeJob(r.Context(), j)
+}
+
+// handleGetJob serves a job, so clients can follow the idea they posted
+// until it is published.
+func (s *service) handleGetJob(w http.ResponseWriter, r *http.Request) {
+	j, ok := s.requestedJob(r)
+	if !ok {
+		s.jsonError(w, "job not found", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct {
+		OK  bool `json:"ok"`
+		Job job  `json:"job"`
+	}{OK: true, Job: j})
+}
+
+// handleJobEvents streams a job as server-sent events, one whenever it
+// changes, until it is done or failed. Each event's data is the job, as
+// served by handleGetJob, without its request and held run. Milestones of the
+// pipeline, such as "lang-detected" or "committed", come before as
+// events of their name, whose data is the milestone.
+func (s *service) handleJobEvents(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if _, ok := s.requestedJob(r); !ok {
+		s.jsonError(w, "job not found", http.StatusNotFound)
+		return
+	}
+	// The stream lasts as long as the pipeline, beyond the server's
+	// write timeout.
+	rc := http.NewResponseController(w)
+	rc.SetWriteDeadline(time.Time{})
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+
+	var last []byte
+	var sent int // milestones
+	for {
+		updated := s.jobs.updates()
+		j, _ := s.jobs.get(id)
+		for _, e := range j.Events[sent:] {
+			data, _ := json.Marshal(e)
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Name, data)
+		}
+		sent = len(j.Events)
+		j.Request, j.Held = ideaRequest{}, nil
+		data, _ := json.Marshal(j)
+		if !bytes.Equal(data, last) {
+			fmt.Fprintf(w, "data: %s\n\n", data)
+			if err := rc.Flush(); err != nil {
+				return
+			}
+			last = data
+		}
+		// A job interrupted by shutdown resumes only after restart.
+		if j.Status != jobRunning || j.Resume {
+			return
+		}
+		select {
+		case <-updated:
+		case <-time.After(15 * time.Second):
+			fmt.Fprint(w, ": keep-alive\n\n")
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// handleRetryJob re-runs a failed job with its original request.
+func (s *service) handleRetryJob(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.requestedJob(r); !ok {
+		s.jsonError(w, "job not found or not retryable", http.StatusNotFound)
+		return
+	}
+	req, ok := s.jobs.retry(r.PathValue("id"))
+	if !ok {
+		s.jsonError(w, "job not found or not retryable", http.StatusNotFound)
+		return
+	}
+	req.requestID = requestIDFromContext(r.Context())
+	go s.processIdea(req)
+
+	// Browsers submit the dashboard form; send them back to it.
+	if r.Header.Get("Accept") != "application/json" {
+		http.Redirect(w, r, "/ideas/admin", http.StatusSeeOther)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ideaResponse{OK: true, Message: "job restarted"})
+}
+
+var adminTmpl = template.Must(template.New("admin").Funcs(template.FuncMap{
+	"ms": func(d time.Duration) string {
+		return d.Round(time.Millisecond).String()
+	},
+	"pct": func(f float64) string {
+		return fmt.Sprintf("%.1f%%", f*100)
+	},
+	"since": func(t time.Time) string {
+		return time.Since(t).Round(time.Second).String() + " ago"
+	},
+	"dur": func(j job) string {
+		return j.duration().Round(time.Millisecond).String()
+	},
+	"models": func(m map[string]tokenUsage) []string {
+		return slices.Sorted(maps.Keys(m))
+	},
+}).Parse(adminHTML))
+
+const adminHTML = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+{{if not (or .Suggested .Review)}}<meta http-equiv="refresh" content="30">{{end}}
+<title>Ideas pipeline</title>
+<style>
+body { font: 14px/1.4 system-ui, sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { text-align: left; padding: 4px 10px; border-bottom: 1px solid #eee; }
+.failed { color: #b00; }
+.running { color: #06c; }
+.num { text-align: right; font-variant-numeric: tabular-nums; }
+.suggestion { max-width: 40em; margin-bottom: 2em; }
+.review { color: #a60; }
+.suggestion input, .suggestion textarea { display: block; width: 100%; margin-bottom: 4px; font: inherit; }
+</style>
+</head>
+<body>
+<h1>Ideas pipeline</h1>
+
+<h2>Queue</h2>
+<table>
+<tr><th>Running</th><td class="num">{{.Running}}</td></tr>
+<tr><th>Published (24h)</th><td class="num">{{.Done24h}}</td></tr>
+<tr><th>Failed (24h)</th><td class="num">{{.Failed24h}}</td></tr>
+<tr><th>Captured / augmented / published / expanded</th>
+<td class="num">{{.Funnel.Captured}} / {{.Funnel.Augmented}} / {{.Funnel.Published}} / {{.Funnel.Expanded}}</td></tr>
+</table>
+
+{{with .StageAvg}}
+<h2>Average stage time</h2>
+<table>
+{{range .}}<tr><th>{{.Name}}</th><td class="num">{{ms .Average}}</td></tr>{{end}}
+</table>
+{{end}}
+
+<h2>Failures</h2>
+{{with .Failures}}
+<table>
+<tr><th>Started</th><th>Title</th><th>Error</th><th></th></tr>
+{{range .}}
+<tr>
+<td>{{since .Started}}</td><td>{{.Title}}</td><td class="failed">{{.Error}}</td>
+<td><form method="post" action="/ideas/admin/jobs/{{.ID}}/retry"><button>Retry</button></form></td>
+</tr>
+{{end}}
+</table>
+{{else}}
+<p>None.</p>
+{{end}}
+
+{{with .Scheduled}}
+<h2>Scheduled</h2>
+<table>
+<tr><th>Publish at</th><th>Title</th></tr>
+{{range .}}
+<tr><td>{{.Next.Format "2006-01-02 15:04"}}</td><td>{{.Title}}<br><small>{{.Path}}</small></td></tr>
+{{end}}
+</table>
+{{end}}
+
+{{with .Queued}}
+<h2>Queued commits</h2>
+<table>
+<tr><th>Queued</th><th>Title</th><th class="num">Attempts</th><th>Next attempt</th><th>Error</th></tr>
+{{range .}}
+<tr>
+<td>{{since .Queued}}</td><td>{{.Title}}<br><small>{{.Path}}</small></td><td class="num">{{.Attempts}}</td>
+<td>{{.Next.Format "15:04:05"}}</td><td class="failed">{{.Error}}</td>
+</tr>
+{{end}}
+</table>
+{{end}}
+
+{{with .Review}}
+<h2>In review</h2>
+{{range .}}
+<form method="post" action="/ideas/admin/jobs/{{.ID}}/approve" class="suggestion">
+<p><small>{{since .Started}}</small> {{.Preview.Title}}</p>
+<textarea name="markdown" rows="16">{{.Preview.Markdown}}</textarea>
+<button>Publish</button>
+<button formaction="/ideas/admin/jobs/{{.ID}}/discard">Discard</button>
+</form>
+{{end}}
+{{end}}
+
+{{with .Suggested}}
+<h2>Suggestions</h2>
+{{range .}}
+<form method="post" action="/ideas/admin/suggestions/{{.ID}}/approve" class="suggestion">
+<p><small>{{since .Created}}{{with .Name}} by {{.}}{{end}}</small></p>
+<input name="title" value="{{.Title}}" placeholder="Title (generated if empty)">
+<textarea name="content" rows="6">{{.Content}}</textarea>
+<button>Publish</button>
+<button name="draft" value="true">Save as draft</button>
+<button formaction="/ideas/admin/suggestions/{{.ID}}/reject">Reject</button>
+</form>
+{{end}}
+{{end}}
+
+<h2>Token usage</h2>
+<table>
+<tr><th>Model</th><th class="num">Requests today</th><th class="num">Tokens today</th><th class="num">Requests this month</th><th class="num">Tokens this month</th></tr>
+{{$today := .Today}}
+{{range $name := models .Month.ByModel}}{{$m := index $.Month.ByModel $name}}{{$d := index $today.ByModel $name}}
+<tr><td>{{$name}}</td><td class="num">{{$d.Requests}}</td><td class="num">{{$d.Tokens}}</td><td class="num">{{$m.Requests}}</td><td class="num">{{$m.Tokens}}</td></tr>
+{{end}}
+<tr><th>Total</th><th class="num">{{.Today.Total.Requests}}</th><th class="num">{{.Today.Total.Tokens}}</th><th class="num">{{.Month.Total.Requests}}</th><th class="num">{{.Month.Total.Tokens}}</th></tr>
+</table>
+{{if .Budget}}<p>Monthly budget: {{.Month.Total.Tokens}} of {{.Budget}} tokens ({{pct .BudgetUsed}}).</p>{{end}}
+
+<h2>Recent jobs</h2>
+<table>
+<tr><th>Started</th><th>Title</th><th>Status</th><th class="num">Tokens</th><th class="num">Total</th><th>Stages</th></tr>
+{{range .Jobs}}
+<tr>
+<td>{{since .Started}}</td>
+<td>{{.Title}}{{with .Path}}<br><small>{{.}}</small>{{end}}</td>
+<td class="{{.Status}}">{{.Status}}</td>
+<td class="num">{{.Tokens}}</td>
+<td class="num">{{dur .}}</td>
+<td>{{range $i, $s := .Stages}}{{if $i}}, {{end}}{{$s.Name}} {{if $s.Duration}}{{ms $s.Duration}}{{else}}…{{end}}{{end}}</td>
+</tr>
+{{end}}
+</table>
+
+<p><small>Generated {{.Generated.Format "2006-01-02 15:04:05"}}. <a href="?format=json">JSON</a></small></p>
+{{if not (or .Suggested .Review)}}<script>
+// Reload as soon as an idea is published, updated, or fails.
+new WebSocket(location.origin.replace(/^http/, "ws") + "/ideas/ws").onmessage = () => location.reload();
+</script>{{end}}
+</body>
+</html>
+`
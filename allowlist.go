@@ -0,0 +1,132 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// parseCIDRs parses a comma-separated list of networks, such as
+// "10.8.0.0/24, 192.168.1.0/24". A bare address stands for itself.
+func parseCIDRs(s string) ([]netip.Prefix, error) {
+	var nets []netip.Prefix
+	for _, item := range splitList(s) {
+		if !strings.Contains(item, "/") {
+			addr, err := netip.ParseAddr(item)
+			if err != nil {
+				return nil, fmt.Errorf("invalid address %q: %w", item, err)
+			}
+			nets = append(nets, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		p, err := netip.ParsePrefix(item)
+		if err != nil {
+			return nil, fmt.Errorf("invalid network %q: %w", item, err)
+		}
+		nets = append(nets, p.Masked())
+	}
+	return nets, nil
+}
+
+// allowCIDRs refuses requests from clients outside nets with 403, as a
+// layer in front of authentication. Public endpoints, which webhooks,
+// feed readers, and readers' suggestions reach from anywhere, are
+// exempt. No nets allow every client.
+func allowCIDRs(nets []netip.Prefix) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if len(nets) == 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if isPublic(r.URL.Path) || allowedIP(nets, readIP(r)) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			writeError(w, http.StatusForbidden, errorResponse{Message: "client address not allowed"})
+		})
+	}
+}
+
+// clientIPKey is the context key of the address of the client of a
+// request.
+type clientIPKey struct{}
+
+// realIP resolves the address of the client of every request, for the
+// allowlist, rate limits, and logs to read with readIP. Forwarding
+// headers are only taken from the proxies in trusted.
+func realIP(trusted []netip.Prefix) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip := forwardedFor(peerIP(r.RemoteAddr), r.Header.Values("X-Forwarded-For"), trusted)
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), clientIPKey{}, ip)))
+		})
+	}
+}
+
+// readIP returns the address of the client of r, as resolved by realIP,
+// or the address it connected from.
+func readIP(r *http.Request) string {
+	if ip, ok := r.Context().Value(clientIPKey{}).(string); ok {
+		return ip
+	}
+	return peerIP(r.RemoteAddr)
+}
+
+// peerIP returns the IP address of the host:port addr.
+func peerIP(addr string) string {
+	host, _, err := net.SplitHostPort(strings.TrimSpace(addr))
+	if err != nil {
+		return "unknown"
+	}
+	if a, err := netip.ParseAddr(host); err == nil {
+		return a.Unmap().String()
+	}
+	return host
+}
+
+// forwardedFor returns the address of the client of a request received
+// from peer with the X-Forwarded-For values xff. Each proxy appends the
+// address it received the request from, so the hops are followed from
+// the right only while they come from a trusted proxy; anything to the
+// left of the first untrusted hop is the client's to make up.
+func forwardedFor(peer string, xff []string, trusted []netip.Prefix) string {
+	var hops []string
+	for _, v := range xff {
+		for h := range strings.SplitSeq(v, ",") {
+			if h = strings.TrimSpace(h); h != "" {
+				hops = append(hops, h)
+			}
+		}
+	}
+	ip := peer
+	for i := len(hops) - 1; i >= 0 && allowedIP(trusted, ip); i-- {
+		addr, err := netip.ParseAddr(hops[i])
+		if err != nil {
+			break
+		}
+		ip = addr.Unmap().String()
+	}
+	return ip
+}
+
+// allowedIP reports whether ip is in one of nets.
+func allowedIP(nets []netip.Prefix, ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, p := range nets {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
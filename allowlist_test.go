@@ -0,0 +1,101 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseCIDRs(t *testing.T) {
+	nets, err := parseCIDRs("10.8.0.5/24, 192.168.1.7,fd00::/8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"10.8.0.0/24", "192.168.1.7/32", "fd00::/8"}
+	if len(nets) != len(want) {
+		t.Fatalf("parseCIDRs = %v, want %v", nets, want)
+	}
+	for i, p := range nets {
+		if p.String() != want[i] {
+			t.Errorf("nets[%d] = %v, want %s", i, p, want[i])
+		}
+	}
+	for _, s := range []string{"10.8.0.0/33", "home"} {
+		if _, err := parseCIDRs(s); err == nil {
+			t.Errorf("parseCIDRs(%q) succeeds", s)
+		}
+	}
+	if nets, err := parseCIDRs(""); err != nil || nets != nil {
+		t.Errorf("parseCIDRs(\"\") = %v, %v", nets, err)
+	}
+}
+
+func TestForwardedFor(t *testing.T) {
+	trusted, _ := parseCIDRs("172.18.0.0/16,127.0.0.1")
+	for _, tt := range []struct {
+		peer string
+		xff  []string
+		want string
+	}{
+		{"203.0.113.1", nil, "203.0.113.1"},
+		{"203.0.113.1", []string{"10.8.0.9"}, "203.0.113.1"},
+		{"172.18.0.2", nil, "172.18.0.2"},
+		{"172.18.0.2", []string{"198.51.100.7"}, "198.51.100.7"},
+		{"172.18.0.2", []string{"10.8.0.9, 198.51.100.7"}, "198.51.100.7"},
+		{"172.18.0.2", []string{"10.8.0.9, 198.51.100.7, 127.0.0.1"}, "198.51.100.7"},
+		{"172.18.0.2", []string{"10.8.0.9", "198.51.100.7"}, "198.51.100.7"},
+		{"172.18.0.2", []string{"10.8.0.9, ::ffff:198.51.100.7"}, "198.51.100.7"},
+		{"172.18.0.2", []string{"10.8.0.9, bogus"}, "172.18.0.2"},
+		{"172.18.0.2", []string{"127.0.0.1"}, "127.0.0.1"},
+	} {
+		if got := forwardedFor(tt.peer, tt.xff, trusted); got != tt.want {
+			t.Errorf("forwardedFor(%s, %q) = %s, want %s", tt.peer, tt.xff, got, tt.want)
+		}
+	}
+	if got := forwardedFor("172.18.0.2", []string{"10.8.0.9"}, nil); got != "172.18.0.2" {
+		t.Errorf("forwardedFor without trusted proxies = %s", got)
+	}
+}
+
+func TestAllowCIDRs(t *testing.T) {
+	nets, _ := parseCIDRs("10.8.0.0/24,192.168.1.7")
+	trusted, _ := parseCIDRs("172.18.0.2")
+	h := realIP(trusted)(allowCIDRs(nets)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+	for _, tt := range []struct {
+		path, remote, forwarded string
+		want                    int
+	}{
+		{"/ideas/post", "10.8.0.9:4000", "", http.StatusOK},
+		{"/ideas/post", "[::ffff:10.8.0.9]:4000", "", http.StatusOK},
+		{"/ideas/post", "192.168.1.7:4000", "", http.StatusOK},
+		{"/ideas/post", "192.168.1.8:4000", "", http.StatusForbidden},
+		{"/ideas/post", "203.0.113.1:4000", "10.8.0.9", http.StatusForbidden},
+		{"/ideas/post", "127.0.0.1:4000", "10.8.0.9, 127.0.0.1", http.StatusForbidden},
+		{"/ideas/post", "172.18.0.2:4000", "10.8.0.9", http.StatusOK},
+		{"/ideas/post", "172.18.0.2:4000", "10.8.0.9, 203.0.113.1", http.StatusForbidden},
+		{"/ideas/post", "172.18.0.2:4000", "203.0.113.1", http.StatusForbidden},
+		{"/ideas/admin", "203.0.113.1:4000", "", http.StatusForbidden},
+		{"/ideas/feed.xml", "203.0.113.1:4000", "", http.StatusOK},
+		{"/ideas/slack/events", "203.0.113.1:4000", "", http.StatusOK},
+	} {
+		r := httptest.NewRequest("GET", tt.path, nil)
+		r.RemoteAddr = tt.remote
+		if tt.forwarded != "" {
+			r.Header.Set("X-Forwarded-For", tt.forwarded)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, r)
+		if rec.Code != tt.want {
+			t.Errorf("%s from %s (%s) = %d, want %d", tt.path, tt.remote, tt.forwarded, rec.Code, tt.want)
+		}
+	}
+
+	// Without networks, every client is allowed.
+	r := httptest.NewRequest("GET", "/ideas/post", nil)
+	r.RemoteAddr = "203.0.113.1:4000"
+	rec := httptest.NewRecorder()
+	allowCIDRs(nil)(http.NotFoundHandler()).ServeHTTP(rec, r)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("without networks = %d", rec.Code)
+	}
+}
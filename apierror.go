@@ -0,0 +1,107 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// Codes of error responses, for clients to tell failures apart without
+// matching messages. They are listed in the README; keep them stable.
+const (
+	codeInvalidRequest = "invalid_request" // malformed or missing input
+	codeUnauthorized   = "unauthorized"    // no valid token or cookie
+	codeForbidden      = "forbidden"
+	codeNotFound       = "not_found"
+	codeConflict       = "conflict"        // the idea changed meanwhile
+	codeTooLarge       = "too_large"       // the body exceeds IDEAS_MAX_BODY_BYTES
+	codeSecretDetected = "secret_detected" // the idea seems to contain a credential
+	codeDuplicate      = "duplicate"       // the idea seems to repeat an earlier one
+	codeBusy           = "busy"            // the worker pool's queue is full
+	codeRateLimited    = "rate_limited"    // too many requests from the client
+	codeLLMTimeout     = "llm_timeout"
+	codeLLMFailed      = "llm_failed"
+	codeGitHubFailed   = "github_failed"
+	codeUpstreamFailed = "upstream_failed" // another service, such as X
+	codeInternal       = "internal"
+)
+
+// errorResponse is the body of every JSON error response.
+type errorResponse struct {
+	OK        bool   `json:"ok"` // always false
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	RequestID string `json:"request_id,omitempty"`
+	MaxBytes  int64  `json:"max_bytes,omitempty"` // with too_large
+
+	Duplicate *duplicateMatch `json:"duplicate,omitempty"` // with duplicate
+	Posted    []string        `json:"posted,omitempty"`    // of a thread posted partway
+}
+
+// requestError is a request refused, with the HTTP status and error to
+// respond with, for the service layer shared by the HTTP and gRPC APIs.
+type requestError struct {
+	status int
+	errorResponse
+}
+
+func (e *requestError) Error() string { return e.Message }
+
+// writeError responds with the error e and the HTTP status.
+func writeError(w http.ResponseWriter, status int, e errorResponse) {
+	e.OK = false
+	e.Code = cmp.Or(e.Code, statusErrorCode(status))
+	e.RequestID = w.Header().Get(requestIDHeader)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(e)
+}
+
+// jsonError responds with msg and the code that goes with the HTTP
+// status.
+func (s *service) jsonError(w http.ResponseWriter, msg string, status int) {
+	writeError(w, status, errorResponse{Message: msg})
+}
+
+// codeError responds with msg and a code more specific than the HTTP
+// status tells.
+func (s *service) codeError(w http.ResponseWriter, code, msg string, status int) {
+	writeError(w, status, errorResponse{Code: code, Message: msg})
+}
+
+// llmError responds that an LLM call failed with err.
+func (s *service) llmError(w http.ResponseWriter, msg string, err error) {
+	code := codeLLMFailed
+	if errors.Is(err, context.DeadlineExceeded) {
+		code = codeLLMTimeout
+	}
+	s.codeError(w, code, msg, http.StatusInternalServerError)
+}
+
+func statusErrorCode(status int) string {
+	switch status {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity, http.StatusUpgradeRequired:
+		return codeInvalidRequest
+	case http.StatusUnauthorized:
+		return codeUnauthorized
+	case http.StatusForbidden:
+		return codeForbidden
+	case http.StatusNotFound:
+		return codeNotFound
+	case http.StatusConflict:
+		return codeConflict
+	case http.StatusRequestEntityTooLarge:
+		return codeTooLarge
+	case http.StatusTooManyRequests:
+		return codeRateLimited
+	case http.StatusBadGateway:
+		return codeUpstreamFailed
+	}
+	return codeInternal
+}
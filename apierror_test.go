@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestErrorResponse(t *testing.T) {
+	s := &service{log: log.New(io.Discard, "", 0)}
+	for _, tt := range []struct {
+		name   string
+		write  func(w http.ResponseWriter)
+		status int
+		code   string
+	}{
+		{"bad request", func(w http.ResponseWriter) { s.jsonError(w, "content is required", http.StatusBadRequest) }, 400, codeInvalidRequest},
+		{"not found", func(w http.ResponseWriter) { s.jsonError(w, "job not found", http.StatusNotFound) }, 404, codeNotFound},
+		{"busy", func(w http.ResponseWriter) { s.busy(w) }, 429, codeBusy},
+		{"github", func(w http.ResponseWriter) { s.codeError(w, codeGitHubFailed, "commit failed", http.StatusBadGateway) }, 502, codeGitHubFailed},
+		{"llm timeout", func(w http.ResponseWriter) {
+			s.llmError(w, "improvement failed", fmt.Errorf("send request: %w", context.DeadlineExceeded))
+		}, 500, codeLLMTimeout},
+		{"llm", func(w http.ResponseWriter) { s.llmError(w, "improvement failed", errors.New("API returned 400")) }, 500, codeLLMFailed},
+	} {
+		rec := httptest.NewRecorder()
+		rec.Header().Set("X-Request-Id", "req-1")
+		tt.write(rec)
+		var e errorResponse
+		if err := json.NewDecoder(rec.Body).Decode(&e); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if rec.Code != tt.status || e.OK || e.Code != tt.code || e.Message == "" || e.RequestID != "req-1" {
+			t.Errorf("%s: %d %+v, want %d with code %s", tt.name, rec.Code, e, tt.status, tt.code)
+		}
+	}
+}
@@ -0,0 +1,222 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// apiKeyPrefix starts every API key, to recognize them, e.g. in leaked
+// configuration.
+const apiKeyPrefix = "idk_"
+
+// apiKeyStore keeps the long-lived API keys that machine clients, such
+// as cron jobs, bots, and shortcuts, send in the X-Api-Key header instead
+// of a login token. Only the SHA-256 of a key is stored; the key itself
+// is shown once, when created.
+type apiKeyStore struct {
+	path string
+	log  *log.Logger
+
+	mu   sync.Mutex
+	keys []*apiKey // oldest first
+}
+
+type apiKey struct {
+	ID       string    `json:"id"`
+	Name     string    `json:"name"`           // what uses it, e.g. "daily cron"
+	User     string    `json:"user,omitempty"` // who created it, on whose behalf it acts
+	Scopes   []string  `json:"scopes"`
+	Prefix   string    `json:"prefix"`         // first characters of the key, to tell keys apart
+	Hash     string    `json:"hash,omitempty"` // hex SHA-256 of the key, not served
+	Created  time.Time `json:"created"`
+	LastUsed time.Time `json:"last_used,omitzero"`
+}
+
+func newAPIKeyStore(path string, l *log.Logger) *apiKeyStore {
+	ks := &apiKeyStore{path: path, log: l}
+	if err := readJSONFile(path, &ks.keys); err != nil {
+		l.Printf("cannot load API keys: %v", err)
+	}
+	return ks
+}
+
+// save persists the store. Callers hold mu.
+func (ks *apiKeyStore) save() {
+	if err := writeJSONFile(ks.path, ks.keys); err != nil {
+		ks.log.Printf("cannot save API keys: %v", err)
+	}
+}
+
+func hashAPIKey(key string) string {
+	h := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(h[:])
+}
+
+// create makes a new key named name for user, granted scopes, and
+// returns it along with what is stored of it.
+func (ks *apiKeyStore) create(name, user string, scopes []string) (string, apiKey) {
+	var b [24]byte
+	rand.Read(b[:])
+	secret := apiKeyPrefix + hex.EncodeToString(b[:])
+	var id [8]byte
+	rand.Read(id[:])
+	k := &apiKey{
+		ID:      hex.EncodeToString(id[:]),
+		Name:    name,
+		User:    user,
+		Scopes:  scopes,
+		Prefix:  secret[:len(apiKeyPrefix)+6],
+		Hash:    hashAPIKey(secret),
+		Created: time.Now(),
+	}
+
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	ks.keys = append(ks.keys, k)
+	ks.save()
+	c := *k
+	c.Hash = ""
+	return secret, c
+}
+
+// list returns the keys, oldest first.
+func (ks *apiKeyStore) list() []apiKey {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	out := make([]apiKey, len(ks.keys))
+	for i, k := range ks.keys {
+		out[i] = *k
+		out[i].Hash = ""
+	}
+	return out
+}
+
+// revoke deletes the key id, reporting false if there is none.
+func (ks *apiKeyStore) revoke(id string) bool {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	n := len(ks.keys)
+	ks.keys = slices.DeleteFunc(ks.keys, func(k *apiKey) bool { return k.ID == id })
+	if len(ks.keys) == n {
+		return false
+	}
+	ks.save()
+	return true
+}
+
+// verify returns the stored key matching key. A nil store has no keys.
+func (ks *apiKeyStore) verify(key string) (apiKey, bool) {
+	if ks == nil || !strings.HasPrefix(key, apiKeyPrefix) {
+		return apiKey{}, false
+	}
+	h := []byte(hashAPIKey(key))
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	for _, k := range ks.keys {
+		if subtle.ConstantTimeCompare(h, []byte(k.Hash)) == 1 {
+			// Writing the store on every request is not worth it for a
+			// rough last use.
+			if now := time.Now(); now.Sub(k.LastUsed) > time.Hour {
+				k.LastUsed = now
+				ks.save()
+			}
+			return *k, true
+		}
+	}
+	return apiKey{}, false
+}
+
+// principal returns who requests made with the key act as.
+func (k apiKey) principal() principal {
+	p := principal{User: k.User, Scopes: k.Scopes, APIKey: k.ID}
+	if len(p.Scopes) == 0 {
+		// Keys made before they had scopes could post.
+		p.Scopes = []string{scopeWrite}
+	}
+	return p
+}
+
+// loginOnly refuses requests authenticated with an API key, so that a
+// key cannot be used to make or revoke keys.
+func (s *service) loginOnly(w http.ResponseWriter, r *http.Request) bool {
+	if p, _ := principalFromContext(r.Context()); p.APIKey != "" {
+		s.jsonError(w, "API keys cannot manage API keys, log in instead", http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+// handleListAPIKeys lists the API keys, without the keys themselves.
+func (s *service) handleListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	if !s.loginOnly(w, r) {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct {
+		OK   bool     `json:"ok"`
+		Keys []apiKey `json:"keys"`
+	}{OK: true, Keys: s.apiKeys.list()})
+}
+
+// handleCreateAPIKey creates an API key. The response is the only time
+// the key is shown.
+func (s *service) handleCreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	if !s.loginOnly(w, r) {
+		return
+	}
+	var req struct {
+		Name   string   `json:"name"`
+		Scopes []string `json:"scopes"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || strings.TrimSpace(req.Name) == "" {
+		s.jsonError(w, "name is required", http.StatusBadRequest)
+		return
+	}
+	if len(req.Scopes) == 0 {
+		req.Scopes = []string{scopeWrite}
+	}
+	for _, sc := range req.Scopes {
+		if sc != scopeRead && sc != scopeWrite && sc != scopeAdmin {
+			s.jsonError(w, fmt.Sprintf("unknown scope %q", sc), http.StatusBadRequest)
+			return
+		}
+	}
+	p, _ := principalFromContext(r.Context())
+	secret, k := s.apiKeys.create(strings.TrimSpace(req.Name), p.User, req.Scopes)
+	s.log.Printf("API key %s (%s) created", k.ID, k.Name)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(struct {
+		OK     bool   `json:"ok"`
+		Key    string `json:"key"`
+		APIKey apiKey `json:"api_key"`
+	}{OK: true, Key: secret, APIKey: k})
+}
+
+// handleRevokeAPIKey deletes an API key, which stops working at once.
+func (s *service) handleRevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	if !s.loginOnly(w, r) {
+		return
+	}
+	id := r.PathValue("id")
+	if !s.apiKeys.revoke(id) {
+		s.jsonError(w, "API key not found", http.StatusNotFound)
+		return
+	}
+	s.log.Printf("API key %s revoked", id)
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAPIKeys(t *testing.T) {
+	l := log.New(io.Discard, "", 0)
+	s := &service{log: l, apiKeys: newAPIKeyStore(filepath.Join(t.TempDir(), "apikeys.json"), l)}
+
+	admin := principal{User: "changkun", Scopes: []string{scopeAdmin}}
+	create := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		r := httptest.NewRequest("POST", "/ideas/admin/keys", strings.NewReader(body))
+		s.handleCreateAPIKey(rec, r.WithContext(withPrincipal(r.Context(), admin)))
+		return rec
+	}
+	if rec := create(`{"name":"bot","scopes":["ideas:everything"]}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("unknown scope: status = %d, want 400", rec.Code)
+	}
+	rec := create(`{"name":"daily cron","scopes":["ideas:admin"]}`)
+	var created struct {
+		Key    string `json:"key"`
+		APIKey apiKey `json:"api_key"`
+	}
+	json.NewDecoder(rec.Body).Decode(&created)
+	if rec.Code != http.StatusCreated || !strings.HasPrefix(created.Key, apiKeyPrefix) || created.APIKey.Hash != "" ||
+		!strings.HasPrefix(created.Key, created.APIKey.Prefix) || created.APIKey.User != "changkun" {
+		t.Fatalf("create = %d %+v", rec.Code, created)
+	}
+	if data, _ := os.ReadFile(s.apiKeys.path); strings.Contains(string(data), created.Key) || !strings.Contains(string(data), hashAPIKey(created.Key)) {
+		t.Errorf("stored keys = %s, want the hash only", data)
+	}
+
+	// The key authenticates requests, but cannot manage keys.
+	var got principal
+	h := auth(s.apiKeys, scopeDefaults{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ = principalFromContext(r.Context())
+		s.handleListAPIKeys(w, r)
+	}))
+	r := httptest.NewRequest("GET", "/ideas/admin/keys", nil)
+	r.Header.Set("X-Api-Key", created.Key)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, r)
+	if got.APIKey != created.APIKey.ID || got.User != "changkun" || rec.Code != http.StatusForbidden {
+		t.Errorf("request with the key: %d, authenticated as %+v", rec.Code, got)
+	}
+	if p, ok := s.checkAPIKey(created.Key); !ok || p.User != "changkun" || p.APIKey != created.APIKey.ID {
+		t.Errorf("checkAPIKey(key) = %+v, %v", p, ok)
+	}
+	if _, ok := s.checkAPIKey(created.Key + "x"); ok {
+		t.Error("checkAPIKey accepts a wrong key")
+	}
+	if _, ok := s.checkAPIKey(""); ok {
+		t.Error("checkAPIKey accepts no key")
+	}
+	if k := newAPIKeyStore(s.apiKeys.path, l).list(); len(k) != 1 || k[0].LastUsed.IsZero() || k[0].Hash != "" {
+		t.Errorf("listed keys = %+v", k)
+	}
+
+	rec = httptest.NewRecorder()
+	r = httptest.NewRequest("DELETE", "/ideas/admin/keys/"+created.APIKey.ID, nil)
+	r.SetPathValue("id", created.APIKey.ID)
+	s.handleRevokeAPIKey(rec, r)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("revoke = %d", rec.Code)
+	}
+	if _, ok := s.apiKeys.verify(created.Key); ok {
+		t.Error("a revoked key still verifies")
+	}
+}
@@ -0,0 +1,464 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ideaDetail is a published idea as served to clients.
+type ideaDetail struct {
+	ID         string    `json:"id"`
+	Path       string    `json:"path"`
+	SHA        string    `json:"sha"`
+	URL        string    `json:"url"`        // on the site
+	GitHubURL  string    `json:"github_url"` // of the file in the repository
+	Date       time.Time `json:"date"`
+	Slug       string    `json:"slug"`
+	Title      string    `json:"title"`
+	TitleZh    string    `json:"title_zh"`
+	Draft      bool      `json:"draft,omitempty"`
+	Tags       []string  `json:"tags,omitempty"`
+	Categories []string  `json:"categories,omitempty"`
+	// FrontMatter is the YAML between the markdown's "---" lines.
+	FrontMatter string    `json:"front_matter"`
+	Markdown    string    `json:"markdown"`             // the file as committed
+	CommitURL   string    `json:"commit_url,omitempty"` // of the last change
+	Created     time.Time `json:"created,omitzero"`     // first committed
+	Updated     time.Time `json:"updated,omitzero"`     // last changed
+}
+
+// ideaSummary is a published idea as listed.
+type ideaSummary struct {
+	ID         string    `json:"id"`
+	Path       string    `json:"path"`
+	URL        string    `json:"url"`
+	Date       time.Time `json:"date"`
+	Slug       string    `json:"slug"`
+	Title      string    `json:"title"`
+	TitleZh    string    `json:"title_zh"`
+	Languages  []string  `json:"languages"` // with text of their own
+	Draft      bool      `json:"draft,omitempty"`
+	Tags       []string  `json:"tags,omitempty"`
+	Categories []string  `json:"categories,omitempty"`
+}
+
+// ideaLanguages returns the languages an idea has text in: both if it
+// was translated, and otherwise the one detected.
+func ideaLanguages(d *indexedIdea) []string {
+	en, zh := strings.TrimSpace(d.ContentEn), strings.TrimSpace(d.ContentZh)
+	switch {
+	case en == zh:
+		return []string{detectLang(en)}
+	case en == "":
+		return []string{"zh"}
+	case zh == "":
+		return []string{"en"}
+	}
+	return []string{"en", "zh"}
+}
+
+// summarize returns the summary of an indexed idea.
+func (s *service) summarize(d *indexedIdea) ideaSummary {
+	return ideaSummary{
+		ID:         d.ID,
+		Path:       d.Path,
+		URL:        s.site.url(d.Slug),
+		Date:       d.Date,
+		Slug:       d.Slug,
+		Title:      d.Title,
+		TitleZh:    d.TitleZh,
+		Languages:  ideaLanguages(d),
+		Draft:      d.Draft,
+		Tags:       d.Tags,
+		Categories: d.Categories,
+	}
+}
+
+// handleListIdeas lists the indexed ideas, newest first, a page of limit
+// at a time, only those with ?tag if given. A page ends with the cursor
+// of the next one, the ID after which it starts, unless it is the last.
+func (s *service) handleListIdeas(w http.ResponseWriter, r *http.Request) {
+	limit := 50
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 500 {
+			s.jsonError(w, "limit must be between 1 and 500", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+	ideas, next := s.listIdeas(limit, r.URL.Query().Get("cursor"), r.URL.Query().Get("tag"))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct {
+		OK         bool          `json:"ok"`
+		Ideas      []ideaSummary `json:"ideas"`
+		NextCursor string        `json:"next_cursor,omitempty"`
+	}{OK: true, Ideas: ideas, NextCursor: next})
+}
+
+// listIdeas returns a page of limit indexed ideas, newest first, after
+// the one of ID cursor if given, only those with tag if given, and the
+// cursor of the next page, or "" if it is the last.
+func (s *service) listIdeas(limit int, cursor, tag string) ([]ideaSummary, string) {
+	ideas := []ideaSummary{}
+	for _, d := range s.index.all() {
+		// IDs start with the date, so they sort newest first.
+		if cursor != "" && d.ID >= cursor || tag != "" && !d.hasTag(tag) {
+			continue
+		}
+		if len(ideas) == limit {
+			return ideas, ideas[limit-1].ID
+		}
+		ideas = append(ideas, s.summarize(d))
+	}
+	return ideas, ""
+}
+
+// handleSearchIdeas serves the ideas matching all terms of ?q, best
+// first, each with a snippet of the text where it matches.
+func (s *service) handleSearchIdeas(w http.ResponseWriter, r *http.Request) {
+	q := strings.TrimSpace(r.URL.Query().Get("q"))
+	if q == "" {
+		s.jsonError(w, "q is required", http.StatusBadRequest)
+		return
+	}
+	limit := 10
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 50 {
+			s.jsonError(w, "limit must be between 1 and 50", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	type result struct {
+		ideaSummary
+		Score   float64 `json:"score"`
+		Snippet string  `json:"snippet"`
+	}
+	resp := struct {
+		OK      bool     `json:"ok"`
+		Results []result `json:"results"`
+	}{OK: true, Results: []result{}}
+	for _, h := range s.index.search(q, limit) {
+		d := h.Idea
+		// The snippet is from the text the terms are found in, English
+		// first, and else from the English text, for a match in the title.
+		text := d.ContentEn
+		for _, t := range []string{d.ContentEn, d.ContentZh, d.AugmentedEn, d.AugmentedZh} {
+			if matchesAny(t, q) {
+				text = t
+				break
+			}
+		}
+		resp.Results = append(resp.Results, result{
+			ideaSummary: s.summarize(d),
+			Score:       h.Score,
+			Snippet:     snippet(text, q, 160),
+		})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// matchesAny reports whether text has any of the query's terms.
+func matchesAny(text, query string) bool {
+	terms := tokenize(text)
+	for _, t := range tokenize(query) {
+		if slices.Contains(terms, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// handleGetIdea serves an idea's markdown, fetched from the repository,
+// with the metadata of the index and the file's commit history. Without
+// the history, the idea is served all the same.
+func (s *service) handleGetIdea(w http.ResponseWriter, r *http.Request) {
+	idea, err := s.getIdea(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeError(w, err.status, err.errorResponse)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct {
+		OK   bool       `json:"ok"`
+		Idea ideaDetail `json:"idea"`
+	}{OK: true, Idea: idea})
+}
+
+// getIdea returns the idea of the given ID with its markdown, fetched
+// from the repository, and its commit history, if GitHub can be asked
+// for it.
+func (s *service) getIdea(ctx context.Context, id string) (ideaDetail, *requestError) {
+	d, ok := s.index.get(id)
+	if !ok {
+		return ideaDetail{}, &requestError{http.StatusNotFound, errorResponse{Message: "idea not found"}}
+	}
+	md, sha, err := s.github.getFile(ctx, d.Path)
+	if err != nil {
+		s.log.Printf("fetch %s: %v", d.Path, err)
+		return ideaDetail{}, &requestError{http.StatusBadGateway, errorResponse{Code: codeGitHubFailed, Message: "failed to fetch the idea from the repository"}}
+	}
+	h, err := s.github.history(ctx, d.Path)
+	if err != nil {
+		s.log.Printf("history of %s: %v", d.Path, err)
+		h = &fileHistory{}
+	}
+	return s.ideaDetail(d, sha, md, h), nil
+}
+
+// handleUpdateIdea replaces an idea's markdown. The request carries the
+// blob SHA the edit started from, so a concurrent change is refused
+// instead of overwritten. With retranslate, the edited language is
+// polished and translated again, replacing the other one, as for a new
+// idea. Like a new idea, it is refused if it seems to contain a
+// credential, unless allow_secrets is set.
+func (s *service) handleUpdateIdea(w http.ResponseWriter, r *http.Request) {
+	d, ok := s.index.get(r.PathValue("id"))
+	if !ok {
+		s.jsonError(w, "idea not found", http.StatusNotFound)
+		return
+	}
+	var req struct {
+		Markdown     string `json:"markdown"`
+		SHA          string `json:"sha"`
+		Retranslate  bool   `json:"retranslate"`
+		Lang         string `json:"lang"` // edited, to retranslate from
+		AllowSecrets bool   `json:"allow_secrets"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		s.jsonError(w, "invalid JSON", http.StatusBadRequest)
+		return
+	}
+	doc, err := parseIdea(req.Markdown)
+	if err != nil {
+		s.jsonError(w, "invalid idea: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.SHA == "" {
+		req.SHA = d.SHA
+	}
+	if req.Retranslate {
+		switch req.Lang {
+		case "en", "zh":
+		case "", "auto":
+			if req.Lang = editedLang(d, doc); req.Lang == "" {
+				s.jsonError(w, `cannot tell which language was edited, set lang to "en" or "zh"`, http.StatusBadRequest)
+				return
+			}
+		default:
+			s.jsonError(w, `lang must be "en", "zh", or "auto"`, http.StatusBadRequest)
+			return
+		}
+		if s.llm != nil {
+			md, err := s.retranslate(r.Context(), req.Markdown, doc, req.Lang)
+			if err != nil {
+				s.log.Printf("retranslating %s failed, committing the edit as is: %v", d.ID, err)
+			} else {
+				req.Markdown = md
+			}
+		}
+	}
+	if err := checkSecrets(req.AllowSecrets, req.Markdown); err != nil {
+		writeError(w, err.status, err.errorResponse)
+		return
+	}
+
+	fc, err := s.github.updateFile(r.Context(), d.Path, req.Markdown, req.SHA, sanitizeCommitMsg("ideas: update "+doc.Title))
+	if errors.Is(err, errFileChanged) {
+		s.jsonError(w, "the idea changed since it was fetched", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		s.log.Printf("update %s: %v", d.Path, err)
+		s.codeError(w, codeGitHubFailed, "failed to commit the idea to the repository", http.StatusBadGateway)
+		return
+	}
+	s.writeUpdated(w, r, d, fc, req.Markdown)
+}
+
+// editedLang returns the language whose title or content an edit changed
+// from the indexed idea, or "" unless it is just one of them.
+func editedLang(d *indexedIdea, doc *ideaDoc) string {
+	en := doc.Title != d.Title || doc.ContentEn != d.ContentEn
+	zh := doc.TitleZh != d.TitleZh || doc.ContentZh != d.ContentZh
+	switch {
+	case en && !zh:
+		return "en"
+	case zh && !en:
+		return "zh"
+	}
+	return ""
+}
+
+// retranslate polishes the title and content of an idea in lang and
+// replaces those in the other language with their translation.
+func (s *service) retranslate(ctx context.Context, md string, doc *ideaDoc, lang string) (string, error) {
+	title, content := doc.Title, doc.ContentEn
+	if lang == "zh" {
+		title, content = doc.TitleZh, doc.ContentZh
+	}
+	tr, err := s.llm.detectAndTranslate(ctx, title, content, lang)
+	if err != nil {
+		return "", err
+	}
+	titleEn, titleZh := tr.PolishedTitle, tr.TranslatedTitle
+	en, zh := tr.PolishedContent, tr.TranslatedContent
+	if lang == "zh" {
+		titleEn, titleZh = titleZh, titleEn
+		en, zh = zh, en
+	}
+	md = strings.ReplaceAll(md, "\r\n", "\n")
+	if md, err = replaceContent(md, en, zh); err != nil {
+		return "", err
+	}
+	if md, err = setFrontMatter(md, "title", cmp.Or(titleEn, doc.Title)); err != nil {
+		return "", err
+	}
+	return setFrontMatter(md, "title_zh", cmp.Or(titleZh, doc.TitleZh))
+}
+
+// handleAppendIdea appends a dated follow-up to an idea, so an idea that
+// evolves over days stays in one file. Like a new idea, the follow-up is
+// polished and translated unless no_translate is set, and refused if it
+// seems to contain a credential unless allow_secrets is set.
+func (s *service) handleAppendIdea(w http.ResponseWriter, r *http.Request) {
+	d, ok := s.index.get(r.PathValue("id"))
+	if !ok {
+		s.jsonError(w, "idea not found", http.StatusNotFound)
+		return
+	}
+	var req struct {
+		Content      string `json:"content"`
+		Lang         string `json:"lang"`
+		NoTranslate  bool   `json:"no_translate"`
+		AllowSecrets bool   `json:"allow_secrets"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		s.jsonError(w, "invalid JSON", http.StatusBadRequest)
+		return
+	}
+	req.Content = strings.TrimSpace(req.Content)
+	if req.Content == "" {
+		s.jsonError(w, "content is required", http.StatusBadRequest)
+		return
+	}
+	switch req.Lang {
+	case "", "en", "zh":
+	case "auto":
+		req.Lang = ""
+	default:
+		s.jsonError(w, `lang must be "en", "zh", or "auto"`, http.StatusBadRequest)
+		return
+	}
+
+	en, zh := req.Content, req.Content
+	if s.llm != nil && !req.NoTranslate {
+		tr, err := s.llm.detectAndTranslate(r.Context(), "", req.Content, req.Lang)
+		switch {
+		case err != nil:
+			s.log.Printf("translating the follow-up of %s failed, appending it as is: %v", d.ID, err)
+		case tr.Lang == "zh":
+			en, zh = tr.TranslatedContent, tr.PolishedContent
+		default:
+			en, zh = tr.PolishedContent, tr.TranslatedContent
+		}
+	}
+
+	md, sha, err := s.github.getFile(r.Context(), d.Path)
+	if err != nil {
+		s.log.Printf("fetch %s: %v", d.Path, err)
+		s.codeError(w, codeGitHubFailed, "failed to fetch the idea from the repository", http.StatusBadGateway)
+		return
+	}
+	md, err = appendFollowUp(md, time.Now(), en, zh)
+	if err != nil {
+		s.jsonError(w, "cannot append to the idea: "+err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	if err := checkSecrets(req.AllowSecrets, md); err != nil {
+		writeError(w, err.status, err.errorResponse)
+		return
+	}
+	fc, err := s.github.updateFile(r.Context(), d.Path, md, sha, sanitizeCommitMsg("ideas: follow up on "+d.Title))
+	if errors.Is(err, errFileChanged) {
+		s.jsonError(w, "the idea changed while appending, try again", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		s.log.Printf("update %s: %v", d.Path, err)
+		s.codeError(w, codeGitHubFailed, "failed to commit the idea to the repository", http.StatusBadGateway)
+		return
+	}
+	s.writeUpdated(w, r, d, fc, md)
+}
+
+// writeUpdated indexes an idea whose new markdown was committed, has the
+// embeddings refreshed in the background, and responds with its detail.
+func (s *service) writeUpdated(w http.ResponseWriter, r *http.Request, d *indexedIdea, fc *fileCommit, md string) {
+	s.log.Printf("updated %s (commit %s)", d.Path, fc.CommitSHA)
+	noteAudit(r.Context(), func(e *auditEntry) { e.Commit = fc.CommitSHA })
+	s.index.put(d.Path, fc.SHA, md)
+	if s.llm != nil {
+		s.embeds.kick()
+	}
+	if nd, ok := s.index.get(d.ID); ok {
+		d = nd
+	}
+	s.status.broadcast(ideaStatus{
+		Type:  statusUpdated,
+		ID:    d.ID,
+		Title: d.Title,
+		Path:  d.Path,
+		URL:   s.site.url(d.Slug),
+	})
+	s.writeIdea(w, d, fc.SHA, md, &fileHistory{Updated: time.Now(), CommitURL: fc.CommitURL})
+}
+
+// writeIdea responds with an idea's detail.
+func (s *service) writeIdea(w http.ResponseWriter, d *indexedIdea, sha, md string, h *fileHistory) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct {
+		OK   bool       `json:"ok"`
+		Idea ideaDetail `json:"idea"`
+	}{OK: true, Idea: s.ideaDetail(d, sha, md, h)})
+}
+
+// ideaDetail returns the detail of an indexed idea, given its markdown
+// of blob SHA sha and its history.
+func (s *service) ideaDetail(d *indexedIdea, sha, md string, h *fileHistory) ideaDetail {
+	fm, _, _ := cutFrontMatter(md)
+	return ideaDetail{
+		ID:          d.ID,
+		Path:        d.Path,
+		SHA:         sha,
+		URL:         s.site.url(d.Slug),
+		GitHubURL:   s.github.fileURL(d.Path),
+		Date:        d.Date,
+		Slug:        d.Slug,
+		Title:       d.Title,
+		TitleZh:     d.TitleZh,
+		Draft:       d.Draft,
+		Tags:        d.Tags,
+		Categories:  d.Categories,
+		FrontMatter: fm,
+		Markdown:    md,
+		CommitURL:   h.CommitURL,
+		Created:     h.Created,
+		Updated:     h.Updated,
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"cmp"
+	"encoding/xml"
+	"html"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// atomEntries is the number of ideas in the Atom feed.
+const atomEntries = 20
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Lang    string      `xml:"http://www.w3.org/XML/1998/namespace lang,attr"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Author  atomPerson  `xml:"author"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+type atomEntry struct {
+	ID         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Published  string         `xml:"published"`
+	Updated    string         `xml:"updated"`
+	Links      []atomLink     `xml:"link"`
+	Categories []atomCategory `xml:"category"`
+	Content    atomText       `xml:"content"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomText struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+// handleFeed serves an Atom feed of the latest published ideas in ?lang,
+// English by default, so readers can follow them before the site is
+// rebuilt.
+func (s *service) handleFeed(w http.ResponseWriter, r *http.Request) {
+	lang := cmp.Or(r.URL.Query().Get("lang"), "en")
+	if lang != "en" && lang != "zh" {
+		s.jsonError(w, `lang must be "en" or "zh"`, http.StatusBadRequest)
+		return
+	}
+	scheme := cmp.Or(r.Header.Get("X-Forwarded-Proto"), "http")
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	self := scheme + "://" + r.Host + r.URL.RequestURI()
+
+	feed := atomFeed{
+		Lang:   lang,
+		ID:     self,
+		Title:  s.feedTitle,
+		Author: atomPerson{Name: s.feedAuthor},
+		Links: []atomLink{
+			{Rel: "self", Type: "application/atom+xml", Href: self},
+			{Rel: "alternate", Type: "text/html", Href: s.site.baseURL},
+		},
+	}
+	var updated time.Time
+	for _, d := range s.index.all() {
+		if len(feed.Entries) == atomEntries {
+			break
+		}
+		if d.Draft {
+			continue
+		}
+		title, content := d.Title, d.ContentEn
+		if lang == "zh" {
+			title, content = cmp.Or(d.TitleZh, d.Title), d.ContentZh
+		}
+		url := s.site.url(d.Slug)
+		e := atomEntry{
+			ID:        url + "#" + lang,
+			Title:     title,
+			Published: d.Date.Format(time.RFC3339),
+			Updated:   d.Date.Format(time.RFC3339),
+			Links:     []atomLink{{Rel: "alternate", Type: "text/html", Href: url}},
+			Content:   atomText{Type: "html", Body: textHTML(content)},
+		}
+		tags := d.Tags
+		if len(tags) == 0 {
+			tags = d.Categories
+		}
+		for _, t := range tags {
+			e.Categories = append(e.Categories, atomCategory{Term: t})
+		}
+		feed.Entries = append(feed.Entries, e)
+		if d.Date.After(updated) {
+			updated = d.Date
+		}
+	}
+	if updated.IsZero() {
+		updated = time.Now()
+	}
+	feed.Updated = updated.Format(time.RFC3339)
+
+	w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+	w.Write([]byte(xml.Header))
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(feed); err != nil {
+		s.log.Printf("feed: %v", err)
+	}
+}
+
+// textHTML turns the paragraphs of an idea's text into HTML, keeping the
+// line breaks within them.
+func textHTML(text string) string {
+	var b strings.Builder
+	for _, p := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n\n") {
+		if p = strings.TrimSpace(p); p != "" {
+			b.WriteString("<p>" + strings.ReplaceAll(html.EscapeString(p), "\n", "<br>") + "</p>")
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestHandleFeed(t *testing.T) {
+	l := log.New(io.Discard, "", 0)
+	s := &service{
+		log:        l,
+		index:      newArchiveIndex(filepath.Join(t.TempDir(), "index.json"), l),
+		site:       newSiteConfig("", "", "", ""),
+		feedTitle:  "Ideas",
+		feedAuthor: "o",
+	}
+	s.index.put("content/ideas/2025-01-01-reward.md", "sha", testIdeaMarkdown)
+	s.index.put("content/ideas/2025-01-02-draft.md", "sha", strings.Replace(testIdeaMarkdown, "---\n\n", "draft: true\n---\n\n", 1))
+
+	feed := func(query string) (*httptest.ResponseRecorder, []feedEntry) {
+		rec := httptest.NewRecorder()
+		s.handleFeed(rec, httptest.NewRequest("GET", "/ideas/feed.xml"+query, nil))
+		if rec.Code != http.StatusOK {
+			return rec, nil
+		}
+		entries, err := parseFeed(rec.Body.Bytes())
+		if err != nil {
+			t.Fatalf("%v:\n%s", err, rec.Body)
+		}
+		return rec, entries
+	}
+
+	tests := []struct {
+		query   string
+		titles  []string
+		summary string
+	}{
+		{"", []string{"Reward hacking"}, "Models exploit rewards."},
+		{"?lang=zh", []string{"Reward hacking"}, "模型利用奖励。"},
+	}
+	for _, tt := range tests {
+		rec, entries := feed(tt.query)
+		var titles []string
+		for _, e := range entries {
+			titles = append(titles, e.title)
+		}
+		if !slices.Equal(titles, tt.titles) || entries[0].summary != tt.summary || entries[0].link != "https://changkun.de/ideas/reward/" {
+			t.Errorf("feed%s = %+v", tt.query, entries)
+		}
+		if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/atom+xml") {
+			t.Errorf("Content-Type = %q", ct)
+		}
+		if !strings.Contains(rec.Body.String(), `<feed xmlns="http://www.w3.org/2005/Atom" xml:lang="`) {
+			t.Errorf("feed%s has no language:\n%s", tt.query, rec.Body)
+		}
+	}
+	if rec, _ := feed("?lang=fr"); rec.Code != http.StatusBadRequest {
+		t.Errorf("lang=fr: status = %d, want 400", rec.Code)
+	}
+}
+
+func TestTextHTML(t *testing.T) {
+	got := textHTML("First <b>line</b>\nsecond line.\r\n\r\nNext & last.\n\n\n")
+	want := "<p>First &lt;b&gt;line&lt;/b&gt;<br>second line.</p><p>Next &amp; last.</p>"
+	if got != want {
+		t.Errorf("textHTML = %q, want %q", got, want)
+	}
+}
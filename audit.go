@@ -0,0 +1,285 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// auditLog is an append-only record of the actions taken through the
+// service, kept as JSON lines. Each entry carries the hash of the one
+// before it, so that an entry edited or dropped afterwards breaks the
+// chain from there on.
+type auditLog struct {
+	path string
+	log  *log.Logger
+
+	mu   sync.Mutex
+	seq  int64  // of the last entry
+	last string // hash of the last entry
+}
+
+// auditEntry is an action: an authenticated request that changed
+// something, or the outcome of a pipeline publishing an idea.
+type auditEntry struct {
+	Seq       int64     `json:"seq"`
+	Time      time.Time `json:"time"`
+	User      string    `json:"user,omitempty"`
+	APIKey    string    `json:"api_key,omitempty"` // ID of the key used, if any
+	Action    string    `json:"action"`            // e.g. "POST /ideas/post", or "publish"
+	RequestID string    `json:"request_id,omitempty"`
+	Idea      string    `json:"idea,omitempty"`
+	Job       string    `json:"job,omitempty"`
+	Status    int       `json:"status,omitempty"` // of the response
+	Commit    string    `json:"commit,omitempty"` // SHA of the commit made
+	Error     string    `json:"error,omitempty"`  // of a failed pipeline
+	Prev      string    `json:"prev"`             // hash of the entry before
+	Hash      string    `json:"hash"`
+}
+
+// hash returns the hash of e, which covers every field but Hash.
+func (e auditEntry) hash() string {
+	e.Hash = ""
+	b, _ := json.Marshal(e)
+	h := sha256.Sum256(b)
+	return hex.EncodeToString(h[:])
+}
+
+// newAuditLog opens the log at path, continuing its chain.
+func newAuditLog(path string, l *log.Logger) *auditLog {
+	a := &auditLog{path: path, log: l}
+	entries, err := a.read()
+	if err != nil {
+		l.Printf("cannot load audit log: %v", err)
+	}
+	if n := len(entries); n > 0 {
+		a.seq, a.last = entries[n-1].Seq, entries[n-1].Hash
+	}
+	return a
+}
+
+// record appends e to the log. A nil log records nothing. The chain
+// only advances once e is written, so a failed write leaves no gap.
+func (a *auditLog) record(e auditEntry) {
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	e.Seq, e.Time, e.Prev = a.seq+1, time.Now().UTC(), a.last
+	e.Hash = e.hash()
+	line, _ := json.Marshal(e)
+
+	f, err := os.OpenFile(a.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err == nil {
+		_, err = f.Write(append(line, '\n'))
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if err != nil {
+		a.log.Printf("cannot write audit log: %v", err)
+		return
+	}
+	a.seq, a.last = e.Seq, e.Hash
+}
+
+// read returns the entries of the log, oldest first.
+func (a *auditLog) read() ([]auditEntry, error) {
+	f, err := os.Open(a.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var entries []auditEntry
+	sc := bufio.NewScanner(f)
+	sc.Buffer(nil, 1<<20)
+	for sc.Scan() {
+		var e auditEntry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			return entries, fmt.Errorf("entry after %d: %w", len(entries), err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, sc.Err()
+}
+
+// verifyAudit returns the sequence number of the first entry that does
+// not match its hash or the entry before it, or 0 if the chain is whole.
+func verifyAudit(entries []auditEntry) int64 {
+	prev := ""
+	for i, e := range entries {
+		if e.Prev != prev || e.Hash != e.hash() || e.Seq != int64(i+1) {
+			return int64(i + 1)
+		}
+		prev = e.Hash
+	}
+	return 0
+}
+
+type auditKey struct{}
+
+// noteAudit adds what only the handler knows to the audit entry of the
+// request of ctx, such as the commit it made, or who made the request
+// for endpoints that authenticate on their own.
+func noteAudit(ctx context.Context, note func(e *auditEntry)) {
+	if e, ok := ctx.Value(auditKey{}).(*auditEntry); ok {
+		note(e)
+	}
+}
+
+// auditActions records the requests of authenticated callers that
+// change something, with their response status, once answered. Requests
+// to endpoints that authenticate on their own are recorded if they note
+// who made them.
+func auditActions(a *auditLog) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if a == nil {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			e := &auditEntry{}
+			r = r.WithContext(context.WithValue(r.Context(), auditKey{}, e))
+			sw := &statusWriter{ResponseWriter: w}
+			next.ServeHTTP(sw, r)
+
+			p, ok := principalFromContext(r.Context())
+			changed := r.Method != http.MethodGet && r.Method != http.MethodHead && r.Method != http.MethodOptions
+			if !(ok && changed) && e.User == "" && e.APIKey == "" {
+				return
+			}
+			if ok {
+				e.User, e.APIKey = p.User, p.APIKey
+			}
+			e.Action = r.Method + " " + r.URL.Path
+			e.RequestID = requestIDFromContext(r.Context())
+			e.Status = cmp.Or(sw.status, http.StatusOK)
+			// The mux sets the route of the request it was given.
+			if strings.Contains(r.Pattern, "/ideas/{id}") {
+				e.Idea = cmp.Or(e.Idea, r.PathValue("id"))
+			}
+			if job, ok := strings.CutPrefix(w.Header().Get("Location"), "/ideas/admin/jobs/"); ok {
+				e.Job = cmp.Or(e.Job, job)
+			} else if strings.Contains(r.Pattern, "/ideas/admin/jobs/{id}") {
+				e.Job = cmp.Or(e.Job, r.PathValue("id"))
+			}
+			a.record(*e)
+		})
+	}
+}
+
+// statusWriter keeps the status of a response.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController flush and hijack the response.
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// auditPublished records the outcome of a pipeline run for the job id,
+// given the request of the idea.
+func (s *service) auditPublished(id string, req ideaRequest, path, commit string, err error) {
+	e := auditEntry{Action: "publish", User: req.user, RequestID: req.requestID, Job: id, Commit: commit}
+	if path != "" {
+		e.Idea = ideaID(path)
+	}
+	if err != nil {
+		e.Error = err.Error()
+	}
+	s.audit.record(e)
+}
+
+// handleAudit serves the audit log, newest first, and whether its chain
+// is whole. The query parameters user, action, idea, and job filter the
+// entries, since takes an RFC 3339 time, and limit caps their number,
+// 100 by default.
+func (s *service) handleAudit(w http.ResponseWriter, r *http.Request) {
+	if s.audit == nil {
+		s.jsonError(w, "the audit log is disabled", http.StatusNotFound)
+		return
+	}
+	q := r.URL.Query()
+	limit := 100
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			s.jsonError(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+	var since time.Time
+	if v := q.Get("since"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			s.jsonError(w, "since must be an RFC 3339 time", http.StatusBadRequest)
+			return
+		}
+		since = t
+	}
+
+	s.audit.mu.Lock()
+	all, err := s.audit.read()
+	s.audit.mu.Unlock()
+	if err != nil {
+		s.log.Printf("read audit log: %v", err)
+	}
+	broken := verifyAudit(all)
+	if err != nil && broken == 0 {
+		broken = int64(len(all) + 1)
+	}
+	match := func(field, want string) bool { return want == "" || field == want }
+	entries := []auditEntry{}
+	for _, e := range slices.Backward(all) {
+		if len(entries) == limit || e.Time.Before(since) {
+			break
+		}
+		if match(e.User, q.Get("user")) && match(e.Action, q.Get("action")) && match(e.Idea, q.Get("idea")) && match(e.Job, q.Get("job")) {
+			entries = append(entries, e)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct {
+		OK       bool         `json:"ok"`
+		Verified bool         `json:"verified"`            // the chain is whole
+		BrokenAt int64        `json:"broken_at,omitempty"` // first entry that does not match
+		Entries  []auditEntry `json:"entries"`
+	}{OK: true, Verified: broken == 0, BrokenAt: broken, Entries: entries})
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAuditChain(t *testing.T) {
+	l := log.New(io.Discard, "", 0)
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	a := newAuditLog(path, l)
+	a.record(auditEntry{User: "changkun", Action: "POST /ideas/post"})
+	a.record(auditEntry{User: "changkun", Action: "publish", Commit: "abc"})
+
+	// Reopened, the log continues the chain.
+	a = newAuditLog(path, l)
+	// A failed write does not advance the chain.
+	a.path = t.TempDir()
+	a.record(auditEntry{User: "changkun", Action: "DELETE /ideas/x"})
+	a.path = path
+	a.record(auditEntry{User: "changkun", Action: "PUT /ideas/x"})
+	entries, err := a.read()
+	if err != nil || len(entries) != 3 || entries[2].Seq != 3 || entries[2].Prev != entries[1].Hash {
+		t.Fatalf("entries = %+v, %v", entries, err)
+	}
+	if n := verifyAudit(entries); n != 0 {
+		t.Errorf("verifyAudit = %d, want 0", n)
+	}
+
+	data, _ := os.ReadFile(path)
+	os.WriteFile(path, []byte(strings.Replace(string(data), `"commit":"abc"`, `"commit":"def"`, 1)), 0o600)
+	entries, _ = a.read()
+	if n := verifyAudit(entries); n != 2 {
+		t.Errorf("verifyAudit of an edited entry = %d, want 2", n)
+	}
+	lines := strings.SplitAfter(string(data), "\n")
+	os.WriteFile(path, []byte(lines[0]+lines[2]), 0o600)
+	entries, _ = a.read()
+	if n := verifyAudit(entries); n != 2 {
+		t.Errorf("verifyAudit of a dropped entry = %d, want 2", n)
+	}
+}
+
+func TestAuditActions(t *testing.T) {
+	l := log.New(io.Discard, "", 0)
+	s := &service{log: l, audit: newAuditLog(filepath.Join(t.TempDir(), "audit.jsonl"), l)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("PUT /ideas/{id}", func(w http.ResponseWriter, r *http.Request) {
+		noteAudit(r.Context(), func(e *auditEntry) { e.Commit = "c0ffee" })
+	})
+	mux.HandleFunc("POST /ideas/post", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Location", "/ideas/admin/jobs/j1")
+		w.WriteHeader(http.StatusAccepted)
+	})
+	mux.HandleFunc("GET /ideas/list", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("POST /ideas/quick", func(w http.ResponseWriter, r *http.Request) {
+		noteAudit(r.Context(), func(e *auditEntry) { e.APIKey = sharedAPIKey })
+	})
+	audited := auditActions(s.audit)(mux)
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !publicPaths[r.URL.Path] {
+			r = r.WithContext(withPrincipal(r.Context(), principal{User: "changkun", Scopes: []string{scopeAdmin}}))
+		}
+		audited.ServeHTTP(w, r)
+	})
+	for _, req := range []struct{ method, path string }{
+		{"PUT", "/ideas/2025-01-01-reward"},
+		{"POST", "/ideas/post"},
+		{"GET", "/ideas/list"}, // reads are not recorded
+		{"POST", "/ideas/quick"},
+	} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(req.method, req.path, nil))
+	}
+
+	get := func(query string) (resp struct {
+		Verified bool
+		Entries  []auditEntry
+	}) {
+		rec := httptest.NewRecorder()
+		s.handleAudit(rec, httptest.NewRequest("GET", "/ideas/admin/audit"+query, nil))
+		json.NewDecoder(rec.Body).Decode(&resp)
+		return resp
+	}
+	resp := get("")
+	if !resp.Verified || len(resp.Entries) != 3 {
+		t.Fatalf("audit = %+v", resp)
+	}
+	quick, post, put := resp.Entries[0], resp.Entries[1], resp.Entries[2]
+	if put.Action != "PUT /ideas/2025-01-01-reward" || put.Idea != "2025-01-01-reward" || put.Commit != "c0ffee" || put.User != "changkun" || put.Status != http.StatusOK {
+		t.Errorf("update entry = %+v", put)
+	}
+	if post.Job != "j1" || post.Status != http.StatusAccepted {
+		t.Errorf("post entry = %+v", post)
+	}
+	if quick.User != "" || quick.APIKey != sharedAPIKey {
+		t.Errorf("quick entry = %+v", quick)
+	}
+	if resp := get("?idea=2025-01-01-reward"); len(resp.Entries) != 1 || resp.Entries[0].Seq != put.Seq {
+		t.Errorf("audit of the idea = %+v", resp.Entries)
+	}
+	if resp := get("?limit=1"); len(resp.Entries) != 1 || resp.Entries[0].Seq != quick.Seq {
+		t.Errorf("audit with limit = %+v", resp.Entries)
+	}
+}
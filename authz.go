@@ -0,0 +1,210 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"changkun.de/x/login"
+)
+
+// Scopes granted to callers. Each includes the ones before it: writers
+// can read, and admins can do everything.
+const (
+	scopeRead  = "ideas:read"  // list, search, and fetch ideas
+	scopeWrite = "ideas:write" // post, edit, and follow the jobs posted
+	scopeAdmin = "ideas:admin" // delete, the dashboard, and the admin routes
+)
+
+// scopeDefaults are the scopes of callers whose login token or cookie
+// names none.
+type scopeDefaults struct {
+	owner []string            // of IDEAS_DEFAULT_SCOPES
+	users map[string]userSite // of the users file, who may only write
+}
+
+// of returns the default scopes of user. Users with a blog of their own
+// have no business with the dashboard and the server's own archive.
+func (d scopeDefaults) of(user string) []string {
+	if _, ok := d.users[user]; ok && user != "" {
+		return []string{scopeWrite}
+	}
+	return d.owner
+}
+
+// principal is who a request was authenticated as.
+type principal struct {
+	User   string
+	Scopes []string
+	APIKey string // ID of the key used, empty for a login token or cookie
+	Cookie bool   // authenticated by the login cookie, which browsers send on their own
+}
+
+// can reports whether p has scope, directly or through a broader one.
+func (p principal) can(scope string) bool {
+	for _, s := range p.Scopes {
+		switch {
+		case s == scope, s == scopeAdmin:
+			return true
+		case s == scopeWrite && scope == scopeRead:
+			return true
+		}
+	}
+	return false
+}
+
+type principalKey struct{}
+
+func withPrincipal(ctx context.Context, p principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// principalFromContext returns who the request was authenticated as,
+// reporting false for public endpoints.
+func principalFromContext(ctx context.Context) (principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(principal)
+	return p, ok
+}
+
+// requiredScope returns the scope needed to make the request r.
+func requiredScope(r *http.Request) string {
+	path := r.URL.Path
+	admin := path == "/ideas/admin" || strings.HasPrefix(path, "/ideas/admin/")
+	switch {
+	case r.Method == http.MethodGet && strings.HasPrefix(path, "/ideas/admin/jobs/"):
+		// Clients follow the jobs of the ideas they post.
+		return scopeWrite
+	case admin, strings.HasPrefix(path, "/ideas/debug/"), r.Method == http.MethodDelete:
+		return scopeAdmin
+	case r.Method == http.MethodGet, r.Method == http.MethodHead:
+		return scopeRead
+	}
+	return scopeWrite
+}
+
+// tokenScopes returns the scopes granted by the claims of a verified
+// login token: "scope", space-separated as in OAuth, and the "scopes"
+// and "roles" lists. It reports false if the token has none of these
+// claims.
+func tokenScopes(token string) ([]string, bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, false
+	}
+	var claims struct {
+		Scope  *string  `json:"scope"`
+		Scopes []string `json:"scopes"`
+		Roles  []string `json:"roles"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, false
+	}
+	if claims.Scope == nil && claims.Scopes == nil && claims.Roles == nil {
+		return nil, false
+	}
+	var scopes []string
+	if claims.Scope != nil {
+		scopes = strings.Fields(*claims.Scope)
+	}
+	scopes = append(scopes, claims.Scopes...)
+	scopes = append(scopes, claims.Roles...)
+	slices.Sort(scopes)
+	return slices.Compact(scopes), true
+}
+
+// auth admits requests made with a valid login token or cookie, or with
+// one of the API keys in the X-Api-Key header, if their scopes allow the
+// request. Handlers find who made it with principalFromContext.
+func auth(keys *apiKeyStore, defaultScopes scopeDefaults) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if isPublic(r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			p, ok := authenticate(w, r, keys, defaultScopes)
+			if !ok {
+				writeError(w, http.StatusUnauthorized, errorResponse{Message: "unauthorized"})
+				return
+			}
+			if scope := requiredScope(r); !p.can(scope) {
+				writeError(w, http.StatusForbidden, errorResponse{Message: "requires the " + scope + " scope"})
+				return
+			}
+			if p.Cookie && !sameOrigin(r) {
+				writeError(w, http.StatusForbidden, errorResponse{Message: "cross-site request refused"})
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(withPrincipal(r.Context(), p)))
+		})
+	}
+}
+
+// authenticateHeader returns who sent the header h: the owner of an API
+// key in X-Api-Key, or the user of a Bearer login token, with the scopes
+// of its claims, or else its defaultScopes.
+func authenticateHeader(h http.Header, keys *apiKeyStore, defaultScopes scopeDefaults) (principal, bool) {
+	if k, ok := keys.verify(h.Get("X-Api-Key")); ok {
+		return k.principal(), true
+	}
+	if auth := h.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		token := strings.TrimPrefix(auth, "Bearer ")
+		if user, err := login.Verify(token); err == nil {
+			scopes, ok := tokenScopes(token)
+			if !ok {
+				scopes = defaultScopes.of(user)
+			}
+			return principal{User: user, Scopes: scopes}, true
+		}
+	}
+	return principal{}, false
+}
+
+// authenticate returns who made r: the owner of an API key, or the user
+// of a login token or cookie, with the scopes of the token's claims, or
+// else its defaultScopes.
+func authenticate(w http.ResponseWriter, r *http.Request, keys *apiKeyStore, defaultScopes scopeDefaults) (principal, bool) {
+	if p, ok := authenticateHeader(r.Header, keys, defaultScopes); ok {
+		return p, true
+	}
+
+	// Fall back to query param / cookie via SDK, whose claims are not at
+	// hand.
+	if user, err := login.HandleAuth(w, r); err == nil {
+		return principal{User: user, Scopes: defaultScopes.of(user), Cookie: true}, true
+	}
+	return principal{}, false
+}
+
+// sameOrigin reports whether r may act with the login cookie: it only
+// reads, or was not made by another site. Browsers tell with
+// Sec-Fetch-Site, or else Origin, which they send along with the
+// requests pages make; without either, r did not come from a page of
+// another site, such as a form posting to the dashboard's routes.
+func sameOrigin(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	if site := r.Header.Get("Sec-Fetch-Site"); site != "" {
+		return site == "same-origin" || site == "none"
+	}
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && u.Host == r.Host
+}
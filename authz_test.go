@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/base64"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestRequiredScope(t *testing.T) {
+	for _, tt := range []struct {
+		method, path, want string
+	}{
+		{"GET", "/ideas/list", scopeRead},
+		{"GET", "/ideas/2025-01-01-reward", scopeRead},
+		{"GET", "/ideas/administrative-debt", scopeRead},
+		{"POST", "/ideas/post", scopeWrite},
+		{"PUT", "/ideas/2025-01-01-reward", scopeWrite},
+		{"GET", "/ideas/admin/jobs/abc/events", scopeWrite},
+		{"POST", "/ideas/admin/jobs/abc/retry", scopeAdmin},
+		{"GET", "/ideas/admin", scopeAdmin},
+		{"GET", "/ideas/debug/pprof/heap", scopeAdmin},
+		{"DELETE", "/ideas/drafts/abc", scopeAdmin},
+	} {
+		if got := requiredScope(httptest.NewRequest(tt.method, tt.path, nil)); got != tt.want {
+			t.Errorf("%s %s: scope = %s, want %s", tt.method, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestPrincipalCan(t *testing.T) {
+	for _, tt := range []struct {
+		scopes []string
+		scope  string
+		want   bool
+	}{
+		{[]string{scopeAdmin}, scopeWrite, true},
+		{[]string{scopeWrite}, scopeRead, true},
+		{[]string{scopeWrite}, scopeAdmin, false},
+		{[]string{scopeRead}, scopeWrite, false},
+		{nil, scopeRead, false},
+	} {
+		if got := (principal{Scopes: tt.scopes}).can(tt.scope); got != tt.want {
+			t.Errorf("%v can %s = %v, want %v", tt.scopes, tt.scope, got, tt.want)
+		}
+	}
+}
+
+func TestTokenScopes(t *testing.T) {
+	jwt := func(claims string) string {
+		return "e30." + base64.RawURLEncoding.EncodeToString([]byte(claims)) + ".sig"
+	}
+	for _, tt := range []struct {
+		name, token string
+		want        []string
+		ok          bool
+	}{
+		{"scope", jwt(`{"sub":"a","scope":"ideas:read ideas:write"}`), []string{scopeRead, scopeWrite}, true},
+		{"lists", jwt(`{"scopes":["ideas:write"],"roles":["ideas:admin","ideas:write"]}`), []string{scopeAdmin, scopeWrite}, true},
+		{"empty scope", jwt(`{"scope":""}`), nil, true},
+		{"no claims", jwt(`{"sub":"a"}`), nil, false},
+		{"not a JWT", "abc", nil, false},
+	} {
+		got, ok := tokenScopes(tt.token)
+		if ok != tt.ok || !slices.Equal(got, tt.want) {
+			t.Errorf("%s: scopes = %v, %v; want %v, %v", tt.name, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestSameOrigin(t *testing.T) {
+	for _, tt := range []struct {
+		method, fetchSite, origin string
+		want                      bool
+	}{
+		{"GET", "cross-site", "https://evil.example", true},
+		{"POST", "same-origin", "https://api.changkun.de", true},
+		{"POST", "none", "", true},
+		{"POST", "cross-site", "", false},
+		{"POST", "same-site", "https://changkun.de", false},
+		{"POST", "", "https://api.changkun.de", true},
+		{"POST", "", "https://evil.example", false},
+		{"POST", "", "null", false},
+		{"DELETE", "", "https://evil.example", false},
+		{"POST", "", "", true},
+	} {
+		r := httptest.NewRequest(tt.method, "https://api.changkun.de/ideas/admin/suggestions/1/approve", nil)
+		if tt.fetchSite != "" {
+			r.Header.Set("Sec-Fetch-Site", tt.fetchSite)
+		}
+		if tt.origin != "" {
+			r.Header.Set("Origin", tt.origin)
+		}
+		if got := sameOrigin(r); got != tt.want {
+			t.Errorf("%s with Sec-Fetch-Site %q, Origin %q: sameOrigin = %v, want %v", tt.method, tt.fetchSite, tt.origin, got, tt.want)
+		}
+	}
+}
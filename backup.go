@@ -0,0 +1,297 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// backupPrefix is the file name prefix of backup archives.
+const backupPrefix = "ideas-backup-"
+
+// backupper periodically exports the idea files and the local service
+// state (index, queues, logs, ...) to a tarball kept in a local
+// directory and/or an S3-compatible bucket.
+type backupper struct {
+	s    *service
+	dir  string    // local backup directory, optional
+	s3   *s3Client // optional
+	keep int       // number of backups to retain per destination
+}
+
+func (b *backupper) run(ctx context.Context, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+		if err := b.backup(ctx); err != nil {
+			b.s.log.Printf("backup failed: %v", err)
+		}
+	}
+}
+
+func (b *backupper) backup(ctx context.Context) error {
+	data, err := b.s.exportArchive(ctx, b.dir)
+	if err != nil {
+		return err
+	}
+	name := backupPrefix + time.Now().UTC().Format("20060102T150405Z") + ".tar.gz"
+
+	if b.dir != "" {
+		if err := os.MkdirAll(b.dir, 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(b.dir, name), data, 0o600); err != nil {
+			return err
+		}
+		if err := b.pruneLocal(); err != nil {
+			b.s.log.Printf("backup retention failed: %v", err)
+		}
+	}
+	if b.s3 != nil {
+		if err := b.s3.put(ctx, name, data); err != nil {
+			return fmt.Errorf("upload backup: %w", err)
+		}
+		if err := b.pruneS3(ctx); err != nil {
+			b.s.log.Printf("backup retention failed: %v", err)
+		}
+	}
+	b.s.log.Printf("backup written: %s (%d bytes)", name, len(data))
+	return nil
+}
+
+func (b *backupper) pruneLocal() error {
+	names, err := filepath.Glob(filepath.Join(b.dir, backupPrefix+"*.tar.gz"))
+	if err != nil {
+		return err
+	}
+	slices.Sort(names) // timestamps sort lexically
+	for len(names) > b.keep {
+		if err := os.Remove(names[0]); err != nil {
+			return err
+		}
+		names = names[1:]
+	}
+	return nil
+}
+
+func (b *backupper) pruneS3(ctx context.Context) error {
+	keys, err := b.s3.list(ctx, backupPrefix)
+	if err != nil {
+		return err
+	}
+	for len(keys) > b.keep {
+		if err := b.s3.delete(ctx, keys[0]); err != nil {
+			return err
+		}
+		keys = keys[1:]
+	}
+	return nil
+}
+
+// exportArchive builds a tar.gz with the idea files of the repository
+// under ideas/ and the local state under data/, except for the directory
+// skip, if any, where backups are kept: archiving them would nest every
+// earlier backup in the next one.
+func (s *service) exportArchive(ctx context.Context, skip string) ([]byte, error) {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	now := time.Now()
+
+	add := func(name string, data []byte) error {
+		if err := tw.WriteHeader(&tar.Header{
+			Name:    name,
+			Mode:    0o644,
+			Size:    int64(len(data)),
+			ModTime: now,
+		}); err != nil {
+			return err
+		}
+		_, err := tw.Write(data)
+		return err
+	}
+
+	dirs := []string{s.site.ideasDir}
+	if s.site.draftsDir != s.site.ideasDir {
+		dirs = append(dirs, s.site.draftsDir)
+	}
+	for _, dir := range dirs {
+		files, err := s.github.listDir(ctx, dir)
+		if errors.Is(err, errDirNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("list %s: %w", dir, err)
+		}
+		for _, f := range files {
+			md, _, err := s.github.getFile(ctx, f.Path)
+			if err != nil {
+				return nil, fmt.Errorf("get %s: %w", f.Path, err)
+			}
+			if err := add(path.Join("ideas", f.Path), []byte(md)); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	var skipped fs.FileInfo
+	if skip != "" {
+		skipped, _ = os.Stat(skip)
+	}
+	err := filepath.WalkDir(s.dataDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if skipped != nil {
+				if fi, err := d.Info(); err == nil && os.SameFile(fi, skipped) {
+					return filepath.SkipDir
+				}
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(s.dataDir, p)
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		return add(path.Join("data", filepath.ToSlash(rel)), data)
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("archive data dir: %w", err)
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// runRestore implements the "restore" subcommand:
+//
+//	ideas restore [-ideas] [-force] <backup.tar.gz | s3://key>
+//
+// It restores the local state into the data directory and, with -ideas,
+// re-creates idea files that are missing in the repository.
+func runRestore(l *log.Logger, args []string) error {
+	fset := flag.NewFlagSet("restore", flag.ExitOnError)
+	restoreIdeas := fset.Bool("ideas", false, "re-create idea files missing in the repository (needs GIT_TOKEN)")
+	force := fset.Bool("force", false, "overwrite existing files in the data directory")
+	fset.Parse(args)
+	if fset.NArg() != 1 {
+		return fmt.Errorf("usage: ideas restore [-ideas] [-force] <backup.tar.gz | s3://key>")
+	}
+	src := fset.Arg(0)
+	dataDir := dataDirFromEnv()
+
+	var data []byte
+	var err error
+	if key, ok := strings.CutPrefix(src, "s3://"); ok {
+		c := s3ClientFromEnv()
+		if c == nil {
+			return fmt.Errorf("IDEAS_BACKUP_S3_BUCKET is not configured")
+		}
+		data, err = c.get(context.Background(), key)
+	} else {
+		data, err = os.ReadFile(src)
+	}
+	if err != nil {
+		return err
+	}
+
+	var gh *githubClient
+	if *restoreIdeas {
+		gh, err = githubClientFromEnv()
+		if err != nil {
+			return err
+		}
+	}
+	return restoreArchive(l, data, dataDir, gh, *force)
+}
+
+// restoreArchive restores the local state in the backup data into
+// dataDir, overwriting existing files only if force is set, and, if gh is
+// not nil, re-creates the idea files missing in its repository.
+func restoreArchive(l *log.Logger, data []byte, dataDir string, gh *githubClient, force bool) error {
+	gr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	tr := tar.NewReader(gr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		name := path.Clean(hdr.Name)
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case strings.HasPrefix(name, "data/"):
+			rel := strings.TrimPrefix(name, "data/")
+			if !filepath.IsLocal(rel) {
+				return fmt.Errorf("unsafe path in backup: %s", name)
+			}
+			dst := filepath.Join(dataDir, filepath.FromSlash(rel))
+			if _, err := os.Stat(dst); err == nil && !force {
+				l.Printf("skipping existing %s (use -force to overwrite)", dst)
+				continue
+			}
+			if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+				return err
+			}
+			if err := os.WriteFile(dst, content, 0o644); err != nil {
+				return err
+			}
+			l.Printf("restored %s", dst)
+		case strings.HasPrefix(name, "ideas/") && gh != nil:
+			p := strings.TrimPrefix(name, "ideas/")
+			ctx := context.Background()
+			if _, _, err := gh.getFile(ctx, p); err == nil {
+				continue // still in the repository
+			}
+			if _, err := gh.createFile(ctx, p, string(content), sanitizeCommitMsg("ideas: restore "+path.Base(p))); err != nil {
+				return fmt.Errorf("restore %s: %w", p, err)
+			}
+			l.Printf("restored %s to the repository", p)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"log"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func readTarGz(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+	gr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{}
+	tr := tar.NewReader(gr)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			return files
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, _ := io.ReadAll(tr)
+		files[h.Name] = string(b)
+	}
+}
+
+func writeTarGz(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	for name, content := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content))}); err != nil {
+			t.Fatal(err)
+		}
+		tw.Write([]byte(content))
+	}
+	tw.Close()
+	gw.Close()
+	return buf.Bytes()
+}
+
+func TestExportArchiveSkipsBackups(t *testing.T) {
+	l := log.New(io.Discard, "", 0)
+	dataDir := t.TempDir()
+	os.WriteFile(filepath.Join(dataDir, "jobs.json"), []byte("[]"), 0o600)
+	empty := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "[]") // a repository without ideas
+	}))
+	defer empty.Close()
+	s := &service{
+		log:     l,
+		dataDir: dataDir,
+		site:    newSiteConfig("", "content/ideas", "", ""),
+		github:  &githubClient{owner: "o", repo: "r", apiURL: empty.URL},
+	}
+	b := &backupper{s: s, dir: filepath.Join(dataDir, "backups"), keep: 5}
+	for range 2 {
+		if err := b.backup(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data, err := s.exportArchive(context.Background(), b.dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := readTarGz(t, data); len(got) != 1 || got["data/jobs.json"] != "[]" {
+		t.Errorf("archive = %v, want only data/jobs.json", slices.Sorted(maps.Keys(got)))
+	}
+}
+
+func TestPrune(t *testing.T) {
+	names := []string{
+		backupPrefix + "20250103T000000Z.tar.gz",
+		backupPrefix + "20250101T000000Z.tar.gz",
+		backupPrefix + "20250104T000000Z.tar.gz",
+		backupPrefix + "20250102T000000Z.tar.gz",
+	}
+	want := []string{backupPrefix + "20250103T000000Z.tar.gz", backupPrefix + "20250104T000000Z.tar.gz"}
+
+	dir := t.TempDir()
+	for _, name := range names {
+		os.WriteFile(filepath.Join(dir, name), nil, 0o600)
+	}
+	os.WriteFile(filepath.Join(dir, "notes.txt"), nil, 0o600)
+	b := &backupper{dir: dir, keep: 2}
+	if err := b.pruneLocal(); err != nil {
+		t.Fatal(err)
+	}
+	entries, _ := os.ReadDir(dir)
+	var left []string
+	for _, e := range entries {
+		left = append(left, e.Name())
+	}
+	if !slices.Equal(left, append(want, "notes.txt")) {
+		t.Errorf("local backups left = %v, want %v and notes.txt", left, want)
+	}
+
+	objects := map[string]bool{}
+	for _, name := range names {
+		objects[name] = true
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := strings.TrimPrefix(r.URL.Path, "/bucket/")
+		switch r.Method {
+		case "GET":
+			io.WriteString(w, "<ListBucketResult>")
+			for name := range objects {
+				io.WriteString(w, "<Contents><Key>"+name+"</Key></Contents>")
+			}
+			io.WriteString(w, "</ListBucketResult>")
+		case "DELETE":
+			delete(objects, key)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer srv.Close()
+	b = &backupper{s3: &s3Client{endpoint: srv.URL, region: "us-east-1", bucket: "bucket"}, keep: 2}
+	if err := b.pruneS3(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := slices.Sorted(maps.Keys(objects)); !slices.Equal(got, want) {
+		t.Errorf("S3 backups left = %v, want %v", got, want)
+	}
+}
+
+func TestRestoreArchive(t *testing.T) {
+	root := t.TempDir()
+	dataDir := filepath.Join(root, "data")
+	os.MkdirAll(dataDir, 0o755)
+	os.WriteFile(filepath.Join(dataDir, "jobs.json"), []byte("current"), 0o600)
+	data := writeTarGz(t, map[string]string{
+		"data/jobs.json":        "restored",
+		"data/sub/index.json":   "restored",
+		"data/../evil":          "escaped",
+		"data/../../evil":       "escaped",
+		"/data/../evil":         "escaped",
+		"../evil":               "escaped",
+		"ideas/content/ideas/x": "idea",
+	})
+	l := log.New(io.Discard, "", 0)
+
+	if err := restoreArchive(l, data, dataDir, nil, false); err != nil {
+		t.Fatal(err)
+	}
+	read := func(p string) string {
+		b, _ := os.ReadFile(p)
+		return string(b)
+	}
+	if got := read(filepath.Join(dataDir, "jobs.json")); got != "current" {
+		t.Errorf("existing file restored without -force: %q", got)
+	}
+	if got := read(filepath.Join(dataDir, "sub", "index.json")); got != "restored" {
+		t.Errorf("sub/index.json = %q, want restored", got)
+	}
+	for _, p := range []string{filepath.Join(root, "evil"), filepath.Join(filepath.Dir(root), "evil"), filepath.Join(dataDir, "evil")} {
+		if _, err := os.Stat(p); err == nil {
+			t.Errorf("restored %s outside the data directory", p)
+		}
+	}
+
+	if err := restoreArchive(l, data, dataDir, nil, true); err != nil {
+		t.Fatal(err)
+	}
+	if got := read(filepath.Join(dataDir, "jobs.json")); got != "restored" {
+		t.Errorf("existing file with -force = %q, want restored", got)
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+type clipRequest struct {
+	URL       string `json:"url"`
+	Selection string `json:"selection"`
+	Note      string `json:"note"`
+	Title     string `json:"title"`
+}
+
+// handleClip is the backend of the web clipper: it fetches and
+// summarizes the clipped page, then publishes it together with the
+// selected text and the note.
+func (s *service) handleClip(w http.ResponseWriter, r *http.Request) {
+	var req clipRequest
+	if !s.readIdea(w, r, &req) {
+		return
+	}
+	u, err := url.Parse(req.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		s.jsonError(w, "a valid http(s) url is required", http.StatusBadRequest)
+		return
+	}
+
+	user := requestUser(r)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+
+		pageTitle, text, err := fetchPage(ctx, req.URL)
+		if err != nil {
+			s.log.Printf("failed to fetch clipped page %s: %v", req.URL, err)
+		}
+		var summary string
+		if text != "" {
+			summary, err = s.llm.summarizePage(ctx, pageTitle, text)
+			if err != nil {
+				s.log.Printf("page summary failed: %v", err)
+			}
+		}
+		s.processIdea(ideaRequest{
+			Title:   req.Title,
+			Content: clipContent(req, pageTitle, summary),
+			user:    user,
+		})
+	}()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ideaResponse{
+		OK:      true,
+		Message: "clip accepted, publishing in background",
+	})
+}
+
+// clipContent composes the idea from the note, the quoted selection,
+// and a summary of the source page.
+func clipContent(req clipRequest, pageTitle, summary string) string {
+	var parts []string
+	if note := strings.TrimSpace(req.Note); note != "" {
+		parts = append(parts, note)
+	}
+	if sel := strings.TrimSpace(req.Selection); sel != "" {
+		lines := strings.Split(sel, "\n")
+		for i, l := range lines {
+			lines[i] = strings.TrimRight("> "+l, " ")
+		}
+		parts = append(parts, strings.Join(lines, "\n"))
+	}
+	parts = append(parts, fmt.Sprintf("Source: [%s](%s)", cmp.Or(pageTitle, req.URL), req.URL))
+	if summary != "" {
+		parts = append(parts, summary)
+	}
+	return strings.Join(parts, "\n\n")
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClipContent(t *testing.T) {
+	tests := []struct {
+		name      string
+		req       clipRequest
+		pageTitle string
+		summary   string
+		want      string
+	}{
+		{
+			name: "url only",
+			req:  clipRequest{URL: "https://example.com/a"},
+			want: "Source: [https://example.com/a](https://example.com/a)",
+		},
+		{
+			name:      "all parts",
+			req:       clipRequest{URL: "https://example.com/a", Note: " Worth a try. ", Selection: "first line \n\nsecond"},
+			pageTitle: "A page",
+			summary:   "The page argues for testing.",
+			want: "Worth a try.\n\n> first line\n>\n> second\n\n" +
+				"Source: [A page](https://example.com/a)\n\nThe page argues for testing.",
+		},
+		{
+			name:      "blank note and selection",
+			req:       clipRequest{URL: "https://example.com/a", Note: "  ", Selection: "\n"},
+			pageTitle: "A page",
+			want:      "Source: [A page](https://example.com/a)",
+		},
+	}
+	for _, tt := range tests {
+		if got := clipContent(tt.req, tt.pageTitle, tt.summary); got != tt.want {
+			t.Errorf("%s: clipContent() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestHandleClip(t *testing.T) {
+	page := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "<html><head><title> A  page </title></head><body><p>Testing pays off.</p></body></html>")
+	}))
+	t.Cleanup(page.Close)
+	// The page is summarized, and the idea then refused by moderation so
+	// that publishing ends without a repository.
+	llm := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		content := `{\"allowed\": false, \"reason\": \"spam\"}`
+		if strings.Contains(string(body), "Testing pays off.") {
+			content = "The page argues for testing."
+		}
+		io.WriteString(w, `{"choices":[{"message":{"content":"`+content+`"}}]}`)
+	}))
+	t.Cleanup(llm.Close)
+
+	l := log.New(io.Discard, "", 0)
+	dir := t.TempDir()
+	s := &service{
+		log:       l,
+		llm:       &llmClient{baseURL: llm.URL, log: l},
+		jobs:      newJobStore(filepath.Join(dir, "jobs.json"), l),
+		lifecycle: newLifecycleStore(filepath.Join(dir, "lifecycle.json"), l),
+		pipelines: map[string][]string{"default": {"moderate"}},
+		maxBody:   1024,
+	}
+
+	tests := []struct {
+		name string
+		body string
+		want int
+	}{
+		{name: "invalid body", body: "{", want: http.StatusBadRequest},
+		{name: "no url", body: `{"note": "hi"}`, want: http.StatusBadRequest},
+		{name: "bad scheme", body: `{"url": "file:///etc/passwd"}`, want: http.StatusBadRequest},
+		{name: "no host", body: `{"url": "https://"}`, want: http.StatusBadRequest},
+		{name: "too large", body: `{"url": "` + page.URL + `", "selection": "` + strings.Repeat("a", 1024) + `"}`, want: http.StatusRequestEntityTooLarge},
+		{name: "accepted", body: `{"url": "` + page.URL + `", "note": "Worth a try."}`, want: http.StatusOK},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		s.handleClip(rec, httptest.NewRequest("POST", "/ideas/clip", strings.NewReader(tt.body)))
+		if rec.Code != tt.want {
+			t.Errorf("%s: status = %d %s, want %d", tt.name, rec.Code, rec.Body, tt.want)
+		}
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		jobs := s.jobs.recent()
+		if len(jobs) == 1 && !jobs[0].Finished.IsZero() {
+			want := "Worth a try.\n\nSource: [A page](" + page.URL + ")\n\nThe page argues for testing."
+			if got := jobs[0].Request.Content; got != want {
+				t.Errorf("clipped content = %q, want %q", got, want)
+			}
+			break
+		}
+		if len(jobs) > 1 || time.Now().After(deadline) {
+			t.Fatalf("jobs = %+v, want the accepted clip finished", jobs)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
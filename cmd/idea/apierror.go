@@ -0,0 +1,44 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"cmp"
+	"errors"
+	"net/http"
+)
+
+// apiError is an error reply of the server. Code is one of the codes
+// listed in the README, such as "conflict" or "llm_timeout", for telling
+// failures apart without matching messages.
+type apiError struct {
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	RequestID string `json:"request_id"`
+}
+
+func (e *apiError) Error() string {
+	if e.RequestID == "" {
+		return e.Message
+	}
+	return e.Message + " (request " + e.RequestID + ")"
+}
+
+// err returns the error of a reply that is not OK.
+func (e apiError) err(resp *http.Response) error {
+	e.Message = cmp.Or(e.Message, resp.Status)
+	e.RequestID = cmp.Or(e.RequestID, resp.Header.Get("X-Request-Id"))
+	return &e
+}
+
+// errorCode returns the code of the server's error reply, or "" if err
+// is not one.
+func errorCode(err error) string {
+	var e *apiError
+	if errors.As(err, &e) {
+		return e.Code
+	}
+	return ""
+}
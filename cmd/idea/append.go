@@ -0,0 +1,106 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// appendIdea appends a follow-up to a published idea.
+func appendIdea(url, token, id, content, lang string, noTranslate, allowSecrets bool) (*publishedIdea, error) {
+	body, _ := json.Marshal(map[string]any{"content": content, "lang": lang, "no_translate": noTranslate, "allow_secrets": allowSecrets})
+	req, _ := http.NewRequest("POST", url+"/ideas/"+id+"/append", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusUnauthorized {
+		return nil, errUnauthorized
+	}
+
+	var result struct {
+		OK bool `json:"ok"`
+		apiError
+		Idea publishedIdea `json:"idea"`
+	}
+	json.NewDecoder(resp.Body).Decode(&result)
+	if !result.OK {
+		return nil, result.err(resp)
+	}
+	return &result.Idea, nil
+}
+
+// runAppend implements the "append" subcommand:
+//
+//	idea append [-f file] [-e] [-lang en|zh|auto] [-no-translate] [-allow-secrets] <id> [text]
+//
+// It appends a dated follow-up to a published idea rather than posting a
+// new one. The follow-up is the text given, or else read from a file,
+// standard input if it is piped, or the editor.
+func runAppend(args []string) {
+	fset := flag.NewFlagSet("append", flag.ExitOnError)
+	file := fset.String("f", "", "append the text of `file`")
+	useEditor := fset.Bool("e", false, "write the follow-up in $IDEA_EDITOR, $VISUAL, or $EDITOR")
+	lang := fset.String("lang", "auto", "language of the follow-up, `en`, zh, or auto")
+	noTranslate := fset.Bool("no-translate", false, "append the text as is in both languages")
+	allowSecrets := fset.Bool("allow-secrets", false, "append the text even if it seems to contain a credential")
+	fset.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: idea append [-f file] [-e] [-lang en|zh|auto] [-no-translate] [-allow-secrets] <id> [text]")
+		fset.PrintDefaults()
+	}
+	fset.Parse(args)
+	if fset.NArg() < 1 || *file != "" && *useEditor {
+		fset.Usage()
+		os.Exit(2)
+	}
+	id, text := fset.Arg(0), strings.Join(fset.Args()[1:], " ")
+	checkLang(*lang)
+
+	var err error
+	switch {
+	case text != "":
+	case *file != "":
+		var data []byte
+		data, err = os.ReadFile(*file)
+		text = string(data)
+	case *useEditor || term.IsTerminal(int(os.Stdin.Fd())):
+		text, err = composeInEditor("")
+	default:
+		var data []byte
+		data, err = io.ReadAll(os.Stdin)
+		text = string(data)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	text = strings.TrimSpace(text)
+	if text == "" {
+		fmt.Fprintln(os.Stderr, "nothing to append")
+		os.Exit(1)
+	}
+
+	idea, err := appendIdea(serverURL(), authenticate(), id, text, *lang, *noTranslate, *allowSecrets)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed: %v\n", err)
+		if errorCode(err) == "secret_detected" {
+			fmt.Fprintln(os.Stderr, "Remove the secret, or append again with -allow-secrets if it is not a real one.")
+		}
+		os.Exit(1)
+	}
+	fmt.Printf("Appended to %s\n%s\n", idea.Title, idea.URL)
+}
@@ -0,0 +1,124 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+var (
+	metaTagRe  = regexp.MustCompile(`(?is)<meta\s[^>]*>`)
+	metaAttrRe = regexp.MustCompile(`(?is)([a-z:-]+)\s*=\s*(?:"([^"]*)"|'([^']*)')`)
+	titleTagRe = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+)
+
+// maxPageSize is how much of a page is read, enough for the head of
+// most pages.
+const maxPageSize = 512 << 10
+
+// fetchBookmark returns the title and description of a web page, from
+// its Open Graph tags or else its <title> and description.
+func fetchBookmark(link string) (title, description string, err error) {
+	req, err := http.NewRequest("GET", link, nil)
+	if err != nil {
+		return "", "", err
+	}
+	req.Header.Set("User-Agent", "idea-cli/1.0")
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", "", fmt.Errorf("fetch %s: %s", link, resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxPageSize))
+	if err != nil {
+		return "", "", fmt.Errorf("fetch %s: %w", link, err)
+	}
+	page := string(body)
+
+	meta := map[string]string{}
+	for _, tag := range metaTagRe.FindAllString(page, -1) {
+		attrs := map[string]string{}
+		for _, m := range metaAttrRe.FindAllStringSubmatch(tag, -1) {
+			attrs[strings.ToLower(m[1])] = m[2] + m[3]
+		}
+		key := strings.ToLower(cmp.Or(attrs["property"], attrs["name"]))
+		if _, ok := meta[key]; key != "" && !ok {
+			meta[key] = attrs["content"]
+		}
+	}
+	if m := titleTagRe.FindStringSubmatch(page); m != nil {
+		title = m[1]
+	}
+	title = cmp.Or(meta["og:title"], meta["twitter:title"], title)
+	description = cmp.Or(meta["og:description"], meta["description"], meta["twitter:description"])
+	clean := func(s string) string {
+		return strings.Join(strings.Fields(html.UnescapeString(s)), " ")
+	}
+	return clean(title), clean(description), nil
+}
+
+// bookmarkText composes a bookmark: the link, titled by the page, the
+// page's description as a quote, and the comment on it, if any.
+func bookmarkText(link, title, description, comment string) string {
+	var b strings.Builder
+	if title != "" {
+		fmt.Fprintf(&b, "[%s](%s)\n", strings.NewReplacer("[", `\[`, "]", `\]`).Replace(title), link)
+	} else {
+		fmt.Fprintf(&b, "<%s>\n", link)
+	}
+	if description != "" {
+		fmt.Fprintf(&b, "\n> %s\n", description)
+	}
+	if comment = strings.TrimSpace(comment); comment != "" {
+		fmt.Fprintf(&b, "\n%s\n", comment)
+	}
+	return b.String()
+}
+
+// postBookmark posts a bookmark of link. The comment is the given one,
+// or, without one, what is piped to standard input or composed after
+// the bookmark in the editor or the interactive input. If the page
+// cannot be fetched, the bare link is bookmarked.
+func postBookmark(url, token string, o postOptions, link, comment string) {
+	fmt.Fprintf(progress, "Fetching %s... ", link)
+	title, description, err := fetchBookmark(link)
+	if err != nil {
+		fmt.Fprintf(progress, "failed: %v\n", err)
+	} else {
+		fmt.Fprintln(progress, "done")
+	}
+
+	interactive := o.editor || term.IsTerminal(int(os.Stdin.Fd()))
+	if comment == "" && !interactive {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		comment = string(data)
+	}
+	text := bookmarkText(link, title, description, comment)
+	if comment == "" && interactive {
+		// Leave room for the comment after the bookmark.
+		composeAndPost(url, token, o, localDraft{Content: text + "\n"})
+		return
+	}
+	if err := postShowingProgress("Posting bookmark", url, token, o.request(o.title, text)); err != nil {
+		fmt.Fprintf(os.Stderr, "failed: %v\n", err)
+		os.Exit(1)
+	}
+}
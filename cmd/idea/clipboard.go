@@ -0,0 +1,83 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// clipboardCommands returns the commands that print the clipboard on
+// this system, in order of preference.
+func clipboardCommands() [][]string {
+	switch runtime.GOOS {
+	case "darwin":
+		return [][]string{{"pbpaste"}}
+	case "windows":
+		return [][]string{{"powershell", "-NoProfile", "-Command",
+			"[Console]::OutputEncoding = [Text.Encoding]::UTF8; Get-Clipboard -Raw"}}
+	}
+	cmds := [][]string{
+		{"xclip", "-selection", "clipboard", "-out"},
+		{"xsel", "--clipboard", "--output"},
+	}
+	if os.Getenv("WAYLAND_DISPLAY") != "" {
+		cmds = append([][]string{{"wl-paste", "--no-newline"}}, cmds...)
+	}
+	return cmds
+}
+
+// readClipboard returns the text on the system clipboard.
+func readClipboard() (string, error) {
+	var errs []error
+	for _, args := range clipboardCommands() {
+		var stderr bytes.Buffer
+		cmd := exec.Command(args[0], args[1:]...)
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		if errors.Is(err, exec.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w: %s", args[0], err, strings.TrimSpace(stderr.String())))
+			continue
+		}
+		return string(out), nil
+	}
+	if len(errs) == 0 {
+		return "", errors.New("no clipboard tool found, install wl-clipboard, xclip, or xsel")
+	}
+	return "", errors.Join(errs...)
+}
+
+// postClipboard posts the clipboard as an idea, or opens it in the
+// external editor first with -e.
+func postClipboard(url, token string, o postOptions) {
+	text, err := readClipboard()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	text = strings.TrimSpace(text)
+	if text == "" {
+		fmt.Fprintln(os.Stderr, "the clipboard is empty")
+		os.Exit(1)
+	}
+	if o.editor {
+		composeAndPost(url, token, o, localDraft{Content: text})
+		return
+	}
+
+	err = postShowingProgress("Posting idea from the clipboard", url, token, o.request(o.title, text))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,18 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+//go:build !windows
+
+package main
+
+import (
+	"io"
+	"os"
+)
+
+// openConsole returns the reader of key input. Terminals other than the
+// Windows console need no preparation beyond raw mode.
+func openConsole() (io.Reader, func(), error) {
+	return os.Stdin, func() {}, nil
+}
@@ -0,0 +1,86 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"io"
+	"os"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"golang.org/x/sys/windows"
+)
+
+const cpUTF8 = 65001
+
+// openConsole prepares the Windows console for the line editor: escape
+// sequences in the output are interpreted instead of printed, and output
+// is UTF-8. The returned reader reads keys from the console, which
+// term.MakeRaw already switched to sending escape sequences.
+func openConsole() (io.Reader, func(), error) {
+	in := windows.Handle(os.Stdin.Fd())
+	out := windows.Handle(os.Stdout.Fd())
+
+	var mode uint32
+	if err := windows.GetConsoleMode(out, &mode); err != nil {
+		return nil, nil, err
+	}
+	vt := mode | windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING | windows.DISABLE_NEWLINE_AUTO_RETURN
+	if err := windows.SetConsoleMode(out, vt); err != nil {
+		return nil, nil, err // before Windows 10
+	}
+	cp, _ := windows.GetConsoleOutputCP()
+	windows.SetConsoleOutputCP(cpUTF8)
+
+	restore := func() {
+		windows.SetConsoleMode(out, mode)
+		if cp != 0 {
+			windows.SetConsoleOutputCP(cp)
+		}
+	}
+	return &consoleReader{h: in}, restore, nil
+}
+
+// consoleReader reads UTF-16 console input as UTF-8. Reading os.Stdin
+// would do the same, but it treats Ctrl+Z as the end of input.
+type consoleReader struct {
+	h    windows.Handle
+	buf  [256]uint16
+	high uint16 // first half of a surrogate pair split across reads
+}
+
+func (c *consoleReader) Read(b []byte) (int, error) {
+	// A UTF-16 unit takes at most 3 bytes in UTF-8, and one more unit
+	// may be carried over from the last read.
+	max := min(len(c.buf), len(b)/3-1)
+	if max <= 0 {
+		return 0, io.ErrShortBuffer
+	}
+	for {
+		var n uint32
+		if err := windows.ReadConsole(c.h, &c.buf[0], uint32(max), &n, nil); err != nil {
+			return 0, err
+		}
+		if n == 0 {
+			return 0, io.EOF
+		}
+		units := c.buf[:n]
+		if c.high != 0 {
+			units = append([]uint16{c.high}, units...)
+			c.high = 0
+		}
+		if last := units[len(units)-1]; utf16.IsSurrogate(rune(last)) && last < 0xdc00 {
+			c.high = last
+			units = units[:len(units)-1]
+		}
+		out := b[:0]
+		for _, r := range utf16.Decode(units) {
+			out = utf8.AppendRune(out, r)
+		}
+		if len(out) > 0 {
+			return len(out), nil
+		}
+	}
+}
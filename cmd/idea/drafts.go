@@ -0,0 +1,232 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"cmp"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// localDraft is an idea saved on this machine to finish later. Drafts
+// are markdown files with the title as a leading "# " heading, like the
+// files "idea watch" posts, and encrypted once "idea unlock" turned
+// encryption on.
+type localDraft struct {
+	Name     string
+	Title    string
+	Content  string
+	Modified time.Time
+}
+
+// draftsDir returns the directory of local drafts, $IDEA_DRAFTS or
+// "idea/drafts" in the user's config directory.
+func draftsDir() (string, error) {
+	if dir := os.Getenv("IDEA_DRAFTS"); dir != "" {
+		return dir, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "idea", "drafts"), nil
+}
+
+// listDrafts returns the local drafts, most recently saved first.
+func listDrafts() ([]localDraft, error) {
+	dir, err := draftsDir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var drafts []localDraft
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(strings.TrimSuffix(e.Name(), sealedExt), ".md")
+		if !ok || e.IsDir() || strings.HasPrefix(name, ".") {
+			continue
+		}
+		d, err := loadDraft(name)
+		if err != nil {
+			return nil, err
+		}
+		drafts = append(drafts, d)
+	}
+	slices.SortFunc(drafts, func(a, b localDraft) int {
+		return b.Modified.Compare(a.Modified)
+	})
+	return drafts, nil
+}
+
+// loadDraft reads the draft with the given name.
+func loadDraft(name string) (localDraft, error) {
+	dir, err := draftsDir()
+	if err != nil {
+		return localDraft{}, err
+	}
+	data, info, err := readLocal(filepath.Join(dir, name+".md"))
+	if err != nil {
+		return localDraft{}, err
+	}
+	title, content := splitNote(string(data))
+	return localDraft{Name: name, Title: title, Content: content, Modified: info.ModTime()}, nil
+}
+
+// saveDraft writes a draft and returns its name. An empty name saves a
+// new draft named after the current time.
+func saveDraft(name, title, content string) (string, error) {
+	dir, err := draftsDir()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", err
+	}
+	if name == "" {
+		name = time.Now().Format("20060102-150405")
+	}
+	if err := writeLocal(filepath.Join(dir, name+".md"), []byte(draftText(title, content))); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// draftText formats a draft as markdown with the title as a heading.
+func draftText(title, content string) string {
+	if title != "" {
+		return "# " + title + "\n\n" + content + "\n"
+	}
+	return content + "\n"
+}
+
+// deleteDraft removes the draft with the given name.
+func deleteDraft(name string) error {
+	dir, err := draftsDir()
+	if err != nil {
+		return err
+	}
+	return removeLocal(filepath.Join(dir, name+".md"))
+}
+
+// findDraft resolves a draft by name or by its number in the list.
+func findDraft(arg string) (localDraft, error) {
+	drafts, err := listDrafts()
+	if err != nil {
+		return localDraft{}, err
+	}
+	if i, err := strconv.Atoi(arg); err == nil && i >= 1 && i <= len(drafts) {
+		return drafts[i-1], nil
+	}
+	name := strings.TrimSuffix(arg, ".md")
+	for _, d := range drafts {
+		if d.Name == name {
+			return d, nil
+		}
+	}
+	return localDraft{}, fmt.Errorf("no draft %q", arg)
+}
+
+// runDrafts implements the "drafts" subcommand:
+//
+//	idea drafts                                    list drafts
+//	idea drafts resume [flags] <n | name>
+//	idea drafts rm <n | name>
+//
+// Drafts are saved with Ctrl+S in the interactive input. Resuming one
+// opens it for editing and posts it; the draft is deleted once posted.
+func runDrafts(args []string) {
+	cmd := "list"
+	if len(args) > 0 {
+		cmd, args = args[0], args[1:]
+	}
+	switch cmd {
+	case "list", "ls":
+		drafts, err := listDrafts()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		if len(drafts) == 0 {
+			fmt.Println("no drafts")
+			return
+		}
+		for i, d := range drafts {
+			fmt.Printf("%2d  %s  %s  %s\n", i+1, d.Name, d.Modified.Format("2006-01-02 15:04"), draftSummary(d))
+		}
+
+	case "resume":
+		fset := flag.NewFlagSet("drafts resume", flag.ExitOnError)
+		o := postOptions{}
+		fset.BoolVar(&o.draft, "d", false, "post as a draft, hidden from the live site")
+		fset.BoolVar(&o.noCrosspost, "no-crosspost", false, "do not announce the idea on social media")
+		fset.BoolVar(&o.allowSecrets, "allow-secrets", false, "publish the idea even if it seems to contain a credential")
+		fset.BoolVar(&o.allowDuplicate, "allow-duplicate", false, "post the idea even if it seems to repeat an earlier one")
+		fset.Func("tags", "comma-separated `tags` that become the idea's categories", func(s string) error {
+			o.tags = parseTags(s)
+			return nil
+		})
+		lang := fset.String("lang", "auto", "language of the idea, `en`, zh, or auto")
+		o.skipFlags(fset)
+		fset.BoolVar(&o.editor, "e", os.Getenv("IDEA_EDITOR") != "", "continue in $IDEA_EDITOR, $VISUAL, or $EDITOR")
+		fset.Usage = func() {
+			fmt.Fprintln(os.Stderr, "usage: idea drafts resume [flags] <n | name>")
+			fset.PrintDefaults()
+		}
+		fset.Parse(args)
+		o.lang = checkLang(*lang)
+		if fset.NArg() != 1 {
+			fset.Usage()
+			os.Exit(2)
+		}
+		d, err := findDraft(fset.Arg(0))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		composeAndPost(serverURL(), authenticate(), o, d)
+
+	case "rm", "delete":
+		if len(args) != 1 {
+			fmt.Fprintln(os.Stderr, "usage: idea drafts rm <n | name>")
+			os.Exit(2)
+		}
+		d, err := findDraft(args[0])
+		if err == nil {
+			err = deleteDraft(d.Name)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("deleted %s\n", d.Name)
+
+	default:
+		fmt.Fprintln(os.Stderr, "usage: idea drafts [list | resume <n | name> | rm <n | name>]")
+		os.Exit(2)
+	}
+}
+
+// draftSummary returns the title of a draft, or the start of its
+// content if it has none.
+func draftSummary(d localDraft) string {
+	s := cmp.Or(d.Title, d.Content)
+	s, _, _ = strings.Cut(s, "\n")
+	if r := []rune(s); len(r) > 60 {
+		s = string(r[:60]) + "…"
+	}
+	return s
+}
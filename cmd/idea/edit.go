@@ -0,0 +1,102 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// updateIdea replaces a published idea's markdown. sha is the blob SHA
+// the edit started from; the server refuses the update if the idea has
+// changed since. With retranslate, the server polishes the text in lang,
+// or the language edited if empty, and translates it again.
+func updateIdea(url, token, id, markdown, sha string, retranslate bool, lang string, allowSecrets bool) (*publishedIdea, error) {
+	body, _ := json.Marshal(map[string]any{"markdown": markdown, "sha": sha, "retranslate": retranslate, "lang": lang, "allow_secrets": allowSecrets})
+	req, _ := http.NewRequest("PUT", url+"/ideas/"+id, bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusUnauthorized {
+		return nil, errUnauthorized
+	}
+
+	var result struct {
+		OK bool `json:"ok"`
+		apiError
+		Idea publishedIdea `json:"idea"`
+	}
+	json.NewDecoder(resp.Body).Decode(&result)
+	if !result.OK {
+		return nil, result.err(resp)
+	}
+	return &result.Idea, nil
+}
+
+// runEdit implements the "edit" subcommand:
+//
+//	idea edit [-retranslate] [-lang en|zh|auto] [-allow-secrets] <id>
+//
+// It opens a published idea's markdown, front matter included, in
+// $IDEA_EDITOR, $VISUAL, or $EDITOR and commits the saved file back.
+// If the update fails, the edited file is kept so the work is not lost.
+func runEdit(args []string) {
+	fset := flag.NewFlagSet("edit", flag.ExitOnError)
+	retranslate := fset.Bool("retranslate", false, "polish the edited language and translate it again into the other")
+	lang := fset.String("lang", "auto", "language edited, `en`, zh, or auto to tell from the edit")
+	allowSecrets := fset.Bool("allow-secrets", false, "commit the edit even if it seems to contain a credential")
+	fset.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: idea edit [-retranslate] [-lang en|zh|auto] [-allow-secrets] <id>")
+		fset.PrintDefaults()
+	}
+	fset.Parse(args)
+	if fset.NArg() != 1 {
+		fset.Usage()
+		os.Exit(2)
+	}
+	checkLang(*lang)
+	url, token := serverURL(), authenticate()
+	idea, err := fetchIdea(url, token, fset.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed: %v\n", err)
+		os.Exit(1)
+	}
+	edited, err := composeInEditor(idea.Markdown)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	if strings.TrimSpace(edited) == strings.TrimSpace(idea.Markdown) {
+		fmt.Println("no changes")
+		return
+	}
+
+	updated, err := updateIdea(url, token, idea.ID, edited, idea.SHA, *retranslate, *lang, *allowSecrets)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed: %v\n", err)
+		if f, ferr := os.CreateTemp("", "idea-edit-*.md"); ferr == nil {
+			f.WriteString(edited)
+			f.Close()
+			fmt.Fprintf(os.Stderr, "your edit is saved in %s\n", f.Name())
+		}
+		switch errorCode(err) {
+		case "conflict":
+			fmt.Fprintf(os.Stderr, "the idea changed since it was opened; run idea edit %s again and reapply the edit\n", idea.ID)
+		case "secret_detected":
+			fmt.Fprintln(os.Stderr, "Remove the secret, or edit again with -allow-secrets if it is not a real one.")
+		}
+		os.Exit(1)
+	}
+	fmt.Printf("updated %s\n", updated.URL)
+}
@@ -0,0 +1,59 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// editorCommand returns the external editor to compose ideas in, from
+// IDEA_EDITOR, VISUAL, or EDITOR. The value may carry arguments, such as
+// "code --wait".
+func editorCommand() []string {
+	ed := cmp.Or(os.Getenv("IDEA_EDITOR"), os.Getenv("VISUAL"), os.Getenv("EDITOR"))
+	if ed == "" {
+		ed = "vi"
+		if runtime.GOOS == "windows" {
+			ed = "notepad"
+		}
+	}
+	return strings.Fields(ed)
+}
+
+// composeInEditor opens the external editor on a temporary markdown file
+// holding initial and returns the saved content.
+func composeInEditor(initial string) (string, error) {
+	f, err := os.CreateTemp("", "idea-*.md")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(f.Name())
+	_, err = f.WriteString(initial)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return "", err
+	}
+
+	args := editorCommand()
+	cmd := exec.Command(args[0], append(args[1:], f.Name())...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("editor %s: %w", args[0], err)
+	}
+	data, err := os.ReadFile(f.Name())
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
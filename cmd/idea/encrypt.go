@@ -0,0 +1,329 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// Drafts and the recovery file can be encrypted at rest, since ideas may
+// hold half-formed thoughts not meant for anyone else. As with age's
+// X25519 recipients, files are sealed to a public key kept next to the
+// drafts, so saving needs no secret, and opening them needs the private
+// key, derived from a secret in the OS keychain. "idea unlock" turns
+// encryption on and hands the key to a shell session, which saves asking
+// the keychain every time.
+
+const (
+	// sealedExt is appended to the name of an encrypted file.
+	sealedExt = ".sealed"
+	// sealMagic starts every encrypted file.
+	sealMagic = "idea-sealed-v1\n"
+	// keychainKeyAccount is the keychain entry of the secret the key is
+	// derived from.
+	keychainKeyAccount = "local-key"
+)
+
+// publicKeyPath returns the file of the public key, whose presence turns
+// encryption on.
+func publicKeyPath() (string, error) {
+	dir, err := draftsDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, ".key.pub"), nil
+}
+
+// recipient returns the public key files are encrypted to, or nil if
+// encryption is off.
+func recipient() (*ecdh.PublicKey, error) {
+	path, err := publicKeyPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return ecdh.X25519().NewPublicKey(b)
+}
+
+var identity = sync.OnceValues(loadIdentity)
+
+// loadIdentity returns the private key from $IDEA_SESSION, set by "idea
+// unlock", or else derives it from the secret in the keychain.
+func loadIdentity() (*ecdh.PrivateKey, error) {
+	if s := os.Getenv("IDEA_SESSION"); s != "" {
+		b, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return nil, fmt.Errorf("parse IDEA_SESSION: %w", err)
+		}
+		return ecdh.X25519().NewPrivateKey(b)
+	}
+	secret, err := keychainGet(keychainService, keychainKeyAccount)
+	if errors.Is(err, errNoCredentials) {
+		return nil, fmt.Errorf("the key of the encrypted drafts is not in %s", keychainName)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return deriveIdentity(strings.TrimSpace(secret))
+}
+
+// deriveIdentity derives the private key from the keychain secret.
+func deriveIdentity(secret string) (*ecdh.PrivateKey, error) {
+	b, err := base64.StdEncoding.DecodeString(secret)
+	if err != nil {
+		return nil, fmt.Errorf("parse the key in %s: %w", keychainName, err)
+	}
+	key, err := hkdf.Key(sha256.New, b, nil, "idea local files", 32)
+	if err != nil {
+		return nil, err
+	}
+	return ecdh.X25519().NewPrivateKey(key)
+}
+
+// sealKey derives the key of one file from the shared secret of its
+// ephemeral key and the recipient.
+func sealKey(shared []byte, ephemeral, recipient *ecdh.PublicKey) (cipher.AEAD, error) {
+	salt := append(ephemeral.Bytes(), recipient.Bytes()...)
+	key, err := hkdf.Key(sha256.New, shared, salt, "idea sealed file", 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts data to pub: the magic line, an ephemeral public key, a
+// nonce, and the AES-GCM ciphertext.
+func seal(pub *ecdh.PublicKey, data []byte) ([]byte, error) {
+	eph, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	shared, err := eph.ECDH(pub)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := sealKey(shared, eph.PublicKey(), pub)
+	if err != nil {
+		return nil, err
+	}
+	out := append([]byte(sealMagic), eph.PublicKey().Bytes()...)
+	nonce := make([]byte, aead.NonceSize())
+	rand.Read(nonce)
+	out = append(out, nonce...)
+	return aead.Seal(out, nonce, data, []byte(sealMagic)), nil
+}
+
+// unseal decrypts what seal encrypted.
+func unseal(key *ecdh.PrivateKey, data []byte) ([]byte, error) {
+	rest, ok := bytes.CutPrefix(data, []byte(sealMagic))
+	if !ok || len(rest) < 32 {
+		return nil, errors.New("not an encrypted file")
+	}
+	eph, err := ecdh.X25519().NewPublicKey(rest[:32])
+	if err != nil {
+		return nil, err
+	}
+	shared, err := key.ECDH(eph)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := sealKey(shared, eph, key.PublicKey())
+	if err != nil {
+		return nil, err
+	}
+	rest = rest[32:]
+	if len(rest) < aead.NonceSize() {
+		return nil, errors.New("truncated encrypted file")
+	}
+	out, err := aead.Open(nil, rest[:aead.NonceSize()], rest[aead.NonceSize():], []byte(sealMagic))
+	if err != nil {
+		return nil, errors.New("wrong key or corrupted file")
+	}
+	return out, nil
+}
+
+// readLocal reads a file kept on this machine, decrypting it if it is
+// stored encrypted.
+func readLocal(path string) ([]byte, os.FileInfo, error) {
+	if info, err := os.Stat(path + sealedExt); err == nil {
+		data, err := os.ReadFile(path + sealedExt)
+		if err != nil {
+			return nil, nil, err
+		}
+		key, err := identity()
+		if err != nil {
+			return nil, nil, err
+		}
+		data, err = unseal(key, data)
+		if err != nil {
+			return nil, nil, fmt.Errorf("decrypt %s: %w", path+sealedExt, err)
+		}
+		return data, info, nil
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	data, err := os.ReadFile(path)
+	return data, info, err
+}
+
+// writeLocal writes a file kept on this machine, encrypted if encryption
+// is on.
+func writeLocal(path string, data []byte) error {
+	pub, err := recipient()
+	if err != nil {
+		return err
+	}
+	if pub == nil {
+		return os.WriteFile(path, data, 0o600)
+	}
+	sealed, err := seal(pub, data)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path+sealedExt, sealed, 0o600); err != nil {
+		return err
+	}
+	// A copy from before encryption was turned on.
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// removeLocal removes a file kept on this machine, in either form.
+func removeLocal(path string) error {
+	errSealed, errPlain := os.Remove(path+sealedExt), os.Remove(path)
+	switch {
+	case errSealed == nil || errPlain == nil:
+		return nil
+	case !errors.Is(errSealed, os.ErrNotExist):
+		return errSealed
+	}
+	return errPlain
+}
+
+// enableEncryption creates the key in the keychain and encrypts the
+// drafts and the recovery file saved so far.
+func enableEncryption() (*ecdh.PrivateKey, error) {
+	secret := make([]byte, 32)
+	rand.Read(secret)
+	enc := base64.StdEncoding.EncodeToString(secret)
+	if err := keychainSet(keychainService, keychainKeyAccount, enc); err != nil {
+		return nil, err
+	}
+	// Some keychain tools report failures only in their output.
+	if got, err := keychainGet(keychainService, keychainKeyAccount); err != nil || strings.TrimSpace(got) != enc {
+		return nil, fmt.Errorf("the key was not stored in %s: %v", keychainName, err)
+	}
+	key, err := deriveIdentity(enc)
+	if err != nil {
+		return nil, err
+	}
+
+	path, err := publicKeyPath()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+	pub := base64.StdEncoding.EncodeToString(key.PublicKey().Bytes())
+	if err := os.WriteFile(path, []byte(pub+"\n"), 0o600); err != nil {
+		return nil, err
+	}
+
+	dir := filepath.Dir(path)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".md") {
+			continue
+		}
+		p := filepath.Join(dir, e.Name())
+		data, err := os.ReadFile(p)
+		if err == nil {
+			err = writeLocal(p, data)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("encrypt %s: %w", e.Name(), err)
+		}
+	}
+	return key, nil
+}
+
+// runUnlock implements the "unlock" subcommand:
+//
+//	eval "$(idea unlock)"
+//
+// It prints the command that hands the key of the encrypted drafts to
+// the shell session, so the keychain is not asked each time. The first
+// time, it turns encryption on: it creates the key and encrypts the
+// drafts saved so far.
+func runUnlock(args []string) {
+	if len(args) != 0 {
+		fmt.Fprintln(os.Stderr, `usage: eval "$(idea unlock)"`)
+		os.Exit(2)
+	}
+	pub, err := recipient()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	var key *ecdh.PrivateKey
+	if pub == nil {
+		key, err = enableEncryption()
+		if err == nil {
+			fmt.Fprintf(os.Stderr, "Drafts are now encrypted; the key is kept in %s.\n", keychainName)
+		}
+	} else {
+		key, err = identity()
+		if err == nil && !key.PublicKey().Equal(pub) {
+			err = errors.New("the key does not match the one the drafts are encrypted to")
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	session := base64.StdEncoding.EncodeToString(key.Bytes())
+	if runtime.GOOS == "windows" {
+		fmt.Printf("$env:IDEA_SESSION = %q\n", session)
+		return
+	}
+	fmt.Printf("export IDEA_SESSION=%s\n", session)
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSeal(t *testing.T) {
+	key, _ := ecdh.X25519().GenerateKey(rand.Reader)
+	other, _ := ecdh.X25519().GenerateKey(rand.Reader)
+	plain := []byte("a half-formed thought")
+
+	sealed, err := seal(key.PublicKey(), plain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(sealed, plain) || !bytes.HasPrefix(sealed, []byte(sealMagic)) {
+		t.Fatalf("sealed = %q", sealed)
+	}
+	if got, err := unseal(key, sealed); err != nil || !bytes.Equal(got, plain) {
+		t.Fatalf("unseal = %q, %v; want %q", got, err, plain)
+	}
+	if again, _ := seal(key.PublicKey(), plain); bytes.Equal(again, sealed) {
+		t.Error("sealing twice gives the same bytes")
+	}
+
+	if _, err := unseal(other, sealed); err == nil {
+		t.Error("unsealed with the wrong key")
+	}
+	tampered := func(i int) []byte {
+		b := bytes.Clone(sealed)
+		b[i] ^= 1
+		return b
+	}
+	for name, data := range map[string][]byte{
+		"ephemeral key": tampered(len(sealMagic)),
+		"nonce":         tampered(len(sealMagic) + 32),
+		"ciphertext":    tampered(len(sealMagic) + 32 + 12),
+		"tag":           tampered(len(sealed) - 1),
+		"truncated":     sealed[:len(sealMagic)+40],
+		"no magic":      sealed[len(sealMagic):],
+		"plain":         plain,
+	} {
+		if got, err := unseal(key, data); err == nil {
+			t.Errorf("%s: unsealed to %q", name, got)
+		}
+	}
+}
+
+func TestHistorySealsDrafts(t *testing.T) {
+	key, _ := ecdh.X25519().GenerateKey(rand.Reader)
+	dir := t.TempDir()
+	t.Setenv("IDEA_DRAFTS", dir)
+	t.Setenv("IDEA_HISTORY", filepath.Join(dir, "history.jsonl"))
+	t.Setenv("IDEA_SESSION", base64.StdEncoding.EncodeToString(key.Bytes()))
+	pub := base64.StdEncoding.EncodeToString(key.PublicKey().Bytes())
+	os.WriteFile(filepath.Join(dir, ".key.pub"), []byte(pub+"\n"), 0o600)
+
+	if err := appendHistory(historyEntry{Title: "Secret plan", Draft: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := appendHistory(historyEntry{Title: "Public idea"}); err != nil {
+		t.Fatal(err)
+	}
+	raw, _ := os.ReadFile(filepath.Join(dir, "history.jsonl"))
+	if strings.Contains(string(raw), "Secret plan") || !strings.Contains(string(raw), "Public idea") {
+		t.Errorf("history file = %s", raw)
+	}
+
+	entries, err := readHistory()
+	if err != nil || len(entries) != 2 || entries[0].Title != "Secret plan" || entries[0].Sealed != "" {
+		t.Errorf("readHistory = %+v, %v", entries, err)
+	}
+}
@@ -0,0 +1,52 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+)
+
+// postFiles posts each file as an idea, titled by its leading "# "
+// heading unless a title was given for a single file. It exits with an
+// error if any file fails, after trying them all.
+func postFiles(url, token string, o postOptions, paths []string) {
+	if o.title != "" && len(paths) > 1 {
+		fmt.Fprintln(os.Stderr, "-t applies to a single file; the others are titled by their headings")
+		os.Exit(2)
+	}
+	failed := 0
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			failed++
+			continue
+		}
+		title, content := splitNote(string(data))
+		if content == "" {
+			// A file holding only a heading is the idea itself.
+			title, content = "", title
+		}
+		if content == "" {
+			fmt.Fprintf(progress, "Skipping %s, it is empty\n", path)
+			continue
+		}
+
+		fmt.Fprintf(progress, "Posting %s... ", path)
+		err = postIdea(url, token, o.request(cmp.Or(o.title, title), content))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed: %v\n", err)
+			failed++
+			continue
+		}
+		fmt.Fprintln(progress, "done")
+	}
+	if failed > 0 {
+		fmt.Fprintf(os.Stderr, "%d of %d files failed\n", failed, len(paths))
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,251 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"cmp"
+	"crypto/ecdh"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// historyEntry records an idea the server accepted.
+type historyEntry struct {
+	Time    time.Time `json:"time"`
+	Server  string    `json:"server"`
+	Profile string    `json:"profile,omitempty"`
+	Title   string    `json:"title,omitempty"`
+	Excerpt string    `json:"excerpt,omitempty"` // start of the content, if untitled
+	Draft   bool      `json:"draft,omitempty"`
+	Tags    []string  `json:"tags,omitempty"`
+	// The server publishes in the background; Job is the job doing
+	// so, Path the file it reported, if any, and Message what it replied.
+	Job     string `json:"job,omitempty"`
+	Path    string `json:"path,omitempty"`
+	Message string `json:"message,omitempty"`
+	// With encryption on, the title and excerpt of a draft, which unlike
+	// a published idea is not public, are kept sealed instead.
+	Sealed string `json:"sealed,omitempty"`
+}
+
+// historyText is what is sealed of a history entry.
+type historyText struct {
+	Title   string `json:"title,omitempty"`
+	Excerpt string `json:"excerpt,omitempty"`
+}
+
+// seal moves the title and excerpt of a draft to Sealed, encrypted, if
+// encryption is on.
+func (e *historyEntry) seal() error {
+	if !e.Draft {
+		return nil
+	}
+	pub, err := recipient()
+	if err != nil || pub == nil {
+		return err
+	}
+	data, err := json.Marshal(historyText{Title: e.Title, Excerpt: e.Excerpt})
+	if err != nil {
+		return err
+	}
+	sealed, err := seal(pub, data)
+	if err != nil {
+		return err
+	}
+	e.Title, e.Excerpt, e.Sealed = "", "", base64.StdEncoding.EncodeToString(sealed)
+	return nil
+}
+
+// open restores the title and excerpt sealed with key.
+func (e *historyEntry) open(key *ecdh.PrivateKey) error {
+	sealed, err := base64.StdEncoding.DecodeString(e.Sealed)
+	if err != nil {
+		return err
+	}
+	data, err := unseal(key, sealed)
+	if err != nil {
+		return err
+	}
+	var t historyText
+	if err := json.Unmarshal(data, &t); err != nil {
+		return err
+	}
+	e.Title, e.Excerpt, e.Sealed = t.Title, t.Excerpt, ""
+	return nil
+}
+
+// newHistoryEntry returns the entry for an idea posted to url now.
+func newHistoryEntry(url string, idea map[string]any) historyEntry {
+	e := historyEntry{Time: time.Now(), Server: url, Profile: activeProfile}
+	e.Title, _ = idea["title"].(string)
+	e.Draft, _ = idea["draft"].(bool)
+	e.Tags, _ = idea["tags"].([]string)
+	if e.Title == "" {
+		content, _ := idea["content"].(string)
+		e.Excerpt = draftSummary(localDraft{Content: strings.TrimSpace(content)})
+	}
+	return e
+}
+
+// historyPath returns the posting history file, $IDEA_HISTORY or
+// "idea/history.jsonl" in $XDG_DATA_HOME, ~/.local/share by default.
+func historyPath() (string, error) {
+	if path := os.Getenv("IDEA_HISTORY"); path != "" {
+		return path, nil
+	}
+	dir := os.Getenv("XDG_DATA_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dir, "idea", "history.jsonl"), nil
+}
+
+// recordHistory appends an entry to the posting history. The history is
+// only a convenience, so failing to write it is reported but not fatal.
+func recordHistory(e historyEntry) {
+	if err := appendHistory(e); err != nil {
+		fmt.Fprintf(os.Stderr, "record history: %v\n", err)
+	}
+}
+
+func appendHistory(e historyEntry) error {
+	path, err := historyPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	if err := e.seal(); err != nil {
+		return err
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(line, '\n'))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// readHistory returns the posting history, oldest first, with sealed
+// entries opened if the key is at hand. Lines that do not parse are
+// skipped.
+func readHistory() ([]historyEntry, error) {
+	path, err := historyPath()
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var entries []historyEntry
+	sc := bufio.NewScanner(f)
+	sc.Buffer(nil, 1<<20)
+	for sc.Scan() {
+		var e historyEntry
+		if json.Unmarshal(sc.Bytes(), &e) == nil {
+			entries = append(entries, e)
+		}
+	}
+	for i := range entries {
+		e := &entries[i]
+		if e.Sealed == "" {
+			continue
+		}
+		key, err := identity()
+		if err == nil {
+			err = e.open(key)
+		}
+		if err != nil {
+			e.Excerpt = "(encrypted)"
+		}
+	}
+	return entries, sc.Err()
+}
+
+// runHistory implements the "history" subcommand:
+//
+//	idea history [-n count] [-json] [query]
+//
+// It lists the ideas posted from this machine, most recent first,
+// optionally only those whose title or excerpt contains query.
+func runHistory(args []string) {
+	fset := flag.NewFlagSet("history", flag.ExitOnError)
+	n := fset.Int("n", 20, "show at most `count` entries, 0 for all")
+	asJSON := fset.Bool("json", false, "print the entries as JSON lines")
+	fset.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: idea history [-n count] [-json] [query]")
+		fset.PrintDefaults()
+	}
+	fset.Parse(args)
+	query := strings.ToLower(strings.Join(fset.Args(), " "))
+
+	entries, err := readHistory()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	slices.Reverse(entries)
+	if query != "" {
+		entries = slices.DeleteFunc(entries, func(e historyEntry) bool {
+			return !strings.Contains(strings.ToLower(e.Title+"\n"+e.Excerpt), query)
+		})
+	}
+	if *n > 0 && len(entries) > *n {
+		entries = entries[:*n]
+	}
+	if len(entries) == 0 {
+		fmt.Println("no history")
+		return
+	}
+
+	var b strings.Builder
+	for _, e := range entries {
+		if *asJSON {
+			line, _ := json.Marshal(e)
+			fmt.Fprintf(&b, "%s\n", line)
+			continue
+		}
+		flags := ""
+		if e.Draft {
+			flags = " [draft]"
+		}
+		for _, tag := range e.Tags {
+			flags += " #" + tag
+		}
+		fmt.Fprintf(&b, "%s  %s%s\n", e.Time.Local().Format("2006-01-02 15:04"), cmp.Or(e.Title, e.Excerpt, "(untitled)"), flags)
+		fmt.Fprintf(&b, "                  %s\n", cmp.Or(e.Path, e.Server))
+	}
+	if *asJSON {
+		fmt.Print(b.String())
+		return
+	}
+	page(b.String())
+}
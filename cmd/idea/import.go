@@ -0,0 +1,135 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// runImport implements the "import" subcommand:
+//
+//	idea import [-augment=false] [-d] <export.zip | vault-dir | note.html>
+//
+// Directories such as an Obsidian vault are zipped in memory before
+// uploading; the server detects the export format.
+func runImport(args []string) {
+	fset := flag.NewFlagSet("import", flag.ExitOnError)
+	augment := fset.Bool("augment", true, "re-augment imported notes with the LLM")
+	draft := fset.Bool("d", false, "import as drafts, hidden from the live site")
+	fset.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: idea import [-augment=false] [-d] <export.zip | vault-dir | note.html>")
+		fset.PrintDefaults()
+	}
+	fset.Parse(args)
+	if fset.NArg() != 1 {
+		fset.Usage()
+		os.Exit(2)
+	}
+
+	src := fset.Arg(0)
+	info, err := os.Stat(src)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	var data []byte
+	if info.IsDir() {
+		data, err = zipDir(src)
+	} else {
+		data, err = os.ReadFile(src)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	url := fmt.Sprintf("%s/ideas/import?augment=%t&draft=%t", serverURL(), *augment, *draft)
+	token := authenticate()
+
+	fmt.Print("Uploading export... ")
+	req, _ := http.NewRequest("POST", url, bytes.NewReader(data))
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed: %v\n", err)
+		os.Exit(1)
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		OK      bool   `json:"ok"`
+		Message string `json:"message"`
+		Notes   []struct {
+			Title string `json:"title"`
+		} `json:"notes"`
+	}
+	json.NewDecoder(resp.Body).Decode(&result)
+
+	if !result.OK {
+		fmt.Fprintf(os.Stderr, "failed: %s\n", result.Message)
+		os.Exit(1)
+	}
+	fmt.Println(result.Message)
+	for _, n := range result.Notes {
+		fmt.Printf("  %s\n", n.Title)
+	}
+}
+
+// zipDir archives the markdown and HTML files of a directory.
+func zipDir(dir string) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		switch filepath.Ext(p) {
+		case ".md", ".html", ".htm":
+		default:
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		hdr, err := zip.FileInfoHeader(info)
+		if err != nil {
+			return err
+		}
+		hdr.Name = filepath.ToSlash(rel)
+		hdr.Method = zip.Deflate
+		w, err := zw.CreateHeader(hdr)
+		if err != nil {
+			return err
+		}
+		content, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(content)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
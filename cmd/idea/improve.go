@@ -0,0 +1,100 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// improveText has the server polish text without publishing it.
+func improveText(url, token, content string) (string, error) {
+	body, _ := json.Marshal(map[string]string{"content": content})
+	req, _ := http.NewRequest("POST", url+"/ideas/improve", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusUnauthorized {
+		return "", errUnauthorized
+	}
+
+	var result struct {
+		OK bool `json:"ok"`
+		apiError
+		Content string `json:"content"`
+	}
+	json.NewDecoder(resp.Body).Decode(&result)
+	if !result.OK {
+		return "", result.err(resp)
+	}
+	return result.Content, nil
+}
+
+// runImprove implements the "improve" subcommand:
+//
+//	idea improve [-f file] [-e]
+//
+// It sends text from a file, the editor, or standard input to the server
+// and prints the improved version. Nothing is published. With neither -f
+// nor -e, text is read from standard input if it is piped, and written
+// in the editor otherwise.
+func runImprove(args []string) {
+	fset := flag.NewFlagSet("improve", flag.ExitOnError)
+	file := fset.String("f", "", "improve the text of `file`")
+	useEditor := fset.Bool("e", false, "write the text in $IDEA_EDITOR, $VISUAL, or $EDITOR")
+	fset.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: idea improve [-f file] [-e]")
+		fset.PrintDefaults()
+	}
+	fset.Parse(args)
+	if fset.NArg() > 0 || *file != "" && *useEditor {
+		fset.Usage()
+		os.Exit(2)
+	}
+
+	var data []byte
+	var err error
+	switch {
+	case *file != "":
+		data, err = os.ReadFile(*file)
+	case *useEditor || term.IsTerminal(int(os.Stdin.Fd())):
+		var s string
+		s, err = composeInEditor("")
+		data = []byte(s)
+	default:
+		data, err = io.ReadAll(os.Stdin)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	content := strings.TrimSpace(string(data))
+	if content == "" {
+		fmt.Fprintln(os.Stderr, "nothing to improve")
+		os.Exit(1)
+	}
+
+	improved, err := improveText(serverURL(), authenticate(), content)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed: %v\n", err)
+		if errorCode(err) == "llm_timeout" {
+			fmt.Fprintln(os.Stderr, "the LLM took too long; try again, or improve a shorter text")
+		}
+		os.Exit(1)
+	}
+	fmt.Println(strings.TrimSpace(improved))
+}
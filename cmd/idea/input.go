@@ -0,0 +1,718 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+const (
+	prompt     = "> "
+	contPrompt = "  "
+)
+
+type escAction int
+
+const (
+	escNone escAction = iota
+	escNewline
+	escPasteStart
+	escPasteEnd
+	escLeft
+	escRight
+	escUp
+	escDown
+	escHome
+	escEnd
+	escDelete
+	escWordLeft
+	escWordRight
+	escDeleteWord
+	escYankPop
+	escRedo
+)
+
+// csiActions maps the final parameters of CSI sequences to actions.
+var csiActions = map[string]escAction{
+	"13;2u":    escNewline, // Shift+Enter (kitty protocol)
+	"27;2;13~": escNewline, // Shift+Enter (xterm modifyOtherKeys)
+	"200~":     escPasteStart,
+	"201~":     escPasteEnd,
+	"A":        escUp,
+	"B":        escDown,
+	"C":        escRight,
+	"D":        escLeft,
+	"H":        escHome,
+	"F":        escEnd,
+	"1~":       escHome,
+	"7~":       escHome,
+	"4~":       escEnd,
+	"8~":       escEnd,
+	"3~":       escDelete,
+	"1;3D":     escWordLeft, // Alt+Left
+	"1;5D":     escWordLeft, // Ctrl+Left
+	"1;3C":     escWordRight,
+	"1;5C":     escWordRight,
+}
+
+// altActions maps the key following ESC (Alt+key) to actions.
+var altActions = map[byte]escAction{
+	'\r': escNewline,
+	'\n': escNewline,
+	'b':  escWordLeft,
+	'f':  escWordRight,
+	'd':  escDeleteWord,
+	'y':  escYankPop,
+	'z':  escRedo,
+	'_':  escRedo,
+}
+
+// parseEscape tries to parse an escape sequence from data.
+// Returns (bytes consumed, action). Returns (0, escNone) if incomplete.
+func parseEscape(data []byte) (int, escAction) {
+	if len(data) < 2 || data[0] != 0x1b {
+		return 0, escNone
+	}
+
+	// ESC [ = CSI sequence.
+	if data[1] == '[' {
+		for i := 2; i < len(data); i++ {
+			ch := data[i]
+			if (ch >= 'A' && ch <= 'Z') || (ch >= 'a' && ch <= 'z') || ch == '~' {
+				return i + 1, csiActions[string(data[2:i+1])]
+			}
+		}
+		if len(data) > 20 {
+			return len(data), escNone
+		}
+		return 0, escNone // incomplete
+	}
+
+	// ESC O = SS3 sequence, sent for arrows and Home/End in application
+	// cursor mode.
+	if data[1] == 'O' {
+		if len(data) < 3 {
+			return 0, escNone // incomplete
+		}
+		return 3, csiActions[string(data[2])]
+	}
+
+	return 2, altActions[data[1]]
+}
+
+// lineEditor is the state of the multi-line input: the text, the cursor
+// position in it, and where the cursor is on the terminal.
+type lineEditor struct {
+	buf   []rune
+	cur   int // cursor index into buf
+	row   int // terminal row of the cursor, relative to the first input line
+	col   int // terminal column of the cursor
+	width int // terminal width, 0 if unknown
+
+	showStatus bool     // render the status line below the input
+	vi         *viState // the vi editing mode, if enabled
+
+	kills     [][]rune // killed text, most recent last
+	yankIdx   int      // kill ring entry last yanked
+	yankStart int      // start of the text last yanked, which ends at cur
+	yanked    bool     // whether the previous key was a yank
+
+	undos, redos []snapshot
+	lastEdit     editKind
+	editCur      int // cursor after the last edit
+}
+
+// snapshot is a buffer state to return to on undo.
+type snapshot struct {
+	buf []rune
+	cur int
+}
+
+type editKind int
+
+const (
+	editNone editKind = iota
+	editInsert
+	editDelete
+	editOther // never merged with the edit before it
+)
+
+const (
+	maxKills = 16  // size of the kill ring
+	maxUndos = 100 // buffer states kept for undo
+)
+
+// checkpoint saves the buffer for undo before an edit. A run of edits of
+// the same kind at the cursor, such as typing or holding backspace, is
+// undone at once.
+func (e *lineEditor) checkpoint(kind editKind) {
+	if kind != editOther && kind == e.lastEdit && e.cur == e.editCur {
+		return
+	}
+	e.undos = append(e.undos, snapshot{slices.Clone(e.buf), e.cur})
+	if len(e.undos) > maxUndos {
+		e.undos = e.undos[1:]
+	}
+	e.redos = nil
+	e.lastEdit = kind
+}
+
+// undo restores the buffer before the last edit.
+func (e *lineEditor) undo() bool {
+	if len(e.undos) == 0 {
+		return false
+	}
+	e.redos = append(e.redos, snapshot{e.buf, e.cur})
+	s := e.undos[len(e.undos)-1]
+	e.undos = e.undos[:len(e.undos)-1]
+	e.buf, e.cur = s.buf, s.cur
+	e.lastEdit = editNone
+	return true
+}
+
+// redo reapplies the last undone edit.
+func (e *lineEditor) redo() bool {
+	if len(e.redos) == 0 {
+		return false
+	}
+	e.undos = append(e.undos, snapshot{e.buf, e.cur})
+	s := e.redos[len(e.redos)-1]
+	e.redos = e.redos[:len(e.redos)-1]
+	e.buf, e.cur = s.buf, s.cur
+	e.lastEdit = editNone
+	return true
+}
+
+// replace replaces the runes between from and to with rs and moves the
+// cursor after them.
+func (e *lineEditor) replace(from, to int, rs []rune) {
+	e.buf = slices.Replace(e.buf, from, to, rs...)
+	e.cur = from + len(rs)
+	e.editCur = e.cur
+}
+
+func (e *lineEditor) insert(r rune) {
+	e.checkpoint(editInsert)
+	e.replace(e.cur, e.cur, []rune{r})
+}
+
+// insertText inserts rs at the cursor as one edit, undone at once.
+func (e *lineEditor) insertText(rs []rune) {
+	if len(rs) == 0 {
+		return
+	}
+	e.checkpoint(editOther)
+	e.replace(e.cur, e.cur, rs)
+}
+
+// delete removes the runes between from and to.
+func (e *lineEditor) delete(from, to int) {
+	e.checkpoint(editDelete)
+	e.replace(from, to, nil)
+}
+
+// kill deletes the runes between from and to and saves them in the kill
+// ring.
+func (e *lineEditor) kill(from, to int) {
+	if from == to {
+		return
+	}
+	e.save(e.buf[from:to])
+	e.checkpoint(editOther)
+	e.replace(from, to, nil)
+}
+
+// save adds a copy of rs to the kill ring.
+func (e *lineEditor) save(rs []rune) {
+	e.kills = append(e.kills, slices.Clone(rs))
+	if len(e.kills) > maxKills {
+		e.kills = e.kills[1:]
+	}
+}
+
+// yank inserts the most recently killed text at the cursor.
+func (e *lineEditor) yank() bool {
+	if len(e.kills) == 0 {
+		return false
+	}
+	e.yankIdx = len(e.kills) - 1
+	e.yankStart = e.cur
+	e.checkpoint(editOther)
+	e.replace(e.cur, e.cur, e.kills[e.yankIdx])
+	e.yanked = true
+	return true
+}
+
+// yankPop replaces the text just yanked with the previous kill ring
+// entry. It only works right after a yank.
+func (e *lineEditor) yankPop(afterYank bool) bool {
+	if !afterYank || len(e.kills) < 2 {
+		return false
+	}
+	e.yankIdx = (e.yankIdx + len(e.kills) - 1) % len(e.kills)
+	e.checkpoint(editOther)
+	e.replace(e.yankStart, e.cur, e.kills[e.yankIdx])
+	e.yanked = true
+	return true
+}
+
+// lineStart returns the index of the first rune of the cursor's line.
+func (e *lineEditor) lineStart() int {
+	i := e.cur
+	for i > 0 && e.buf[i-1] != '\n' {
+		i--
+	}
+	return i
+}
+
+// lineEnd returns the index of the newline ending the cursor's line, or
+// the end of the buffer.
+func (e *lineEditor) lineEnd() int {
+	i := e.cur
+	for i < len(e.buf) && e.buf[i] != '\n' {
+		i++
+	}
+	return i
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+// wordLeft returns the start of the word before the cursor.
+func (e *lineEditor) wordLeft() int {
+	i := e.cur
+	for i > 0 && !isWordRune(e.buf[i-1]) {
+		i--
+	}
+	for i > 0 && isWordRune(e.buf[i-1]) {
+		i--
+	}
+	return i
+}
+
+// wordRight returns the end of the word after the cursor.
+func (e *lineEditor) wordRight() int {
+	i := e.cur
+	for i < len(e.buf) && !isWordRune(e.buf[i]) {
+		i++
+	}
+	for i < len(e.buf) && isWordRune(e.buf[i]) {
+		i++
+	}
+	return i
+}
+
+// moveVertical moves the cursor to the previous (-1) or next (+1) line,
+// keeping its column where possible.
+func (e *lineEditor) moveVertical(dir int) {
+	start := e.lineStart()
+	col := e.cur - start
+	switch {
+	case dir < 0 && start > 0:
+		e.cur = start - 1 // end of the previous line
+		prev := e.lineStart()
+		e.cur = min(prev+col, start-1)
+	case dir > 0:
+		end := e.lineEnd()
+		if end == len(e.buf) {
+			return
+		}
+		e.cur = end + 1
+		e.cur = min(e.cur+col, e.lineEnd())
+	}
+}
+
+// render returns the terminal output that redraws the input and places
+// the cursor. Lines longer than the terminal are wrapped here rather than
+// by the terminal, so the editor knows which row every rune is on.
+func (e *lineEditor) render() string {
+	var b strings.Builder
+	if e.row > 0 {
+		fmt.Fprintf(&b, "\x1b[%dA", e.row)
+	}
+	b.WriteString("\r\x1b[J" + prompt)
+
+	width := e.width
+	if width <= len(prompt) {
+		width = math.MaxInt // unknown or too narrow to wrap
+	}
+	row, col := 0, len(prompt)
+	wrap := func() {
+		row++
+		col = len(contPrompt)
+		b.WriteString("\r\n" + contPrompt)
+	}
+	curRow, curCol := row, col
+	for i, r := range e.buf {
+		if r == '\n' {
+			if i == e.cur {
+				curRow, curCol = row, col
+			}
+			wrap()
+			continue
+		}
+		w := runeWidth(r)
+		if r == '\t' {
+			w = 8 - col%8
+		}
+		if col+w > width {
+			wrap()
+		}
+		if i == e.cur {
+			curRow, curCol = row, col
+		}
+		if r == '\t' {
+			b.WriteString(strings.Repeat(" ", w))
+		} else {
+			b.WriteRune(r)
+		}
+		col += w
+	}
+	if e.cur == len(e.buf) {
+		if col >= width {
+			wrap()
+		}
+		curRow, curCol = row, col
+	}
+	if e.showStatus {
+		status := statusLine(e.buf)
+		if e.vi != nil {
+			status = e.viLabel() + " " + status
+		}
+		if width != math.MaxInt {
+			status = truncateWidth(status, width-1)
+		}
+		b.WriteString("\r\n" + styleDim + status + styleReset)
+		row++
+	}
+
+	// The terminal cursor is now at the end of the output.
+	if row > curRow {
+		fmt.Fprintf(&b, "\x1b[%dA", row-curRow)
+	}
+	b.WriteString("\r")
+	if curCol > 0 {
+		fmt.Fprintf(&b, "\x1b[%dC", curCol)
+	}
+	e.row, e.col = curRow, curCol
+	return b.String()
+}
+
+// finish returns the terminal output that leaves the input as typed,
+// without the status line, and moves below it.
+func (e *lineEditor) finish() string {
+	e.cur = len(e.buf)
+	e.showStatus = false
+	return e.render() + "\r\n"
+}
+
+// errSaveDraft is returned by readInput, along with the input, when the
+// user asks to save it as a draft.
+var errSaveDraft = errors.New("save draft")
+
+// readInput reads an idea in the interactive line editor, starting with
+// initial, with the keys and editing mode of cfg. If changed is not nil,
+// it is called with the input whenever it changes.
+func readInput(initial string, cfg inputConfig, changed func(string)) (string, error) {
+	fd := int(os.Stdin.Fd())
+	oldState, err := term.MakeRaw(fd)
+	if err != nil {
+		return "", err
+	}
+	defer term.Restore(fd, oldState)
+
+	in, restoreConsole, err := openConsole()
+	if err != nil {
+		return "", fmt.Errorf("console: %w", err)
+	}
+	defer restoreConsole()
+
+	// Enable bracket paste mode.
+	os.Stdout.WriteString("\x1b[?2004h")
+	defer os.Stdout.WriteString("\x1b[?2004l")
+
+	e := lineEditor{buf: []rune(initial), showStatus: true}
+	e.cur = len(e.buf)
+	if cfg.vi {
+		e.vi = &viState{}
+	}
+	inPaste := false
+	var pasted []rune // text pasted so far, inserted when the paste ends
+	previewing := false
+
+	write := func(s string) { os.Stdout.WriteString(s) }
+	redraw := func() {
+		e.width = termWidth()
+		write(e.render())
+	}
+	redraw()
+
+	raw := make([]byte, 4096)
+	var pending []byte
+	last := initial
+	translated := false // the key at the start of pending is rebound
+
+	for {
+		n, err := in.Read(raw)
+		if err != nil {
+			return "", err
+		}
+		pending = append(pending, raw[:n]...)
+
+	keys:
+		for len(pending) > 0 {
+			// Any key closes the preview.
+			if previewing {
+				pending = nil
+				previewing = false
+				write("\x1b[?1049l")
+				break
+			}
+
+			// Rebound keys, which do not apply to pasted text, are
+			// replaced by the default input of their action.
+			if !inPaste && !translated {
+				if rest, action, ok := cfg.keys.translate(pending); ok {
+					pending = append([]byte(keyActions[action]), rest...)
+					translated = action != "none"
+					continue
+				}
+			}
+			translated = false
+
+			// Escape sequences.
+			if pending[0] == 0x1b {
+				consumed, action := parseEscape(pending)
+				if consumed == 0 && e.vi != nil && len(pending) == 1 && !inPaste {
+					// A lone Esc, as sequences arrive in one read.
+					pending = pending[1:]
+					e.viEscape()
+					redraw()
+					continue
+				}
+				if consumed == 0 {
+					break // incomplete
+				}
+				pending = pending[consumed:]
+				if inPaste && action != escPasteEnd {
+					continue // pasted text, not keys
+				}
+				afterYank := e.yanked
+				e.yanked = false
+				switch action {
+				case escNewline:
+					e.insert('\n')
+					redraw()
+				case escPasteStart:
+					inPaste = true
+				case escPasteEnd:
+					// The paste is inserted and drawn at once, however
+					// long it is.
+					inPaste = false
+					text := strings.NewReplacer("\r\n", "\n", "\r", "\n").Replace(string(pasted))
+					e.insertText([]rune(text))
+					pasted = pasted[:0]
+					redraw()
+				case escLeft:
+					if e.cur > 0 {
+						e.cur--
+						redraw()
+					}
+				case escRight:
+					if e.cur < len(e.buf) {
+						e.cur++
+						redraw()
+					}
+				case escUp:
+					e.moveVertical(-1)
+					redraw()
+				case escDown:
+					e.moveVertical(1)
+					redraw()
+				case escHome:
+					e.cur = e.lineStart()
+					redraw()
+				case escEnd:
+					e.cur = e.lineEnd()
+					redraw()
+				case escDelete:
+					if e.cur < len(e.buf) {
+						e.delete(e.cur, e.cur+1)
+						redraw()
+					}
+				case escWordLeft:
+					e.cur = e.wordLeft()
+					redraw()
+				case escWordRight:
+					e.cur = e.wordRight()
+					redraw()
+				case escDeleteWord:
+					if end := e.wordRight(); end > e.cur {
+						e.kill(e.cur, end)
+						redraw()
+					}
+				case escYankPop:
+					if e.yankPop(afterYank) {
+						redraw()
+					}
+				case escRedo:
+					if e.redo() {
+						redraw()
+					}
+				}
+				continue
+			}
+
+			if inPaste {
+				r, size := utf8.DecodeRune(pending)
+				if r == utf8.RuneError && size <= 1 && len(pending) < 4 {
+					break keys // incomplete UTF-8
+				}
+				pending = pending[size:]
+				if r >= 0x20 && r != utf8.RuneError || r == '\t' || r == '\n' || r == '\r' {
+					pasted = append(pasted, r)
+				}
+				continue
+			}
+
+			ch := pending[0]
+			e.yanked = false
+
+			// Keys are commands in vi normal mode.
+			if e.vi != nil && e.vi.normal && (ch >= 0x20 || ch == 0x12 || ch == 0x08) {
+				r, size := utf8.DecodeRune(pending)
+				if r == utf8.RuneError && size <= 1 && len(pending) < 4 {
+					break keys // incomplete UTF-8
+				}
+				pending = pending[size:]
+				switch ch {
+				case 0x12: // Ctrl+R: redo
+					e.redo()
+				case 0x7f, 0x08: // Backspace
+					e.viKey('h')
+				default:
+					e.viKey(r)
+				}
+				redraw()
+				continue
+			}
+
+			switch {
+			case ch == 0x03: // Ctrl+C
+				write(e.finish())
+				return "", fmt.Errorf("interrupted")
+
+			case ch == 0x01: // Ctrl+A: start of line
+				pending = pending[1:]
+				e.cur = e.lineStart()
+				redraw()
+
+			case ch == 0x05: // Ctrl+E: end of line
+				pending = pending[1:]
+				e.cur = e.lineEnd()
+				redraw()
+
+			case ch == 0x0b: // Ctrl+K: kill to end of line
+				pending = pending[1:]
+				end := e.lineEnd()
+				if end == e.cur && end < len(e.buf) {
+					end++ // join the next line
+				}
+				e.kill(e.cur, end)
+				redraw()
+
+			case ch == 0x19: // Ctrl+Y: yank
+				pending = pending[1:]
+				if e.yank() {
+					redraw()
+				}
+
+			case ch == 0x1a || ch == 0x1f: // Ctrl+Z or Ctrl+_: undo
+				pending = pending[1:]
+				if e.undo() {
+					redraw()
+				}
+
+			case ch == 0x13: // Ctrl+S: save as draft
+				write(e.finish())
+				return string(e.buf), errSaveDraft
+
+			case ch == 0x10: // Ctrl+P: preview
+				pending = pending[1:]
+				// Show the preview on the alternate screen, which keeps
+				// the input as it is.
+				write("\x1b[?1049h\x1b[H" + renderMarkdown(string(e.buf)) +
+					"\r\n" + styleDim + "Press any key to return to editing." + styleReset)
+				previewing = true
+
+			case ch == 0x15: // Ctrl+U: clear all
+				pending = pending[1:]
+				e.kill(0, len(e.buf))
+				redraw()
+
+			case ch == 0x17: // Ctrl+W: delete word
+				pending = pending[1:]
+				i := e.cur
+				for i > 0 && e.buf[i-1] == ' ' {
+					i--
+				}
+				for i > 0 && e.buf[i-1] != ' ' && e.buf[i-1] != '\n' {
+					i--
+				}
+				e.kill(i, e.cur)
+				redraw()
+
+			case ch == '\n': // Ctrl+J: newline
+				pending = pending[1:]
+				e.insert('\n')
+				redraw()
+
+			case ch == '\r': // Enter: submit
+				write(e.finish())
+				return string(e.buf), nil
+
+			case ch == 0x7f || ch == 0x08: // Backspace
+				pending = pending[1:]
+				if e.cur > 0 {
+					e.delete(e.cur-1, e.cur)
+					redraw()
+				}
+
+			default:
+				r, size := utf8.DecodeRune(pending)
+				if r == utf8.RuneError && size <= 1 && len(pending) < 4 {
+					break keys // incomplete UTF-8
+				}
+				if r == utf8.RuneError {
+					pending = pending[1:]
+					continue
+				}
+				pending = pending[size:]
+				if r >= 0x20 || r == '\t' {
+					e.insert(r)
+					redraw()
+				}
+			}
+		}
+
+		if inPaste {
+			continue
+		}
+		if s := string(e.buf); changed != nil && s != last {
+			changed(s)
+			last = s
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// Keys of the line editor, as readInput applies them.
+type editorKey func(e *lineEditor)
+
+func typed(s string) editorKey {
+	return func(e *lineEditor) {
+		for _, r := range s {
+			e.insert(r)
+		}
+	}
+}
+
+func moveTo(cur int) editorKey { return func(e *lineEditor) { e.cur = cur } }
+
+func backspace(e *lineEditor) { e.delete(e.cur-1, e.cur) }
+
+func undo(e *lineEditor) { e.undo() }
+
+func redo(e *lineEditor) { e.redo() }
+
+func yank(e *lineEditor) { e.yank() }
+
+// yankPop is Alt+Y, which only pops right after a yank.
+func yankPop(e *lineEditor) {
+	afterYank := e.yanked
+	e.yanked = false
+	e.yankPop(afterYank)
+}
+
+func kill(from, to int) editorKey { return func(e *lineEditor) { e.kill(from, to) } }
+
+func TestLineEditorEdits(t *testing.T) {
+	tests := []struct {
+		name    string
+		buf     string
+		cur     int
+		keys    []editorKey
+		want    string
+		wantCur int
+	}{
+		{name: "typing", keys: []editorKey{typed("héllo")}, want: "héllo", wantCur: 5},
+		{name: "typing is undone at once", keys: []editorKey{typed("hello"), undo}},
+		{name: "undo and redo", keys: []editorKey{typed("hi"), undo, redo}, want: "hi", wantCur: 2},
+		{name: "undo is cut where the cursor moved", keys: []editorKey{typed("ab"), moveTo(0), typed("x"), undo}, want: "ab"},
+		{name: "backspaces are undone at once", buf: "hello", cur: 5, keys: []editorKey{backspace, backspace, undo}, want: "hello", wantCur: 5},
+		{name: "typing and deleting are two edits", keys: []editorKey{typed("ab"), backspace, undo}, want: "ab", wantCur: 2},
+		{name: "paste is its own edit", buf: "a", cur: 1, keys: []editorKey{typed("b"), func(e *lineEditor) { e.insertText([]rune("cd")) }, undo}, want: "ab", wantCur: 2},
+		{name: "an edit clears redo", keys: []editorKey{typed("ab"), undo, typed("c"), redo}, want: "c", wantCur: 1},
+		{name: "nothing to undo", buf: "abc", cur: 1, keys: []editorKey{undo, redo}, want: "abc", wantCur: 1},
+		{name: "kill and yank", buf: "foo bar", cur: 4, keys: []editorKey{kill(4, 7), yank, yank}, want: "foo barbar", wantCur: 10},
+		{name: "kill is undone", buf: "foo bar", keys: []editorKey{kill(0, 4), undo}, want: "foo bar"},
+		{name: "empty kill", buf: "foo", keys: []editorKey{kill(1, 1), yank}, want: "foo"},
+		{name: "yank pop", buf: "one two", keys: []editorKey{kill(4, 7), kill(0, 4), yank, yankPop}, want: "two", wantCur: 3},
+		{name: "yank pop cycles", buf: "one two", keys: []editorKey{kill(4, 7), kill(0, 4), yank, yankPop, yankPop}, want: "one ", wantCur: 4},
+		{name: "yank pop after another key", buf: "one two", keys: []editorKey{kill(4, 7), kill(0, 4), yank, func(e *lineEditor) { e.yanked = false }, yankPop}, want: "one ", wantCur: 4},
+		{name: "yank pop is undone", buf: "one two", keys: []editorKey{kill(4, 7), kill(0, 4), yank, yankPop, undo}, want: "one ", wantCur: 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &lineEditor{buf: []rune(tt.buf), cur: tt.cur}
+			for _, k := range tt.keys {
+				k(e)
+			}
+			if string(e.buf) != tt.want || e.cur != tt.wantCur {
+				t.Errorf("buf = %q, cur = %d; want %q, %d", string(e.buf), e.cur, tt.want, tt.wantCur)
+			}
+		})
+	}
+}
+
+func TestLineEditorKillRing(t *testing.T) {
+	e := &lineEditor{buf: []rune(strings.Repeat("x", maxKills+2))}
+	for range maxKills + 2 {
+		e.kill(0, 1)
+	}
+	if len(e.kills) != maxKills {
+		t.Errorf("kill ring holds %d entries, want %d", len(e.kills), maxKills)
+	}
+}
+
+func TestLineEditorMotions(t *testing.T) {
+	const buf = "foo, bar_baz 42\n  qux"
+	tests := []struct {
+		cur       int
+		wordLeft  int
+		wordRight int
+	}{
+		{cur: 0, wordLeft: 0, wordRight: 3},
+		{cur: 3, wordLeft: 0, wordRight: 12},
+		{cur: 4, wordLeft: 0, wordRight: 12},
+		{cur: 7, wordLeft: 5, wordRight: 12},
+		{cur: 13, wordLeft: 5, wordRight: 15},
+		{cur: 18, wordLeft: 13, wordRight: 21},
+		{cur: 21, wordLeft: 18, wordRight: 21},
+	}
+	for _, tt := range tests {
+		e := &lineEditor{buf: []rune(buf), cur: tt.cur}
+		if l, r := e.wordLeft(), e.wordRight(); l != tt.wordLeft || r != tt.wordRight {
+			t.Errorf("from %d: wordLeft = %d, wordRight = %d; want %d, %d", tt.cur, l, r, tt.wordLeft, tt.wordRight)
+		}
+	}
+}
+
+func TestLineEditorMoveVertical(t *testing.T) {
+	const buf = "abc\nd\nefgh"
+	tests := []struct {
+		cur  int
+		dir  int
+		want int
+	}{
+		{cur: 1, dir: 1, want: 5},  // to the end of a shorter line
+		{cur: 4, dir: 1, want: 6},  // to the start of the next line
+		{cur: 5, dir: 1, want: 7},  // keeping the column
+		{cur: 9, dir: 1, want: 9},  // on the last line
+		{cur: 9, dir: -1, want: 5}, // to the end of a shorter line
+		{cur: 7, dir: -1, want: 5},
+		{cur: 4, dir: -1, want: 0},
+		{cur: 2, dir: -1, want: 2}, // on the first line
+	}
+	for _, tt := range tests {
+		e := &lineEditor{buf: []rune(buf), cur: tt.cur}
+		e.moveVertical(tt.dir)
+		if e.cur != tt.want {
+			t.Errorf("moveVertical(%d) from %d: cur = %d, want %d", tt.dir, tt.cur, e.cur, tt.want)
+		}
+	}
+}
+
+// csiRe matches the cursor movements and clearing of the rendered input.
+var csiRe = regexp.MustCompile(`\x1b\[[0-9;]*[A-Za-z]`)
+
+func TestLineEditorRender(t *testing.T) {
+	tests := []struct {
+		name     string
+		buf      string
+		cur      int
+		width    int
+		lines    string // the output without escape sequences
+		row, col int
+	}{
+		{name: "no width", buf: "abcdefg", cur: 7, lines: "> abcdefg", col: 9},
+		{name: "wrapped", buf: "abcdefg", cur: 7, width: 6, lines: "> abcd\n  efg", row: 1, col: 5},
+		{name: "cursor on the first row", buf: "abcdefg", cur: 1, width: 6, lines: "> abcd\n  efg", col: 3},
+		{name: "cursor at a wrap", buf: "abcdefg", cur: 4, width: 6, lines: "> abcd\n  efg", row: 1, col: 2},
+		{name: "full last row", buf: "abcd", cur: 4, width: 6, lines: "> abcd\n  ", row: 1, col: 2},
+		{name: "newlines", buf: "ab\n\ncd", cur: 3, lines: "> ab\n  \n  cd", row: 1, col: 2},
+		{name: "wide runes", buf: "语言模型", cur: 2, width: 7, lines: "> 语言\n  模型", row: 1, col: 2},
+		{name: "tab", buf: "a\tb", cur: 2, lines: "> a     b", col: 8},
+		{name: "too narrow to wrap", buf: "abc", cur: 3, width: 2, lines: "> abc", col: 5},
+	}
+	for _, tt := range tests {
+		e := &lineEditor{buf: []rune(tt.buf), cur: tt.cur, width: tt.width}
+		out := e.render()
+		lines := strings.ReplaceAll(csiRe.ReplaceAllString(out, ""), "\r", "")
+		if lines != tt.lines || e.row != tt.row || e.col != tt.col {
+			t.Errorf("%s: render() = %q, row %d, col %d; want %q, %d, %d", tt.name, out, e.row, e.col, tt.lines, tt.row, tt.col)
+		}
+	}
+
+	// A redraw starts on the first row of the input.
+	e := &lineEditor{buf: []rune("abcdefg"), cur: 7, width: 6}
+	e.render()
+	if out := e.render(); !strings.HasPrefix(out, "\x1b[1A\r") {
+		t.Errorf("redraw = %q, want it to move up a row first", out)
+	}
+}
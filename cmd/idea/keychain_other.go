@@ -0,0 +1,55 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+//go:build !darwin && !windows
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+const keychainName = "the Secret Service keyring"
+
+// The Secret Service (GNOME Keyring, KWallet) is used through
+// secret-tool, from the libsecret-tools package, to stay free of cgo.
+
+func keychainGet(service, account string) (string, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command("secret-tool", "lookup", "service", service, "account", account)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	switch {
+	case errors.Is(err, exec.ErrNotFound):
+		return "", fmt.Errorf("secret-tool not found, install libsecret-tools: %w", err)
+	case err != nil && stderr.Len() == 0:
+		return "", errNoCredentials // lookup fails silently for a missing item
+	case err != nil:
+		return "", fmt.Errorf("secret-tool: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return string(out), nil
+}
+
+func keychainSet(service, account, secret string) error {
+	cmd := exec.Command("secret-tool", "store", "--label=idea CLI login", "service", service, "account", account)
+	cmd.Stdin = strings.NewReader(secret)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("secret-tool: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func keychainDelete(service, account string) error {
+	if _, err := keychainGet(service, account); err != nil {
+		return err
+	}
+	if out, err := exec.Command("secret-tool", "clear", "service", service, "account", account).CombinedOutput(); err != nil {
+		return fmt.Errorf("secret-tool: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
@@ -0,0 +1,209 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+)
+
+// keyActions are the actions keys can be bound to in the config file,
+// with the input that performs them by default. "none" unbinds a key.
+var keyActions = map[string]string{
+	"submit":              "\r",
+	"newline":             "\n",
+	"clear":               "\x15",
+	"delete-word":         "\x17",
+	"delete-word-forward": "\x1bd",
+	"kill-line":           "\x0b",
+	"yank":                "\x19",
+	"undo":                "\x1a",
+	"redo":                "\x1bz",
+	"line-start":          "\x01",
+	"line-end":            "\x05",
+	"word-left":           "\x1bb",
+	"word-right":          "\x1bf",
+	"save-draft":          "\x13",
+	"preview":             "\x10",
+	"none":                "",
+}
+
+// hintKeys are the keys named in the input's hint by default.
+var hintKeys = map[string][]string{
+	"newline":    {"alt+enter", "ctrl+j"},
+	"submit":     {"enter"},
+	"save-draft": {"ctrl+s"},
+}
+
+// keyBindings maps key names, such as "enter" or "ctrl+d", to actions.
+type keyBindings map[string]string
+
+// parseKeyBindings validates the "keys" of the config file, for example
+// {"enter": "newline", "ctrl+d": "submit"}.
+func parseKeyBindings(keys map[string]string) (keyBindings, error) {
+	b := keyBindings{}
+	for key, action := range keys {
+		key, action = strings.ToLower(key), strings.ToLower(action)
+		if !validKeyName(key) {
+			return nil, fmt.Errorf("unknown key %q, want e.g. enter, shift+enter, alt+enter, backspace, ctrl+d, or alt+d", key)
+		}
+		if key == "ctrl+c" {
+			return nil, fmt.Errorf("ctrl+c always cancels and cannot be bound")
+		}
+		if _, ok := keyActions[action]; !ok {
+			return nil, fmt.Errorf("unknown action %q for %s, have: %s", action, key,
+				strings.Join(slices.Sorted(maps.Keys(keyActions)), ", "))
+		}
+		b[key] = action
+	}
+	return b, nil
+}
+
+// validKeyName reports whether keys of the name can be told apart: Ctrl+I
+// and Ctrl+M are sent as Tab and Enter, and Alt+[ and Alt+O start escape
+// sequences.
+func validKeyName(name string) bool {
+	switch name {
+	case "enter", "shift+enter", "ctrl+enter", "alt+enter", "backspace":
+		return true
+	case "ctrl+i", "ctrl+m", "alt+[", "alt+o":
+		return false
+	}
+	if c, ok := strings.CutPrefix(name, "ctrl+"); ok && len(c) == 1 {
+		return c[0] >= 'a' && c[0] <= 'z' || c == "_"
+	}
+	if c, ok := strings.CutPrefix(name, "alt+"); ok && len(c) == 1 {
+		return c[0] > ' ' && c[0] < 0x7f
+	}
+	return false
+}
+
+// inputConfig configures the interactive input.
+type inputConfig struct {
+	keys keyBindings
+	vi   bool // the vi editing mode rather than the default Emacs-like keys
+}
+
+// loadInputConfig returns the key bindings and editing mode of the
+// config file, if any.
+func loadInputConfig() (inputConfig, error) {
+	c, path, err := loadConfig()
+	if errors.Is(err, os.ErrNotExist) {
+		return inputConfig{keys: keyBindings{}}, nil
+	}
+	if err != nil {
+		return inputConfig{}, err
+	}
+	b, err := parseKeyBindings(c.Keys)
+	if err != nil {
+		return inputConfig{}, fmt.Errorf("%s: keys: %w", path, err)
+	}
+	switch c.Editing {
+	case "", "emacs", "vi":
+	default:
+		return inputConfig{}, fmt.Errorf("%s: editing must be emacs or vi, not %q", path, c.Editing)
+	}
+	return inputConfig{keys: b, vi: c.Editing == "vi"}, nil
+}
+
+// hint describes how to finish the input.
+func (c inputConfig) hint() string {
+	h := c.keys.hint()
+	if c.vi {
+		h += ", Esc for vi normal mode"
+	}
+	return h
+}
+
+// keyName names the key at the start of data and returns the bytes it
+// takes, or 0 if it is incomplete or not a key that can be bound.
+func keyName(data []byte) (string, int) {
+	ch := data[0]
+	switch {
+	case ch == 0x1b:
+		n, _ := parseEscape(data)
+		switch {
+		case n == 0:
+			return "", 0
+		case data[1] == '[':
+			switch string(data[2:n]) {
+			case "13;2u", "27;2;13~":
+				return "shift+enter", n
+			case "13;5u", "27;5;13~":
+				return "ctrl+enter", n
+			}
+			return "", 0
+		case data[1] == '\r' || data[1] == '\n':
+			return "alt+enter", n
+		case data[1] > ' ' && data[1] < 0x7f && data[1] != 'O':
+			return "alt+" + string(data[1]), n
+		}
+		return "", 0
+	case ch == '\r':
+		return "enter", 1
+	case ch == 0x7f:
+		return "backspace", 1
+	case ch == 0x1f:
+		return "ctrl+_", 1
+	case ch >= 0x01 && ch <= 0x1a && ch != '\t':
+		return "ctrl+" + string(rune('a'+ch-1)), 1
+	}
+	return "", 0
+}
+
+// translate looks up the key at the start of data. If it is bound, it
+// returns the action and the input after the key.
+func (b keyBindings) translate(data []byte) (rest []byte, action string, ok bool) {
+	name, n := keyName(data)
+	action, ok = b[name]
+	if n == 0 || !ok {
+		return data, "", false
+	}
+	return data[n:], action, true
+}
+
+// keysFor returns the names of the keys that perform action, for the
+// input's hint: the default ones not bound to something else, and those
+// bound to it.
+func (b keyBindings) keysFor(action string) []string {
+	var keys []string
+	for _, k := range hintKeys[action] {
+		if _, ok := b[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	for _, k := range slices.Sorted(maps.Keys(b)) {
+		if b[k] == action {
+			keys = append(keys, k)
+		}
+	}
+	for i, k := range keys {
+		parts := strings.Split(k, "+")
+		for j, p := range parts {
+			parts[j] = strings.ToUpper(p[:1]) + p[1:]
+		}
+		keys[i] = strings.Join(parts, "+")
+	}
+	return keys
+}
+
+// hint describes how to finish the input.
+func (b keyBindings) hint() string {
+	var hints []string
+	for _, h := range []struct{ action, does string }{
+		{"newline", "for newline"},
+		{"submit", "to send"},
+		{"save-draft", "to save a draft"},
+	} {
+		if keys := b.keysFor(h.action); len(keys) > 0 {
+			hints = append(hints, strings.Join(keys, " or ")+" "+h.does)
+		}
+	}
+	return strings.Join(hints, ", ")
+}
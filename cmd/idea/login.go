@@ -0,0 +1,137 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"changkun.de/x/login"
+	"golang.org/x/term"
+)
+
+// keychainService names the CLI's entries in the OS keychain.
+const keychainService = "changkun.de/x/ideas"
+
+// errNoCredentials is returned when the keychain holds no credentials.
+var errNoCredentials = errors.New("no credentials stored")
+
+// credentials are the login service user and password kept in the OS
+// keychain, so LOGIN_PASS need not sit in the environment.
+type credentials struct {
+	User string `json:"user"`
+	Pass string `json:"pass"`
+}
+
+// keychainAccount is the keychain entry of the credentials, one per
+// profile.
+func keychainAccount() string {
+	if activeProfile != "" {
+		return "login:" + activeProfile
+	}
+	return "login"
+}
+
+func loadCredentials() (credentials, error) {
+	secret, err := keychainGet(keychainService, keychainAccount())
+	if err != nil {
+		return credentials{}, err
+	}
+	var c credentials
+	if err := json.Unmarshal([]byte(secret), &c); err != nil {
+		return credentials{}, fmt.Errorf("parse stored credentials: %w", err)
+	}
+	return c, nil
+}
+
+func storeCredentials(c credentials) error {
+	secret, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	if err := keychainSet(keychainService, keychainAccount(), string(secret)); err != nil {
+		return err
+	}
+	// Some keychain tools report failures only in their output, so read
+	// the credentials back to be sure they were stored.
+	if got, err := loadCredentials(); err != nil || got != c {
+		return fmt.Errorf("credentials were not stored in %s: %v", keychainName, err)
+	}
+	return nil
+}
+
+func deleteCredentials() error {
+	return keychainDelete(keychainService, keychainAccount())
+}
+
+// setLoginEndpoint points the login client at $LOGIN_URL, if set.
+func setLoginEndpoint() {
+	if v := os.Getenv("LOGIN_URL"); v != "" {
+		login.AuthEndpoint = strings.TrimRight(v, "/") + "/auth"
+		login.VerifyEndpoint = strings.TrimRight(v, "/") + "/verify"
+	}
+}
+
+// runLogin implements the "login" subcommand:
+//
+//	idea login
+//
+// It asks for the user and password, checks them with the login
+// service, and stores them in the OS keychain.
+func runLogin() {
+	setLoginEndpoint()
+	in := bufio.NewReader(os.Stdin)
+
+	user := os.Getenv("LOGIN_USER")
+	if user != "" {
+		fmt.Printf("User [%s]: ", user)
+	} else {
+		fmt.Print("User: ")
+	}
+	line, _ := in.ReadString('\n')
+	user = cmp.Or(strings.TrimSpace(line), user)
+	if user == "" {
+		fmt.Fprintln(os.Stderr, "a user is required")
+		os.Exit(1)
+	}
+
+	fmt.Print("Password: ")
+	pass, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Println()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if _, err := login.RequestToken(user, string(pass)); err != nil {
+		fmt.Fprintf(os.Stderr, "login failed: %v\n", err)
+		os.Exit(1)
+	}
+	if err := storeCredentials(credentials{User: user, Pass: string(pass)}); err != nil {
+		fmt.Fprintf(os.Stderr, "store credentials: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Logged in as %s; credentials are kept in %s.\n", user, keychainName)
+}
+
+// runLogout implements the "logout" subcommand, which removes the stored
+// credentials.
+func runLogout() {
+	err := deleteCredentials()
+	switch {
+	case errors.Is(err, errNoCredentials):
+		fmt.Println("Not logged in.")
+	case err != nil:
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	default:
+		fmt.Println("Logged out.")
+	}
+}
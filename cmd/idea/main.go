@@ -6,54 +6,363 @@ package main
 
 import (
 	"bytes"
+	"cmp"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"strings"
-	"unicode/utf8"
+	"time"
 
 	"changkun.de/x/login"
 	"golang.org/x/term"
 )
 
 func main() {
+	args, err := selectProfile(os.Args[1:])
+	if err == nil {
+		err = configureHTTP(os.Getenv("IDEA_CA_FILE"))
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	if len(args) > 0 {
+		switch args[0] {
+		case "import":
+			runImport(args[1:])
+			return
+		case "mcp":
+			runMCP()
+			return
+		case "watch":
+			runWatch(args[1:])
+			return
+		case "drafts":
+			runDrafts(args[1:])
+			return
+		case "show":
+			runShow(args[1:])
+			return
+		case "edit":
+			runEdit(args[1:])
+			return
+		case "append":
+			runAppend(args[1:])
+			return
+		case "open":
+			runOpen(args[1:])
+			return
+		case "notify":
+			runNotify(args[1:])
+			return
+		case "unlock":
+			runUnlock(args[1:])
+			return
+		case "history":
+			runHistory(args[1:])
+			return
+		case "improve":
+			runImprove(args[1:])
+			return
+		case "stats":
+			runStats(args[1:])
+			return
+		case "login":
+			runLogin()
+			return
+		case "logout":
+			runLogout()
+			return
+		case "whoami":
+			runWhoami()
+			return
+		}
+	}
+
 	title := flag.String("t", "", "idea title (optional, auto-generated if empty)")
-	flag.Parse()
+	draft := flag.Bool("d", false, "post as a draft, hidden from the live site")
+	noCrosspost := flag.Bool("no-crosspost", false, "do not announce the idea on social media")
+	allowSecrets := flag.Bool("allow-secrets", false, "publish the idea even if it seems to contain a credential, such as an example key")
+	allowDuplicate := flag.Bool("allow-duplicate", false, "post the idea even if it seems to repeat an earlier one")
+	tags := flag.String("tags", "", "comma-separated `tags`, e.g. go,performance, that become the idea's categories")
+	lang := flag.String("lang", "auto", "language of the idea, `en`, zh, or auto, which decides the direction of translation")
+	thread := flag.String("thread", "", "print a published idea (by ID) as a thread of short posts")
+	threadPost := flag.String("thread-post", "", "with -thread, also post the thread to \"x\" or \"mastodon\"")
+	useEditor := flag.Bool("e", os.Getenv("IDEA_EDITOR") != "", "compose in $IDEA_EDITOR, $VISUAL, or $EDITOR (default if IDEA_EDITOR is set)")
+	profileName := flag.String("profile", "", "post with the named profile of the config file (must come first when used with a subcommand)")
+	dry := flag.Bool("n", false, "dry run: print the request instead of posting it")
+	flag.BoolVar(&jsonOutput, "json", false, "wait until published and print the result as JSON")
+	flag.DurationVar(&requestTimeout, "timeout", requestTimeout, "give up on posting after `duration` per attempt")
+	flag.IntVar(&requestRetries, "retries", requestRetries, "retry posting up to `n` times on network errors and 429, 502, 503, or 504, with backoff")
+	caFile := flag.String("cacert", "", "also trust the PEM certificates in `file`, for a server with a private CA (default $IDEA_CA_FILE)")
+	bookmark := flag.String("url", "", "bookmark the page at `link`, with its title and description; further arguments are the comment on it")
+	review := flag.Bool("review", false, "show the idea as it will be published, to publish, edit, or discard it")
+	at := flag.String("at", "", "publish the idea at `time`, such as \"2025-06-01 09:00\" in local time or in RFC 3339, rather than right away")
+	flag.BoolVar(&detach, "detach", false, "return once the server accepts the idea, and notify on the desktop when it is published")
+	flag.BoolVar(&confirmTitle, "confirm-title", true, "without -t, accept or edit the generated title before publishing, when posting from a terminal")
+	clip := flag.Bool("clip", false, "post the text on the clipboard; with -e, edit it first")
+	var files []string
+	flag.Func("f", "post the markdown `file` as an idea, titled by its leading \"# \" heading; repeatable, and further arguments are files too", func(path string) error {
+		files = append(files, path)
+		return nil
+	})
+	var o postOptions
+	o.skipFlags(flag.CommandLine)
+	flag.CommandLine.Parse(args)
+	if *profileName != "" {
+		if err := useProfile(*profileName); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if *caFile != "" {
+		if err := configureHTTP(*caFile); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		// Trusted by the notifier too, which -detach leaves running.
+		os.Setenv("IDEA_CA_FILE", *caFile)
+	}
 
-	url := os.Getenv("IDEAS_URL")
-	if url == "" {
-		url = "https://api.changkun.de"
+	url := serverURL()
+	if jsonOutput {
+		progress = io.Discard
 	}
-	if v := os.Getenv("LOGIN_URL"); v != "" {
-		login.AuthEndpoint = strings.TrimRight(v, "/") + "/auth"
+	var token string
+	if *dry {
+		// Report what would happen rather than stopping at the first
+		// problem.
+		dryRun, progress = true, io.Discard
+		fmt.Fprintf(os.Stderr, "endpoint: POST %s/ideas/post\n", url)
+		if user, t, err := loginToken(); err != nil {
+			fmt.Fprintf(os.Stderr, "auth: %v\n", err)
+		} else {
+			fmt.Fprintf(os.Stderr, "auth: ok, as %s\n", user)
+			token = t
+		}
+		if activeProfile != "" {
+			fmt.Fprintf(os.Stderr, "profile: %s\n", activeProfile)
+		}
+	} else {
+		token = authenticate()
 	}
-	loginUser := os.Getenv("LOGIN_USER")
-	if loginUser == "" {
-		fmt.Fprintln(os.Stderr, "LOGIN_USER is required")
-		os.Exit(1)
+
+	if *thread != "" {
+		if *dry {
+			fmt.Fprintln(os.Stderr, "-n does not apply to -thread")
+			os.Exit(2)
+		}
+		runThread(url, token, *thread, *threadPost)
+		return
 	}
-	loginPass := os.Getenv("LOGIN_PASS")
-	if loginPass == "" {
-		fmt.Fprintln(os.Stderr, "LOGIN_PASS is required")
-		os.Exit(1)
+
+	o.title, o.draft, o.noCrosspost, o.editor = *title, *draft, *noCrosspost, *useEditor
+	o.tags, o.lang = parseTags(*tags), checkLang(*lang)
+	o.review, o.allowSecrets, o.allowDuplicate = *review, *allowSecrets, *allowDuplicate
+	if *at != "" {
+		t, err := parsePublishAt(*at)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid -at: %v\n", err)
+			os.Exit(2)
+		}
+		o.publishAt = t
+	}
+	if detach && (o.review || jsonOutput) {
+		fmt.Fprintln(os.Stderr, "-detach works with neither -review nor -json, which wait for the idea")
+		os.Exit(2)
+	}
+	if o.review && !dryRun && (jsonOutput || len(files) > 0 || !term.IsTerminal(int(os.Stdin.Fd())) || !term.IsTerminal(int(os.Stdout.Fd()))) {
+		fmt.Fprintln(os.Stderr, "-review needs a terminal to answer in, and works with neither -json nor -f")
+		os.Exit(2)
+	}
+	if len(files) > 0 {
+		postFiles(url, token, o, append(files, flag.Args()...))
+		return
 	}
+	if *clip {
+		postClipboard(url, token, o)
+		return
+	}
+	if *bookmark != "" {
+		postBookmark(url, token, o, *bookmark, strings.Join(flag.Args(), " "))
+		return
+	}
+	composeAndPost(url, token, o, localDraft{})
+}
+
+// postOptions are the flags of posting an idea.
+type postOptions struct {
+	title       string
+	draft       bool
+	noCrosspost bool
+	tags        []string
+	lang        string    // "en", "zh", or empty to detect
+	editor      bool      // compose in the external editor
+	review      bool      // review the idea before the server publishes it
+	publishAt   time.Time // publish at this time rather than right away
+
+	allowSecrets   bool // publish even if the server finds a credential
+	allowDuplicate bool // post even if the server finds an earlier idea alike
+
+	// Skipped LLM stages of the server's pipeline.
+	noTitle, noTranslate, noAugment, noTags bool
+}
+
+// skipFlags registers the flags that skip LLM stages on fs.
+func (o *postOptions) skipFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&o.noTitle, "no-title", false, "do not generate a title; untitled ideas are published as \"Untitled\"")
+	fs.BoolVar(&o.noTranslate, "no-translate", false, "do not polish and translate; the text is published as is in both languages")
+	fs.BoolVar(&o.noAugment, "no-augment", false, "do not write the LLM deep dive")
+	fs.BoolVar(&o.noTags, "no-tags", false, "do not suggest tags for an idea given none")
+}
+
+// request returns the body of posting content with these options.
+func (o postOptions) request(title, content string) map[string]any {
+	idea := map[string]any{
+		"title":        title,
+		"content":      content,
+		"draft":        o.draft,
+		"no_crosspost": o.noCrosspost,
+	}
+	if len(o.tags) > 0 {
+		idea["tags"] = o.tags
+	}
+	if o.lang != "" {
+		idea["lang"] = o.lang
+	}
+	if o.review {
+		idea["review"] = true
+	}
+	if o.allowSecrets {
+		idea["allow_secrets"] = true
+	}
+	if o.allowDuplicate {
+		idea["allow_duplicate"] = true
+	}
+	if !o.publishAt.IsZero() {
+		idea["publish_at"] = o.publishAt.Format(time.RFC3339)
+	}
+	for key, skip := range map[string]bool{"no_title": o.noTitle, "no_translate": o.noTranslate, "no_augment": o.noAugment, "no_tags": o.noTags} {
+		if skip {
+			idea[key] = true
+		}
+	}
+	return idea
+}
 
-	// Obtain JWT from login service.
-	token, err := login.RequestToken(loginUser, loginPass)
+// parsePublishAt parses the -at flag, in RFC 3339 or as a date and time
+// in local time, and checks that it is in the future.
+func parsePublishAt(s string) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339, s)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "login failed: %v\n", err)
-		os.Exit(1)
+		t, err = time.ParseInLocation("2006-01-02 15:04", s, time.Local)
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is neither RFC 3339 nor \"2006-01-02 15:04\"", s)
+	}
+	if !t.After(time.Now()) {
+		return time.Time{}, fmt.Errorf("%s is not in the future", t.Format("2006-01-02 15:04"))
+	}
+	return t, nil
+}
+
+// checkLang validates the -lang flag and returns the language to send,
+// empty to have the server detect it.
+func checkLang(lang string) string {
+	switch lang {
+	case "en", "zh":
+		return lang
+	case "", "auto":
+		return ""
+	}
+	fmt.Fprintln(os.Stderr, "-lang must be en, zh, or auto")
+	os.Exit(2)
+	return ""
+}
+
+// parseTags splits a comma-separated list of tags.
+func parseTags(s string) []string {
+	var tags []string
+	for tag := range strings.SplitSeq(s, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
 	}
+	return tags
+}
 
-	var content string
+// composeAndPost reads an idea from the external editor, the interactive
+// input, or standard input, and posts it. Editing starts from d, which
+// is deleted once posted if it is a saved draft.
+func composeAndPost(url, token string, o postOptions, d localDraft) {
+	var (
+		content string
+		err     error
+	)
+	title := cmp.Or(o.title, d.Title)
+
+	// Offer what was being composed when the CLI last ended without
+	// posting, and keep saving the input until it is posted.
+	var rec *autosaver
+	if path, err := recoveryPath(); err == nil && term.IsTerminal(int(os.Stdin.Fd())) {
+		if d.Name == "" && d.Content == "" {
+			if t, c, ok := offerRecovery(path); ok {
+				title, d.Content = cmp.Or(o.title, t), c
+			}
+		}
+		rec = newAutosaver(path)
+	}
 
-	if term.IsTerminal(int(os.Stdin.Fd())) {
-		fmt.Println("idea (Alt+Enter or Ctrl+J for newline, Enter to send)")
-		content, err = readInput()
+	if o.editor {
+		initial := d.Content
+		if title != "" {
+			initial = "# " + title + "\n\n" + initial
+		}
+		content, err = composeInEditor(initial)
 		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		// As in watched files, a leading "# " line is the title.
+		if t, c := splitNote(content); t != "" && o.title == "" {
+			title, content = t, c
+		}
+	} else if term.IsTerminal(int(os.Stdin.Fd())) {
+		var cfg inputConfig
+		cfg, err = loadInputConfig()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("idea (%s)\n", cfg.hint())
+		var changed func(string)
+		if rec != nil {
+			changed = func(s string) { rec.update(title, s) }
+		}
+		content, err = readInput(d.Content, cfg, changed)
+		if errors.Is(err, errSaveDraft) {
+			if strings.TrimSpace(content) == "" {
+				return
+			}
+			name, err := saveDraft(d.Name, title, content)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "error: %v\n", err)
+				os.Exit(1)
+			}
+			if rec != nil {
+				rec.clear()
+			}
+			fmt.Printf("Saved draft %s, resume with: idea drafts resume %s\n", name, name)
+			return
+		}
+		if err != nil {
+			if rec != nil {
+				rec.flush()
+			}
 			if err.Error() == "interrupted" {
 				os.Exit(130)
 			}
@@ -71,228 +380,191 @@ func main() {
 
 	content = strings.TrimSpace(content)
 	if content == "" {
+		if rec != nil {
+			rec.clear()
+		}
 		os.Exit(0)
 	}
+	if rec != nil {
+		// Keep the idea until the server has it.
+		rec.update(title, content)
+		rec.flush()
+	}
 
-	fmt.Print("Posting idea... ")
-
-	body, _ := json.Marshal(map[string]string{
-		"title":   *title,
-		"content": content,
-	})
-	req, _ := http.NewRequest("POST", strings.TrimRight(url, "/")+"/ideas/post", bytes.NewReader(body))
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+token)
-
-	resp, err := http.DefaultClient.Do(req)
+	err = postShowingProgress("Posting idea", url, token, o.request(title, content))
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "failed: %v\n", err)
+		switch errorCode(err) {
+		case "secret_detected":
+			fmt.Fprintln(os.Stderr, "Remove the secret, or post again with -allow-secrets if it is not a real one.")
+		case "duplicate":
+			fmt.Fprintln(os.Stderr, "See it with idea show, or post again with -allow-duplicate if it is new.")
+		}
+		if rec != nil {
+			fmt.Fprintln(os.Stderr, "The idea is kept and offered again next time.")
+		}
 		os.Exit(1)
 	}
-	defer resp.Body.Close()
-
-	var result struct {
-		OK      bool   `json:"ok"`
-		Message string `json:"message"`
+	if rec != nil && !dryRun {
+		rec.clear()
 	}
-	json.NewDecoder(resp.Body).Decode(&result)
-
-	if result.OK {
-		fmt.Println("done")
-	} else {
-		fmt.Fprintf(os.Stderr, "failed: %s\n", result.Message)
-		os.Exit(1)
+	if d.Name != "" && !dryRun {
+		if err := deleteDraft(d.Name); err != nil {
+			fmt.Fprintf(os.Stderr, "delete draft: %v\n", err)
+		}
 	}
 }
 
-const (
-	prompt     = "> "
-	contPrompt = "  "
+// In a dry run, postIdea prints the ideas it would post, as JSON on
+// standard output, and progress messages are dropped so the output can be
+// piped.
+var (
+	dryRun   bool
+	progress io.Writer = os.Stdout
 )
 
-type escAction int
-
-const (
-	escNone escAction = iota
-	escNewline
-	escPasteStart
-	escPasteEnd
-)
-
-// parseEscape tries to parse an escape sequence from data.
-// Returns (bytes consumed, action). Returns (0, escNone) if incomplete.
-func parseEscape(data []byte) (int, escAction) {
-	if len(data) < 2 || data[0] != 0x1b {
-		return 0, escNone
-	}
-
-	// ESC [ = CSI sequence.
-	if data[1] == '[' {
-		for i := 2; i < len(data); i++ {
-			ch := data[i]
-			if (ch >= 'A' && ch <= 'Z') || (ch >= 'a' && ch <= 'z') || ch == '~' {
-				seq := string(data[2 : i+1])
-				switch seq {
-				case "13;2u":
-					return i + 1, escNewline // Shift+Enter (kitty protocol)
-				case "200~":
-					return i + 1, escPasteStart
-				case "201~":
-					return i + 1, escPasteEnd
-				}
-				return i + 1, escNone
+// With -json, postIdea prints a postResult per idea instead, and progress
+// messages are dropped.
+var jsonOutput bool
+
+// With confirmTitle, ideas posted from a terminal without a title are held
+// once the server generates one, to accept or edit it before publishing.
+var confirmTitle bool
+
+// errUnauthorized is returned when the server rejects the token.
+var errUnauthorized = errors.New("unauthorized")
+
+// postIdea submits an idea to the server at url and records it in the
+// local history once accepted. With -json, it waits until the idea is
+// published and prints the result.
+func postIdea(url, token string, idea map[string]any) error {
+	applyProfile(idea)
+	if dryRun {
+		body, _ := json.MarshalIndent(idea, "", "  ")
+		fmt.Printf("%s\n", body)
+		return nil
+	}
+	if !jsonOutput {
+		job, err := submitIdea(url, token, idea)
+		if err == nil && job != "" && detach {
+			if err := notifyInBackground(job); err != nil {
+				fmt.Fprintf(os.Stderr, "cannot notify once published: %v\n", err)
 			}
 		}
-		if len(data) > 20 {
-			return len(data), escNone
-		}
-		return 0, escNone // incomplete
+		return err
 	}
 
-	// ESC + Enter = Alt+Enter.
-	if data[1] == '\r' || data[1] == '\n' {
-		return 2, escNewline
+	start := time.Now()
+	job, err := submitIdea(url, token, idea)
+	res := postResult{OK: err == nil, Job: job}
+	if err == nil && job != "" {
+		res = followJob(url, token, job)
+	}
+	if err != nil {
+		res.Error = err.Error()
+	}
+	res.DurationMS = time.Since(start).Milliseconds()
+	out, _ := json.Marshal(res)
+	fmt.Printf("%s\n", out)
+	if err == nil && !res.OK {
+		err = errors.New(res.Error)
 	}
+	return err
+}
 
-	return 2, escNone
+// applyProfile adds the pipeline of the selected profile to an idea,
+// unless it names one.
+func applyProfile(idea map[string]any) {
+	if _, ok := idea["pipeline"]; !ok && profilePipeline != "" {
+		idea["pipeline"] = profilePipeline
+	}
 }
 
-func readInput() (string, error) {
-	fd := int(os.Stdin.Fd())
-	oldState, err := term.MakeRaw(fd)
+// submitIdea posts an idea and returns the ID of the job publishing it,
+// if the server reports one. If the server cannot be reached, the idea
+// may be committed to the repository instead; see postPending.
+func submitIdea(url, token string, idea map[string]any) (job string, err error) {
+	body, _ := json.Marshal(idea)
+	req, _ := http.NewRequest("POST", url+"/ideas/post", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := doWithRetry(req)
 	if err != nil {
-		return "", err
+		return "", postPending(url, idea, err)
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusUnauthorized:
+		return "", errUnauthorized
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return "", postPending(url, idea, errors.New(resp.Status))
 	}
-	defer term.Restore(fd, oldState)
 
-	// Enable bracket paste mode.
-	os.Stdout.WriteString("\x1b[?2004h")
-	defer os.Stdout.WriteString("\x1b[?2004l")
+	var result struct {
+		OK bool `json:"ok"`
+		apiError
+		Filename string `json:"filename"`
+		Job      string `json:"job"`
+	}
+	json.NewDecoder(resp.Body).Decode(&result)
+	if !result.OK {
+		return "", result.err(resp)
+	}
 
-	var buf []rune
-	inPaste := false
-	displayLines := 1
+	e := newHistoryEntry(url, idea)
+	e.Job, e.Path, e.Message = result.Job, result.Filename, result.Message
+	recordHistory(e)
+	return result.Job, nil
+}
 
-	write := func(s string) { os.Stdout.WriteString(s) }
-	write(prompt)
+// serverURL returns the base URL of the ideas service.
+func serverURL() string {
+	url := os.Getenv("IDEAS_URL")
+	if url == "" {
+		url = "https://api.changkun.de"
+	}
+	return strings.TrimRight(url, "/")
+}
 
-	raw := make([]byte, 256)
-	var pending []byte
+// authenticate obtains a JWT from the login service, exiting on failure.
+func authenticate() string {
+	_, token, err := loginToken()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	return token
+}
 
-	for {
-		n, err := os.Stdin.Read(raw)
-		if err != nil {
-			return "", err
+// loginToken obtains a JWT from the login service and returns it with
+// the user it was issued to. Credentials come from LOGIN_USER and
+// LOGIN_PASS, or else from the OS keychain, where "idea login" stores
+// them.
+func loginToken() (user, token string, err error) {
+	setLoginEndpoint()
+	loginUser := os.Getenv("LOGIN_USER")
+	loginPass := os.Getenv("LOGIN_PASS")
+	if loginPass == "" {
+		c, err := loadCredentials()
+		switch {
+		case errors.Is(err, errNoCredentials):
+			return "", "", errors.New("LOGIN_USER and LOGIN_PASS are required, or run: idea login")
+		case err != nil:
+			return "", "", fmt.Errorf("LOGIN_PASS is not set and the keychain failed: %w", err)
 		}
-		pending = append(pending, raw[:n]...)
-
-		for len(pending) > 0 {
-			// Escape sequences.
-			if pending[0] == 0x1b {
-				consumed, action := parseEscape(pending)
-				if consumed == 0 {
-					break // incomplete
-				}
-				pending = pending[consumed:]
-				switch action {
-				case escNewline:
-					buf = append(buf, '\n')
-					displayLines++
-					write("\r\n" + contPrompt)
-				case escPasteStart:
-					inPaste = true
-				case escPasteEnd:
-					inPaste = false
-				}
-				continue
-			}
-
-			ch := pending[0]
-
-			switch {
-			case ch == 0x03: // Ctrl+C
-				write("\r\n")
-				return "", fmt.Errorf("interrupted")
-
-			case ch == 0x15: // Ctrl+U: clear all
-				pending = pending[1:]
-				buf = nil
-				displayLines = redraw(buf, displayLines)
-
-			case ch == 0x17: // Ctrl+W: delete word
-				pending = pending[1:]
-				for len(buf) > 0 && buf[len(buf)-1] == ' ' {
-					buf = buf[:len(buf)-1]
-				}
-				for len(buf) > 0 && buf[len(buf)-1] != ' ' && buf[len(buf)-1] != '\n' {
-					buf = buf[:len(buf)-1]
-				}
-				displayLines = redraw(buf, displayLines)
-
-			case ch == '\n': // Ctrl+J: newline
-				pending = pending[1:]
-				buf = append(buf, '\n')
-				displayLines++
-				write("\r\n" + contPrompt)
-
-			case ch == '\r': // Enter: submit (or newline in paste mode)
-				pending = pending[1:]
-				if inPaste {
-					buf = append(buf, '\n')
-					displayLines++
-					write("\r\n" + contPrompt)
-				} else {
-					write("\r\n")
-					return string(buf), nil
-				}
-
-			case ch == 0x7f || ch == 0x08: // Backspace
-				pending = pending[1:]
-				if len(buf) > 0 {
-					buf = buf[:len(buf)-1]
-					displayLines = redraw(buf, displayLines)
-				}
-
-			default:
-				r, size := utf8.DecodeRune(pending)
-				if r == utf8.RuneError && size <= 1 && len(pending) < 4 {
-					break // incomplete UTF-8
-				}
-				if r == utf8.RuneError {
-					pending = pending[1:]
-					continue
-				}
-				pending = pending[size:]
-				if r >= 0x20 || r == '\t' {
-					buf = append(buf, r)
-					var rb [4]byte
-					n := utf8.EncodeRune(rb[:], r)
-					os.Stdout.Write(rb[:n])
-				}
-			}
+		loginUser, loginPass = cmp.Or(loginUser, c.User), c.Pass
+		if loginUser != c.User {
+			return "", "", fmt.Errorf("LOGIN_USER is %s but the keychain holds the password of %s", loginUser, c.User)
 		}
 	}
-}
-
-// redraw clears the input area and reprints the buffer.
-// Returns the new display line count.
-func redraw(buf []rune, prevLines int) int {
-	if prevLines > 1 {
-		fmt.Fprintf(os.Stdout, "\x1b[%dA", prevLines-1)
+	if loginUser == "" {
+		return "", "", errors.New("LOGIN_USER is required")
 	}
-	os.Stdout.WriteString("\r\x1b[J")
 
-	newLines := 1
-	os.Stdout.WriteString(prompt)
-	for _, r := range buf {
-		if r == '\n' {
-			newLines++
-			os.Stdout.WriteString("\r\n" + contPrompt)
-		} else {
-			var rb [4]byte
-			n := utf8.EncodeRune(rb[:], r)
-			os.Stdout.Write(rb[:n])
-		}
+	token, err = login.RequestToken(loginUser, loginPass)
+	if err != nil {
+		return "", "", fmt.Errorf("login failed: %w", err)
 	}
-	return newLines
+	return loginUser, token, nil
 }
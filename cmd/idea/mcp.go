@@ -0,0 +1,103 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+)
+
+// runMCP implements the "mcp" subcommand, the stdio transport of the
+// ideas MCP server. It reads newline-delimited JSON-RPC messages from
+// stdin, relays them to the server with the user's credentials, and
+// writes the responses to stdout. Diagnostics go to stderr, as stdout
+// belongs to the protocol.
+//
+// Example Claude Desktop configuration:
+//
+//	"ideas": {
+//	  "command": "idea",
+//	  "args": ["mcp"],
+//	  "env": {"LOGIN_USER": "...", "LOGIN_PASS": "..."}
+//	}
+func runMCP() {
+	url := serverURL() + "/ideas/mcp"
+	token := authenticate()
+
+	in := bufio.NewReader(os.Stdin)
+	for {
+		line, err := in.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			resp, status, rerr := relayMCP(url, token, line)
+			if status == http.StatusUnauthorized {
+				// The token expired during a long session.
+				token = authenticate()
+				resp, _, rerr = relayMCP(url, token, line)
+			}
+			if rerr != nil {
+				fmt.Fprintf(os.Stderr, "idea mcp: %v\n", rerr)
+				resp = rpcErrorFor(line, rerr)
+			}
+			if len(resp) > 0 {
+				os.Stdout.Write(append(bytes.TrimSpace(resp), '\n'))
+			}
+		}
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "idea mcp: %v\n", err)
+			os.Exit(1)
+		}
+	}
+}
+
+// relayMCP posts one message and returns the response body, which is
+// empty for notifications.
+func relayMCP(url, token string, msg []byte) ([]byte, int, error) {
+	req, _ := http.NewRequest("POST", url, bytes.NewReader(msg))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, resp.StatusCode, err
+	}
+	switch {
+	case resp.StatusCode == http.StatusAccepted:
+		return nil, resp.StatusCode, nil
+	case resp.StatusCode != http.StatusOK:
+		return nil, resp.StatusCode, fmt.Errorf("server returned %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+	}
+	return body, resp.StatusCode, nil
+}
+
+// rpcErrorFor builds a JSON-RPC error response for a request that could
+// not be relayed, so that the client does not wait forever.
+func rpcErrorFor(msg []byte, err error) []byte {
+	var req struct {
+		ID json.RawMessage `json:"id"`
+	}
+	if json.Unmarshal(msg, &req) != nil || req.ID == nil {
+		return nil // notifications get no response
+	}
+	resp, _ := json.Marshal(map[string]any{
+		"jsonrpc": "2.0",
+		"id":      req.ID,
+		"error":   map[string]any{"code": -32603, "message": err.Error()},
+	})
+	return resp
+}
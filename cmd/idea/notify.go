@@ -0,0 +1,116 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"time"
+)
+
+// detach makes posting return once the idea is accepted, leaving a
+// process behind that notifies on the desktop when it is published.
+var detach bool
+
+// desktopNotify shows a desktop notification. The texts are passed as
+// arguments or environment, never as part of a script.
+func desktopNotify(title, message string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("osascript",
+			"-e", "on run argv",
+			"-e", "display notification (item 2 of argv) with title (item 1 of argv)",
+			"-e", "end run",
+			title, message)
+	case "windows":
+		cmd = exec.Command("powershell", "-NoProfile", "-Command",
+			"Add-Type -AssemblyName System.Windows.Forms; "+
+				"$n = New-Object System.Windows.Forms.NotifyIcon; "+
+				"$n.Icon = [System.Drawing.SystemIcons]::Information; $n.Visible = $true; "+
+				"$n.ShowBalloonTip(10000, $env:IDEA_NOTIFY_TITLE, $env:IDEA_NOTIFY_MESSAGE, 'Info'); "+
+				"Start-Sleep 10; $n.Dispose()")
+		cmd.Env = append(os.Environ(), "IDEA_NOTIFY_TITLE="+title, "IDEA_NOTIFY_MESSAGE="+message)
+	default:
+		cmd = exec.Command("notify-send", "--app-name=idea", title, message)
+	}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s: %w: %s", cmd.Args[0], err, out)
+	}
+	return nil
+}
+
+// notifyInBackground starts "idea notify" for the job, detached from the
+// terminal so that it outlives this process.
+func notifyInBackground(id string) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(exe, "notify", id)
+	cmd.SysProcAttr = detachedProcess()
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	return cmd.Process.Release()
+}
+
+// runNotify implements the "notify" subcommand:
+//
+//	idea notify <job>
+//
+// It follows a publishing job, as posting with -detach does in the
+// background, and shows a desktop notification once the idea is
+// published or fails. A dropped connection is followed again, for up to
+// an hour.
+func runNotify(args []string) {
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "usage: idea notify <job>")
+		os.Exit(2)
+	}
+	url, token, id := serverURL(), authenticate(), args[0]
+
+	var (
+		j   *publishJob
+		err error
+	)
+	deadline := time.Now().Add(time.Hour)
+	for {
+		j, err = followEvents(context.Background(), url, token, id, func(publishJob) {})
+		if err == nil || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Second)
+	}
+
+	var title, message string
+	switch {
+	case err != nil:
+		title, message = "Idea status unknown", fmt.Sprintf("lost track of job %s: %v", id, err)
+	case j.Status == "done":
+		title, message = "Idea published", cmp.Or(j.Title, "Untitled")
+		if j.URL != "" {
+			message += "\n" + j.URL
+		}
+	case j.Status == "failed":
+		title, message = "Idea failed to publish", cmp.Or(j.Title, "Untitled")+"\n"+j.Error
+	case j.Status == "scheduled":
+		title, message = "Idea scheduled", cmp.Or(j.Title, "Untitled")+"\nTo be published at "+j.PublishAt.Local().Format("2006-01-02 15:04")
+	case j.Status == "queued":
+		title, message = "Idea waiting to be committed", cmp.Or(j.Title, "Untitled")+"\nThe server retries committing it.\n"+j.Error
+	case j.Status == "review":
+		title, message = "Idea waiting for review", cmp.Or(j.Title, "Untitled")+"\nApprove or discard it on the dashboard."
+	default:
+		title, message = "Idea "+j.Status, cmp.Or(j.Title, "Untitled")
+	}
+	if err := desktopNotify(title, message); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+}
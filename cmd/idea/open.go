@@ -0,0 +1,78 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"runtime"
+	"strings"
+)
+
+// openBrowser opens url in the default browser.
+func openBrowser(url string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", url)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+	return cmd.Start()
+}
+
+// runOpen implements the "open" subcommand:
+//
+//	idea open [-github] [-print] <id>
+//
+// It opens a published idea on the site, or its file on GitHub. The ID
+// may also be given as the file's path, as "idea history" lists it.
+func runOpen(args []string) {
+	fset := flag.NewFlagSet("open", flag.ExitOnError)
+	github := fset.Bool("github", false, "open the file in the repository instead of the site")
+	printOnly := fset.Bool("print", false, "print the URL instead of opening it")
+	fset.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: idea open [-github] [-print] <id>")
+		fset.PrintDefaults()
+	}
+	fset.Parse(args)
+	if fset.NArg() != 1 {
+		fset.Usage()
+		os.Exit(2)
+	}
+	id := strings.TrimSuffix(path.Base(fset.Arg(0)), ".md")
+
+	idea, err := fetchIdea(serverURL(), authenticate(), id)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed: %v\n", err)
+		os.Exit(1)
+	}
+	url := idea.URL
+	if *github {
+		url = idea.GitHubURL
+	}
+	if url == "" {
+		fmt.Fprintln(os.Stderr, "the server did not return the URL; it may need updating")
+		os.Exit(1)
+	}
+	if *printOnly {
+		fmt.Println(url)
+		return
+	}
+	if idea.Draft && !*github {
+		fmt.Fprintln(os.Stderr, "note: the idea is a draft and may not be on the live site")
+	}
+	if err := openBrowser(url); err != nil {
+		fmt.Fprintf(os.Stderr, "cannot open a browser (%v); the URL is\n", err)
+		fmt.Println(url)
+		os.Exit(1)
+	}
+	fmt.Println(url)
+}
@@ -0,0 +1,91 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+// postPending is the fallback when the server cannot be reached, failing
+// with cause: with GIT_TOKEN set, it commits the idea straight to the
+// repository for the server to publish once it is back. Without it, it
+// returns cause. Drafts are never committed: the repository is public.
+// Neither are ideas that seem to contain a credential, which the server
+// would refuse, unless they were posted with -allow-secrets.
+func postPending(url string, idea map[string]any, cause error) error {
+	if os.Getenv("GIT_TOKEN") == "" {
+		return cause
+	}
+	if draft, _ := idea["draft"].(bool); draft {
+		return fmt.Errorf("%w; drafts are not committed to the public repository", cause)
+	}
+	title, _ := idea["title"].(string)
+	content, _ := idea["content"].(string)
+	if allow, _ := idea["allow_secrets"].(bool); !allow {
+		if found := findSecrets(title, content); len(found) > 0 {
+			return fmt.Errorf("%w; not committed to the public repository as the idea seems to contain a secret (%s); remove it, or post with -allow-secrets", cause, strings.Join(found, ", "))
+		}
+	}
+	p, err := commitPending(idea)
+	if err != nil {
+		return fmt.Errorf("%v; committing to the repository failed too: %w", cause, err)
+	}
+	fmt.Fprintf(os.Stderr, "server unreachable (%v); committed to %s for the server to publish later\n", cause, p)
+	e := newHistoryEntry(url, idea)
+	e.Path, e.Message = p, "pending, committed while the server was unreachable"
+	recordHistory(e)
+	return nil
+}
+
+// commitPending commits the request of an idea, and when it was captured,
+// as a JSON file to $GIT_PENDING_DIR of $GIT_REPO, and returns its path.
+// The server runs these through its pipeline, like posted ideas.
+func commitPending(idea map[string]any) (string, error) {
+	repo := cmp.Or(os.Getenv("GIT_REPO"), "changkun/blog")
+	if !strings.Contains(repo, "/") {
+		return "", fmt.Errorf("GIT_REPO must be in owner/repo format, got: %s", repo)
+	}
+	pending := maps.Clone(idea)
+	now := time.Now()
+	pending["captured"] = now
+	data, err := json.MarshalIndent(pending, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	p := path.Join(cmp.Or(os.Getenv("GIT_PENDING_DIR"), ".ideas/pending"),
+		fmt.Sprintf("%s-%s.json", now.UTC().Format("20060102T150405Z"), rand.Text()[:6]))
+
+	title, _ := idea["title"].(string)
+	body, _ := json.Marshal(map[string]string{
+		"message": "ideas(pending): " + cmp.Or(title, "untitled"),
+		"content": base64.StdEncoding.EncodeToString(data),
+	})
+	req, _ := http.NewRequest("PUT", "https://api.github.com/repos/"+repo+"/contents/"+p, bytes.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+os.Getenv("GIT_TOKEN"))
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	resp, err := doWithRetry(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<10))
+		return "", fmt.Errorf("GitHub API returned %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	return p, nil
+}
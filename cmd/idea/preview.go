@@ -0,0 +1,95 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+// ANSI styles used by the preview.
+const (
+	styleReset     = "\x1b[0m"
+	styleBold      = "\x1b[1m"
+	styleDim       = "\x1b[2m"
+	styleItalic    = "\x1b[3m"
+	styleUnderline = "\x1b[4m"
+	styleCode      = "\x1b[36m"
+)
+
+var (
+	mdHeading = regexp.MustCompile(`^(#{1,6})\s+(.*)$`)
+	mdBullet  = regexp.MustCompile(`^(\s*)[-*+]\s+(.*)$`)
+	mdOrdered = regexp.MustCompile(`^(\s*)(\d+[.)])\s+(.*)$`)
+	mdQuote   = regexp.MustCompile(`^\s*>\s?(.*)$`)
+	mdRule    = regexp.MustCompile(`^\s*([-*_])(\s*([-*_])){2,}\s*$`)
+
+	mdCode   = regexp.MustCompile("`([^`]+)`")
+	mdLink   = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+	mdBold   = regexp.MustCompile(`\*\*([^*]+)\*\*|__([^_]+)__`)
+	mdItalic = regexp.MustCompile(`\*([^*\s][^*]*)\*|\b_([^_\s][^_]*)_\b`)
+)
+
+// renderMarkdown formats markdown for the terminal: headings, lists,
+// quotes, code, emphasis, and links. Lines end in "\r\n" since the
+// terminal is in raw mode.
+func renderMarkdown(src string) string {
+	var b strings.Builder
+	inFence := false
+	for line := range strings.Lines(src) {
+		line = strings.TrimRight(line, "\r\n")
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inFence = !inFence
+			b.WriteString(styleDim + "────" + styleReset + "\r\n")
+			continue
+		}
+		if inFence {
+			b.WriteString("    " + styleCode + line + styleReset + "\r\n")
+			continue
+		}
+
+		switch {
+		case mdHeading.MatchString(line):
+			m := mdHeading.FindStringSubmatch(line)
+			style := styleBold
+			if len(m[1]) == 1 {
+				style += styleUnderline
+			}
+			b.WriteString(style + renderInline(m[2]) + styleReset)
+		case mdRule.MatchString(line):
+			b.WriteString(styleDim + strings.Repeat("─", 40) + styleReset)
+		case mdBullet.MatchString(line):
+			m := mdBullet.FindStringSubmatch(line)
+			b.WriteString(m[1] + "  • " + renderInline(m[2]))
+		case mdOrdered.MatchString(line):
+			m := mdOrdered.FindStringSubmatch(line)
+			b.WriteString(m[1] + "  " + m[2] + " " + renderInline(m[3]))
+		case mdQuote.MatchString(line):
+			m := mdQuote.FindStringSubmatch(line)
+			b.WriteString(styleDim + "│ " + styleReset + styleItalic + renderInline(m[1]) + styleReset)
+		default:
+			b.WriteString(renderInline(line))
+		}
+		b.WriteString("\r\n")
+	}
+	return b.String()
+}
+
+// renderInline formats code spans, links, and emphasis within a line.
+// Code spans are set aside first so their content is left as is.
+func renderInline(s string) string {
+	var codes []string
+	s = mdCode.ReplaceAllStringFunc(s, func(m string) string {
+		codes = append(codes, styleCode+m[1:len(m)-1]+styleReset)
+		return "\x00"
+	})
+	s = mdLink.ReplaceAllString(s, styleUnderline+"$1"+styleReset+styleDim+" ($2)"+styleReset)
+	s = mdBold.ReplaceAllString(s, styleBold+"$1$2\x1b[22m")
+	s = mdItalic.ReplaceAllString(s, styleItalic+"$1$2\x1b[23m")
+	for _, c := range codes {
+		s = strings.Replace(s, "\x00", c, 1)
+	}
+	return s
+}
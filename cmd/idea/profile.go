@@ -0,0 +1,137 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// profile is a named server to post to, selected with -profile or
+// $IDEA_PROFILE. Its settings take precedence over the environment.
+type profile struct {
+	URL      string `json:"url,omitempty"`       // IDEAS_URL
+	LoginURL string `json:"login_url,omitempty"` // LOGIN_URL
+	User     string `json:"user,omitempty"`      // LOGIN_USER
+	// PasswordEnv names the variable holding the password. Without it,
+	// the password is the one "idea -profile <name> login" stored.
+	PasswordEnv string `json:"password_env,omitempty"`
+	// Pipeline is the server pipeline ideas posted to this profile run.
+	Pipeline string `json:"pipeline,omitempty"`
+}
+
+// cliConfig is the CLI config file, for example:
+//
+//	{
+//	  "profiles": {
+//	    "work": {"url": "https://ideas.example.com", "login_url": "https://login.example.com", "user": "me", "pipeline": "work"}
+//	  },
+//	  "keys": {"enter": "newline", "ctrl+d": "submit"},
+//	  "editing": "vi"
+//	}
+type cliConfig struct {
+	Profiles map[string]profile `json:"profiles"`
+	// Keys rebinds keys of the interactive input; see keyActions.
+	Keys map[string]string `json:"keys,omitempty"`
+	// Editing is the editing mode of the interactive input, "emacs"
+	// (the default) or "vi".
+	Editing string `json:"editing,omitempty"`
+}
+
+// loadConfig reads the config file and returns it with its path.
+func loadConfig() (cliConfig, string, error) {
+	path, err := configPath()
+	if err != nil {
+		return cliConfig{}, "", err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cliConfig{}, path, err
+	}
+	var c cliConfig
+	if err := json.Unmarshal(data, &c); err != nil {
+		return cliConfig{}, path, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return c, path, nil
+}
+
+// activeProfile is the name of the selected profile, if any, and
+// profilePipeline its pipeline.
+var activeProfile, profilePipeline string
+
+// configPath returns the CLI config file, $IDEA_CONFIG or
+// "idea/config.json" in the user's config directory.
+func configPath() (string, error) {
+	if path := os.Getenv("IDEA_CONFIG"); path != "" {
+		return path, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "idea", "config.json"), nil
+}
+
+// selectProfile takes a leading "-profile <name>" off args, or uses
+// $IDEA_PROFILE, and applies the profile. It returns the remaining
+// arguments.
+func selectProfile(args []string) ([]string, error) {
+	name := os.Getenv("IDEA_PROFILE")
+	if len(args) > 0 {
+		flagName, value, hasValue := strings.Cut(strings.TrimLeft(args[0], "-"), "=")
+		if strings.HasPrefix(args[0], "-") && flagName == "profile" {
+			switch {
+			case hasValue:
+				name, args = value, args[1:]
+			case len(args) > 1:
+				name, args = args[1], args[2:]
+			default:
+				return nil, fmt.Errorf("-profile needs a name")
+			}
+		}
+	}
+	if name == "" {
+		return args, nil
+	}
+	return args, useProfile(name)
+}
+
+// useProfile applies the named profile from the config file.
+func useProfile(name string) error {
+	c, path, err := loadConfig()
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("profile %s: %w", name, err)
+	}
+	if err != nil {
+		return err
+	}
+	p, ok := c.Profiles[name]
+	if !ok {
+		return fmt.Errorf("no profile %q in %s, have: %s", name, path,
+			strings.Join(slices.Sorted(maps.Keys(c.Profiles)), ", "))
+	}
+
+	set := func(key, value string) {
+		if value != "" {
+			os.Setenv(key, value)
+		}
+	}
+	set("IDEAS_URL", p.URL)
+	set("LOGIN_URL", p.LoginURL)
+	set("LOGIN_USER", p.User)
+	// A password from the environment belongs to another server.
+	os.Unsetenv("LOGIN_PASS")
+	if p.PasswordEnv != "" {
+		set("LOGIN_PASS", os.Getenv(p.PasswordEnv))
+	}
+	activeProfile, profilePipeline = name, p.Pipeline
+	return nil
+}
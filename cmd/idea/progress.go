@@ -0,0 +1,248 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// stageLabels describe the stages of the server's pipeline while they run.
+var stageLabels = map[string]string{
+	"wait":         "waiting for a worker",
+	"fetch":        "fetching linked pages",
+	"title":        "writing a title",
+	"detect-lang":  "detecting the language",
+	"moderate":     "reviewing",
+	"translate":    "polishing and translating",
+	"augment":      "writing the deep dive",
+	"tag":          "tagging",
+	"link-check":   "checking links",
+	"pre-publish":  "running hooks",
+	"slug":         "naming",
+	"commit":       "committing",
+	"index":        "indexing",
+	"post-publish": "running hooks",
+	"crosspost":    "announcing",
+	"notify":       "notifying",
+}
+
+// postShowingProgress posts an idea, announced as label. On a terminal,
+// it follows the server publishing the idea and shows the stage it is
+// in, and has its title confirmed if the server generates it. Otherwise,
+// or with -detach, it returns once the idea is accepted; detached, a
+// desktop notification follows when it is published.
+func postShowingProgress(label, url, token string, idea map[string]any) error {
+	if dryRun || jsonOutput || !term.IsTerminal(int(os.Stdout.Fd())) {
+		fmt.Fprintf(progress, "%s... ", label)
+		if err := postIdea(url, token, idea); err != nil {
+			return err
+		}
+		fmt.Fprintln(progress, "done")
+		return nil
+	}
+
+	applyProfile(idea)
+	// A generated title is confirmed by holding the idea for review.
+	titleOnly := confirmTitle && !detach && idea["review"] == nil && idea["title"] == "" && idea["no_title"] == nil &&
+		term.IsTerminal(int(os.Stdin.Fd()))
+	if titleOnly {
+		idea["review"] = true
+	}
+	sp := startSpinner(label, "sending")
+	job, err := submitIdea(url, token, idea)
+	if err != nil {
+		sp.stop("")
+		return err
+	}
+	if job == "" {
+		sp.stop(label + "... done\n")
+		return nil
+	}
+	if detach {
+		if err := notifyInBackground(job); err != nil {
+			sp.stop(label + "... accepted, publishing continues on the server\n")
+			fmt.Fprintf(os.Stderr, "cannot notify once published: %v\n", err)
+			return nil
+		}
+		sp.stop(label + "... accepted, a notification follows once published\n")
+		return nil
+	}
+
+	sp.set("accepted")
+	j, err := watchJob(url, token, job, sp)
+	for err == nil && j.Status == "review" {
+		sp.stop("")
+		publish := true
+		if titleOnly {
+			err = confirmJobTitle(url, token, job, j.Preview)
+		} else {
+			publish, err = reviewJob(url, token, job, j.Preview)
+		}
+		if err != nil {
+			return err
+		}
+		if !publish {
+			fmt.Println(label + "... discarded")
+			return nil
+		}
+		sp = startSpinner(label, "approved")
+		j, err = watchJob(url, token, job, sp)
+	}
+	switch {
+	case err != nil:
+		// Interrupted, an older server, or the connection dropped.
+		sp.stop(label + "... accepted, publishing continues on the server\n")
+		return nil
+	case j.Status == "failed":
+		sp.stop("")
+		return errors.New(j.Error)
+	case j.Status == "scheduled":
+		sp.stop(fmt.Sprintf("%s... scheduled for %s\n%s\n", label, j.PublishAt.Local().Format("2006-01-02 15:04"), j.URL))
+		return nil
+	case j.Status == "queued":
+		sp.stop(label + "... committing failed, the server retries in the background\n")
+		return nil
+	}
+	final := fmt.Sprintf("%s... done in %s\n", label, sp.elapsed())
+	if j.URL != "" {
+		final += j.URL + "\n"
+	}
+	sp.stop(final)
+	return nil
+}
+
+// watchJob follows a job, showing its stage on the spinner, until it is
+// no longer running. The idea is accepted, so Ctrl+C only stops watching
+// it.
+func watchJob(url, token, id string, sp *spinner) (*publishJob, error) {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+	return followEvents(ctx, url, token, id, func(j publishJob) {
+		if n := len(j.Stages); n > 0 {
+			name := j.Stages[n-1].Name
+			sp.set(cmp.Or(stageLabels[name], name))
+		}
+	})
+}
+
+// followEvents follows the events of a job until it is done or failed,
+// calling update for each, and returns the final job. Named events, the
+// milestones of the pipeline, are skipped: the job carries them too.
+func followEvents(ctx context.Context, url, token, id string, update func(publishJob)) (*publishJob, error) {
+	req, _ := http.NewRequestWithContext(ctx, "GET", url+"/ideas/admin/jobs/"+id+"/events", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "text/event-stream")
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(resp.Status)
+	}
+
+	sc := bufio.NewScanner(resp.Body)
+	sc.Buffer(nil, 1<<20)
+	var named bool
+	for sc.Scan() {
+		line := sc.Text()
+		if line == "" {
+			named = false
+		}
+		if strings.HasPrefix(line, "event: ") {
+			named = true
+		}
+		data, ok := strings.CutPrefix(line, "data: ")
+		if !ok || named {
+			continue
+		}
+		var j publishJob
+		if err := json.Unmarshal([]byte(data), &j); err != nil {
+			return nil, fmt.Errorf("decode event: %w", err)
+		}
+		update(j)
+		if j.Status != "running" {
+			return &j, nil
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return nil, errors.New("the event stream ended early")
+}
+
+// spinner shows a label, a status, and the time elapsed on the current
+// line until stopped.
+type spinner struct {
+	label string
+	start time.Time
+	done  chan struct{}
+	wg    sync.WaitGroup
+
+	mu     sync.Mutex
+	status string
+}
+
+func startSpinner(label, status string) *spinner {
+	sp := &spinner{label: label, status: status, start: time.Now(), done: make(chan struct{})}
+	sp.wg.Add(1)
+	go sp.run()
+	return sp
+}
+
+func (sp *spinner) run() {
+	defer sp.wg.Done()
+	const frames = "⠋⠙⠹⠸⠼⠴⠦⠧⠇⠏"
+	t := time.NewTicker(100 * time.Millisecond)
+	defer t.Stop()
+	for i := 0; ; i++ {
+		sp.mu.Lock()
+		line := fmt.Sprintf("%c %s: %s (%s)", []rune(frames)[i%10], sp.label, sp.status, sp.elapsed())
+		sp.mu.Unlock()
+		if i > 50 {
+			line += " · Ctrl+C stops watching, publishing continues"
+		}
+		if w := termWidth(); w > 0 {
+			line = truncateWidth(line, w-1)
+		}
+		fmt.Print("\r\x1b[K" + line)
+		select {
+		case <-sp.done:
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// set changes the status shown.
+func (sp *spinner) set(status string) {
+	sp.mu.Lock()
+	sp.status = status
+	sp.mu.Unlock()
+}
+
+func (sp *spinner) elapsed() time.Duration {
+	return time.Since(sp.start).Truncate(time.Second)
+}
+
+// stop clears the line and prints final in its place.
+func (sp *spinner) stop(final string) {
+	close(sp.done)
+	sp.wg.Wait()
+	fmt.Print("\r\x1b[K" + final)
+}
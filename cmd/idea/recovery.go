@@ -0,0 +1,112 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// autosaveDelay is how long after an edit the input is written to the
+// recovery file.
+const autosaveDelay = time.Second
+
+// recoveryPath returns the file the input being composed is saved to, so
+// it survives a crash, a lost terminal, or an accidental Ctrl+C.
+func recoveryPath() (string, error) {
+	dir, err := draftsDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, ".recovery.md"), nil
+}
+
+// autosaver writes the input to the recovery file shortly after it
+// changes.
+type autosaver struct {
+	path string
+
+	mu    sync.Mutex
+	text  string
+	timer *time.Timer
+}
+
+func newAutosaver(path string) *autosaver {
+	return &autosaver{path: path}
+}
+
+// update schedules saving the input.
+func (a *autosaver) update(title, content string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.text = ""
+	if strings.TrimSpace(content) != "" {
+		a.text = draftText(title, content)
+	}
+	if a.timer == nil {
+		a.timer = time.AfterFunc(autosaveDelay, a.flush)
+	}
+}
+
+// flush writes the latest input now.
+func (a *autosaver) flush() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.timer != nil {
+		a.timer.Stop()
+		a.timer = nil
+	}
+	if a.text == "" {
+		removeLocal(a.path)
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(a.path), 0o700); err != nil {
+		return
+	}
+	writeLocal(a.path, []byte(a.text))
+}
+
+// clear stops saving and removes the recovery file, once the input was
+// posted or saved as a draft.
+func (a *autosaver) clear() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.timer != nil {
+		a.timer.Stop()
+		a.timer = nil
+	}
+	a.text = "" // for a flush already under way
+	removeLocal(a.path)
+}
+
+// offerRecovery asks whether to restore input that was not posted last
+// time, and returns it if so. Declining discards it.
+func offerRecovery(path string) (title, content string, ok bool) {
+	data, info, err := readLocal(path)
+	if errors.Is(err, os.ErrNotExist) || err == nil && strings.TrimSpace(string(data)) == "" {
+		return "", "", false
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "read recovery file: %v\n", err)
+		return "", "", false
+	}
+	when := " from " + info.ModTime().Format("Jan 2 15:04")
+	title, content = splitNote(string(data))
+	fmt.Printf("Found an unsent idea%s: %s\n", when, draftSummary(localDraft{Title: title, Content: content}))
+	fmt.Print("Restore it? [Y/n] ")
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "", "y", "yes":
+		return title, content, true
+	}
+	removeLocal(path)
+	return "", "", false
+}
@@ -0,0 +1,110 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+)
+
+// postResult is what -json prints for each posted idea.
+type postResult struct {
+	OK         bool          `json:"ok"`
+	Error      string        `json:"error,omitempty"`
+	Job        string        `json:"job,omitempty"`
+	ID         string        `json:"id,omitempty"`
+	Path       string        `json:"path,omitempty"`
+	URL        string        `json:"url,omitempty"`
+	CommitURL  string        `json:"commit_url,omitempty"`
+	PublishAt  time.Time     `json:"publish_at,omitzero"` // if scheduled rather than published
+	DurationMS int64         `json:"duration_ms"`         // from posting to published
+	Stages     []stageResult `json:"stages,omitempty"`
+}
+
+type stageResult struct {
+	Name       string `json:"name"`
+	DurationMS int64  `json:"duration_ms"`
+}
+
+// Following a job gives up after jobTimeout, a little longer than the
+// server allows a pipeline to run.
+const (
+	jobPollInterval = 2 * time.Second
+	jobTimeout      = 6 * time.Minute
+)
+
+// followJob waits for the job publishing an idea to finish and returns
+// its outcome.
+func followJob(url, token, id string) postResult {
+	res := postResult{Job: id}
+	deadline := time.Now().Add(jobTimeout)
+	for {
+		j, err := fetchJob(url, token, id)
+		if err != nil {
+			res.Error = err.Error()
+			return res
+		}
+		if j.Status != "running" {
+			res.OK = j.Status == "done" || j.Status == "scheduled"
+			res.Error = j.Error
+			res.Path, res.URL, res.CommitURL, res.PublishAt = j.Path, j.URL, j.CommitURL, j.PublishAt
+			if j.Path != "" {
+				res.ID = strings.TrimSuffix(path.Base(j.Path), ".md")
+			}
+			for _, s := range j.Stages {
+				res.Stages = append(res.Stages, stageResult{Name: s.Name, DurationMS: s.Duration.Milliseconds()})
+			}
+			return res
+		}
+		if time.Now().After(deadline) {
+			res.Error = "timed out waiting for the idea to be published"
+			return res
+		}
+		time.Sleep(jobPollInterval)
+	}
+}
+
+// publishJob is a job as served by GET /ideas/admin/jobs/{id}.
+type publishJob struct {
+	Status    string      `json:"status"` // running, review, scheduled, queued, done, failed, or discarded
+	Title     string      `json:"title"`
+	Error     string      `json:"error"`
+	Preview   *jobPreview `json:"preview"` // in review
+	Path      string      `json:"path"`
+	URL       string      `json:"url"`
+	CommitURL string      `json:"commit_url"`
+	PublishAt time.Time   `json:"publish_at"` // if scheduled
+	Stages    []struct {
+		Name     string        `json:"name"`
+		Duration time.Duration `json:"duration"`
+	} `json:"stages"`
+}
+
+func fetchJob(url, token, id string) (*publishJob, error) {
+	req, _ := http.NewRequest("GET", url+"/ideas/admin/jobs/"+id, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusUnauthorized {
+		return nil, errUnauthorized
+	}
+
+	var result struct {
+		OK bool `json:"ok"`
+		apiError
+		Job publishJob `json:"job"`
+	}
+	json.NewDecoder(resp.Body).Decode(&result)
+	if !result.OK {
+		return nil, result.err(resp)
+	}
+	return &result.Job, nil
+}
@@ -0,0 +1,84 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"os"
+	"time"
+)
+
+// Posting an idea is given up after requestTimeout per attempt, and
+// retried up to requestRetries times on failures that may be transient,
+// set by -timeout and -retries.
+var (
+	requestTimeout = 30 * time.Second
+	requestRetries = 3
+)
+
+// Backoff between attempts doubles from retryBase up to retryMax, with
+// jitter.
+const (
+	retryBase = time.Second
+	retryMax  = 30 * time.Second
+)
+
+// retryable reports whether a request that failed with err, or got the
+// response status, is worth another attempt: network errors and timeouts,
+// and the gateway being down or overloaded.
+func retryable(status int, err error) bool {
+	if err != nil {
+		return !errors.Is(err, context.Canceled)
+	}
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// backoff returns how long to wait before the given retry, counting
+// from 0: exponential, and randomized between half and all of it, so
+// clients failing together do not retry together.
+func backoff(retry int) time.Duration {
+	d := min(retryBase<<retry, retryMax)
+	return d/2 + rand.N(d/2+1)
+}
+
+// doWithRetry sends req, retrying transient failures. The request body
+// must be replayable, as for bodies from bytes.NewReader.
+func doWithRetry(req *http.Request) (*http.Response, error) {
+	client := &http.Client{Transport: httpClient.Transport, Timeout: requestTimeout}
+	for retry := 0; ; retry++ {
+		if retry > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+		resp, err := client.Do(req)
+		status := 0
+		if err == nil {
+			status = resp.StatusCode
+		}
+		if retry >= requestRetries || !retryable(status, err) {
+			return resp, err
+		}
+
+		why := fmt.Sprint(err)
+		if err == nil {
+			why = resp.Status
+			resp.Body.Close()
+		}
+		d := backoff(retry)
+		fmt.Fprintf(os.Stderr, "\n%s; retrying in %s (%d/%d)\n", why, d.Round(100*time.Millisecond), retry+1, requestRetries)
+		time.Sleep(d)
+	}
+}
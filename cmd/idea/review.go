@@ -0,0 +1,138 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// jobPreview is an idea held for review, as it would be published.
+type jobPreview struct {
+	Title    string `json:"title"`
+	TitleZh  string `json:"title_zh"`
+	Lang     string `json:"lang"`
+	Markdown string `json:"markdown"` // the file, with the slug generated on publishing if empty
+}
+
+// decideJob approves or discards an idea held for review. Approving with
+// markdown publishes it in place of the preview, and with a title, under
+// that title.
+func decideJob(url, token, id, action, markdown, title string) error {
+	body, _ := json.Marshal(map[string]string{"markdown": markdown, "title": title})
+	req, _ := http.NewRequest("POST", url+"/ideas/admin/jobs/"+id+"/"+action, bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusUnauthorized {
+		return errUnauthorized
+	}
+
+	var result struct {
+		OK bool `json:"ok"`
+		apiError
+	}
+	json.NewDecoder(resp.Body).Decode(&result)
+	if !result.OK {
+		return result.err(resp)
+	}
+	return nil
+}
+
+// reviewJob shows an idea held for review and asks whether to publish it
+// as is, edit it first, or discard it. It reports whether the idea is
+// being published. Interrupting leaves it held, to decide on from the
+// dashboard.
+func reviewJob(url, token, id string, p *jobPreview) (publish bool, err error) {
+	if p == nil {
+		return false, errors.New("the server sent no preview to review")
+	}
+	md := p.Markdown
+	page(md)
+	in := bufio.NewReader(os.Stdin)
+	for {
+		fmt.Print("Publish? [Y]es, [e]dit, [d]iscard: ")
+		answer, err := in.ReadString('\n')
+		if err != nil {
+			return false, fmt.Errorf("no answer, the idea is left in review: %w", err)
+		}
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "", "y", "yes":
+			edited := ""
+			if md != p.Markdown {
+				edited = md
+			}
+			if err := decideJob(url, token, id, "approve", edited, ""); err != nil {
+				// The idea stays held, so it can be fixed.
+				fmt.Fprintf(os.Stderr, "failed: %v\n", err)
+				continue
+			}
+			return true, nil
+		case "e", "edit":
+			edited, err := composeInEditor(md)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "error: %v\n", err)
+				continue
+			}
+			if strings.TrimSpace(edited) != "" {
+				md = edited
+			}
+		case "d", "discard", "n", "no":
+			return false, decideJob(url, token, id, "discard", "", "")
+		}
+	}
+}
+
+// confirmJobTitle shows the title generated for an idea held for review
+// and publishes it under that title or an edited one.
+func confirmJobTitle(url, token, id string, p *jobPreview) error {
+	if p == nil {
+		return errors.New("the server sent no preview to review")
+	}
+	title := p.Title
+	if p.Lang == "zh" {
+		title = p.TitleZh
+	}
+	in := bufio.NewReader(os.Stdin)
+	for {
+		fmt.Printf("Title: %s — [Y]es, [e]dit: ", title)
+		answer, err := in.ReadString('\n')
+		if err != nil {
+			return fmt.Errorf("no answer, the idea is left in review: %w", err)
+		}
+		edited := ""
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "", "y", "yes":
+		case "e", "edit":
+			fmt.Print("New title: ")
+			line, err := in.ReadString('\n')
+			if err != nil {
+				return fmt.Errorf("no answer, the idea is left in review: %w", err)
+			}
+			if edited = strings.TrimSpace(line); edited == "" {
+				continue
+			}
+		default:
+			continue
+		}
+		if err := decideJob(url, token, id, "approve", "", edited); err != nil {
+			// The idea stays held, so it can be tried again.
+			fmt.Fprintf(os.Stderr, "failed: %v\n", err)
+			continue
+		}
+		return nil
+	}
+}
@@ -0,0 +1,39 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import "regexp"
+
+// secretPatterns match credentials that must never be published, along
+// with what they are. They are those the server refuses ideas for, kept
+// here for ideas committed to the repository while it is unreachable.
+var secretPatterns = []struct {
+	kind string
+	re   *regexp.Regexp
+}{
+	{"AWS access key", regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"private key", regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY( BLOCK)?-----`)},
+	{"GitHub token", regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36}|github_pat_[A-Za-z0-9_]{22,})\b`)},
+	{"Slack token", regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}`)},
+	{"API key", regexp.MustCompile(`\bsk-[A-Za-z0-9_-]{20,}`)},
+	{"ideas API key", regexp.MustCompile(`\bidk_[0-9a-f]{48}\b`)},
+	{"JSON web token", regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}`)},
+	{"bearer token", regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9._~+/-]{20,}`)},
+}
+
+// findSecrets returns the kinds of credentials found in texts, in the
+// order of secretPatterns.
+func findSecrets(texts ...string) []string {
+	var kinds []string
+	for _, p := range secretPatterns {
+		for _, t := range texts {
+			if p.re.MatchString(t) {
+				kinds = append(kinds, p.kind)
+				break
+			}
+		}
+	}
+	return kinds
+}
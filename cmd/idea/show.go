@@ -0,0 +1,157 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// publishedIdea is an idea as served by GET /ideas/{id}.
+type publishedIdea struct {
+	ID         string    `json:"id"`
+	Path       string    `json:"path"`
+	SHA        string    `json:"sha"`
+	URL        string    `json:"url"`
+	GitHubURL  string    `json:"github_url"`
+	Date       time.Time `json:"date"`
+	Title      string    `json:"title"`
+	TitleZh    string    `json:"title_zh"`
+	Draft      bool      `json:"draft"`
+	Categories []string  `json:"categories"`
+	Markdown   string    `json:"markdown"`
+}
+
+// fetchIdea gets a published idea from the server.
+func fetchIdea(url, token, id string) (*publishedIdea, error) {
+	req, _ := http.NewRequest("GET", url+"/ideas/"+id, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusUnauthorized {
+		return nil, errUnauthorized
+	}
+
+	var result struct {
+		OK bool `json:"ok"`
+		apiError
+		Idea publishedIdea `json:"idea"`
+	}
+	json.NewDecoder(resp.Body).Decode(&result)
+	if !result.OK {
+		return nil, result.err(resp)
+	}
+	return &result.Idea, nil
+}
+
+// similarIdea is an idea as served by GET /ideas/{id}/similar.
+type similarIdea struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+	URL   string `json:"url"` // empty for drafts
+}
+
+// fetchSimilar gets the k ideas most similar to the given one.
+func fetchSimilar(url, token, id string, k int) ([]similarIdea, error) {
+	req, _ := http.NewRequest("GET", fmt.Sprintf("%s/ideas/%s/similar?k=%d", url, id, k), nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		OK bool `json:"ok"`
+		apiError
+		Ideas []similarIdea `json:"ideas"`
+	}
+	json.NewDecoder(resp.Body).Decode(&result)
+	if !result.OK {
+		return nil, result.err(resp)
+	}
+	return result.Ideas, nil
+}
+
+// runShow implements the "show" subcommand:
+//
+//	idea show <id>
+//
+// It prints an idea's metadata, the ideas most similar to it, and its
+// markdown through $PAGER.
+func runShow(args []string) {
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "usage: idea show <id>")
+		os.Exit(2)
+	}
+	url, token := serverURL(), authenticate()
+	idea, err := fetchIdea(url, token, args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed: %v\n", err)
+		os.Exit(1)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n", idea.Title)
+	if idea.TitleZh != "" && idea.TitleZh != idea.Title {
+		fmt.Fprintf(&b, "%s\n", idea.TitleZh)
+	}
+	fmt.Fprintf(&b, "\nID:    %s\n", idea.ID)
+	fmt.Fprintf(&b, "Date:  %s\n", idea.Date.Format("2006-01-02 15:04"))
+	fmt.Fprintf(&b, "URL:   %s\n", idea.URL)
+	fmt.Fprintf(&b, "Path:  %s\n", cmp.Or(idea.GitHubURL, idea.Path))
+	if len(idea.Categories) > 0 {
+		fmt.Fprintf(&b, "Tags:  %s\n", strings.Join(idea.Categories, ", "))
+	}
+	if idea.Draft {
+		b.WriteString("Draft: yes\n")
+	}
+	// Ideas not embedded yet have no similar ones; that is no reason to
+	// fail showing it.
+	if similar, err := fetchSimilar(url, token, idea.ID, 3); err == nil && len(similar) > 0 {
+		b.WriteString("\nSee also:\n")
+		for _, s := range similar {
+			fmt.Fprintf(&b, "  %s  %s\n", s.Title, cmp.Or(s.URL, "(draft)"))
+		}
+	}
+	b.WriteString("\n" + idea.Markdown)
+	page(b.String())
+}
+
+// page shows text through $PAGER, or prints it if the output is not a
+// terminal.
+func page(text string) {
+	if !term.IsTerminal(int(os.Stdout.Fd())) {
+		fmt.Print(text)
+		return
+	}
+	pager := os.Getenv("PAGER")
+	if pager == "" {
+		pager = "less -FRX"
+		if runtime.GOOS == "windows" {
+			pager = "more"
+		}
+	}
+	args := strings.Fields(pager)
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = strings.NewReader(text)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		fmt.Print(text)
+	}
+}
@@ -0,0 +1,170 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ideaStats is the reply of GET /ideas/stats.
+type ideaStats struct {
+	Streak struct {
+		Current int    `json:"current"`
+		Longest int    `json:"longest"`
+		LastDay string `json:"last_day"`
+		Today   bool   `json:"today"`
+	} `json:"streak"`
+	Archive struct {
+		Total  int `json:"total"`
+		Months []struct {
+			Month string `json:"month"`
+			Ideas int    `json:"ideas"`
+		} `json:"months"`
+		Languages    map[string]int `json:"languages"`
+		AverageWords map[string]int `json:"average_words"`
+	} `json:"archive"`
+	Activity struct {
+		Usage struct {
+			Requests         int `json:"requests"`
+			PromptTokens     int `json:"prompt_tokens"`
+			CompletionTokens int `json:"completion_tokens"`
+		} `json:"usage"`
+		Failed       int            `json:"failed"`
+		FailedStages map[string]int `json:"failed_stages"`
+	} `json:"activity"`
+}
+
+// fetchStats gets the statistics over the given number of months.
+func fetchStats(url, token string, months int) (*ideaStats, error) {
+	req, _ := http.NewRequest("GET", url+"/ideas/stats?months="+strconv.Itoa(months), nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusUnauthorized {
+		return nil, errUnauthorized
+	}
+
+	var result struct {
+		ideaStats
+		OK bool `json:"ok"`
+		apiError
+	}
+	json.NewDecoder(resp.Body).Decode(&result)
+	if !result.OK {
+		return nil, result.err(resp)
+	}
+	return &result.ideaStats, nil
+}
+
+// sparkline draws counts as a row of block characters, scaled to the
+// largest.
+func sparkline(counts []int) string {
+	const blocks = "▁▂▃▄▅▆▇█"
+	levels := []rune(blocks)
+	top := maxCount(counts)
+	var b strings.Builder
+	for _, n := range counts {
+		if n == 0 {
+			b.WriteRune(' ')
+			continue
+		}
+		b.WriteRune(levels[(n*(len(levels)-1)+top-1)/top])
+	}
+	return b.String()
+}
+
+// maxCount returns the largest count, at least 1 to scale by.
+func maxCount(counts []int) int {
+	top := 1
+	for _, n := range counts {
+		top = max(top, n)
+	}
+	return top
+}
+
+// runStats implements the "stats" subcommand:
+//
+//	idea stats [-months n]
+//
+// It prints the number of ideas per month as a table with bars, the
+// split between languages, and the average length.
+func runStats(args []string) {
+	fset := flag.NewFlagSet("stats", flag.ExitOnError)
+	months := fset.Int("months", 12, "cover the last `n` months")
+	fset.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: idea stats [-months n]")
+		fset.PrintDefaults()
+	}
+	fset.Parse(args)
+	if fset.NArg() > 0 {
+		fset.Usage()
+		os.Exit(2)
+	}
+
+	st, err := fetchStats(serverURL(), authenticate(), *months)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed: %v\n", err)
+		os.Exit(1)
+	}
+
+	a := st.Archive
+	counts := make([]int, len(a.Months))
+	for i, m := range a.Months {
+		counts[i] = m.Ideas
+	}
+	top := maxCount(counts)
+	barWidth := 40
+	if w := termWidth(); w > 0 {
+		barWidth = max(min(barWidth, w-16), 10)
+	}
+
+	fmt.Printf("%d ideas  %s\n\n", a.Total, sparkline(counts))
+	for i, m := range a.Months {
+		bar := strings.Repeat("█", (counts[i]*barWidth+top-1)/top)
+		fmt.Printf("%s  %4d  %s\n", m.Month, m.Ideas, bar)
+	}
+
+	fmt.Println()
+	langs := make([]string, 0, len(a.Languages))
+	for lang := range a.Languages {
+		langs = append(langs, lang)
+	}
+	slices.Sort(langs)
+	for _, lang := range langs {
+		n := a.Languages[lang]
+		fmt.Printf("%-8s %4d ideas (%d%%), %d words on average\n", lang, n, n*100/max(a.Total, 1), a.AverageWords[lang])
+	}
+
+	s := st.Streak
+	fmt.Printf("\nStreak: %d days (longest %d)", s.Current, s.Longest)
+	if s.Current > 0 && !s.Today {
+		fmt.Print(", no idea yet today")
+	}
+	fmt.Println()
+
+	act := st.Activity
+	u := act.Usage
+	fmt.Printf("LLM: %d tokens in %d requests (%d prompt, %d completion)\n", u.PromptTokens+u.CompletionTokens, u.Requests, u.PromptTokens, u.CompletionTokens)
+	fmt.Printf("Failed pipelines: %d", act.Failed)
+	var stages []string
+	for stage, n := range act.FailedStages {
+		stages = append(stages, fmt.Sprintf("%s %d", stage, n))
+	}
+	if len(stages) > 0 {
+		slices.Sort(stages)
+		fmt.Printf(" (%s)", strings.Join(stages, ", "))
+	}
+	fmt.Println()
+}
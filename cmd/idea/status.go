@@ -0,0 +1,54 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// statusLine summarizes the input for the line below it.
+func statusLine(buf []rune) string {
+	s := string(buf)
+	return fmt.Sprintf("%d chars · %d words · %d lines · %s",
+		len(buf), countWords(s), strings.Count(s, "\n")+1, detectLang(s))
+}
+
+// countWords counts words in s, where every CJK character counts as a
+// word, as the server does.
+func countWords(s string) int {
+	n := 0
+	for _, f := range strings.Fields(s) {
+		cjk := 0
+		for _, r := range f {
+			if unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) {
+				cjk++
+			}
+		}
+		n += max(cjk, 1)
+	}
+	return n
+}
+
+// detectLang guesses whether text is primarily Chinese or English the
+// way the server does: by checking if more than half the non-space runes
+// are CJK.
+func detectLang(s string) string {
+	var cjk, total int
+	for _, r := range s {
+		if unicode.IsSpace(r) || unicode.IsPunct(r) {
+			continue
+		}
+		total++
+		if unicode.Is(unicode.Han, r) {
+			cjk++
+		}
+	}
+	if total > 0 && cjk*2 > total {
+		return "zh"
+	}
+	return "en"
+}
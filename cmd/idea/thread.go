@@ -0,0 +1,50 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// runThread prints the thread generated for a published idea and, if
+// target is "x" or "mastodon", posts it there.
+func runThread(url, token, id, target string) {
+	body, _ := json.Marshal(map[string]string{"post": target})
+	req, _ := http.NewRequest("POST", url+"/ideas/"+id+"/thread", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed: %v\n", err)
+		os.Exit(1)
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		OK      bool     `json:"ok"`
+		Message string   `json:"message"`
+		Posts   []string `json:"posts"`
+		Posted  []string `json:"posted"`
+	}
+	json.NewDecoder(resp.Body).Decode(&result)
+	if !result.OK {
+		fmt.Fprintf(os.Stderr, "failed: %s\n", result.Message)
+		os.Exit(1)
+	}
+	for i, p := range result.Posts {
+		if i > 0 {
+			fmt.Print("\n---\n\n")
+		}
+		fmt.Println(p)
+	}
+	if len(result.Posted) > 0 {
+		fmt.Printf("\nPosted: %s\n", result.Posted[0])
+	}
+}
@@ -0,0 +1,44 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// httpClient makes every request of the CLI, through the proxy and with
+// the certificate authorities configured by configureHTTP.
+var httpClient = http.DefaultClient
+
+// configureHTTP sets up httpClient to go through the proxy of
+// HTTPS_PROXY, HTTP_PROXY, and NO_PROXY, and, if caFile is set, to also
+// trust the PEM certificates in it, as for a self-hosted server with a
+// private CA. It replaces http.DefaultTransport too, so the login
+// service is reached the same way.
+func configureHTTP(caFile string) error {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.Proxy = http.ProxyFromEnvironment
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return fmt.Errorf("read CA file: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no PEM certificates in %s", caFile)
+		}
+		t.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+	http.DefaultTransport = t
+	httpClient = &http.Client{Transport: t}
+	return nil
+}
@@ -0,0 +1,375 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"slices"
+	"unicode"
+)
+
+// viState is the state of the vi editing mode of the interactive input.
+// Insert mode keeps the default keys; Esc switches to normal mode, where
+// keys are commands over the whole multi-line input.
+type viState struct {
+	normal bool
+	keys   []rune // the command typed so far in normal mode, e.g. "2d"
+}
+
+// viLabel names the vi mode for the status line.
+func (e *lineEditor) viLabel() string {
+	if e.vi.normal {
+		return "-- NORMAL --"
+	}
+	return "-- INSERT --"
+}
+
+// viEscape switches to normal mode, or cancels the command being typed.
+// As in vi, the cursor steps back onto the last character inserted.
+func (e *lineEditor) viEscape() {
+	if !e.vi.normal && e.cur > e.lineStart() {
+		e.cur--
+	}
+	e.vi.normal = true
+	e.vi.keys = e.vi.keys[:0]
+}
+
+// viKey runs a key typed in normal mode, once it completes a command.
+func (e *lineEditor) viKey(r rune) {
+	e.vi.keys = append(e.vi.keys, r)
+	if e.viCommand(e.vi.keys) {
+		e.vi.keys = e.vi.keys[:0]
+	}
+	if e.vi.normal {
+		e.clampNormal()
+	}
+}
+
+// clampNormal keeps the cursor on a character, as normal mode has no
+// position after the end of a line.
+func (e *lineEditor) clampNormal() {
+	if e.cur == e.lineEnd() && e.cur > e.lineStart() {
+		e.cur--
+	}
+}
+
+// viCommand runs the command keys, an optional count, an operator (d, c,
+// or y) with a motion or doubled for whole lines, or a motion or command
+// on its own. It reports false if the command is incomplete; invalid
+// commands are dropped.
+func (e *lineEditor) viCommand(keys []rune) bool {
+	count := 0
+	for len(keys) > 0 && (keys[0] >= '1' && keys[0] <= '9' || count > 0 && keys[0] == '0') {
+		count = count*10 + int(keys[0]-'0')
+		keys = keys[1:]
+	}
+	if len(keys) == 0 {
+		return false
+	}
+	n := max(count, 1)
+
+	switch cmd := keys[0]; cmd {
+	case 'd', 'c', 'y':
+		if len(keys) < 2 || keys[1] == 'g' && len(keys) < 3 {
+			return false
+		}
+		if keys[1] == cmd {
+			e.viLines(cmd, n)
+			return true
+		}
+		motion := keys[1:]
+		if cmd == 'c' && motion[0] == 'w' {
+			motion = []rune{'e'} // cw changes to the end of the word
+		}
+		to, inclusive, ok := e.viMotion(motion, n)
+		if !ok {
+			return true
+		}
+		if slices.Contains([]rune("jkGg"), motion[0]) {
+			// Moving across lines takes whole lines.
+			from, to := min(e.cur, to), max(e.cur, to)
+			e.cur = from
+			e.viLines(cmd, countRune(e.buf[from:to], '\n')+1)
+			return true
+		}
+		from := e.cur
+		if to < from {
+			from, to = to, from
+		} else if inclusive {
+			to = min(to+1, len(e.buf))
+		}
+		e.viOperate(cmd, from, to)
+	case 'x':
+		e.viOperate('d', e.cur, min(e.cur+n, e.lineEnd()))
+	case 'X':
+		e.viOperate('d', max(e.cur-n, e.lineStart()), e.cur)
+	case 'D':
+		e.viOperate('d', e.cur, e.lineEnd())
+	case 'C':
+		e.viOperate('c', e.cur, e.lineEnd())
+	case 's':
+		e.viOperate('c', e.cur, min(e.cur+n, e.lineEnd()))
+	case 'S':
+		e.viLines('c', n)
+	case 'i':
+		e.vi.normal = false
+	case 'a':
+		e.cur = min(e.cur+1, e.lineEnd())
+		e.vi.normal = false
+	case 'I':
+		e.cur = e.firstNonBlank()
+		e.vi.normal = false
+	case 'A':
+		e.cur = e.lineEnd()
+		e.vi.normal = false
+	case 'o':
+		e.cur = e.lineEnd()
+		e.insert('\n')
+		e.vi.normal = false
+	case 'O':
+		e.cur = e.lineStart()
+		e.insert('\n')
+		e.cur--
+		e.vi.normal = false
+	case 'p', 'P':
+		e.viPut(cmd == 'p', n)
+	case 'u':
+		for range n {
+			e.undo()
+		}
+	default:
+		to, _, ok := e.viMotion(keys, n)
+		if ok {
+			e.cur = to
+		}
+		return ok || len(keys) > 1 || keys[0] != 'g'
+	}
+	return true
+}
+
+// viMotion returns where the motion keys move the cursor n times, and
+// whether an operator over it includes the character it ends on. It
+// reports false for keys that are no motion.
+func (e *lineEditor) viMotion(keys []rune, n int) (to int, inclusive, ok bool) {
+	saved := e.cur
+	defer func() { e.cur = saved }()
+	switch keys[0] {
+	case 'h':
+		e.cur = max(e.cur-n, e.lineStart())
+	case 'l', ' ':
+		e.cur = min(e.cur+n, e.lineEnd())
+	case 'j', 'k':
+		dir := 1
+		if keys[0] == 'k' {
+			dir = -1
+		}
+		for range n {
+			e.moveVertical(dir)
+		}
+	case 'w':
+		for range n {
+			e.cur = e.viWordStart()
+		}
+	case 'b':
+		for range n {
+			e.cur = e.viWordBack()
+		}
+	case 'e':
+		for range n {
+			e.cur = e.viWordEnd()
+		}
+		inclusive = true
+	case '0':
+		e.cur = e.lineStart()
+	case '^':
+		e.cur = e.firstNonBlank()
+	case '$':
+		e.cur = e.lineEnd()
+	case 'G':
+		e.cur = len(e.buf)
+		e.cur = e.lineStart()
+	case 'g':
+		if len(keys) < 2 || keys[1] != 'g' {
+			return 0, false, false
+		}
+		e.cur = 0
+	default:
+		return 0, false, false
+	}
+	return e.cur, inclusive, true
+}
+
+// viOperate deletes, changes, or yanks the runes between from and to.
+// An empty range leaves the text last deleted or yanked as it is.
+func (e *lineEditor) viOperate(op rune, from, to int) {
+	if op == 'y' {
+		if from < to {
+			e.save(e.buf[from:to])
+		}
+		e.cur = from
+		return
+	}
+	e.kill(from, to)
+	e.cur = from
+	if op == 'c' {
+		e.vi.normal = false
+	}
+}
+
+// viLines deletes, changes, or yanks n whole lines from the cursor's.
+// The saved text ends with a newline, so putting it adds whole lines.
+func (e *lineEditor) viLines(op rune, n int) {
+	from := e.lineStart()
+	to := from
+	for i := range n {
+		for to < len(e.buf) && e.buf[to] != '\n' {
+			to++
+		}
+		if i < n-1 && to < len(e.buf) {
+			to++
+		}
+	}
+	e.save(append(slices.Clone(e.buf[from:to]), '\n'))
+
+	switch op {
+	case 'c':
+		e.checkpoint(editOther)
+		e.replace(from, to, nil)
+		e.vi.normal = false
+	case 'd':
+		// Take the newline after the lines, or before the last line.
+		switch {
+		case to < len(e.buf):
+			to++
+		case from > 0:
+			from--
+		}
+		e.checkpoint(editOther)
+		e.replace(from, to, nil)
+		e.cur = min(from, len(e.buf))
+		e.cur = e.firstNonBlank()
+	}
+}
+
+// viPut puts the text last deleted or yanked n times after (p) or before
+// (P) the cursor, or below or above the cursor's line if it is whole
+// lines.
+func (e *lineEditor) viPut(after bool, n int) {
+	if len(e.kills) == 0 {
+		return
+	}
+	text := e.kills[len(e.kills)-1]
+	if len(text) == 0 {
+		return
+	}
+	var rs []rune
+	for range n {
+		rs = append(rs, text...)
+	}
+	at := e.cur
+	lines := text[len(text)-1] == '\n'
+	switch {
+	case lines && after && e.lineEnd() == len(e.buf):
+		// Below the last line, which has no newline to put it after.
+		at = len(e.buf)
+		rs = append([]rune{'\n'}, rs[:len(rs)-1]...)
+	case lines && after:
+		at = e.lineEnd() + 1
+	case lines:
+		at = e.lineStart()
+	case after:
+		at = min(e.cur+1, e.lineEnd())
+	}
+	e.checkpoint(editOther)
+	e.replace(at, at, rs)
+	if lines {
+		e.cur = at
+		if after && rs[0] == '\n' {
+			e.cur++
+		}
+	} else {
+		e.cur--
+	}
+}
+
+// countRune counts r in rs.
+func countRune(rs []rune, r rune) int {
+	n := 0
+	for _, c := range rs {
+		if c == r {
+			n++
+		}
+	}
+	return n
+}
+
+// firstNonBlank returns the first character of the cursor's line that is
+// not a space.
+func (e *lineEditor) firstNonBlank() int {
+	i := e.lineStart()
+	for i < len(e.buf) && (e.buf[i] == ' ' || e.buf[i] == '\t') {
+		i++
+	}
+	return i
+}
+
+// viClass classes runes for word motions: blanks, word characters, and
+// other punctuation.
+func viClass(r rune) int {
+	switch {
+	case unicode.IsSpace(r):
+		return 0
+	case isWordRune(r):
+		return 1
+	}
+	return 2
+}
+
+// viWordStart returns the start of the next word.
+func (e *lineEditor) viWordStart() int {
+	i := e.cur
+	if i < len(e.buf) {
+		c := viClass(e.buf[i])
+		for i < len(e.buf) && c != 0 && viClass(e.buf[i]) == c {
+			i++
+		}
+	}
+	for i < len(e.buf) && viClass(e.buf[i]) == 0 {
+		i++
+	}
+	return i
+}
+
+// viWordEnd returns the last character of the word at or after the one
+// after the cursor.
+func (e *lineEditor) viWordEnd() int {
+	i := e.cur + 1
+	for i < len(e.buf) && viClass(e.buf[i]) == 0 {
+		i++
+	}
+	if i >= len(e.buf) {
+		return max(len(e.buf)-1, 0)
+	}
+	c := viClass(e.buf[i])
+	for i+1 < len(e.buf) && viClass(e.buf[i+1]) == c {
+		i++
+	}
+	return i
+}
+
+// viWordBack returns the start of the word before the cursor.
+func (e *lineEditor) viWordBack() int {
+	i := e.cur
+	for i > 0 && viClass(e.buf[i-1]) == 0 {
+		i--
+	}
+	if i == 0 {
+		return 0
+	}
+	c := viClass(e.buf[i-1])
+	for i > 0 && viClass(e.buf[i-1]) == c {
+		i--
+	}
+	return i
+}
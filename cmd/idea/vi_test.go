@@ -0,0 +1,88 @@
+package main
+
+import "testing"
+
+func TestViKeys(t *testing.T) {
+	tests := []struct {
+		name    string
+		buf     string
+		cur     int
+		keys    string
+		want    string
+		wantCur int
+		insert  bool // whether the keys leave normal mode
+	}{
+		{name: "yank nothing and put", buf: "hello", keys: "yhp", want: "hello"},
+		{name: "x", buf: "hello", keys: "x", want: "ello"},
+		{name: "count x", buf: "hello", cur: 1, keys: "3x", want: "ho", wantCur: 1},
+		{name: "x then p", buf: "ab", keys: "xp", want: "ba", wantCur: 1},
+		{name: "X", buf: "hello", cur: 2, keys: "X", want: "hllo", wantCur: 1},
+		{name: "D", buf: "hello world", cur: 5, keys: "D", want: "hello", wantCur: 4},
+		{name: "dw", buf: "foo bar baz", keys: "dw", want: "bar baz"},
+		{name: "de", buf: "foo bar baz", keys: "de", want: " bar baz"},
+		{name: "db", buf: "foo bar", cur: 4, keys: "db", want: "bar"},
+		{name: "cw", buf: "foo bar", keys: "cw", want: " bar", insert: true},
+		{name: "yw and P", buf: "foo bar", cur: 4, keys: "ywP", want: "foo barbar", wantCur: 6},
+		{name: "dd", buf: "one\ntwo\nthree", cur: 4, keys: "dd", want: "one\nthree", wantCur: 4},
+		{name: "dd last line", buf: "one\ntwo", cur: 5, keys: "dd", want: "one"},
+		{name: "2dd", buf: "one\ntwo\nthree", keys: "2dd", want: "three"},
+		{name: "dj", buf: "a\nb\nc", keys: "dj", want: "c"},
+		{name: "dG", buf: "a\nb\nc", cur: 2, keys: "dG", want: "a"},
+		{name: "yy and p", buf: "one\ntwo", keys: "yyp", want: "one\none\ntwo", wantCur: 4},
+		{name: "yy and p below the last line", buf: "one\ntwo", cur: 4, keys: "yyp", want: "one\ntwo\ntwo", wantCur: 8},
+		{name: "yy and P", buf: "one\ntwo", cur: 4, keys: "yyP", want: "one\ntwo\ntwo", wantCur: 4},
+		{name: "cc", buf: "one\ntwo", cur: 5, keys: "cc", want: "one\n", wantCur: 4, insert: true},
+		{name: "undo", buf: "hello", keys: "xxu", want: "ello"},
+		{name: "o", buf: "one\ntwo", keys: "o", want: "one\n\ntwo", wantCur: 4, insert: true},
+		{name: "O", buf: "one\ntwo", cur: 4, keys: "O", want: "one\n\ntwo", wantCur: 4, insert: true},
+		{name: "A", buf: "one\ntwo", keys: "A", want: "one\ntwo", wantCur: 3, insert: true},
+		{name: "a at the end of a line", buf: "ab", cur: 1, keys: "a", want: "ab", wantCur: 2, insert: true},
+		{name: "incomplete operator", buf: "hello", keys: "d", want: "hello"},
+		{name: "invalid motion", buf: "hello", keys: "dz", want: "hello"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &lineEditor{buf: []rune(tt.buf), cur: tt.cur, vi: &viState{normal: true}}
+			for _, r := range tt.keys {
+				e.viKey(r)
+			}
+			if string(e.buf) != tt.want || e.cur != tt.wantCur || e.vi.normal == tt.insert {
+				t.Errorf("%q: buf = %q, cur = %d, normal = %v; want %q, %d, %v",
+					tt.keys, string(e.buf), e.cur, e.vi.normal, tt.want, tt.wantCur, !tt.insert)
+			}
+		})
+	}
+}
+
+func TestViMotions(t *testing.T) {
+	const buf = "  foo.bar baz\nqux"
+	tests := []struct {
+		cur  int
+		keys string
+		want int
+	}{
+		{cur: 2, keys: "w", want: 5},
+		{cur: 2, keys: "3w", want: 10},
+		{cur: 2, keys: "e", want: 4},
+		{cur: 10, keys: "b", want: 6},
+		{cur: 10, keys: "0", want: 0},
+		{cur: 10, keys: "^", want: 2},
+		{cur: 2, keys: "$", want: 12},
+		{cur: 2, keys: "l", want: 3},
+		{cur: 2, keys: "h", want: 1},
+		{cur: 0, keys: "10h", want: 0},
+		{cur: 2, keys: "j", want: 16},
+		{cur: 15, keys: "k", want: 1},
+		{cur: 2, keys: "G", want: 14},
+		{cur: 15, keys: "gg", want: 0},
+	}
+	for _, tt := range tests {
+		e := &lineEditor{buf: []rune(buf), cur: tt.cur, vi: &viState{normal: true}}
+		for _, r := range tt.keys {
+			e.viKey(r)
+		}
+		if e.cur != tt.want || len(e.vi.keys) != 0 {
+			t.Errorf("%q from %d: cur = %d, pending %q; want %d", tt.keys, tt.cur, e.cur, string(e.vi.keys), tt.want)
+		}
+	}
+}
@@ -0,0 +1,222 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// A dropped file whose post fails is posted again after a backoff,
+// doubling from minWatchBackoff, so that an outage of the server of a
+// quarter of an hour is ridden out. After maxWatchAttempts, the file is
+// moved to failed/.
+const (
+	maxWatchAttempts = 6
+	minWatchBackoff  = 30 * time.Second
+)
+
+// runWatch implements the "watch" subcommand:
+//
+//	idea watch [-interval 2s] [-d] [-archive dir] <dir>
+//
+// It polls the directory and posts every markdown or text file saved
+// there, then moves the file to the archive folder, dir/processed/ by
+// default. Polling rather than
+// file system notifications keeps the CLI dependency-free and works on
+// network and synced folders alike.
+func runWatch(args []string) {
+	fset := flag.NewFlagSet("watch", flag.ExitOnError)
+	interval := fset.Duration("interval", 2*time.Second, "how often to scan the directory")
+	draft := fset.Bool("d", false, "post as drafts, hidden from the live site")
+	archive := fset.String("archive", "processed", "move posted files to `dir`, relative to the watched directory unless absolute")
+	fset.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: idea watch [-interval 2s] [-d] [-archive dir] <dir>")
+		fset.PrintDefaults()
+	}
+	fset.Parse(args)
+	if fset.NArg() != 1 {
+		fset.Usage()
+		os.Exit(2)
+	}
+	dir := fset.Arg(0)
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		fmt.Fprintf(os.Stderr, "not a directory: %s\n", dir)
+		os.Exit(1)
+	}
+
+	l := log.New(os.Stderr, "idea watch: ", log.LstdFlags)
+	w := &watcher{
+		dir:      dir,
+		draft:    *draft,
+		archive:  *archive,
+		url:      serverURL(),
+		token:    authenticate(),
+		log:      l,
+		seen:     map[string]fileState{},
+		attempts: map[string]watchAttempts{},
+	}
+	l.Printf("watching %s", dir)
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, os.Interrupt)
+	t := time.NewTicker(*interval)
+	defer t.Stop()
+	for {
+		w.scan()
+		select {
+		case <-quit:
+			return
+		case <-t.C:
+		}
+	}
+}
+
+type watcher struct {
+	dir     string
+	draft   bool
+	archive string // where posted files go
+	url     string
+	token   string
+	log     *log.Logger
+
+	seen     map[string]fileState // size and mtime at the previous scan
+	attempts map[string]watchAttempts
+}
+
+// watchAttempts are the failed posts of a file.
+type watchAttempts struct {
+	n    int
+	next time.Time // of the next attempt
+}
+
+type fileState struct {
+	size    int64
+	modTime time.Time
+}
+
+// scan posts the files that did not change since the previous scan, so
+// that files still being written are left alone.
+func (w *watcher) scan() {
+	entries, err := os.ReadDir(w.dir)
+	if err != nil {
+		w.log.Printf("cannot read directory: %v", err)
+		return
+	}
+	current := map[string]fileState{}
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !isDroppedNote(name) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		st := fileState{size: info.Size(), modTime: info.ModTime()}
+		current[name] = st
+		if prev, ok := w.seen[name]; !ok || prev != st || st.size == 0 {
+			continue
+		}
+		if a, ok := w.attempts[name]; ok && time.Now().Before(a.next) {
+			continue
+		}
+		w.process(name)
+	}
+	w.seen = current
+}
+
+func isDroppedNote(name string) bool {
+	if strings.HasPrefix(name, ".") || strings.HasSuffix(name, "~") {
+		return false
+	}
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".md", ".markdown", ".txt":
+		return true
+	}
+	return false
+}
+
+func (w *watcher) process(name string) {
+	p := filepath.Join(w.dir, name)
+	data, err := os.ReadFile(p)
+	if err != nil {
+		w.log.Printf("cannot read %s: %v", name, err)
+		return
+	}
+	title, content := splitNote(string(data))
+	if content == "" {
+		title, content = "", title
+	}
+	if content == "" {
+		w.moveTo(w.archive, name)
+		return
+	}
+
+	idea := map[string]any{"title": title, "content": content, "draft": w.draft}
+	err = postIdea(w.url, w.token, idea)
+	if errors.Is(err, errUnauthorized) {
+		w.token = authenticate()
+		err = postIdea(w.url, w.token, idea)
+	}
+	if err != nil {
+		a := w.attempts[name]
+		a.n++
+		if a.n >= maxWatchAttempts {
+			w.log.Printf("posting %s failed (attempt %d), giving up: %v", name, a.n, err)
+			w.moveTo("failed", name)
+			delete(w.attempts, name)
+			return
+		}
+		backoff := minWatchBackoff << (a.n - 1)
+		a.next = time.Now().Add(backoff)
+		w.attempts[name] = a
+		w.log.Printf("posting %s failed (attempt %d), retrying in %s: %v", name, a.n, backoff, err)
+		return
+	}
+	delete(w.attempts, name)
+	w.log.Printf("posted %s", name)
+	w.moveTo(w.archive, name)
+}
+
+// moveTo moves the file into the given folder, a subfolder unless
+// absolute, adding a timestamp if a file with the same name was
+// processed before.
+func (w *watcher) moveTo(sub, name string) {
+	dst := sub
+	if !filepath.IsAbs(dst) {
+		dst = filepath.Join(w.dir, sub)
+	}
+	if err := os.MkdirAll(dst, 0o755); err != nil {
+		w.log.Printf("cannot create %s: %v", dst, err)
+		return
+	}
+	target := filepath.Join(dst, name)
+	if _, err := os.Stat(target); err == nil {
+		ext := filepath.Ext(name)
+		target = filepath.Join(dst, strings.TrimSuffix(name, ext)+time.Now().Format("-20060102-150405")+ext)
+	}
+	if err := os.Rename(filepath.Join(w.dir, name), target); err != nil {
+		w.log.Printf("cannot move %s: %v", name, err)
+	}
+}
+
+// splitNote uses a leading "# " heading as the title; otherwise the
+// server generates one.
+func splitNote(s string) (title, content string) {
+	s = strings.TrimSpace(strings.ReplaceAll(s, "\r\n", "\n"))
+	if rest, ok := strings.CutPrefix(s, "# "); ok {
+		title, content, _ = strings.Cut(rest, "\n")
+		return strings.TrimSpace(title), strings.TrimSpace(content)
+	}
+	return "", s
+}
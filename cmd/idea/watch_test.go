@@ -0,0 +1,59 @@
+package main
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatchBackoff(t *testing.T) {
+	posts := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		posts++
+		w.WriteHeader(http.StatusBadRequest)
+		io.WriteString(w, `{"ok":false,"error":"rejected"}`)
+	}))
+	defer srv.Close()
+	t.Setenv("IDEA_HISTORY", filepath.Join(t.TempDir(), "history.jsonl"))
+
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "note.md"), []byte("# Title\n\nText"), 0o644)
+	w := &watcher{
+		dir:      dir,
+		archive:  "processed",
+		url:      srv.URL,
+		log:      log.New(io.Discard, "", 0),
+		seen:     map[string]fileState{},
+		attempts: map[string]watchAttempts{},
+	}
+
+	// The first scan sees the file, the second posts it, and the next
+	// ones wait for the backoff.
+	for range 4 {
+		w.scan()
+	}
+	if a := w.attempts["note.md"]; posts != 1 || a.n != 1 || time.Until(a.next) < minWatchBackoff-time.Second {
+		t.Fatalf("after the first failure: %d posts, attempts %+v", posts, a)
+	}
+
+	for i := 2; i <= maxWatchAttempts; i++ {
+		a := w.attempts["note.md"]
+		a.next = time.Now()
+		w.attempts["note.md"] = a
+		w.scan()
+		if posts != i {
+			t.Fatalf("attempt %d: %d posts", i, posts)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "failed", "note.md")); err != nil {
+		t.Errorf("not moved to failed/ after %d attempts: %v", maxWatchAttempts, err)
+	}
+	if _, ok := w.attempts["note.md"]; ok {
+		t.Error("attempts of a failed file kept")
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"changkun.de/x/login"
+)
+
+// tokenExpiry returns the expiry of a JWT from its "exp" claim. The
+// signature is not checked; the login service does that.
+func tokenExpiry(token string) (time.Time, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}, errors.New("not a JWT")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("decode claims: %w", err)
+	}
+	var claims struct {
+		Exp int64 `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return time.Time{}, fmt.Errorf("decode claims: %w", err)
+	}
+	if claims.Exp == 0 {
+		return time.Time{}, errors.New("no expiry")
+	}
+	return time.Unix(claims.Exp, 0), nil
+}
+
+// runWhoami implements the "whoami" subcommand:
+//
+//	idea whoami
+//
+// It obtains a token the way posting does, has the login service verify
+// it, and prints who it belongs to, when it expires, and the server ideas
+// would go to, so authentication problems can be told apart without
+// composing an idea.
+func runWhoami() {
+	source := "keychain"
+	if os.Getenv("LOGIN_PASS") != "" {
+		source = "LOGIN_PASS"
+	}
+	fmt.Printf("Server:      %s\n", serverURL())
+	if activeProfile != "" {
+		fmt.Printf("Profile:     %s\n", activeProfile)
+	}
+
+	user, token, err := loginToken()
+	fmt.Printf("Login:       %s\n", login.AuthEndpoint)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fmt.Printf("Credentials: %s\n", source)
+
+	verified, err := login.Verify(token)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "token verification failed: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("User:        %s\n", verified)
+	if verified != user {
+		fmt.Fprintf(os.Stderr, "warning: the token was requested for %s but belongs to %s\n", user, verified)
+	}
+	if exp, err := tokenExpiry(token); err != nil {
+		fmt.Printf("Expires:     unknown (%v)\n", err)
+	} else {
+		fmt.Printf("Expires:     %s (in %s)\n", exp.Local().Format("2006-01-02 15:04"), time.Until(exp).Round(time.Minute))
+	}
+}
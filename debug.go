@@ -0,0 +1,28 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"net/http"
+	"net/http/pprof"
+)
+
+// pprofHandler serves the runtime profiles of net/http/pprof under
+// /ideas/debug/pprof/, for example to capture a CPU profile while
+// posting is slow:
+//
+//	curl -H "Authorization: Bearer $TOKEN" -o cpu.pprof \
+//		'https://api.changkun.de/ideas/debug/pprof/profile?seconds=30'
+//	go tool pprof -http=: cpu.pprof
+func pprofHandler() http.Handler {
+	m := http.NewServeMux()
+	m.HandleFunc("/debug/pprof/", pprof.Index)
+	m.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	m.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	m.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	m.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	// pprof.Index finds the profiles under /debug/pprof/.
+	return http.StripPrefix("/ideas", m)
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPprofHandler(t *testing.T) {
+	h := pprofHandler()
+	for _, tt := range []struct {
+		path, want string
+	}{
+		{"/ideas/debug/pprof/", "heap"},
+		{"/ideas/debug/pprof/goroutine?debug=1", "goroutine profile"},
+		{"/ideas/debug/pprof/cmdline", ""},
+	} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest("GET", tt.path, nil))
+		if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), tt.want) {
+			t.Errorf("GET %s = %d %.100q", tt.path, rec.Code, rec.Body.String())
+		}
+	}
+}
@@ -0,0 +1,220 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+)
+
+var errNoIdeas = errors.New("no ideas in the given period")
+
+// minDigestRelatedScore is the cosine similarity above which an earlier
+// idea is offered to the digest as a connection.
+const minDigestRelatedScore = 0.5
+
+// digestIdea is an idea included in a digest.
+type digestIdea struct {
+	id  string
+	doc *ideaDoc
+	url string
+}
+
+// weekIdeas returns the published ideas of the seven days up to and
+// including end, oldest first.
+func (s *service) weekIdeas(ctx context.Context, end time.Time) ([]digestIdea, error) {
+	files, err := s.github.listDir(ctx, s.site.ideasDir)
+	if err != nil {
+		return nil, fmt.Errorf("list ideas: %w", err)
+	}
+
+	last := end.Format("2006-01-02")
+	first := end.AddDate(0, 0, -6).Format("2006-01-02")
+	var ideas []digestIdea
+	for _, f := range files {
+		name := path.Base(f.Path)
+		if len(name) < len("2006-01-02") || !strings.HasSuffix(name, ".md") {
+			continue
+		}
+		if day := name[:len("2006-01-02")]; day < first || day > last {
+			continue
+		}
+		if strings.Contains(name, "-weekly-digest") {
+			continue
+		}
+
+		md, _, err := s.github.getFile(ctx, f.Path)
+		if err != nil {
+			return nil, fmt.Errorf("get %s: %w", f.Path, err)
+		}
+		doc, err := parseIdea(md)
+		if err != nil {
+			s.log.Printf("skipping unparsable idea %s: %v", f.Path, err)
+			continue
+		}
+		if doc.Draft {
+			continue
+		}
+		ideas = append(ideas, digestIdea{id: ideaID(f.Path), doc: doc, url: s.site.url(doc.Slug)})
+	}
+	return ideas, nil
+}
+
+// compileDigest writes and commits a post connecting the ideas of the
+// week ending at end.
+func (s *service) compileDigest(ctx context.Context, end time.Time) (*published, error) {
+	ideas, err := s.weekIdeas(ctx, end)
+	if err != nil {
+		return nil, err
+	}
+	if len(ideas) == 0 {
+		return nil, errNoIdeas
+	}
+	s.log.Printf("compiling weekly digest of %d ideas...", len(ideas))
+
+	inWeek := map[string]bool{}
+	for _, idea := range ideas {
+		inWeek[idea.id] = true
+	}
+	var prompt strings.Builder
+	for _, idea := range ideas {
+		fmt.Fprintf(&prompt, "## %s\nURL: %s\n\n%s\n\n", idea.doc.Title, idea.url, idea.doc.ContentEn)
+
+		// Surface connections to ideas captured in earlier weeks.
+		rel, _ := s.embeds.related(idea.id, 3)
+		for _, r := range rel {
+			d, ok := s.index.get(r.ID)
+			if !ok || d.Draft || inWeek[r.ID] || r.Score < minDigestRelatedScore {
+				continue
+			}
+			fmt.Fprintf(&prompt, "Related earlier idea: [%s](%s)\n", d.Title, s.site.url(d.Slug))
+		}
+		prompt.WriteString("\n")
+	}
+	narrativeEn, err := s.llm.writeDigest(ctx, prompt.String())
+	if err != nil {
+		return nil, fmt.Errorf("write digest: %w", err)
+	}
+	narrativeZh, err := s.llm.translateContent(ctx, narrativeEn, "zh")
+	if err != nil {
+		s.log.Printf("digest translation failed: %v", err)
+		narrativeZh = narrativeEn
+	}
+
+	var listEn, listZh strings.Builder
+	for _, idea := range ideas {
+		fmt.Fprintf(&listEn, "- [%s](%s)\n", idea.doc.Title, idea.url)
+		fmt.Fprintf(&listZh, "- [%s](%s)\n", idea.doc.TitleZh, idea.url)
+	}
+
+	start := end.AddDate(0, 0, -6)
+	year, week := end.ISOWeek()
+	slug := fmt.Sprintf("weekly-digest-%d-w%02d", year, week)
+	titleEn := fmt.Sprintf("Weekly ideas digest: %s – %s", start.Format("Jan 2"), end.Format("Jan 2, 2006"))
+	titleZh := fmt.Sprintf("每周想法摘要：%s 至 %s", start.Format("2006-01-02"), end.Format("2006-01-02"))
+	md := buildMarkdown(bilingualContent{
+		date:      end,
+		slug:      slug,
+		titleEn:   titleEn,
+		titleZh:   titleZh,
+		contentEn: narrativeEn + "\n\n" + strings.TrimSpace(listEn.String()),
+		contentZh: narrativeZh + "\n\n" + strings.TrimSpace(listZh.String()),
+	})
+
+	filePath := s.site.filePath(fmt.Sprintf("%s-%s.md", end.Format("2006-01-02"), slug), false)
+	commitMsg := sanitizeCommitMsg("ideas: " + titleEn)
+	fc, err := s.github.createFile(ctx, filePath, md, commitMsg)
+	if err != nil {
+		return nil, fmt.Errorf("commit digest: %w", err)
+	}
+	s.index.put(filePath, fc.SHA, md)
+	s.log.Printf("weekly digest published: %s", filePath)
+	return &published{path: filePath, url: s.site.url(slug), commitURL: fc.CommitURL}, nil
+}
+
+// handleDigest compiles the digest of the past week on demand.
+func (s *service) handleDigest(w http.ResponseWriter, r *http.Request) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+		defer cancel()
+		if _, err := s.compileDigest(ctx, time.Now()); err != nil {
+			s.log.Printf("weekly digest failed: %v", err)
+		}
+	}()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ideaResponse{
+		OK:      true,
+		Message: "digest accepted, publishing in background",
+	})
+}
+
+// digestScheduler compiles the weekly digest once a week at the
+// configured weekday and hour.
+type digestScheduler struct {
+	s         *service
+	weekday   time.Weekday
+	hour      int
+	statePath string
+}
+
+type digestState struct {
+	Last string `json:"last"` // date of the last digest, 2006-01-02
+}
+
+func (d *digestScheduler) run(ctx context.Context) {
+	t := time.NewTicker(15 * time.Minute)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+
+		now := time.Now()
+		if now.Weekday() != d.weekday || now.Hour() < d.hour {
+			continue
+		}
+		var st digestState
+		if err := readJSONFile(d.statePath, &st); err != nil {
+			d.s.log.Printf("cannot load digest state: %v", err)
+			continue
+		}
+		today := now.Format("2006-01-02")
+		if st.Last == today {
+			continue
+		}
+
+		runCtx, cancel := context.WithTimeout(ctx, 10*time.Minute)
+		_, err := d.s.compileDigest(runCtx, now)
+		cancel()
+		if err != nil && !errors.Is(err, errNoIdeas) {
+			d.s.log.Printf("weekly digest failed: %v", err)
+			continue
+		}
+		if err := writeJSONFile(d.statePath, digestState{Last: today}); err != nil {
+			d.s.log.Printf("cannot save digest state: %v", err)
+		}
+	}
+}
+
+// parseWeekday parses a weekday name such as "sunday" or "sun".
+func parseWeekday(s string) (time.Weekday, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		name := strings.ToLower(d.String())
+		if s == name || s == name[:3] {
+			return d, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid weekday: %q", s)
+}
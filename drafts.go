@@ -0,0 +1,244 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"cmp"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxDrafts is the number of drafts kept on the server.
+const maxDrafts = 500
+
+// draftStore keeps half-finished ideas on the server, not committed to
+// the blog, so they can be picked up and published from any device.
+// Unlike ideas posted with "draft": true, they go through no pipeline
+// until published.
+type draftStore struct {
+	path string
+	log  *log.Logger
+
+	mu     sync.Mutex
+	drafts []*savedDraft // least recently updated first
+}
+
+// savedDraft is an idea request saved to be published later.
+type savedDraft struct {
+	ID string `json:"id"`
+	ideaRequest
+	User    string    `json:"user,omitempty"` // who saved it
+	Created time.Time `json:"created"`
+	Updated time.Time `json:"updated"`
+}
+
+func newDraftStore(path string, l *log.Logger) *draftStore {
+	ds := &draftStore{path: path, log: l}
+	if err := readJSONFile(path, &ds.drafts); err != nil {
+		l.Printf("cannot load drafts: %v", err)
+	}
+	return ds
+}
+
+// save persists the store. Callers hold mu.
+func (ds *draftStore) save() {
+	if err := writeJSONFile(ds.path, ds.drafts); err != nil {
+		ds.log.Printf("cannot save drafts: %v", err)
+	}
+}
+
+// put saves req as the draft id, or as a new draft of user if id is
+// empty. It reports false if there is no draft id.
+func (ds *draftStore) put(id, user string, req ideaRequest) (savedDraft, bool) {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	now := time.Now()
+	d := &savedDraft{User: user, Created: now}
+	if id == "" {
+		var b [8]byte
+		rand.Read(b[:])
+		d.ID = hex.EncodeToString(b[:])
+	} else {
+		i := slices.IndexFunc(ds.drafts, func(d *savedDraft) bool { return d.ID == id })
+		if i < 0 {
+			return savedDraft{}, false
+		}
+		d = ds.drafts[i]
+		ds.drafts = slices.Delete(ds.drafts, i, i+1)
+	}
+	d.ideaRequest, d.Updated = req, now
+	ds.drafts = append(ds.drafts, d)
+	if len(ds.drafts) > maxDrafts {
+		ds.drafts = slices.Delete(ds.drafts, 0, len(ds.drafts)-maxDrafts)
+	}
+	ds.save()
+	return *d, true
+}
+
+// list returns the drafts, most recently updated first.
+func (ds *draftStore) list() []savedDraft {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	out := make([]savedDraft, 0, len(ds.drafts))
+	for _, d := range slices.Backward(ds.drafts) {
+		out = append(out, *d)
+	}
+	return out
+}
+
+// get returns the draft id.
+func (ds *draftStore) get(id string) (savedDraft, bool) {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	i := slices.IndexFunc(ds.drafts, func(d *savedDraft) bool { return d.ID == id })
+	if i < 0 {
+		return savedDraft{}, false
+	}
+	return *ds.drafts[i], true
+}
+
+// take removes the draft id and returns it.
+func (ds *draftStore) take(id string) (savedDraft, bool) {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	i := slices.IndexFunc(ds.drafts, func(d *savedDraft) bool { return d.ID == id })
+	if i < 0 {
+		return savedDraft{}, false
+	}
+	d := *ds.drafts[i]
+	ds.drafts = slices.Delete(ds.drafts, i, i+1)
+	ds.save()
+	return d, true
+}
+
+// restore puts back a draft taken to be published.
+func (ds *draftStore) restore(d savedDraft) {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	ds.drafts = append(ds.drafts, &d)
+	ds.save()
+}
+
+// handleSaveDraft stores an idea request on the server without
+// publishing it. With "id", it replaces that draft.
+func (s *service) handleSaveDraft(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID string `json:"id"`
+		ideaRequest
+	}
+	if !s.readIdea(w, r, &req) {
+		return
+	}
+	if strings.TrimSpace(req.Title) == "" && strings.TrimSpace(req.Content) == "" {
+		s.jsonError(w, "title or content is required", http.StatusBadRequest)
+		return
+	}
+	if msg := s.checkIdea(&req.ideaRequest); msg != "" {
+		s.jsonError(w, msg, http.StatusBadRequest)
+		return
+	}
+	if d, ok := s.drafts.get(req.ID); req.ID != "" && (!ok || !s.actsFor(r.Context(), d.User)) {
+		s.jsonError(w, "draft not found", http.StatusNotFound)
+		return
+	}
+	d, ok := s.drafts.put(req.ID, requestUser(r), req.ideaRequest)
+	if !ok {
+		s.jsonError(w, "draft not found", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if req.ID == "" {
+		w.WriteHeader(http.StatusCreated)
+	}
+	json.NewEncoder(w).Encode(struct {
+		OK    bool       `json:"ok"`
+		Draft savedDraft `json:"draft"`
+	}{OK: true, Draft: d})
+}
+
+// handleListDrafts lists the drafts of the caller stored on the server,
+// most recently updated first.
+func (s *service) handleListDrafts(w http.ResponseWriter, r *http.Request) {
+	drafts := []savedDraft{}
+	for _, d := range s.drafts.list() {
+		if s.actsFor(r.Context(), d.User) {
+			drafts = append(drafts, d)
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct {
+		OK     bool         `json:"ok"`
+		Drafts []savedDraft `json:"drafts"`
+	}{OK: true, Drafts: drafts})
+}
+
+// requestedDraft returns the draft of the path of r, reporting false if
+// there is none or it is another user's.
+func (s *service) requestedDraft(r *http.Request) (savedDraft, bool) {
+	d, ok := s.drafts.get(r.PathValue("id"))
+	return d, ok && s.actsFor(r.Context(), d.User)
+}
+
+// handleDeleteDraft discards a draft stored on the server.
+func (s *service) handleDeleteDraft(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.requestedDraft(r); !ok {
+		s.jsonError(w, "draft not found", http.StatusNotFound)
+		return
+	}
+	if _, ok := s.drafts.take(r.PathValue("id")); !ok {
+		s.jsonError(w, "draft not found", http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlePublishDraft publishes a draft stored on the server as if it
+// were posted to /ideas/post, and removes it.
+func (s *service) handlePublishDraft(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	d, ok := s.requestedDraft(r)
+	if !ok {
+		s.jsonError(w, "draft not found", http.StatusNotFound)
+		return
+	}
+	req := d.ideaRequest
+	req.requestID = requestIDFromContext(r.Context())
+	req.user = requestUser(r)
+	msg := s.checkIdea(&req)
+	if strings.TrimSpace(req.Content) == "" {
+		msg = "the draft has no content"
+	}
+	if msg != "" {
+		s.jsonError(w, msg, http.StatusBadRequest)
+		return
+	}
+	if _, ok := s.drafts.take(id); !ok {
+		// Published by another request meanwhile.
+		s.jsonError(w, "draft not found", http.StatusNotFound)
+		return
+	}
+	job, ok := s.enqueueIdea(req)
+	if !ok {
+		s.drafts.restore(d)
+		s.busy(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/ideas/admin/jobs/"+job)
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(ideaResponse{
+		OK:      true,
+		Message: "draft " + cmp.Or(d.Title, id) + " accepted, publishing in background",
+		Job:     job,
+	})
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDrafts(t *testing.T) {
+	l := log.New(io.Discard, "", 0)
+	dir := t.TempDir()
+	s := &service{
+		log:       l,
+		jobs:      newJobStore(filepath.Join(dir, "jobs.json"), l),
+		lifecycle: newLifecycleStore(filepath.Join(dir, "lifecycle.json"), l),
+		drafts:    newDraftStore(filepath.Join(dir, "drafts.json"), l),
+		site:      newSiteConfig("", "", "", ""),
+		// Held for review before publishing, the idea needs no LLM or
+		// repository.
+		pipelines: map[string][]string{"default": {"detect-lang", "publish"}},
+	}
+	save := func(body string) (int, savedDraft) {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		s.handleSaveDraft(rec, httptest.NewRequest("POST", "/ideas/draft", strings.NewReader(body)))
+		var resp struct {
+			Draft savedDraft `json:"draft"`
+		}
+		json.NewDecoder(rec.Body).Decode(&resp)
+		return rec.Code, resp.Draft
+	}
+	list := func() []savedDraft {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		s.handleListDrafts(rec, httptest.NewRequest("GET", "/ideas/drafts", nil))
+		var resp struct {
+			Drafts []savedDraft `json:"drafts"`
+		}
+		json.NewDecoder(rec.Body).Decode(&resp)
+		return resp.Drafts
+	}
+	withID := func(method, target, id string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		r := httptest.NewRequest(method, target, nil)
+		r.SetPathValue("id", id)
+		switch method {
+		case "DELETE":
+			s.handleDeleteDraft(rec, r)
+		default:
+			s.handlePublishDraft(rec, r)
+		}
+		return rec
+	}
+
+	for _, tt := range []struct {
+		name, body string
+		want       int
+	}{
+		{"empty", `{}`, http.StatusBadRequest},
+		{"bad lang", `{"content":"x","lang":"fr"}`, http.StatusBadRequest},
+		{"unknown draft", `{"id":"nope","content":"x"}`, http.StatusNotFound},
+	} {
+		if code, _ := save(tt.body); code != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.name, code, tt.want)
+		}
+	}
+
+	code, a := save(`{"title":"Half an idea","review":true}`)
+	if code != http.StatusCreated || a.ID == "" || a.Title != "Half an idea" {
+		t.Fatalf("save = %d %+v", code, a)
+	}
+	_, b := save(`{"content":"Another one.","tags":["go"],"lang":"auto"}`)
+	if got := list(); len(got) != 2 || got[0].ID != b.ID || got[1].ID != a.ID || got[0].Lang != "" {
+		t.Fatalf("list = %+v", got)
+	}
+
+	// A draft without content cannot be published yet.
+	if rec := withID("POST", "/ideas/drafts/"+a.ID+"/publish", a.ID); rec.Code != http.StatusBadRequest {
+		t.Errorf("publishing without content: status = %d, want 400", rec.Code)
+	}
+	time.Sleep(time.Millisecond)
+	code, a2 := save(`{"id":"` + a.ID + `","title":"Half an idea","content":"Now finished.","review":true}`)
+	if code != http.StatusOK || a2.ID != a.ID || !a2.Created.Equal(a.Created) || !a2.Updated.After(a.Updated) {
+		t.Fatalf("update = %d %+v", code, a2)
+	}
+	if got := list(); got[0].ID != a.ID {
+		t.Errorf("updated draft not listed first: %+v", got)
+	}
+
+	rec := withID("POST", "/ideas/drafts/"+a.ID+"/publish", a.ID)
+	var resp ideaResponse
+	json.NewDecoder(rec.Body).Decode(&resp)
+	if rec.Code != http.StatusAccepted || resp.Job == "" || rec.Header().Get("Location") != "/ideas/admin/jobs/"+resp.Job {
+		t.Fatalf("publish = %d %+v", rec.Code, resp)
+	}
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		if j, _ := s.jobs.get(resp.Job); j.Status == jobReview {
+			if j.Request.Content != "Now finished." {
+				t.Errorf("published request = %+v", j.Request)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the draft was not published")
+		}
+	}
+	if rec := withID("POST", "/ideas/drafts/"+a.ID+"/publish", a.ID); rec.Code != http.StatusNotFound {
+		t.Errorf("publishing twice: status = %d, want 404", rec.Code)
+	}
+
+	if rec := withID("DELETE", "/ideas/drafts/"+b.ID, b.ID); rec.Code != http.StatusNoContent {
+		t.Errorf("delete: status = %d", rec.Code)
+	}
+	if got := newDraftStore(s.drafts.path, l).list(); len(got) != 0 {
+		t.Errorf("drafts left = %+v", got)
+	}
+}
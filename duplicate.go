@@ -0,0 +1,74 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// defaultDuplicateScore is the cosine similarity to an earlier idea at
+// which a new one is taken for a repeat of it.
+const defaultDuplicateScore = 0.9
+
+// duplicateMatch is the earlier idea a new one seems to repeat.
+type duplicateMatch struct {
+	ID    string  `json:"id"`
+	Title string  `json:"title"`
+	URL   string  `json:"url"`
+	Score float64 `json:"score"`
+}
+
+// nearest returns the idea whose embedding is most similar to vec.
+func (es *embeddingStore) nearest(vec []float64) (relatedIdea, bool) {
+	es.mu.RLock()
+	defer es.mu.RUnlock()
+	var best relatedIdea
+	found := false
+	for id, e := range es.vectors {
+		score := cosine(vec, e.Vector)
+		if !found || score > best.Score || score == best.Score && id < best.ID {
+			best, found = relatedIdea{ID: id, Score: score}, true
+		}
+	}
+	return best, found
+}
+
+// findDuplicate compares the embedding of req with those of the indexed
+// ideas, returning the earlier idea it seems to repeat, or nil. Ideas
+// going to another user's blog are not compared, as only the server's
+// own ideas are indexed.
+func (s *service) findDuplicate(ctx context.Context, req ideaRequest) (*duplicateMatch, error) {
+	if s.duplicateScore <= 0 || s.embeds == nil || s.llm == nil {
+		return nil, nil
+	}
+	if _, own := s.userSite(req.user); !own {
+		return nil, nil
+	}
+	s.embeds.mu.RLock()
+	empty := len(s.embeds.vectors) == 0
+	s.embeds.mu.RUnlock()
+	if empty {
+		return nil, nil
+	}
+
+	// Posting waits for this, so do not wait long on the LLM.
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	vecs, err := s.llm.embed(ctx, []string{embeddingText(&indexedIdea{Title: req.Title, ContentEn: req.Content})})
+	if err != nil {
+		return nil, fmt.Errorf("embed idea: %w", err)
+	}
+	best, ok := s.embeds.nearest(vecs[0])
+	if !ok || best.Score < s.duplicateScore {
+		return nil, nil
+	}
+	d, ok := s.index.get(best.ID)
+	if !ok {
+		return nil, nil
+	}
+	return &duplicateMatch{ID: d.ID, Title: d.Title, URL: s.site.url(d.Slug), Score: best.Score}, nil
+}
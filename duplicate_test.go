@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFindDuplicate(t *testing.T) {
+	// Ideas about rewards are embedded alike, all others apart from them.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct{ Input []string }
+		json.NewDecoder(r.Body).Decode(&req)
+		vec := "[0, 1]"
+		if strings.Contains(strings.ToLower(req.Input[0]), "reward") {
+			vec = "[1, 0.1]"
+		}
+		io.WriteString(w, `{"data": [{"index": 0, "embedding": `+vec+`}]}`)
+	}))
+	defer srv.Close()
+	l := log.New(io.Discard, "", 0)
+	dir := t.TempDir()
+	s := &service{
+		log:            l,
+		llm:            &llmClient{baseURL: srv.URL, log: l},
+		index:          newArchiveIndex(filepath.Join(dir, "index.json"), l),
+		embeds:         newEmbeddingStore(filepath.Join(dir, "embeddings.json"), l),
+		site:           newSiteConfig("", "", "", "https://changkun.de/ideas/"),
+		duplicateScore: defaultDuplicateScore,
+	}
+	s.index.put("content/ideas/2025-01-01-reward.md", "sha", testIdeaMarkdown)
+	s.embeds.vectors["2025-01-01-reward"] = storedEmbedding{SHA: "sha", Vector: []float64{1, 0}}
+
+	dup, err := s.findDuplicate(context.Background(), ideaRequest{Content: "Models game their reward."})
+	if err != nil || dup == nil || dup.ID != "2025-01-01-reward" || dup.Title != "Reward hacking" || dup.Score < 0.99 {
+		t.Errorf("findDuplicate of a repeat = %+v, %v", dup, err)
+	}
+	if dup, err := s.findDuplicate(context.Background(), ideaRequest{Content: "Goroutines leak."}); dup != nil || err != nil {
+		t.Errorf("findDuplicate of a new idea = %+v, %v", dup, err)
+	}
+	s.users = map[string]userSite{"alice": {}}
+	if dup, _ := s.findDuplicate(context.Background(), ideaRequest{Content: "Models game their reward.", user: "alice"}); dup != nil {
+		t.Errorf("an idea of another user is compared with the server's: %+v", dup)
+	}
+
+	rec := httptest.NewRecorder()
+	s.handlePost(rec, httptest.NewRequest("POST", "/ideas/post", strings.NewReader(`{"content":"Models game their reward."}`)))
+	var resp errorResponse
+	json.NewDecoder(rec.Body).Decode(&resp)
+	if rec.Code != http.StatusConflict || resp.Code != codeDuplicate || resp.Duplicate == nil || resp.Duplicate.URL != "https://changkun.de/ideas/reward/" {
+		t.Errorf("post of a repeat = %d %+v", rec.Code, resp)
+	}
+
+	// Failing to embed the idea does not refuse it.
+	s.llm.baseURL = "http://127.0.0.1:0"
+	if dup, err := s.findDuplicate(context.Background(), ideaRequest{Content: "Models game their reward."}); dup != nil || err == nil {
+		t.Errorf("findDuplicate with the LLM down = %+v, %v", dup, err)
+	}
+}
@@ -0,0 +1,334 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+func (c *llmClient) embed(ctx context.Context, inputs []string) ([][]float64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	body, err := json.Marshal(map[string]any{
+		"model": c.embeddingModel,
+		"input": inputs,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+
+	url := strings.TrimRight(c.baseURL, "/") + "/embeddings"
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.apiKey.value())
+	setRequestID(req)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("embeddings API returned %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	var result struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float64 `json:"embedding"`
+		} `json:"data"`
+		Usage tokenUsage `json:"usage"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, fmt.Errorf("unmarshal response: %w", err)
+	}
+	c.usage.record(ctx, c.embeddingModel, result.Usage)
+	if len(result.Data) != len(inputs) {
+		return nil, fmt.Errorf("got %d embeddings for %d inputs", len(result.Data), len(inputs))
+	}
+	vecs := make([][]float64, len(inputs))
+	for _, d := range result.Data {
+		if d.Index < 0 || d.Index >= len(vecs) {
+			return nil, fmt.Errorf("embedding index out of range: %d", d.Index)
+		}
+		vecs[d.Index] = d.Embedding
+	}
+	return vecs, nil
+}
+
+// embeddingStore keeps one embedding per idea, keyed by idea ID, and
+// persists them to the data directory.
+type embeddingStore struct {
+	path string
+	log  *log.Logger
+
+	mu      sync.RWMutex
+	vectors map[string]storedEmbedding
+
+	wake chan struct{} // asks run to refresh now
+}
+
+type storedEmbedding struct {
+	SHA    string    `json:"sha"` // blob SHA the vector was computed from
+	Vector []float64 `json:"vector"`
+}
+
+func newEmbeddingStore(path string, l *log.Logger) *embeddingStore {
+	es := &embeddingStore{path: path, log: l, vectors: map[string]storedEmbedding{}, wake: make(chan struct{}, 1)}
+	if err := readJSONFile(path, &es.vectors); err != nil {
+		l.Printf("cannot load embeddings: %v", err)
+	}
+	return es
+}
+
+// embeddingBatch is the number of ideas embedded per API request.
+const embeddingBatch = 32
+
+// refresh computes embeddings for indexed ideas that are new or changed
+// and drops those of deleted ideas.
+func (es *embeddingStore) refresh(ctx context.Context, llm *llmClient, idx *archiveIndex) error {
+	ideas := idx.all()
+	present := map[string]bool{}
+	var stale []*indexedIdea
+	es.mu.RLock()
+	for _, d := range ideas {
+		present[d.ID] = true
+		if e, ok := es.vectors[d.ID]; !ok || e.SHA != d.SHA {
+			stale = append(stale, d)
+		}
+	}
+	var deleted []string
+	for id := range es.vectors {
+		if !present[id] {
+			deleted = append(deleted, id)
+		}
+	}
+	es.mu.RUnlock()
+	if len(stale) == 0 && len(deleted) == 0 {
+		return nil
+	}
+
+	for batch := range slices.Chunk(stale, embeddingBatch) {
+		inputs := make([]string, len(batch))
+		for i, d := range batch {
+			inputs[i] = embeddingText(d)
+		}
+		vecs, err := llm.embed(ctx, inputs)
+		if err != nil {
+			return err
+		}
+		es.mu.Lock()
+		for i, d := range batch {
+			es.vectors[d.ID] = storedEmbedding{SHA: d.SHA, Vector: vecs[i]}
+		}
+		es.mu.Unlock()
+	}
+
+	es.mu.Lock()
+	for _, id := range deleted {
+		delete(es.vectors, id)
+	}
+	err := writeJSONFile(es.path, es.vectors)
+	es.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("save embeddings: %w", err)
+	}
+	es.log.Printf("embeddings refreshed: %d computed, %d removed", len(stale), len(deleted))
+	return nil
+}
+
+// embedIdea computes the embedding of one indexed idea, unless it has one
+// for its current version.
+func (es *embeddingStore) embedIdea(ctx context.Context, llm *llmClient, d *indexedIdea) error {
+	es.mu.RLock()
+	e, ok := es.vectors[d.ID]
+	es.mu.RUnlock()
+	if ok && e.SHA == d.SHA {
+		return nil
+	}
+	vecs, err := llm.embed(ctx, []string{embeddingText(d)})
+	if err != nil {
+		return err
+	}
+	es.mu.Lock()
+	defer es.mu.Unlock()
+	es.vectors[d.ID] = storedEmbedding{SHA: d.SHA, Vector: vecs[0]}
+	if err := writeJSONFile(es.path, es.vectors); err != nil {
+		return fmt.Errorf("save embeddings: %w", err)
+	}
+	return nil
+}
+
+// embeddingText is the text an idea is embedded from. English is used
+// for all ideas so vectors are comparable across languages.
+func embeddingText(d *indexedIdea) string {
+	text := d.Title + "\n\n" + d.ContentEn
+	if len(text) > 8000 {
+		text = text[:8000]
+	}
+	return text
+}
+
+// relatedIdea is an idea similar to another one.
+type relatedIdea struct {
+	ID    string
+	Score float64
+}
+
+// related returns up to k ideas most similar to the given one.
+func (es *embeddingStore) related(id string, k int) ([]relatedIdea, bool) {
+	es.mu.RLock()
+	defer es.mu.RUnlock()
+
+	src, ok := es.vectors[id]
+	if !ok {
+		return nil, false
+	}
+	var rel []relatedIdea
+	for other, e := range es.vectors {
+		if other == id {
+			continue
+		}
+		rel = append(rel, relatedIdea{ID: other, Score: cosine(src.Vector, e.Vector)})
+	}
+	slices.SortFunc(rel, func(a, b relatedIdea) int {
+		if a.Score > b.Score {
+			return -1
+		}
+		if a.Score < b.Score {
+			return 1
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+	if len(rel) > k {
+		rel = rel[:k]
+	}
+	return rel, true
+}
+
+func cosine(a, b []float64) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += a[i] * b[i]
+		na += a[i] * a[i]
+		nb += b[i] * b[i]
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
+
+// run refreshes embeddings periodically so ideas picked up by the index
+// sync get embedded too, and when kicked. It is the only one refreshing,
+// so refreshes never overlap.
+func (es *embeddingStore) run(ctx context.Context, llm *llmClient, idx *archiveIndex, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		case <-es.wake:
+		}
+		if err := es.refresh(ctx, llm, idx); err != nil {
+			es.log.Printf("embedding refresh failed: %v", err)
+		}
+	}
+}
+
+// kick asks run to refresh the embeddings soon, without waiting for it.
+// Kicks while a refresh is pending are merged into it.
+func (es *embeddingStore) kick() {
+	select {
+	case es.wake <- struct{}{}:
+	default:
+	}
+}
+
+type relatedResponse struct {
+	OK    bool          `json:"ok"`
+	Ideas []relatedItem `json:"ideas"`
+}
+
+type relatedItem struct {
+	ID      string  `json:"id"`
+	Title   string  `json:"title"`
+	TitleZh string  `json:"title_zh"`
+	URL     string  `json:"url,omitempty"` // not of drafts
+	Draft   bool    `json:"draft,omitempty"`
+	Score   float64 `json:"score"`
+}
+
+// handleRelated serves GET /ideas/{id}/related?k=5, and the same at
+// /ideas/{id}/similar. Drafts are only related for the owner of the
+// server's blog, and have no URL as they are not published.
+func (s *service) handleRelated(w http.ResponseWriter, r *http.Request) {
+	k := 5
+	if v := r.URL.Query().Get("k"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 50 {
+			s.jsonError(w, "k must be between 1 and 50", http.StatusBadRequest)
+			return
+		}
+		k = n
+	}
+
+	// All ideas are ranked, so that k remain after leaving out drafts.
+	rel, ok := s.embeds.related(r.PathValue("id"), math.MaxInt)
+	if !ok {
+		s.jsonError(w, "idea not found", http.StatusNotFound)
+		return
+	}
+	owner := s.isOwner(r.Context())
+	resp := relatedResponse{OK: true, Ideas: []relatedItem{}}
+	for _, rel := range rel {
+		if len(resp.Ideas) == k {
+			break
+		}
+		d, ok := s.index.get(rel.ID)
+		if !ok || d.Draft && !owner {
+			continue
+		}
+		item := relatedItem{
+			ID:      d.ID,
+			Title:   d.Title,
+			TitleZh: d.TitleZh,
+			Draft:   d.Draft,
+			Score:   rel.Score,
+		}
+		if !d.Draft {
+			item.URL = s.site.url(d.Slug)
+		}
+		resp.Ideas = append(resp.Ideas, item)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCosine(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		a, b []float64
+		want float64
+	}{
+		{name: "same", a: []float64{1, 2}, b: []float64{2, 4}, want: 1},
+		{name: "orthogonal", a: []float64{1, 0}, b: []float64{0, 3}, want: 0},
+		{name: "opposite", a: []float64{1, 1}, b: []float64{-1, -1}, want: -1},
+		{name: "diagonal", a: []float64{1, 0}, b: []float64{1, 1}, want: 1 / math.Sqrt2},
+		{name: "zero", a: []float64{0, 0}, b: []float64{1, 1}, want: 0},
+		{name: "lengths differ", a: []float64{1}, b: []float64{1, 1}, want: 0},
+		{name: "empty", want: 0},
+	} {
+		if got := cosine(tt.a, tt.b); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%s: cosine(%v, %v) = %v, want %v", tt.name, tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestEmbeddingRelated(t *testing.T) {
+	es := newEmbeddingStore(filepath.Join(t.TempDir(), "embeddings.json"), log.New(io.Discard, "", 0))
+	es.vectors = map[string]storedEmbedding{
+		"a": {Vector: []float64{1, 0}},
+		"b": {Vector: []float64{1, 1}},
+		"c": {Vector: []float64{0, 1}},
+		"d": {Vector: []float64{1, 0.1}},
+		"e": {Vector: []float64{1, 1}},
+	}
+	rel, ok := es.related("a", 2)
+	if !ok || len(rel) != 2 || rel[0].ID != "d" || rel[1].ID != "b" {
+		t.Errorf("related(a, 2) = %+v, %v", rel, ok)
+	}
+	// Ties are ordered by ID, and k may exceed the ideas.
+	rel, _ = es.related("c", 10)
+	if len(rel) != 4 || rel[0].ID != "b" || rel[1].ID != "e" || rel[3].ID != "a" {
+		t.Errorf("related(c, 10) = %+v", rel)
+	}
+	if _, ok := es.related("missing", 2); ok {
+		t.Error("related of an idea without an embedding reports ok")
+	}
+}
+
+func TestHandleRelated(t *testing.T) {
+	l := log.New(io.Discard, "", 0)
+	dir := t.TempDir()
+	s := &service{
+		log:    l,
+		index:  newArchiveIndex(filepath.Join(dir, "index.json"), l),
+		embeds: newEmbeddingStore(filepath.Join(dir, "embeddings.json"), l),
+		site:   newSiteConfig("", "", "", ""),
+		users:  map[string]userSite{"alice": {}},
+	}
+	draft := strings.Replace(testIdeaMarkdown, "---\n\n", "draft: true\n---\n\n", 1)
+	s.index.put("content/ideas/2025-01-01-a.md", "sha", testIdeaMarkdown)
+	s.index.put("content/ideas/2025-01-02-b.md", "sha", draft)
+	s.index.put("content/ideas/2025-01-03-c.md", "sha", testIdeaMarkdown)
+	s.embeds.vectors = map[string]storedEmbedding{
+		"2025-01-01-a": {Vector: []float64{1, 0}},
+		"2025-01-02-b": {Vector: []float64{1, 0.1}},
+		"2025-01-03-c": {Vector: []float64{1, 1}},
+	}
+
+	tests := []struct {
+		name  string
+		user  string
+		want  []string
+		draft bool // whether the first is a draft without a URL
+	}{
+		{name: "owner", user: "changkun", want: []string{"2025-01-02-b"}, draft: true},
+		{name: "other user", user: "alice", want: []string{"2025-01-03-c"}},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/ideas/2025-01-01-a/similar?k=1", nil)
+		r.SetPathValue("id", "2025-01-01-a")
+		r = r.WithContext(withPrincipal(r.Context(), principal{User: tt.user}))
+		rec := httptest.NewRecorder()
+		s.handleRelated(rec, r)
+
+		var resp relatedResponse
+		json.NewDecoder(rec.Body).Decode(&resp)
+		var ids []string
+		for _, idea := range resp.Ideas {
+			ids = append(ids, idea.ID)
+		}
+		if !slices.Equal(ids, tt.want) {
+			t.Errorf("%s: related = %v, want %v", tt.name, ids, tt.want)
+			continue
+		}
+		if got := resp.Ideas[0]; got.Draft != tt.draft || (got.URL == "") != tt.draft {
+			t.Errorf("%s: related = %+v, want draft %v", tt.name, got, tt.draft)
+		}
+	}
+}
+
+func TestEmbed(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		status  int
+		body    string
+		want    [][]float64
+		wantErr string
+	}{
+		{
+			name: "out of order",
+			body: `{"data":[{"index":1,"embedding":[0,1]},{"index":0,"embedding":[1,0]}],"usage":{"prompt_tokens":4}}`,
+			want: [][]float64{{1, 0}, {0, 1}},
+		},
+		{name: "too few", body: `{"data":[{"index":0,"embedding":[1,0]}]}`, wantErr: "got 1 embeddings for 2 inputs"},
+		{name: "index out of range", body: `{"data":[{"index":0,"embedding":[1]},{"index":2,"embedding":[1]}]}`, wantErr: "out of range"},
+		{name: "invalid", body: `{"data":`, wantErr: "unmarshal response"},
+		{name: "error status", status: http.StatusTooManyRequests, body: `rate limited`, wantErr: "returned 429: rate limited"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/embeddings" {
+					http.NotFound(w, r)
+					return
+				}
+				if tt.status != 0 {
+					w.WriteHeader(tt.status)
+				}
+				io.WriteString(w, tt.body)
+			}))
+			defer srv.Close()
+			c := &llmClient{baseURL: srv.URL, log: log.New(io.Discard, "", 0)}
+
+			got, err := c.embed(context.Background(), []string{"a", "b"})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("embed = %v, %v; want %v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestEmbedIdea(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		io.WriteString(w, `{"data":[{"index":0,"embedding":[1,0]}]}`)
+	}))
+	defer srv.Close()
+	l := log.New(io.Discard, "", 0)
+	c := &llmClient{baseURL: srv.URL, log: l}
+	path := filepath.Join(t.TempDir(), "embeddings.json")
+	es := newEmbeddingStore(path, l)
+	es.vectors["old"] = storedEmbedding{SHA: "sha", Vector: []float64{0, 1}}
+
+	d := &indexedIdea{ID: "new", SHA: "sha1", Title: "Reward hacking"}
+	for range 2 {
+		if err := es.embedIdea(context.Background(), c, d); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("embedded an unchanged idea again: %d calls", calls)
+	}
+	reloaded := newEmbeddingStore(path, l)
+	if len(reloaded.vectors) != 2 || reloaded.vectors["new"].SHA != "sha1" {
+		t.Errorf("persisted embeddings = %+v", reloaded.vectors)
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"net/http"
+	"path"
+	"time"
+)
+
+// exportTimeout bounds writing an export, which fetches every idea file
+// from the repository and outlasts the server's write timeout.
+const exportTimeout = 15 * time.Minute
+
+// handleExport streams a tar.gz of the indexed idea files, drafts
+// included, under ideas/ at their repository paths, and their metadata
+// in index.json, for backups and migrations without access to the
+// repository.
+func (s *service) handleExport(w http.ResponseWriter, r *http.Request) {
+	ideas := s.index.all()
+	http.NewResponseController(w).SetWriteDeadline(time.Now().Add(exportTimeout))
+	name := "ideas-export-" + time.Now().UTC().Format("20060102T150405Z") + ".tar.gz"
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+name+`"`)
+
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+	add := func(name string, data []byte, mod time.Time) error {
+		if err := tw.WriteHeader(&tar.Header{
+			Name:    name,
+			Mode:    0o644,
+			Size:    int64(len(data)),
+			ModTime: mod,
+		}); err != nil {
+			return err
+		}
+		_, err := tw.Write(data)
+		return err
+	}
+	// Once streaming, the status is sent; aborting the response tells
+	// the client that the archive is incomplete.
+	abort := func(what string, err error) {
+		s.log.Printf("export: %s: %v", what, err)
+		panic(http.ErrAbortHandler)
+	}
+
+	summaries := make([]ideaSummary, 0, len(ideas))
+	for _, d := range ideas {
+		md, _, err := s.github.getFile(r.Context(), d.Path)
+		if err != nil {
+			abort("get "+d.Path, err)
+		}
+		if err := add(path.Join("ideas", d.Path), []byte(md), d.Date); err != nil {
+			abort("write "+d.Path, err)
+		}
+		summaries = append(summaries, s.summarize(d))
+	}
+	index, _ := json.MarshalIndent(summaries, "", "  ")
+	if err := add("index.json", index, time.Now()); err != nil {
+		abort("write index.json", err)
+	}
+	if err := tw.Close(); err != nil {
+		abort("close archive", err)
+	}
+	if err := gw.Close(); err != nil {
+		abort("close archive", err)
+	}
+	s.log.Printf("exported %d ideas", len(summaries))
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+func TestHandleExport(t *testing.T) {
+	l := log.New(io.Discard, "", 0)
+	files := map[string]string{"/repos/o/r/contents/content/ideas/2025-01-01-reward.md": testIdeaMarkdown}
+	s := &service{
+		log:    l,
+		index:  newArchiveIndex(filepath.Join(t.TempDir(), "index.json"), l),
+		site:   newSiteConfig("", "", "", ""),
+		github: fakeGitHub(t, files),
+	}
+	s.index.put("content/ideas/2025-01-01-reward.md", "sha", testIdeaMarkdown)
+
+	rec := httptest.NewRecorder()
+	s.handleExport(rec, httptest.NewRequest("GET", "/ideas/export", nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/gzip" {
+		t.Fatalf("export = %d %v", rec.Code, rec.Header())
+	}
+	gr, err := gzip.NewReader(rec.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]string{}
+	tr := tar.NewReader(gr)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, _ := io.ReadAll(tr)
+		got[h.Name] = string(b)
+	}
+	if got["ideas/content/ideas/2025-01-01-reward.md"] != testIdeaMarkdown || len(got) != 2 {
+		t.Errorf("archive = %v", got)
+	}
+	var index []ideaSummary
+	if err := json.Unmarshal([]byte(got["index.json"]), &index); err != nil || len(index) != 1 || index[0].ID != "2025-01-01-reward" || index[0].Title != "Reward hacking" {
+		t.Errorf("index.json = %s, %v", got["index.json"], err)
+	}
+
+	// A file the repository cannot serve cuts the archive off.
+	delete(files, "/repos/o/r/contents/content/ideas/2025-01-01-reward.md")
+	defer func() {
+		if r := recover(); r != http.ErrAbortHandler {
+			t.Errorf("export of a missing file panics with %v", r)
+		}
+	}()
+	s.handleExport(httptest.NewRecorder(), httptest.NewRequest("GET", "/ideas/export", nil))
+}
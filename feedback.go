@@ -0,0 +1,394 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// feedbackStore keeps notable reader comments as follow-up notes of the
+// ideas they respond to.
+type feedbackStore struct {
+	path string
+	log  *log.Logger
+
+	mu        sync.Mutex
+	notes     map[string][]*feedbackNote // by idea ID
+	revisited map[string]bool            // idea IDs with a revisit draft
+}
+
+type feedbackNote struct {
+	ID      string    `json:"id"` // comment node ID
+	Author  string    `json:"author"`
+	Body    string    `json:"body"`
+	URL     string    `json:"url"`
+	Created time.Time `json:"created"`
+
+	// Appended is set once the note is committed to its idea as a
+	// follow-up.
+	Appended bool `json:"appended,omitempty"`
+}
+
+type feedbackFile struct {
+	Notes     map[string][]*feedbackNote `json:"notes"`
+	Revisited []string                   `json:"revisited,omitempty"`
+}
+
+func newFeedbackStore(path string, l *log.Logger) *feedbackStore {
+	fs := &feedbackStore{
+		path:      path,
+		log:       l,
+		notes:     map[string][]*feedbackNote{},
+		revisited: map[string]bool{},
+	}
+	var f feedbackFile
+	if err := readJSONFile(path, &f); err != nil {
+		l.Printf("cannot load feedback: %v", err)
+	}
+	if f.Notes != nil {
+		fs.notes = f.Notes
+	}
+	for _, id := range f.Revisited {
+		fs.revisited[id] = true
+	}
+	return fs
+}
+
+// save persists the store. Callers hold mu.
+func (fs *feedbackStore) save() {
+	f := feedbackFile{Notes: fs.notes}
+	for id := range fs.revisited {
+		f.Revisited = append(f.Revisited, id)
+	}
+	slices.Sort(f.Revisited)
+	if err := writeJSONFile(fs.path, f); err != nil {
+		fs.log.Printf("cannot save feedback: %v", err)
+	}
+}
+
+// add appends a note to an idea unless it is already known, and reports
+// whether it was added and how many notes the idea has.
+func (fs *feedbackStore) add(ideaID string, n *feedbackNote) (added bool, count int) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	for _, old := range fs.notes[ideaID] {
+		if old.ID == n.ID {
+			return false, len(fs.notes[ideaID])
+		}
+	}
+	fs.notes[ideaID] = append(fs.notes[ideaID], n)
+	fs.save()
+	return true, len(fs.notes[ideaID])
+}
+
+// get returns the notes of an idea, oldest first.
+func (fs *feedbackStore) get(ideaID string) []*feedbackNote {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	return slices.Clone(fs.notes[ideaID])
+}
+
+// markAppended records that a note was committed to its idea. The note
+// is replaced rather than changed, as callers of get may be reading it.
+func (fs *feedbackStore) markAppended(ideaID, noteID string) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	for i, n := range fs.notes[ideaID] {
+		if n.ID == noteID {
+			c := *n
+			c.Appended = true
+			fs.notes[ideaID][i] = &c
+			fs.save()
+			return
+		}
+	}
+}
+
+// markRevisited records that a revisit draft was created for the idea and
+// reports whether this is the first time.
+func (fs *feedbackStore) markRevisited(ideaID string) bool {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	if fs.revisited[ideaID] {
+		return false
+	}
+	fs.revisited[ideaID] = true
+	fs.save()
+	return true
+}
+
+// commentWebhook receives GitHub Discussions webhooks, which is where
+// giscus stores blog comments.
+type commentWebhook struct {
+	secret   string
+	minWords int             // comments with fewer words are not notable
+	revisit  int             // notes that trigger a revisit draft, 0 disables
+	ignore   map[string]bool // GitHub logins whose comments are skipped
+}
+
+// verify checks the X-Hub-Signature-256 header as described in
+// https://docs.github.com/en/webhooks/using-webhooks/validating-webhook-deliveries.
+func (c *commentWebhook) verify(r *http.Request, body []byte) error {
+	mac := hmac.New(sha256.New, []byte(c.secret))
+	mac.Write(body)
+	want := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(want), []byte(r.Header.Get("X-Hub-Signature-256"))) {
+		return fmt.Errorf("signature mismatch")
+	}
+	return nil
+}
+
+// notable reports whether a comment is worth keeping: written by a human
+// other than the author, and long enough to carry a thought.
+func (c *commentWebhook) notable(login, userType, body string) bool {
+	if userType == "Bot" || c.ignore[strings.ToLower(login)] {
+		return false
+	}
+	return countWords(body) >= c.minWords
+}
+
+// countWords counts words in s, where every CJK character counts as a
+// word. Quoted lines of replies are not counted.
+func countWords(s string) int {
+	n := 0
+	for line := range strings.Lines(s) {
+		if strings.HasPrefix(strings.TrimSpace(line), ">") {
+			continue
+		}
+		for _, f := range strings.Fields(line) {
+			cjk := 0
+			for _, r := range f {
+				if unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) {
+					cjk++
+				}
+			}
+			n += max(cjk, 1)
+		}
+	}
+	return n
+}
+
+type discussionCommentEvent struct {
+	Action  string `json:"action"`
+	Comment struct {
+		NodeID    string    `json:"node_id"`
+		Body      string    `json:"body"`
+		HTMLURL   string    `json:"html_url"`
+		CreatedAt time.Time `json:"created_at"`
+		User      struct {
+			Login string `json:"login"`
+			Type  string `json:"type"`
+		} `json:"user"`
+	} `json:"comment"`
+	Discussion struct {
+		Title string `json:"title"`
+		Body  string `json:"body"`
+	} `json:"discussion"`
+}
+
+// handleCommentWebhook handles discussion_comment events of the
+// repository giscus is configured with.
+func (s *service) handleCommentWebhook(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := s.comments.verify(r, body); err != nil {
+		s.log.Printf("comment webhook verification failed: %v", err)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if r.Header.Get("X-GitHub-Event") != "discussion_comment" {
+		w.WriteHeader(http.StatusNoContent) // e.g. the initial ping
+		return
+	}
+	var ev discussionCommentEvent
+	if err := json.Unmarshal(body, &ev); err != nil {
+		http.Error(w, "invalid event", http.StatusBadRequest)
+		return
+	}
+	if ev.Action != "created" || !s.comments.notable(ev.Comment.User.Login, ev.Comment.User.Type, ev.Comment.Body) {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	idea, ok := s.ideaForDiscussion(ev.Discussion.Title + "\n" + ev.Discussion.Body)
+	if !ok {
+		s.log.Printf("comment %s does not belong to a known idea: %q", ev.Comment.HTMLURL, ev.Discussion.Title)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	note := &feedbackNote{
+		ID:      ev.Comment.NodeID,
+		Author:  ev.Comment.User.Login,
+		Body:    strings.TrimSpace(ev.Comment.Body),
+		URL:     ev.Comment.HTMLURL,
+		Created: ev.Comment.CreatedAt,
+	}
+	added, count := s.feedback.add(idea.ID, note)
+	if !added {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	s.log.Printf("feedback from %s on %s", note.Author, idea.ID)
+	if s.github != nil {
+		go func() {
+			if err := s.appendFeedback(context.WithoutCancel(r.Context()), idea, note); err != nil {
+				s.log.Printf("appending feedback to %s failed: %v", idea.ID, err)
+			}
+		}()
+	}
+	s.notifier.send(r.Context(), notification{
+		title:   "New feedback on " + idea.Title,
+		message: note.Body,
+		url:     note.URL,
+	})
+	if s.comments.revisit > 0 && count >= s.comments.revisit && s.feedback.markRevisited(idea.ID) {
+		req := revisitDraft(idea, s.site.url(idea.Slug), s.feedback.get(idea.ID))
+		go func() {
+			if _, err := s.processIdea(req); err != nil {
+				s.log.Printf("revisit draft for %s failed: %v", idea.ID, err)
+			}
+		}()
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ideaForDiscussion finds the published idea a giscus discussion belongs
+// to. giscus titles discussions by the page path or URL and links the
+// page in the body, so we look for "<ideas path>/<slug>" in both.
+func (s *service) ideaForDiscussion(text string) (*indexedIdea, bool) {
+	prefix := "ideas"
+	if u, err := url.Parse(s.site.baseURL); err == nil {
+		prefix = strings.Trim(u.Path, "/")
+	}
+	re := regexp.MustCompile(`(?:^|[/\s])` + regexp.QuoteMeta(prefix) + `/([^/\s?#)\]]+)`)
+	slugs := map[string]bool{}
+	for _, m := range re.FindAllStringSubmatch(text, -1) {
+		slugs[m[1]] = true
+	}
+	for _, idea := range s.index.all() {
+		if !idea.Draft && slugs[idea.Slug] {
+			return idea, true
+		}
+	}
+	return nil, false
+}
+
+// feedbackAttempts bounds how often appending a note is tried when the
+// idea changes in between, such as for another comment.
+const feedbackAttempts = 3
+
+// appendFeedback appends a note to its idea as a follow-up dated when the
+// comment was written, and marks it appended. The comment is quoted in
+// both languages with its shortcodes defused, and linked to. Comments
+// that seem to contain a credential or that moderation rejects are kept
+// as notes only.
+func (s *service) appendFeedback(ctx context.Context, d *indexedIdea, n *feedbackNote) error {
+	if found := findSecrets(n.Body); len(found) > 0 {
+		return fmt.Errorf("%w (%s)", errSecretFound, strings.Join(found, ", "))
+	}
+	if s.llm != nil {
+		allowed, reason, err := s.llm.moderate(ctx, "Comment on "+d.Title, n.Body)
+		if err != nil {
+			return fmt.Errorf("moderate: %w", err)
+		}
+		if !allowed {
+			return fmt.Errorf("rejected by moderation: %s", reason)
+		}
+	}
+
+	quote := "> " + strings.ReplaceAll(defuseShortcodes(n.Body), "\n", "\n> ")
+	date := n.Created
+	if date.IsZero() {
+		date = time.Now()
+	}
+	for attempt := 1; ; attempt++ {
+		md, sha, err := s.github.getFile(ctx, d.Path)
+		if err != nil {
+			return fmt.Errorf("fetch %s: %w", d.Path, err)
+		}
+		md, err = appendFollowUp(md, date,
+			fmt.Sprintf("[%s](%s) commented:\n\n%s", n.Author, n.URL, quote),
+			fmt.Sprintf("[%s](%s) 评论道：\n\n%s", n.Author, n.URL, quote))
+		if err != nil {
+			return err
+		}
+		fc, err := s.github.updateFile(ctx, d.Path, md, sha, sanitizeCommitMsg("ideas: feedback on "+d.Title))
+		if errors.Is(err, errFileChanged) && attempt < feedbackAttempts {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("update %s: %w", d.Path, err)
+		}
+		s.log.Printf("updated %s (commit %s)", d.Path, fc.CommitSHA)
+		s.index.put(d.Path, fc.SHA, md)
+		s.feedback.markAppended(d.ID, n.ID)
+		if s.llm != nil {
+			s.embeds.kick()
+		}
+		return nil
+	}
+}
+
+// defuseShortcodes keeps the shortcodes of Hugo and the tags of Jekyll
+// in s from being run, which would let a reader's text close the language
+// blocks of an idea. The first brace is written as an entity, which
+// renders the same.
+func defuseShortcodes(s string) string {
+	return strings.NewReplacer("{{", "&#123;{", "{%", "&#123;%").Replace(s)
+}
+
+// revisitDraft creates a draft asking to revisit an idea in the light of
+// the feedback it received.
+func revisitDraft(idea *indexedIdea, url string, notes []*feedbackNote) ideaRequest {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Revisit [%s](%s) in the light of what readers said: what still holds, and what would I now say differently?", idea.Title, url)
+	for _, n := range notes {
+		b.WriteString("\n\n> ")
+		b.WriteString(strings.ReplaceAll(n.Body, "\n", "\n> "))
+		fmt.Fprintf(&b, "\n>\n> — [%s](%s)", n.Author, n.URL)
+	}
+	return ideaRequest{
+		Title:   "Revisiting: " + idea.Title,
+		Content: b.String(),
+		Draft:   true,
+	}
+}
+
+// handleFeedback lists the follow-up notes collected for an idea.
+func (s *service) handleFeedback(w http.ResponseWriter, r *http.Request) {
+	idea, ok := s.index.get(r.PathValue("id"))
+	if !ok {
+		s.jsonError(w, "idea not found", http.StatusNotFound)
+		return
+	}
+	notes := s.feedback.get(idea.ID)
+	if notes == nil {
+		notes = []*feedbackNote{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct {
+		OK    bool            `json:"ok"`
+		Notes []*feedbackNote `json:"notes"`
+	}{OK: true, Notes: notes})
+}
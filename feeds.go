@@ -0,0 +1,235 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"cmp"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// feedWatcher polls RSS/Atom feeds and turns new entries into draft
+// ideas, so interesting reads become writing prompts.
+type feedWatcher struct {
+	s         *service
+	urls      []string
+	interval  time.Duration
+	statePath string
+
+	mu   sync.Mutex
+	seen map[string][]string // feed URL -> recently seen entry IDs
+}
+
+// maxSeenPerFeed bounds the remembered entry IDs of a single feed.
+const maxSeenPerFeed = 500
+
+type feedEntry struct {
+	id      string
+	title   string
+	link    string
+	summary string
+}
+
+// feedDoc covers the subset of RSS 2.0 and Atom that we need.
+type feedDoc struct {
+	XMLName xml.Name
+	// RSS 2.0
+	Items []struct {
+		Title       string `xml:"title"`
+		Link        string `xml:"link"`
+		GUID        string `xml:"guid"`
+		Description string `xml:"description"`
+	} `xml:"channel>item"`
+	// Atom
+	Entries []struct {
+		Title string `xml:"title"`
+		ID    string `xml:"id"`
+		Links []struct {
+			Href string `xml:"href,attr"`
+			Rel  string `xml:"rel,attr"`
+		} `xml:"link"`
+		Summary string `xml:"summary"`
+		Content string `xml:"content"`
+	} `xml:"entry"`
+}
+
+func parseFeed(data []byte) ([]feedEntry, error) {
+	var doc feedDoc
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parse feed: %w", err)
+	}
+
+	var entries []feedEntry
+	switch doc.XMLName.Local {
+	case "rss":
+		for _, it := range doc.Items {
+			link := strings.TrimSpace(it.Link)
+			entries = append(entries, feedEntry{
+				id:      cmp.Or(strings.TrimSpace(it.GUID), link),
+				title:   strings.TrimSpace(it.Title),
+				link:    link,
+				summary: plainText(it.Description),
+			})
+		}
+	case "feed":
+		for _, e := range doc.Entries {
+			var link string
+			for _, l := range e.Links {
+				if l.Rel == "" || l.Rel == "alternate" {
+					link = l.Href
+					break
+				}
+			}
+			entries = append(entries, feedEntry{
+				id:      cmp.Or(strings.TrimSpace(e.ID), link),
+				title:   strings.TrimSpace(e.Title),
+				link:    link,
+				summary: plainText(cmp.Or(e.Summary, e.Content)),
+			})
+		}
+	default:
+		return nil, fmt.Errorf("unsupported feed format: <%s>", doc.XMLName.Local)
+	}
+	return entries, nil
+}
+
+// plainText strips HTML and collapses whitespace of a feed summary.
+func plainText(s string) string {
+	s = strings.Join(strings.Fields(stripHTMLTags(s)), " ")
+	if len(s) > 1024 {
+		s = s[:1024] + "..."
+	}
+	return s
+}
+
+func (fw *feedWatcher) run(ctx context.Context) {
+	if err := readJSONFile(fw.statePath, &fw.seen); err != nil {
+		fw.s.log.Printf("cannot load feed state: %v", err)
+	}
+	if fw.seen == nil {
+		fw.seen = map[string][]string{}
+	}
+
+	t := time.NewTicker(fw.interval)
+	defer t.Stop()
+	for {
+		for _, u := range fw.urls {
+			if err := fw.poll(ctx, u); err != nil {
+				fw.s.log.Printf("feed %s: %v", u, err)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+func (fw *feedWatcher) poll(ctx context.Context, url string) error {
+	entries, err := fetchFeed(ctx, url)
+	if err != nil {
+		return err
+	}
+
+	fw.mu.Lock()
+	seen, known := fw.seen[url]
+	isSeen := map[string]bool{}
+	for _, id := range seen {
+		isSeen[id] = true
+	}
+	fw.mu.Unlock()
+	var fresh []feedEntry
+	for _, e := range entries {
+		if e.id != "" && !isSeen[e.id] {
+			isSeen[e.id] = true
+			fresh = append(fresh, e)
+		}
+	}
+
+	// On the first poll of a feed only remember the backlog, otherwise
+	// subscribing would flood the drafts.
+	if !known {
+		return fw.markSeen(url, fresh...)
+	}
+	// An entry is only seen once its draft is created, so a failed one
+	// is tried again on the next poll.
+	for _, e := range fresh {
+		fw.s.log.Printf("new feed entry, creating draft: %s", e.title)
+		if _, err := fw.s.processIdea(feedDraft(e)); err != nil {
+			fw.s.log.Printf("feed draft failed: %v", err)
+			continue
+		}
+		if err := fw.markSeen(url, e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// markSeen remembers entries of the feed at url and saves the state.
+func (fw *feedWatcher) markSeen(url string, entries ...feedEntry) error {
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+	seen := fw.seen[url]
+	for _, e := range entries {
+		seen = append(seen, e.id)
+	}
+	if len(seen) > maxSeenPerFeed {
+		seen = seen[len(seen)-maxSeenPerFeed:]
+	}
+	fw.seen[url] = seen
+	if err := writeJSONFile(fw.statePath, fw.seen); err != nil {
+		return fmt.Errorf("save feed state: %w", err)
+	}
+	return nil
+}
+
+// feedDraft turns a feed entry into a draft idea prompting a response.
+func feedDraft(e feedEntry) ideaRequest {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Summarize & respond to this: [%s](%s)", cmp.Or(e.title, e.link), e.link)
+	if e.summary != "" {
+		fmt.Fprintf(&b, "\n\n> %s", e.summary)
+	}
+	return ideaRequest{
+		Title:    e.title,
+		Content:  b.String(),
+		Draft:    true,
+		Pipeline: "feed",
+	}
+}
+
+func fetchFeed(ctx context.Context, url string) ([]feedEntry, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "ChangkunIdeasBot/1.0")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	if err != nil {
+		return nil, err
+	}
+	return parseFeed(data)
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"sync/atomic"
+	"testing"
+)
+
+func TestParseFeed(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []feedEntry
+	}{
+		{
+			name: "rss",
+			input: `<?xml version="1.0"?>
+<rss version="2.0"><channel><title>Blog</title>
+<item><title>First</title><link>https://example.com/1</link><guid>id-1</guid>
+<description>&lt;p&gt;Hello   &lt;b&gt;world&lt;/b&gt;&lt;/p&gt;</description></item>
+<item><title>Second</title><link>https://example.com/2</link></item>
+</channel></rss>`,
+			want: []feedEntry{
+				{id: "id-1", title: "First", link: "https://example.com/1", summary: "Hello world"},
+				{id: "https://example.com/2", title: "Second", link: "https://example.com/2"},
+			},
+		},
+		{
+			name: "atom",
+			input: `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom"><title>Blog</title>
+<entry><title>Post</title><id>urn:1</id>
+<link rel="self" href="https://example.com/self"/>
+<link rel="alternate" href="https://example.com/post"/>
+<summary>Short summary</summary></entry>
+</feed>`,
+			want: []feedEntry{
+				{id: "urn:1", title: "Post", link: "https://example.com/post", summary: "Short summary"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseFeed([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("parseFeed: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d entries, want %d: %+v", len(got), len(tt.want), got)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("entry %d\n got: %+v\nwant: %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+
+	if _, err := parseFeed([]byte(`<html></html>`)); err == nil {
+		t.Error("parseFeed(html) succeeded, want error")
+	}
+}
+
+func TestFeedPoll(t *testing.T) {
+	feed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `<rss version="2.0"><channel>
+<item><title>First</title><link>https://example.com/1</link></item>
+<item><title>Second</title><link>https://example.com/2</link></item>
+</channel></rss>`)
+	}))
+	t.Cleanup(feed.Close)
+	var reject atomic.Bool
+	var calls atomic.Int32
+	llm := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		allowed := `{\"allowed\": true}`
+		if reject.Load() {
+			allowed = `{\"allowed\": false, \"reason\": \"spam\"}`
+		}
+		io.WriteString(w, `{"choices":[{"message":{"content":"`+allowed+`"}}]}`)
+	}))
+	t.Cleanup(llm.Close)
+
+	l := log.New(io.Discard, "", 0)
+	dir := t.TempDir()
+	fw := &feedWatcher{
+		s: &service{
+			log:       l,
+			llm:       &llmClient{baseURL: llm.URL, log: l},
+			jobs:      newJobStore(filepath.Join(dir, "jobs.json"), l),
+			lifecycle: newLifecycleStore(filepath.Join(dir, "lifecycle.json"), l),
+			pipelines: map[string][]string{"feed": {"moderate"}},
+		},
+		statePath: filepath.Join(dir, "feeds.json"),
+		seen:      map[string][]string{},
+	}
+
+	// The first poll only remembers the backlog.
+	if err := fw.poll(t.Context(), feed.URL); err != nil || calls.Load() != 0 || len(fw.seen[feed.URL]) != 2 {
+		t.Fatalf("first poll: %v, %d calls, seen %v", err, calls.Load(), fw.seen[feed.URL])
+	}
+
+	fw.seen[feed.URL] = nil
+	reject.Store(true)
+	if err := fw.poll(t.Context(), feed.URL); err != nil || calls.Load() != 2 {
+		t.Fatalf("failing poll: %v, %d calls", err, calls.Load())
+	}
+	if seen := fw.seen[feed.URL]; len(seen) != 0 {
+		t.Errorf("seen after failed drafts = %v, want none", seen)
+	}
+
+	// The failed entries are tried again, and only once they succeed.
+	reject.Store(false)
+	for range 2 {
+		if err := fw.poll(t.Context(), feed.URL); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if calls.Load() != 4 {
+		t.Errorf("%d calls, want the two entries retried once", calls.Load())
+	}
+	var saved map[string][]string
+	readJSONFile(fw.statePath, &saved)
+	if want := []string{"https://example.com/1", "https://example.com/2"}; !slices.Equal(saved[feed.URL], want) {
+		t.Errorf("saved state = %v, want %v", saved, want)
+	}
+}
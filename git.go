@@ -6,28 +6,35 @@ package main
 
 import (
 	"bytes"
+	"cmp"
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"path"
 	"strings"
 	"time"
 	"unicode"
 )
 
 type githubClient struct {
-	token string
+	token *secret
 	owner string
 	repo  string
 	name  string
 	email string
+
+	apiURL string // empty for https://api.github.com
 }
 
 type createFileRequest struct {
 	Message   string          `json:"message"`
-	Content   string          `json:"content"` // base64-encoded
+	Content   string          `json:"content"`       // base64-encoded
+	SHA       string          `json:"sha,omitempty"` // blob SHA of the file to replace
 	Committer *githubCommiter `json:"committer,omitempty"`
 }
 
@@ -36,13 +43,39 @@ type githubCommiter struct {
 	Email string `json:"email"`
 }
 
-func (g *githubClient) createFile(ctx context.Context, path, content, commitMsg string) error {
+// fileCommit describes a file written by a commit.
+type fileCommit struct {
+	SHA       string // blob SHA of the file
+	CommitSHA string
+	CommitURL string
+}
+
+// fileURL returns the page of a file on GitHub, on the default branch.
+func (g *githubClient) fileURL(path string) string {
+	return "https://github.com/" + g.owner + "/" + g.repo + "/blob/HEAD/" + path
+}
+
+// errFileChanged is returned when a file to update no longer has the
+// expected blob SHA.
+var errFileChanged = errors.New("file changed since it was read")
+
+func (g *githubClient) createFile(ctx context.Context, path, content, commitMsg string) (*fileCommit, error) {
+	return g.putFile(ctx, path, content, "", commitMsg)
+}
+
+// updateFile replaces a file whose current blob SHA is sha.
+func (g *githubClient) updateFile(ctx context.Context, path, content, sha, commitMsg string) (*fileCommit, error) {
+	return g.putFile(ctx, path, content, sha, commitMsg)
+}
+
+func (g *githubClient) putFile(ctx context.Context, path, content, sha, commitMsg string) (*fileCommit, error) {
 	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
 	reqBody := createFileRequest{
 		Message: commitMsg,
 		Content: base64.StdEncoding.EncodeToString([]byte(content)),
+		SHA:     sha,
 		Committer: &githubCommiter{
 			Name:  g.name,
 			Email: g.email,
@@ -51,34 +84,245 @@ func (g *githubClient) createFile(ctx context.Context, path, content, commitMsg
 
 	body, err := json.Marshal(reqBody)
 	if err != nil {
-		return fmt.Errorf("marshal request: %w", err)
+		return nil, fmt.Errorf("marshal request: %w", err)
 	}
 
-	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/contents/%s",
-		g.owner, g.repo, path)
-	req, err := http.NewRequestWithContext(ctx, "PUT", url, bytes.NewReader(body))
+	req, err := g.newRequest(ctx, "PUT", "/contents/"+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if sha != "" && resp.StatusCode == http.StatusConflict {
+		return nil, errFileChanged
+	}
+	if resp.StatusCode != http.StatusCreated && (sha == "" || resp.StatusCode != http.StatusOK) {
+		respBody, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("GitHub API returned %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	var result struct {
+		Content struct {
+			SHA string `json:"sha"`
+		} `json:"content"`
+		Commit struct {
+			SHA     string `json:"sha"`
+			HTMLURL string `json:"html_url"`
+		} `json:"commit"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+	return &fileCommit{
+		SHA:       result.Content.SHA,
+		CommitSHA: result.Commit.SHA,
+		CommitURL: result.Commit.HTMLURL,
+	}, nil
+}
+
+// deleteFile removes a file whose current blob SHA is sha.
+func (g *githubClient) deleteFile(ctx context.Context, path, sha, commitMsg string) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	body, err := json.Marshal(createFileRequest{
+		Message:   commitMsg,
+		SHA:       sha,
+		Committer: &githubCommiter{Name: g.name, Email: g.email},
+	})
+	if err != nil {
+		return fmt.Errorf("marshal request: %w", err)
+	}
+	req, err := g.newRequest(ctx, "DELETE", "/contents/"+path, bytes.NewReader(body))
 	if err != nil {
 		return fmt.Errorf("create request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+g.token)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("send request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusConflict {
+		return errFileChanged
+	}
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("GitHub API returned %d: %s", resp.StatusCode, string(respBody))
+	}
+	return nil
+}
+
+// newRequest creates a GitHub API request for an endpoint relative to
+// the configured repository, e.g. "/contents/README.md".
+func (g *githubClient) newRequest(ctx context.Context, method, endpoint string, body io.Reader) (*http.Request, error) {
+	url := fmt.Sprintf("%s/repos/%s/%s%s", cmp.Or(g.apiURL, "https://api.github.com"), g.owner, g.repo, endpoint)
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+g.token.value())
 	req.Header.Set("Accept", "application/vnd.github+json")
 	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	setRequestID(req)
+	return req, nil
+}
 
+// getJSON performs a GET request and decodes the JSON response into v.
+func (g *githubClient) getJSON(ctx context.Context, endpoint string, v any) error {
+	req, err := g.newRequest(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		return fmt.Errorf("send request: %w", err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusCreated {
+	if resp.StatusCode != http.StatusOK {
 		respBody, _ := io.ReadAll(resp.Body)
 		return fmt.Errorf("GitHub API returned %d: %s", resp.StatusCode, string(respBody))
 	}
-
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
 	return nil
 }
 
+var errDirNotFound = errors.New("directory not found")
+
+// repoFile is a file entry in the repository.
+type repoFile struct {
+	Path string `json:"path"`
+	SHA  string `json:"sha"`
+	Type string `json:"type"`
+}
+
+// listDir lists the files of a repository directory. It goes through the
+// git trees API, which unlike the contents API is not capped at 1000
+// entries.
+func (g *githubClient) listDir(ctx context.Context, dir string) ([]repoFile, error) {
+	return g.listFiles(ctx, dir, false)
+}
+
+// listTree lists the files of a repository directory and all its
+// subdirectories.
+func (g *githubClient) listTree(ctx context.Context, dir string) ([]repoFile, error) {
+	return g.listFiles(ctx, dir, true)
+}
+
+func (g *githubClient) listFiles(ctx context.Context, dir string, recursive bool) ([]repoFile, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	dir = strings.Trim(dir, "/")
+	parent := strings.TrimSuffix(path.Dir(dir), ".")
+	var entries []repoFile
+	if err := g.getJSON(ctx, "/contents/"+parent, &entries); err != nil {
+		return nil, err
+	}
+	var sha string
+	for _, e := range entries {
+		if e.Path == dir && e.Type == "dir" {
+			sha = e.SHA
+			break
+		}
+	}
+	if sha == "" {
+		return nil, fmt.Errorf("%w: %s", errDirNotFound, dir)
+	}
+
+	var tree struct {
+		Tree []struct {
+			Path string `json:"path"`
+			SHA  string `json:"sha"`
+			Type string `json:"type"`
+		} `json:"tree"`
+	}
+	endpoint := "/git/trees/" + sha
+	if recursive {
+		endpoint += "?recursive=1"
+	}
+	if err := g.getJSON(ctx, endpoint, &tree); err != nil {
+		return nil, err
+	}
+	var files []repoFile
+	for _, t := range tree.Tree {
+		if t.Type != "blob" {
+			continue
+		}
+		files = append(files, repoFile{Path: dir + "/" + t.Path, SHA: t.SHA, Type: "file"})
+	}
+	return files, nil
+}
+
+// getFile returns the content and blob SHA of a repository file.
+func (g *githubClient) getFile(ctx context.Context, path string) (content, sha string, err error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	var f struct {
+		Content  string `json:"content"`
+		Encoding string `json:"encoding"`
+		SHA      string `json:"sha"`
+	}
+	if err := g.getJSON(ctx, "/contents/"+path, &f); err != nil {
+		return "", "", err
+	}
+	if f.Encoding != "base64" {
+		return "", "", fmt.Errorf("unexpected encoding: %q", f.Encoding)
+	}
+	// The content is wrapped at 60 columns.
+	data, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(f.Content, "\n", ""))
+	if err != nil {
+		return "", "", fmt.Errorf("decode content: %w", err)
+	}
+	return string(data), f.SHA, nil
+}
+
+// fileHistory is when a file was first committed and last changed.
+type fileHistory struct {
+	Created   time.Time
+	Updated   time.Time
+	CommitURL string // of the last change
+}
+
+// history returns the history of a repository file from its last 100
+// commits. A file changed more often is taken as created with the oldest
+// of them.
+func (g *githubClient) history(ctx context.Context, p string) (*fileHistory, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	var commits []struct {
+		HTMLURL string `json:"html_url"`
+		Commit  struct {
+			Committer struct {
+				Date time.Time `json:"date"`
+			} `json:"committer"`
+		} `json:"commit"`
+	}
+	if err := g.getJSON(ctx, "/commits?per_page=100&path="+url.QueryEscape(p), &commits); err != nil {
+		return nil, err
+	}
+	if len(commits) == 0 {
+		return nil, fmt.Errorf("no commits of %s", p)
+	}
+	return &fileHistory{
+		Created:   commits[len(commits)-1].Commit.Committer.Date,
+		Updated:   commits[0].Commit.Committer.Date,
+		CommitURL: commits[0].HTMLURL,
+	}, nil
+}
+
 // sanitizeCommitMsg strips control characters and truncates the message.
 func sanitizeCommitMsg(s string) string {
 	var b strings.Builder
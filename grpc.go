@@ -0,0 +1,355 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"net/netip"
+	"time"
+
+	ideasv1 "changkun.de/x/ideas/proto/ideas/v1"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// grpcScopes are the scopes needed to call the methods of the gRPC
+// service, as for their HTTP endpoints.
+var grpcScopes = map[string]string{
+	ideasv1.Ideas_Post_FullMethodName:    scopeWrite,
+	ideasv1.Ideas_Improve_FullMethodName: scopeWrite,
+	ideasv1.Ideas_List_FullMethodName:    scopeRead,
+	ideasv1.Ideas_Get_FullMethodName:     scopeRead,
+	ideasv1.Ideas_Watch_FullMethodName:   scopeWrite, // clients follow the jobs of the ideas they post
+}
+
+// grpcGate authenticates and authorizes the calls of the gRPC service,
+// logs them, and records those that change something in the audit log,
+// as the middleware of the HTTP API does for requests.
+type grpcGate struct {
+	log           *log.Logger
+	keys          *apiKeyStore
+	defaultScopes scopeDefaults
+	allowed       []netip.Prefix // of IDEAS_ALLOW_CIDRS, nil for any
+	trusted       []netip.Prefix // of IDEAS_TRUSTED_PROXIES
+	audit         *auditLog
+}
+
+// newGRPCServer returns a gRPC server of the ideas.v1 service over the
+// same service as the HTTP API, which takes messages up to the size of
+// idea request bodies.
+func newGRPCServer(s *service, g *grpcGate) *grpc.Server {
+	srv := grpc.NewServer(
+		grpc.MaxRecvMsgSize(int(cmp.Or(s.maxBody, defaultMaxBody))),
+		grpc.ChainUnaryInterceptor(g.unary),
+		grpc.ChainStreamInterceptor(g.stream),
+	)
+	ideasv1.RegisterIdeasServer(srv, &grpcIdeas{s: s})
+	return srv
+}
+
+// authorize returns the context of a call to method, with the request
+// ID, taken from the x-request-id metadata if valid, and the principal
+// who made it.
+func (g *grpcGate) authorize(ctx context.Context, method string) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	h := http.Header{}
+	for k, vs := range md {
+		for _, v := range vs {
+			h.Add(k, v)
+		}
+	}
+	id := h.Get(requestIDHeader)
+	if !validRequestID(id) {
+		id = newRequestID()
+	}
+	ctx = withRequestID(ctx, id)
+	grpc.SetHeader(ctx, metadata.Pairs(requestIDHeader, id))
+
+	ip := "unknown"
+	if p, ok := peer.FromContext(ctx); ok {
+		ip = forwardedFor(peerIP(p.Addr.String()), md.Get("x-forwarded-for"), g.trusted)
+	}
+	g.log.Println(ip, "gRPC", method, id)
+	if len(g.allowed) > 0 && !allowedIP(g.allowed, ip) {
+		return ctx, status.Error(codes.PermissionDenied, "client address not allowed")
+	}
+
+	p, ok := authenticateHeader(h, g.keys, g.defaultScopes)
+	if !ok {
+		return ctx, status.Error(codes.Unauthenticated, "unauthorized")
+	}
+	scope, ok := grpcScopes[method]
+	if !ok {
+		scope = scopeAdmin
+	}
+	if !p.can(scope) {
+		return ctx, status.Errorf(codes.PermissionDenied, "the %s scope is required", scope)
+	}
+	return withPrincipal(ctx, p), nil
+}
+
+// audited runs the call to method, recording it in the audit log unless
+// it only reads.
+func (g *grpcGate) audited(ctx context.Context, method string, call func(context.Context) error) error {
+	if grpcScopes[method] == scopeRead {
+		return call(ctx)
+	}
+	e := &auditEntry{}
+	err := call(context.WithValue(ctx, auditKey{}, e))
+	p, _ := principalFromContext(ctx)
+	e.User, e.APIKey = p.User, p.APIKey
+	e.Action = "gRPC " + method
+	e.RequestID = requestIDFromContext(ctx)
+	if err != nil {
+		e.Error = err.Error()
+	}
+	g.audit.record(*e)
+	return err
+}
+
+func (g *grpcGate) unary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	ctx, err := g.authorize(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	var resp any
+	err = g.audited(ctx, info.FullMethod, func(ctx context.Context) error {
+		resp, err = handler(ctx, req)
+		return err
+	})
+	return resp, err
+}
+
+func (g *grpcGate) stream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := g.authorize(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return g.audited(ctx, info.FullMethod, func(ctx context.Context) error {
+		return handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+	})
+}
+
+// contextStream is a server stream with the context of its call.
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context { return s.ctx }
+
+// errNotOwner refuses reading the server's own archive to the users of
+// the users file, as ownerOnly does.
+var errNotOwner = status.Error(codes.PermissionDenied, "only the owner of the server's blog may do this")
+
+// grpcIdeas implements the ideas.v1 service with the service of the
+// HTTP API.
+type grpcIdeas struct {
+	ideasv1.UnimplementedIdeasServer
+	s *service
+}
+
+func (g *grpcIdeas) Post(ctx context.Context, in *ideasv1.PostRequest) (*ideasv1.PostResponse, error) {
+	req := ideaRequest{
+		Title:          in.Title,
+		Content:        in.Content,
+		Draft:          in.Draft,
+		Tags:           in.Tags,
+		NoCrosspost:    in.NoCrosspost,
+		NoTitle:        in.NoTitle,
+		NoTranslate:    in.NoTranslate,
+		NoAugment:      in.NoAugment,
+		NoTags:         in.NoTags,
+		Pipeline:       in.Pipeline,
+		Lang:           in.Lang,
+		Review:         in.Review,
+		AllowSecrets:   in.AllowSecrets,
+		AllowDuplicate: in.AllowDuplicate,
+	}
+	if in.PublishAt != nil {
+		req.PublishAt = in.PublishAt.AsTime()
+	}
+	id, err := g.s.postIdea(ctx, req)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	noteAudit(ctx, func(e *auditEntry) { e.Job = id })
+	return &ideasv1.PostResponse{Job: id}, nil
+}
+
+func (g *grpcIdeas) Improve(ctx context.Context, in *ideasv1.ImproveRequest) (*ideasv1.ImproveResponse, error) {
+	if in.Content == "" {
+		return nil, status.Error(codes.InvalidArgument, "content is required")
+	}
+	improved, err := g.s.llm.improveContent(ctx, in.Content)
+	if err != nil {
+		g.s.log.Printf("content improvement failed: %v", err)
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, status.Error(codes.DeadlineExceeded, "content improvement failed")
+		}
+		return nil, status.Error(codes.Internal, "content improvement failed")
+	}
+	return &ideasv1.ImproveResponse{Content: improved}, nil
+}
+
+func (g *grpcIdeas) List(ctx context.Context, in *ideasv1.ListRequest) (*ideasv1.ListResponse, error) {
+	if !g.s.isOwner(ctx) {
+		return nil, errNotOwner
+	}
+	if in.Limit < 0 || in.Limit > 500 {
+		return nil, status.Error(codes.InvalidArgument, "limit must be between 1 and 500")
+	}
+	ideas, next := g.s.listIdeas(cmp.Or(int(in.Limit), 50), in.Cursor, in.Tag)
+	resp := &ideasv1.ListResponse{NextCursor: next}
+	for _, d := range ideas {
+		resp.Ideas = append(resp.Ideas, &ideasv1.IdeaSummary{
+			Id:         d.ID,
+			Path:       d.Path,
+			Url:        d.URL,
+			Date:       timestamppb.New(d.Date),
+			Slug:       d.Slug,
+			Title:      d.Title,
+			TitleZh:    d.TitleZh,
+			Languages:  d.Languages,
+			Draft:      d.Draft,
+			Tags:       d.Tags,
+			Categories: d.Categories,
+		})
+	}
+	return resp, nil
+}
+
+func (g *grpcIdeas) Get(ctx context.Context, in *ideasv1.GetRequest) (*ideasv1.Idea, error) {
+	if !g.s.isOwner(ctx) {
+		return nil, errNotOwner
+	}
+	d, err := g.s.getIdea(ctx, in.Id)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return &ideasv1.Idea{
+		Id:          d.ID,
+		Path:        d.Path,
+		Sha:         d.SHA,
+		Url:         d.URL,
+		GithubUrl:   d.GitHubURL,
+		Date:        timestamppb.New(d.Date),
+		Slug:        d.Slug,
+		Title:       d.Title,
+		TitleZh:     d.TitleZh,
+		Draft:       d.Draft,
+		Tags:        d.Tags,
+		Categories:  d.Categories,
+		FrontMatter: d.FrontMatter,
+		Markdown:    d.Markdown,
+		CommitUrl:   d.CommitURL,
+		Created:     timestampOrNil(d.Created),
+		Updated:     timestampOrNil(d.Updated),
+	}, nil
+}
+
+func (g *grpcIdeas) Watch(in *ideasv1.WatchRequest, stream grpc.ServerStreamingServer[ideasv1.Job]) error {
+	if j, ok := g.s.jobs.get(in.Job); !ok || !g.s.canSeeJob(stream.Context(), j) {
+		return status.Error(codes.NotFound, "job not found")
+	}
+	var last *ideasv1.Job
+	for {
+		updated := g.s.jobs.updates()
+		j, _ := g.s.jobs.get(in.Job)
+		msg := grpcJob(j)
+		if !proto.Equal(msg, last) {
+			if err := stream.Send(msg); err != nil {
+				return err
+			}
+			last = msg
+		}
+		// A job interrupted by shutdown resumes only after restart.
+		if j.Status != jobRunning || j.Resume {
+			return nil
+		}
+		select {
+		case <-updated:
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		}
+	}
+}
+
+// grpcJob returns the message of the job j.
+func grpcJob(j job) *ideasv1.Job {
+	msg := &ideasv1.Job{
+		Id:        j.ID,
+		Title:     j.Title,
+		Status:    j.Status,
+		Error:     j.Error,
+		Path:      j.Path,
+		Url:       j.URL,
+		CommitUrl: j.CommitURL,
+		Tokens:    int32(j.Tokens),
+		Started:   timestamppb.New(j.Started),
+		Finished:  timestampOrNil(j.Finished),
+	}
+	if n := len(j.Stages); n > 0 {
+		msg.Stage = j.Stages[n-1].Name
+	}
+	return msg
+}
+
+func timestampOrNil(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+// grpcError returns the gRPC status of a refused request, with the code
+// of its HTTP error response as the reason of its error info.
+func grpcError(e *requestError) error {
+	c := codes.Internal
+	switch e.status {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		c = codes.InvalidArgument
+	case http.StatusNotFound:
+		c = codes.NotFound
+	case http.StatusConflict:
+		c = codes.AlreadyExists
+	case http.StatusTooManyRequests:
+		c = codes.ResourceExhausted
+	case http.StatusBadGateway:
+		c = codes.Unavailable
+	}
+	info := &errdetails.ErrorInfo{Reason: cmp.Or(e.Code, statusErrorCode(e.status)), Domain: "ideas"}
+	if d := e.Duplicate; d != nil {
+		info.Metadata = map[string]string{"id": d.ID, "title": d.Title, "url": d.URL}
+	}
+	st, err := status.New(c, e.Message).WithDetails(info)
+	if err != nil {
+		return status.Error(c, e.Message)
+	}
+	return st.Err()
+}
+
+// serveGRPC serves srv on addr until it is stopped.
+func serveGRPC(srv *grpc.Server, addr string, l *log.Logger) {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		l.Fatalf("cannot listen on %s for gRPC, err: %v\n", addr, err)
+	}
+	l.Printf("ideas gRPC service is serving on %s...", addr)
+	if err := srv.Serve(lis); err != nil {
+		l.Fatalf("gRPC server failed: %v", err)
+	}
+}
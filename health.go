@@ -0,0 +1,129 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"changkun.de/x/login"
+)
+
+// healthTTL is how long the result of checking the dependencies is
+// served before they are checked again, so that frequent probes of the
+// public endpoint do not flood them.
+const healthTTL = 30 * time.Second
+
+// healthReport is the state of the service's dependencies.
+type healthReport struct {
+	OK           bool                        `json:"ok"`
+	Checked      time.Time                   `json:"checked"`
+	Dependencies map[string]dependencyHealth `json:"dependencies"`
+}
+
+// dependencyHealth leaves out why a dependency failed, since the report
+// is public; the error is logged instead.
+type dependencyHealth struct {
+	OK        bool  `json:"ok"`
+	LatencyMS int64 `json:"latency_ms"`
+}
+
+// healthCache keeps the last health report. The zero value is ready to
+// use.
+type healthCache struct {
+	mu     sync.Mutex
+	report healthReport
+}
+
+// get returns the report checked within healthTTL of now, or else runs
+// check for a new one. Concurrent callers wait for the same check.
+func (c *healthCache) get(now time.Time, check func() healthReport) healthReport {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.report.Checked.IsZero() || now.Sub(c.report.Checked) >= healthTTL {
+		c.report = check()
+	}
+	return c.report
+}
+
+// handleHealth reports whether the login service, the LLM API, and the
+// GitHub repository are reachable, with 503 Service Unavailable if any
+// is not. Unlike /ideas/ping, it tells whether ideas can be published.
+func (s *service) handleHealth(w http.ResponseWriter, r *http.Request) {
+	// The report is shared with other callers, so the checks outlive a
+	// client that goes away.
+	ctx := context.WithoutCancel(r.Context())
+	rep := s.health.get(time.Now(), func() healthReport { return s.checkHealth(ctx) })
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if !rep.OK {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(rep)
+}
+
+// checkHealth checks the dependencies at once.
+func (s *service) checkHealth(ctx context.Context) healthReport {
+	checks := map[string]func(context.Context) error{
+		"login": func(ctx context.Context) error { return checkReachable(ctx, login.VerifyEndpoint) },
+	}
+	if s.llm != nil {
+		checks["llm"] = func(ctx context.Context) error { return checkReachable(ctx, s.llm.baseURL) }
+	}
+	if s.github != nil {
+		checks["github"] = func(ctx context.Context) error { return s.github.getJSON(ctx, "", &struct{}{}) }
+	}
+
+	rep := healthReport{OK: true, Checked: time.Now(), Dependencies: map[string]dependencyHealth{}}
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for name, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+			defer cancel()
+			start := time.Now()
+			err := check(ctx)
+			d := dependencyHealth{OK: err == nil, LatencyMS: time.Since(start).Milliseconds()}
+			if err != nil {
+				s.log.Printf("health: %s: %v", name, err)
+			}
+			mu.Lock()
+			rep.Dependencies[name] = d
+			rep.OK = rep.OK && d.OK
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	return rep
+}
+
+// checkReachable reports an error if url cannot be reached or fails
+// with a server error. Client errors, such as for a missing token, still
+// show that the server is up.
+func checkReachable(ctx context.Context, url string) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	setRequestID(req)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("send request: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("server returned %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"changkun.de/x/login"
+)
+
+func TestHealth(t *testing.T) {
+	var hits atomic.Int32
+	var llmDown atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		switch r.URL.Path {
+		case "/verify":
+			w.WriteHeader(http.StatusMethodNotAllowed) // up, if not for GET
+		case "/llm":
+			if llmDown.Load() {
+				w.WriteHeader(http.StatusBadGateway)
+			}
+		case "/repos/o/r":
+			io.WriteString(w, `{"full_name":"o/r"}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	verify := login.VerifyEndpoint
+	login.VerifyEndpoint = srv.URL + "/verify"
+	defer func() { login.VerifyEndpoint = verify }()
+
+	var logs bytes.Buffer
+	l := log.New(&logs, "", 0)
+	s := &service{
+		log:    l,
+		llm:    &llmClient{baseURL: srv.URL + "/llm", log: l},
+		github: &githubClient{apiURL: srv.URL, owner: "o", repo: "r"},
+	}
+	get := func() (int, healthReport) {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		s.handleHealth(rec, httptest.NewRequest("GET", "/ideas/healthz", nil))
+		var rep healthReport
+		json.NewDecoder(rec.Body).Decode(&rep)
+		return rec.Code, rep
+	}
+
+	code, rep := get()
+	if code != http.StatusOK || !rep.OK || len(rep.Dependencies) != 3 {
+		t.Fatalf("healthz = %d %+v", code, rep)
+	}
+	for name, d := range rep.Dependencies {
+		if !d.OK {
+			t.Errorf("%s = %+v", name, d)
+		}
+	}
+
+	// The report is cached, so the LLM going down shows only later.
+	llmDown.Store(true)
+	if code, _ := get(); code != http.StatusOK || hits.Load() != 3 {
+		t.Errorf("cached healthz = %d after %d checks", code, hits.Load())
+	}
+	s.health.report.Checked = time.Now().Add(-healthTTL)
+	code, rep = get()
+	if code != http.StatusServiceUnavailable || rep.OK || rep.Dependencies["llm"].OK || !rep.Dependencies["github"].OK {
+		t.Errorf("healthz with the LLM down = %d %+v", code, rep)
+	}
+	if !strings.Contains(logs.String(), "health: llm:") {
+		t.Errorf("logs = %q, want the LLM error", logs.String())
+	}
+}
@@ -0,0 +1,235 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Hook points.
+const (
+	hookPrePublish  = "pre-publish"
+	hookPostPublish = "post-publish"
+)
+
+// Hook failure policies.
+const (
+	hookAbort  = "abort"  // stop the pipeline, pre-publish only
+	hookIgnore = "ignore" // log, report in the notification, and go on
+	hookDraft  = "draft"  // publish as a draft instead, pre-publish only
+)
+
+// hook runs an external command or calls a URL with the idea as JSON,
+// so the pipeline can be extended without changing the service.
+type hook struct {
+	Name      string            `json:"name"`
+	When      string            `json:"when"`              // hookPrePublish or hookPostPublish
+	Command   []string          `json:"command,omitempty"` // program and arguments, idea on stdin
+	URL       string            `json:"url,omitempty"`     // receives the idea as a POST body
+	Headers   map[string]string `json:"headers,omitempty"`
+	Timeout   string            `json:"timeout,omitempty"`    // default 30s
+	OnFailure string            `json:"on_failure,omitempty"` // default abort pre-publish, ignore post-publish
+
+	timeout time.Duration
+}
+
+// hookIdea is the JSON a hook receives. Pre-publish hooks may reply with
+// a JSON object of the fields to change, e.g. {"draft": true}; an empty
+// reply changes nothing.
+type hookIdea struct {
+	Event       string   `json:"event"`
+	ID          string   `json:"id"` // capture ID
+	Title       string   `json:"title"`
+	Content     string   `json:"content"`
+	Tags        []string `json:"tags"`
+	Draft       bool     `json:"draft"`
+	Lang        string   `json:"lang,omitempty"`
+	TitleEn     string   `json:"title_en,omitempty"`
+	TitleZh     string   `json:"title_zh,omitempty"`
+	ContentEn   string   `json:"content_en,omitempty"`
+	ContentZh   string   `json:"content_zh,omitempty"`
+	AugmentedEn string   `json:"augmented_en,omitempty"`
+	AugmentedZh string   `json:"augmented_zh,omitempty"`
+	Path        string   `json:"path,omitempty"` // post-publish only
+	URL         string   `json:"url,omitempty"`  // post-publish only
+}
+
+// loadHooks reads hook definitions such as:
+//
+//	[
+//	  {"name": "vale", "when": "pre-publish", "command": ["./hooks/lint.sh"], "timeout": "10s", "on_failure": "draft"},
+//	  {"name": "index", "when": "post-publish", "url": "https://example.com/hook", "headers": {"Authorization": "Bearer ..."}}
+//	]
+func loadHooks(path string) ([]*hook, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var hooks []*hook
+	if err := json.Unmarshal(data, &hooks); err != nil {
+		return nil, fmt.Errorf("parse hooks: %w", err)
+	}
+	for i, h := range hooks {
+		h.Name = cmp.Or(h.Name, fmt.Sprintf("hook %d", i+1))
+		if h.When != hookPrePublish && h.When != hookPostPublish {
+			return nil, fmt.Errorf("%s: when must be %s or %s", h.Name, hookPrePublish, hookPostPublish)
+		}
+		if (len(h.Command) == 0) == (h.URL == "") {
+			return nil, fmt.Errorf("%s: exactly one of command and url is required", h.Name)
+		}
+		h.timeout, err = time.ParseDuration(cmp.Or(h.Timeout, "30s"))
+		if err != nil || h.timeout <= 0 {
+			return nil, fmt.Errorf("%s: invalid timeout %q", h.Name, h.Timeout)
+		}
+		if h.OnFailure == "" {
+			h.OnFailure = hookIgnore
+			if h.When == hookPrePublish {
+				h.OnFailure = hookAbort
+			}
+		}
+		switch {
+		case h.OnFailure != hookAbort && h.OnFailure != hookIgnore && h.OnFailure != hookDraft:
+			return nil, fmt.Errorf("%s: unknown failure policy %q", h.Name, h.OnFailure)
+		case (h.OnFailure == hookDraft || h.OnFailure == hookAbort) && h.When != hookPrePublish:
+			// After publishing, the idea is live: failing the job would
+			// report it as not published.
+			return nil, fmt.Errorf("%s: the %s policy only applies to pre-publish hooks", h.Name, h.OnFailure)
+		}
+	}
+	return hooks, nil
+}
+
+// call runs the hook with the idea as input and returns its output.
+func (h *hook) call(ctx context.Context, input []byte) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	if h.URL != "" {
+		req, err := http.NewRequestWithContext(ctx, "POST", h.URL, bytes.NewReader(input))
+		if err != nil {
+			return nil, fmt.Errorf("create request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		for k, v := range h.Headers {
+			req.Header.Set(k, v)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("send request: %w", err)
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+		if err != nil {
+			return nil, fmt.Errorf("read response: %w", err)
+		}
+		if resp.StatusCode/100 != 2 {
+			return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+		}
+		return body, nil
+	}
+
+	cmd := exec.CommandContext(ctx, h.Command[0], h.Command[1:]...)
+	cmd.Stdin = bytes.NewReader(input)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	// Children of the command may keep the pipes open after it is killed.
+	cmd.WaitDelay = time.Second
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("timed out after %s", h.timeout)
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%w: %s", err, msg)
+		}
+		return nil, err
+	}
+	return stdout.Bytes(), nil
+}
+
+// runHooks calls the hooks of the given hook point in order. Pre-publish
+// hooks may change the idea before it is published.
+func (s *service) runHooks(run *pipelineRun, when string) error {
+	var stageStarted bool
+	for _, h := range s.hooks {
+		if h.When != when {
+			continue
+		}
+		if !stageStarted {
+			s.jobs.stage(run.id, when)
+			stageStarted = true
+		}
+		in := run.hookIdea(when)
+		input, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("marshal hook input: %w", err)
+		}
+		out, err := h.call(run.ctx, input)
+		if err == nil && when == hookPrePublish && len(bytes.TrimSpace(out)) > 0 {
+			// Fields present in the reply override the input.
+			if err = json.Unmarshal(out, &in); err == nil {
+				run.applyHookIdea(in)
+			} else {
+				err = fmt.Errorf("invalid reply: %w", err)
+			}
+		}
+		if err == nil {
+			continue
+		}
+
+		err = fmt.Errorf("%s hook %s: %w", when, h.Name, err)
+		s.log.Print(err)
+		switch h.OnFailure {
+		case hookAbort:
+			return err
+		case hookDraft:
+			run.req.Draft = true
+			run.warnings = append(run.warnings, err.Error()+", saved as draft")
+		default:
+			run.warnings = append(run.warnings, err.Error())
+		}
+	}
+	return nil
+}
+
+func (run *pipelineRun) hookIdea(event string) hookIdea {
+	return hookIdea{
+		Event:       event,
+		ID:          run.id,
+		Title:       run.req.Title,
+		Content:     run.req.Content,
+		Tags:        run.req.Tags,
+		Draft:       run.req.Draft,
+		Lang:        run.lang,
+		TitleEn:     run.titleEn,
+		TitleZh:     run.titleZh,
+		ContentEn:   run.contentEn,
+		ContentZh:   run.contentZh,
+		AugmentedEn: run.augmentedEn,
+		AugmentedZh: run.augmentedZh,
+		Path:        run.path,
+		URL:         run.url,
+	}
+}
+
+func (run *pipelineRun) applyHookIdea(h hookIdea) {
+	run.req.Title = h.Title
+	run.req.Content = h.Content
+	run.req.Tags = h.Tags
+	run.req.Draft = h.Draft
+	run.titleEn, run.titleZh = h.TitleEn, h.TitleZh
+	run.contentEn, run.contentZh = h.ContentEn, h.ContentZh
+	run.augmentedEn, run.augmentedZh = h.AugmentedEn, h.AugmentedZh
+}
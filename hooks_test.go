@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadHooks(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		wantErr string
+	}{
+		{name: "valid", config: `[{"when": "pre-publish", "command": ["true"]}, {"when": "post-publish", "url": "http://x"}]`},
+		{name: "bad point", config: `[{"when": "later", "command": ["true"]}]`, wantErr: "when must be"},
+		{name: "no action", config: `[{"when": "pre-publish"}]`, wantErr: "exactly one"},
+		{name: "both actions", config: `[{"when": "pre-publish", "command": ["true"], "url": "http://x"}]`, wantErr: "exactly one"},
+		{name: "bad timeout", config: `[{"when": "pre-publish", "command": ["true"], "timeout": "soon"}]`, wantErr: "invalid timeout"},
+		{name: "draft after publish", config: `[{"when": "post-publish", "url": "http://x", "on_failure": "draft"}]`, wantErr: "only applies"},
+		{name: "abort after publish", config: `[{"when": "post-publish", "url": "http://x", "on_failure": "abort"}]`, wantErr: "only applies"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "hooks.json")
+			os.WriteFile(path, []byte(tt.config), 0o644)
+			hooks, err := loadHooks(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("loadHooks: %v", err)
+			}
+			if hooks[0].OnFailure != hookAbort || hooks[1].OnFailure != hookIgnore || hooks[0].timeout != 30*time.Second {
+				t.Errorf("defaults = %+v, %+v", hooks[0], hooks[1])
+			}
+		})
+	}
+}
+
+func TestRunHooks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	var got hookIdea
+	var auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		json.NewDecoder(r.Body).Decode(&got)
+		w.Write([]byte(`{"title": "ignored after publishing"}`))
+	}))
+	defer srv.Close()
+
+	l := log.New(io.Discard, "", 0)
+	s := &service{log: l, jobs: newJobStore(filepath.Join(t.TempDir(), "jobs.json"), l)}
+	newRun := func() *pipelineRun {
+		return &pipelineRun{
+			ctx:     context.Background(),
+			id:      "c1",
+			req:     ideaRequest{Title: "Idea", Content: "text"},
+			titleEn: "Idea",
+			path:    "content/ideas/x.md",
+		}
+	}
+	sh := func(script string) []string { return []string{"sh", "-c", script} }
+
+	s.hooks = []*hook{
+		{Name: "rename", When: hookPrePublish, Command: sh(`cat >/dev/null; echo '{"title_en": "Renamed", "tags": ["go"]}'`), timeout: 5 * time.Second, OnFailure: hookAbort},
+		{Name: "noop", When: hookPrePublish, Command: sh(`cat >/dev/null`), timeout: 5 * time.Second, OnFailure: hookAbort},
+		{Name: "announce", When: hookPostPublish, URL: srv.URL, Headers: map[string]string{"Authorization": "Bearer k"}, timeout: 5 * time.Second, OnFailure: hookIgnore},
+	}
+	run := newRun()
+	if err := s.runHooks(run, hookPrePublish); err != nil {
+		t.Fatalf("pre-publish: %v", err)
+	}
+	if run.titleEn != "Renamed" || run.req.Title != "Idea" || len(run.req.Tags) != 1 {
+		t.Errorf("after pre-publish: %+v %+v", run.req, run)
+	}
+	if err := s.runHooks(run, hookPostPublish); err != nil {
+		t.Fatalf("post-publish: %v", err)
+	}
+	if got.Event != hookPostPublish || got.TitleEn != "Renamed" || got.Path != "content/ideas/x.md" || auth != "Bearer k" {
+		t.Errorf("post-publish hook got %+v, auth %q", got, auth)
+	}
+	if run.titleEn != "Renamed" {
+		t.Error("post-publish replies must not change the idea")
+	}
+
+	fail := sh(`echo broken >&2; exit 3`)
+	tests := []struct {
+		policy    string
+		wantErr   bool
+		wantDraft bool
+	}{
+		{policy: hookAbort, wantErr: true},
+		{policy: hookIgnore},
+		{policy: hookDraft, wantDraft: true},
+	}
+	for _, tt := range tests {
+		s.hooks = []*hook{{Name: "lint", When: hookPrePublish, Command: fail, timeout: 5 * time.Second, OnFailure: tt.policy}}
+		run := newRun()
+		err := s.runHooks(run, hookPrePublish)
+		if (err != nil) != tt.wantErr || run.req.Draft != tt.wantDraft {
+			t.Errorf("%s: err = %v, draft = %v", tt.policy, err, run.req.Draft)
+		}
+		if !tt.wantErr && (len(run.warnings) != 1 || !strings.Contains(run.warnings[0], "broken")) {
+			t.Errorf("%s: warnings = %q", tt.policy, run.warnings)
+		}
+	}
+
+	s.hooks = []*hook{{Name: "slow", When: hookPrePublish, Command: sh("sleep 5"), timeout: 50 * time.Millisecond, OnFailure: hookAbort}}
+	if err := s.runHooks(newRun(), hookPrePublish); err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("slow hook: err = %v", err)
+	}
+}
@@ -5,9 +5,12 @@
 package main
 
 import (
+	"cmp"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"html"
 	"io"
 	"log"
 	"net/http"
@@ -18,15 +21,94 @@ import (
 )
 
 type service struct {
-	log    *log.Logger
-	llm    *llmClient
-	github *githubClient
+	log         *log.Logger
+	dataDir     string // local state such as feed positions
+	llm         *llmClient
+	stt         *sttClient
+	github      *githubClient
+	index       *archiveIndex
+	embeds      *embeddingStore
+	lifecycle   *lifecycleStore
+	feedback    *feedbackStore
+	suggestions *suggestionStore
+	drafts      *draftStore
+	pending     *pendingStore // of the ideas the CLI committed to the repository
+	jobs        *jobStore
+	status      statusHub       // clients of /ideas/ws
+	health      healthCache     // of /ideas/healthz
+	commits     *commitQueue    // nil if failed commits are not retried
+	pool        *workerPool     // runs the pipelines, nil to run each right away
+	maxBody     int64           // of idea request bodies, 0 for defaultMaxBody
+	halt        context.Context // done when shutdown interrupts the pipelines, nil if never
+	usage       *usageMeter
+	site        siteConfig
+	users       map[string]userSite // by user, nil in single-user mode
+	feedTitle   string              // of the Atom feed
+	feedAuthor  string
+	tax         *taxonomy       // nil if no category taxonomy is configured
+	slack       *slackClient    // nil if Slack intake is disabled
+	telegram    *telegramClient // nil if Telegram intake is disabled
+	comments    *commentWebhook // nil if comment ingestion is disabled
+	mastodon    *mastodonClient // nil if cross-posting is disabled
+	x           *xClient        // nil if posting threads to X is disabled
+	notifier    *notifier       // nil if push notifications are disabled
+	apiKey      string          // shared key for machine clients, optional
+	apiKeys     *apiKeyStore    // managed keys for machine clients
+	audit       *auditLog       // nil if actions are not recorded
+
+	// duplicateScore is the similarity to an earlier idea at which a
+	// posted idea is refused as a repeat, 0 to post repeats.
+	duplicateScore float64
+
+	bridgeLimit  *rateLimiter // per-client limit of the GET bridge
+	suggestLimit *rateLimiter // per-client limit of reader suggestions
+	publicLimit  *rateLimiter // per-client limit of the public endpoints
+	captcha      *turnstile   // nil if suggestions need no captcha
+
+	tokenBudget int // monthly LLM token budget shown on the dashboard, optional
+
+	pipelines map[string][]string // named pipelines, nil for the default only
+	hooks     []*hook             // external pre- and post-publish hooks
 }
 
 type ideaRequest struct {
-	Title     string `json:"title"`
-	Content   string `json:"content"`
-	Augmented string `json:"augmented"`
+	Title     string   `json:"title"`
+	Content   string   `json:"content"`
+	Augmented string   `json:"augmented"`
+	Draft     bool     `json:"draft"`
+	Tags      []string `json:"tags"`
+	// NoCrosspost skips announcing the idea on social media.
+	NoCrosspost bool `json:"no_crosspost"`
+	// NoTitle, NoTranslate, and NoAugment skip the LLM stages of the
+	// pipeline, to publish an idea as written.
+	NoTitle     bool `json:"no_title,omitempty"`
+	NoTranslate bool `json:"no_translate,omitempty"`
+	NoAugment   bool `json:"no_augment,omitempty"`
+	// NoTags skips suggesting tags for an idea posted without any.
+	NoTags bool `json:"no_tags,omitempty"`
+	// Pipeline names the configured pipeline to run, empty for the default.
+	Pipeline string `json:"pipeline,omitempty"`
+	// Lang is the language of the content, "en" or "zh", which decides
+	// the direction of translation. Empty or "auto" detects it.
+	Lang string `json:"lang,omitempty"`
+	// Review holds the idea before publishing until it is approved, with
+	// edits if need be, or discarded.
+	Review bool `json:"review,omitempty"`
+	// PublishAt schedules the idea, once processed, to be committed at
+	// that time rather than right away. It is also the idea's date.
+	PublishAt time.Time `json:"publish_at,omitzero"`
+	// AllowSecrets publishes the idea even though it seems to contain a
+	// credential, such as an example key that is not real.
+	AllowSecrets bool `json:"allow_secrets,omitempty"`
+	// AllowDuplicate posts the idea even though it seems to repeat an
+	// earlier one.
+	AllowDuplicate bool `json:"allow_duplicate,omitempty"`
+
+	// Options set by internal callers such as importers.
+	date        time.Time // original capture date, defaults to now
+	skipAugment bool
+	requestID   string // of the HTTP request that posted the idea
+	user        string // who posted it, whose blog it goes to
 }
 
 type ideaResponse struct {
@@ -34,33 +116,92 @@ type ideaResponse struct {
 	Message  string `json:"message,omitempty"`
 	Content  string `json:"content,omitempty"`
 	Filename string `json:"filename,omitempty"`
+	Job      string `json:"job,omitempty"` // ID of the job publishing the idea
 }
 
 func (s *service) handlePost(w http.ResponseWriter, r *http.Request) {
 	var req ideaRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		s.jsonError(w, "invalid request body", http.StatusBadRequest)
+	if !s.readIdea(w, r, &req) {
 		return
 	}
-	if req.Content == "" {
-		s.jsonError(w, "content is required", http.StatusBadRequest)
+	id, err := s.postIdea(r.Context(), req)
+	if err != nil {
+		if err.Code == codeBusy {
+			s.busy(w)
+			return
+		}
+		writeError(w, err.status, err.errorResponse)
 		return
 	}
 
-	// Accept immediately, process in background.
-	go s.processIdea(req)
-
 	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/ideas/admin/jobs/"+id)
+	w.WriteHeader(http.StatusAccepted)
 	json.NewEncoder(w).Encode(ideaResponse{
 		OK:      true,
 		Message: "idea accepted, publishing in background",
+		Job:     id,
 	})
 }
 
+// postIdea checks an idea posted by the caller of ctx and starts the job
+// publishing it, returning the job's ID.
+func (s *service) postIdea(ctx context.Context, req ideaRequest) (string, *requestError) {
+	if req.Content == "" {
+		return "", &requestError{http.StatusBadRequest, errorResponse{Message: "content is required"}}
+	}
+	if msg := s.checkIdea(&req); msg != "" {
+		return "", &requestError{http.StatusBadRequest, errorResponse{Message: msg}}
+	}
+	if err := checkSecrets(req.AllowSecrets, req.Title, req.Content, req.Augmented); err != nil {
+		return "", err
+	}
+	req.requestID = requestIDFromContext(ctx)
+	p, _ := principalFromContext(ctx)
+	req.user = p.User
+	if !req.AllowDuplicate {
+		// Failing to tell is no reason to refuse the idea.
+		dup, err := s.findDuplicate(ctx, req)
+		if err != nil {
+			s.log.Printf("duplicate check failed: %v", err)
+		}
+		if dup != nil {
+			msg := fmt.Sprintf("the idea seems to repeat %s %q (similarity %.2f); set allow_duplicate to post it anyway", dup.ID, dup.Title, dup.Score)
+			return "", &requestError{http.StatusConflict, errorResponse{Code: codeDuplicate, Message: msg, Duplicate: dup}}
+		}
+	}
+
+	// Accept immediately, process in background. The pipeline takes up to
+	// a minute or two, so the client follows the job instead.
+	id, ok := s.enqueueIdea(req)
+	if !ok {
+		return "", &requestError{http.StatusTooManyRequests, errorResponse{Code: codeBusy, Message: errBusy.Error()}}
+	}
+	return id, nil
+}
+
+// checkIdea validates the options of an idea request, normalizing "auto"
+// to detecting the language. It returns what is wrong, or "".
+func (s *service) checkIdea(req *ideaRequest) string {
+	if !s.hasPipeline(req.Pipeline) {
+		return "unknown pipeline"
+	}
+	switch req.Lang {
+	case "", "en", "zh":
+	case "auto":
+		req.Lang = ""
+	default:
+		return `lang must be "en", "zh", or "auto"`
+	}
+	if !req.PublishAt.IsZero() && !req.PublishAt.After(time.Now()) {
+		return "publish_at must be in the future"
+	}
+	return ""
+}
+
 func (s *service) handleImprove(w http.ResponseWriter, r *http.Request) {
 	var req ideaRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		s.jsonError(w, "invalid request body", http.StatusBadRequest)
+	if !s.readIdea(w, r, &req) {
 		return
 	}
 	if req.Content == "" {
@@ -71,7 +212,7 @@ func (s *service) handleImprove(w http.ResponseWriter, r *http.Request) {
 	improved, err := s.llm.improveContent(r.Context(), req.Content)
 	if err != nil {
 		s.log.Printf("content improvement failed: %v", err)
-		s.jsonError(w, "content improvement failed", http.StatusInternalServerError)
+		s.llmError(w, "content improvement failed", err)
 		return
 	}
 
@@ -79,157 +220,425 @@ func (s *service) handleImprove(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(ideaResponse{OK: true, Content: improved})
 }
 
-func (s *service) processIdea(req ideaRequest) {
+// published describes an idea that has been committed to the repository.
+type published struct {
+	path      string // repository file path
+	url       string // public URL on the site
+	commitURL string
+	queued    bool // committed later, as scheduled or after a failed commit
+}
+
+// processIdea runs the idea through its pipeline, by default fetching
+// linked pages, generating a title, translating, augmenting, publishing
+// to the repository, and announcing it. It waits for a worker of the
+// pool, however many ideas are queued.
+func (s *service) processIdea(req ideaRequest) (*published, error) {
+	id := s.startJob(req)
+	var (
+		p   *published
+		err error
+	)
+	s.pool.do(func() { p, err = s.runIdea(id, req) })
+	return p, err
+}
+
+// enqueueIdea starts the job publishing req and queues it for a worker
+// of the pool. It reports false, starting no job, if the queue is full.
+func (s *service) enqueueIdea(req ideaRequest) (string, bool) {
+	if !s.pool.reserve() {
+		return "", false
+	}
+	id := s.startJob(req)
+	s.pool.run(func() { s.runIdea(id, req) })
+	return id, true
+}
+
+// startJob records a newly captured idea and starts the job publishing
+// it, waiting for a worker. It returns the capture ID, which is also the
+// job ID.
+func (s *service) startJob(req ideaRequest) string {
+	captureID := s.lifecycle.capture()
+	s.jobs.start(captureID, req)
+	if s.pool != nil {
+		s.jobs.stage(captureID, "wait")
+	}
+	return captureID
+}
+
+// runIdea runs the pipeline of the job started for req. If the request
+// asks for review, it returns nil once the idea is held for it.
+func (s *service) runIdea(captureID string, req ideaRequest) (*published, error) {
+	run := &pipelineRun{id: captureID, req: req, enriched: req.Content}
+	return s.runStages(run, s.stagesFor(req))
+}
+
+// runStages runs the given stages of a pipeline run, holding it before
+// publishing if it awaits review.
+func (s *service) runStages(run *pipelineRun, stages []string) (*published, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
 	defer cancel()
-
-	// Fetch linked content if the idea contains URLs.
-	enriched := req.Content
-	if urls := extractURLs(req.Content); len(urls) > 0 {
-		for _, u := range urls {
-			s.log.Printf("fetching linked content: %s", u)
-			text, err := fetchURL(ctx, u)
-			if err != nil {
-				s.log.Printf("failed to fetch %s: %v", u, err)
-				continue
-			}
-			enriched += fmt.Sprintf("\n\n--- Linked content from %s ---\n%s", u, text)
+	// Calls made for the run carry the ID of the request that posted the
+	// idea, or else of the job.
+	run.ctx = withRequestID(withJobID(ctx, run.id), cmp.Or(run.req.requestID, run.id))
+
+	// Shutting down cancels the stages before publishing, whose work is
+	// redone after restart, but never a run that started publishing.
+	detach := func() bool { return true }
+	if s.halt != nil {
+		detach = context.AfterFunc(s.halt, cancel)
+	}
+	// A run resumed once its queued commit succeeded has published.
+	publishing := run.committed
+	if publishing {
+		detach()
+	}
+	for i, name := range stages {
+		run.rest = stages[i+1:]
+		if name == "publish" && run.req.Review && !run.reviewed {
+			s.holdForReview(run, stages[i:])
+			return nil, nil
+		}
+		if !publishing && (s.halted() || name == "publish" && !detach()) {
+			return nil, s.interrupt(run)
+		}
+		publishing = publishing || name == "publish"
+		err := pipelineStages[name](s, run)
+		if err != nil && !publishing && s.halted() {
+			return nil, s.interrupt(run)
+		}
+		if errors.Is(err, errScheduled) {
+			s.log.Printf("idea %s scheduled for %s", run.path, run.req.PublishAt.Format(time.RFC3339))
+			s.jobs.schedule(run.id, run.path, run.url, run.req.PublishAt)
+			return &published{path: run.path, url: run.url, queued: true}, nil
+		}
+		if errors.Is(err, errCommitQueued) {
+			s.log.Printf("idea %s queued: %v", run.path, err)
+			s.jobs.queue(run.id, run.path, run.url, err)
+			return &published{path: run.path, url: run.url, queued: true}, nil
+		}
+		if err != nil {
+			s.log.Printf("job %s (request %s): stage %s failed: %v", run.id, requestIDFromContext(run.ctx), name, err)
+			s.jobs.finish(run.id, nil, err)
+			s.auditPublished(run.id, run.req, run.path, "", err)
+			s.status.broadcast(ideaStatus{
+				Type:  statusFailed,
+				Job:   run.id,
+				Title: cmp.Or(run.titleEn, run.req.Title),
+				Error: err.Error(),
+				user:  run.req.user,
+			})
+			s.notifier.send(ctx, notification{
+				title:   "Idea failed to publish",
+				message: fmt.Sprintf("%s\n\n%v", cmp.Or(run.titleEn, run.req.Title, "Untitled"), err),
+				high:    true,
+			})
+			return nil, err
 		}
 	}
+	p := &published{path: run.path, url: run.url, commitURL: run.commitURL}
+	s.jobs.finish(run.id, p, nil)
+	s.auditPublished(run.id, run.req, run.path, run.commitSHA, nil)
+	s.status.broadcast(ideaStatus{
+		Type:  statusPublished,
+		Job:   run.id,
+		Title: cmp.Or(run.titleEn, run.req.Title),
+		Draft: run.req.Draft,
+		Path:  p.path,
+		URL:   p.url,
+		user:  run.req.user,
+	})
+	return p, nil
+}
 
-	// Generate title in original language if not provided.
-	if req.Title == "" {
-		s.log.Printf("generating title for idea...")
-		title, err := s.llm.generateTitle(ctx, enriched)
+// stageFetch appends the text of linked pages for the LLM stages.
+func (s *service) stageFetch(run *pipelineRun) error {
+	s.jobs.stage(run.id, "fetch")
+	for _, u := range extractURLs(run.req.Content) {
+		s.log.Printf("fetching linked content: %s", u)
+		text, err := fetchURL(run.ctx, u)
 		if err != nil {
-			s.log.Printf("title generation failed: %v", err)
-			req.Title = "Untitled"
-		} else {
-			req.Title = title
+			s.log.Printf("failed to fetch %s: %v", u, err)
+			continue
 		}
-		s.log.Printf("generated title: %s", req.Title)
+		run.enriched += fmt.Sprintf("\n\n--- Linked content from %s ---\n%s", u, text)
 	}
+	return nil
+}
 
-	// Detect language, polish, and translate title+content in one LLM call.
+// stageTitle generates a title in the original language if not provided.
+func (s *service) stageTitle(run *pipelineRun) error {
+	if run.req.Title != "" {
+		return nil
+	}
+	s.jobs.stage(run.id, "title")
+	s.log.Printf("generating title for idea...")
+	title, err := s.llm.generateTitle(run.ctx, run.enriched)
+	if err != nil {
+		s.log.Printf("title generation failed: %v", err)
+		run.req.Title = "Untitled"
+	} else {
+		run.req.Title = title
+		s.jobs.event(run.id, "title-generated", title)
+	}
+	s.log.Printf("generated title: %s", run.req.Title)
+	s.jobs.setTitle(run.id, run.req.Title)
+	return nil
+}
+
+// stageTranslate detects the language, unless given, polishes, and
+// translates title and content in one LLM call.
+func (s *service) stageTranslate(run *pipelineRun) error {
+	s.jobs.stage(run.id, "translate")
 	s.log.Printf("detecting language, polishing, and translating...")
-	tr, err := s.llm.detectAndTranslate(ctx, req.Title, req.Content)
-	var lang string
-	var titleEn, titleZh, contentEn, contentZh string
+	ctx, req := run.ctx, run.req
+	tr, err := s.llm.detectAndTranslate(ctx, req.Title, req.Content, req.Lang)
 	if err != nil {
 		s.log.Printf("detect+translate failed, falling back to separate translation: %v", err)
-		lang = detectLang(req.Content)
-		if lang == "en" {
-			titleEn = req.Title
-			contentEn = req.Content
-			titleZh, err = s.llm.translateContent(ctx, req.Title, "zh")
+		run.lang = cmp.Or(req.Lang, detectLang(req.Content))
+		s.jobs.event(run.id, "lang-detected", run.lang)
+		if run.lang == "en" {
+			run.titleEn = req.Title
+			run.contentEn = req.Content
+			run.titleZh, err = s.llm.translateContent(ctx, req.Title, "zh")
 			if err != nil {
 				s.log.Printf("title translation fallback failed: %v", err)
-				titleZh = req.Title
+				run.titleZh = req.Title
 			}
-			contentZh, err = s.llm.translateContent(ctx, req.Content, "zh")
+			run.contentZh, err = s.llm.translateContent(ctx, req.Content, "zh")
 			if err != nil {
 				s.log.Printf("content translation fallback failed: %v", err)
-				contentZh = req.Content
+				run.contentZh = req.Content
 			}
 		} else {
-			titleZh = req.Title
-			contentZh = req.Content
-			titleEn, err = s.llm.translateContent(ctx, req.Title, "en")
+			run.titleZh = req.Title
+			run.contentZh = req.Content
+			run.titleEn, err = s.llm.translateContent(ctx, req.Title, "en")
 			if err != nil {
 				s.log.Printf("title translation fallback failed: %v", err)
-				titleEn = req.Title
+				run.titleEn = req.Title
 			}
-			contentEn, err = s.llm.translateContent(ctx, req.Content, "en")
+			run.contentEn, err = s.llm.translateContent(ctx, req.Content, "en")
 			if err != nil {
 				s.log.Printf("content translation fallback failed: %v", err)
-				contentEn = req.Content
+				run.contentEn = req.Content
 			}
 		}
+		if err == nil {
+			s.jobs.event(run.id, "translated", otherLanguage(run.lang))
+		}
 	} else {
-		lang = tr.Lang
-		if lang == "en" {
-			titleEn = tr.PolishedTitle
-			titleZh = tr.TranslatedTitle
-			contentEn = tr.PolishedContent
-			contentZh = tr.TranslatedContent
+		run.lang = tr.Lang
+		s.jobs.event(run.id, "lang-detected", run.lang)
+		s.jobs.event(run.id, "polished", run.lang)
+		s.jobs.event(run.id, "translated", otherLanguage(run.lang))
+		if run.lang == "en" {
+			run.titleEn = tr.PolishedTitle
+			run.titleZh = tr.TranslatedTitle
+			run.contentEn = tr.PolishedContent
+			run.contentZh = tr.TranslatedContent
 		} else {
-			titleZh = tr.PolishedTitle
-			titleEn = tr.TranslatedTitle
-			contentZh = tr.PolishedContent
-			contentEn = tr.TranslatedContent
+			run.titleZh = tr.PolishedTitle
+			run.titleEn = tr.TranslatedTitle
+			run.contentZh = tr.PolishedContent
+			run.contentEn = tr.TranslatedContent
 		}
 	}
-	s.log.Printf("detected language: %s", lang)
-
-	// Generate short slug via LLM, fall back to mechanical slugify.
-	now := time.Now()
-	s.log.Printf("generating short slug...")
-	slug, err := s.llm.generateSlug(ctx, titleEn)
-	if err != nil {
-		s.log.Printf("LLM slug generation failed, using fallback: %v", err)
-		slug = slugify(titleEn)
-	}
-	s.log.Printf("slug: %s", slug)
-	filename := fmt.Sprintf("%s-%s.md", now.Format("2006-01-02"), slug)
+	s.log.Printf("detected language: %s", run.lang)
+	return nil
+}
 
-	// Augment in original language.
-	augmented := req.Augmented
-	if augmented == "" {
+// stageAugment augments the idea in its original language and translates
+// the augmentation.
+func (s *service) stageAugment(run *pipelineRun) error {
+	s.jobs.stage(run.id, "augment")
+	req := run.req
+	run.augmented = req.Augmented
+	if req.skipAugment {
+		s.log.Printf("skipping augmentation for: %s", req.Title)
+	} else if run.augmented == "" {
 		s.log.Printf("augmenting idea: %s", req.Title)
-		augmented, err = s.llm.augment(ctx, req.Title, enriched)
+		augmented, err := s.llm.augment(run.ctx, req.Title, run.enriched)
 		if err != nil {
 			s.log.Printf("LLM augmentation failed, publishing without augmentation: %v", err)
-			augmented = ""
+		} else {
+			run.augmented = augmented
+			s.lifecycle.augmented(run.id)
+			s.jobs.event(run.id, "augmented", "")
 		}
 	} else {
 		s.log.Printf("using provided augmented content for: %s", req.Title)
 	}
+	if run.augmented == "" {
+		return nil
+	}
 
-	// Translate augmented content.
-	var augmentedEn, augmentedZh string
-	if augmented != "" {
-		if lang == "en" {
-			augmentedEn = augmented
-		} else {
-			augmentedZh = augmented
-		}
-		otherLang := "zh"
-		if lang == "zh" {
-			otherLang = "en"
-		}
-		s.log.Printf("translating augmented content to %s...", otherLang)
-		translatedAugmented, err := s.llm.translateContent(ctx, augmented, otherLang)
+	lang := cmp.Or(run.lang, detectLang(req.Content))
+	otherLang := "zh"
+	if lang == "en" {
+		run.augmentedEn = run.augmented
+	} else {
+		run.augmentedZh = run.augmented
+		otherLang = "en"
+	}
+	s.log.Printf("translating augmented content to %s...", otherLang)
+	translated, err := s.llm.translateContent(run.ctx, run.augmented, otherLang)
+	if err != nil {
+		s.log.Printf("augmented translation failed: %v", err)
+	} else if lang == "en" {
+		run.augmentedZh = translated
+	} else {
+		run.augmentedEn = translated
+	}
+	return nil
+}
+
+// stagePublish commits the idea to the repository and indexes it,
+// running the pre- and post-publish hooks around it. Pipelines without
+// translation publish the original text in both languages. A run resumed
+// after its queued commit only runs the post-publish hooks.
+func (s *service) stagePublish(run *pipelineRun) error {
+	if run.committed {
+		return s.runHooks(run, hookPostPublish)
+	}
+	run.keepOriginal()
+	if err := s.runHooks(run, hookPrePublish); err != nil {
+		return err
+	}
+	ctx, req := run.ctx, run.req
+
+	// Generate short slug via LLM, fall back to mechanical slugify.
+	now := time.Now()
+	if !req.date.IsZero() {
+		now = req.date
+	}
+	scheduled := s.commits != nil && req.PublishAt.After(time.Now())
+	if scheduled {
+		now = req.PublishAt.Local()
+	}
+	s.jobs.stage(run.id, "slug")
+	slug := run.slug
+	if slug == "" {
+		s.log.Printf("generating short slug...")
+		var err error
+		slug, err = s.llm.generateSlug(ctx, run.titleEn)
 		if err != nil {
-			s.log.Printf("augmented translation failed: %v", err)
-		} else {
-			if lang == "en" {
-				augmentedZh = translatedAugmented
-			} else {
-				augmentedEn = translatedAugmented
-			}
+			s.log.Printf("LLM slug generation failed, using fallback: %v", err)
+			slug = slugify(run.titleEn)
 		}
 	}
+	s.log.Printf("slug: %s", slug)
+	filename := fmt.Sprintf("%s-%s.md", now.Format("2006-01-02"), slug)
+	md := s.runMarkdown(run, now, slug)
+	// Ideas go to a public repository, and the LLM stages may have
+	// quoted what the client sent.
+	if found := findSecrets(md); len(found) > 0 && !req.AllowSecrets {
+		return fmt.Errorf("%w (%s)", errSecretFound, strings.Join(found, ", "))
+	}
 
-	llmGenerated := req.Augmented == "" && augmented != ""
-	md := buildMarkdown(bilingualContent{
-		date:         now,
-		slug:         slug,
-		titleEn:      titleEn,
-		titleZh:      titleZh,
-		contentEn:    contentEn,
-		contentZh:    contentZh,
-		augmentedEn:  augmentedEn,
-		augmentedZh:  augmentedZh,
-		llmGenerated: llmGenerated,
-	})
+	us, own := s.userSite(req.user)
+	filePath := us.site.filePath(filename, req.Draft)
+	commitMsg := sanitizeCommitMsg(fmt.Sprintf("ideas: %s", run.titleEn))
+	if req.Draft {
+		commitMsg = sanitizeCommitMsg(fmt.Sprintf("ideas(draft): %s", run.titleEn))
+	}
+	run.path = filePath
+	run.url = us.site.url(slug)
+	c := queuedCommit{
+		Job:      run.id,
+		Path:     filePath,
+		Markdown: md,
+		Message:  commitMsg,
+		Title:    run.titleEn,
+		URL:      run.url,
+		Draft:    req.Draft,
+		User:     req.user,
+	}
+	if s.commits != nil {
+		c.Run = run.save()
+	}
+	if scheduled {
+		s.commits.schedule(c, req.PublishAt)
+		return errScheduled
+	}
+	s.jobs.stage(run.id, "commit")
+	fc, err := us.github.createFile(ctx, filePath, md, commitMsg)
+	if err != nil && s.commits != nil {
+		// Keep the work of the LLM stages rather than fail the run.
+		c.Error = err.Error()
+		s.commits.add(c)
+		return fmt.Errorf("%w: %w", errCommitQueued, err)
+	}
+	if err != nil {
+		return fmt.Errorf("GitHub commit failed: %w", err)
+	}
+	s.indexCommitted(ctx, run.id, own, filePath, md, fc)
+	run.commitURL, run.commitSHA = fc.CommitURL, fc.CommitSHA
+	s.log.Printf("idea published: %s", filePath)
+	return s.runHooks(run, hookPostPublish)
+}
 
-	filePath := "content/ideas/" + filename
-	commitMsg := sanitizeCommitMsg(fmt.Sprintf("ideas: %s", titleEn))
-	if err := s.github.createFile(ctx, filePath, md, commitMsg); err != nil {
-		s.log.Printf("GitHub commit failed: %v", err)
+// indexCommitted indexes the idea file just committed for the job,
+// embeds it, and records it as published. The index is of the server's
+// own repository, so ideas committed to another user's are not indexed.
+func (s *service) indexCommitted(ctx context.Context, id string, own bool, path, md string, fc *fileCommit) {
+	s.jobs.event(id, "committed", fc.CommitURL)
+	if !own {
 		return
 	}
-	s.log.Printf("idea published: %s", filename)
+	s.index.put(path, fc.SHA, md)
+	s.lifecycle.published(id, ideaID(path))
+	s.jobs.stage(id, "index")
+	d, ok := s.index.get(ideaID(path))
+	if !ok {
+		return
+	}
+	if err := s.embeds.embedIdea(ctx, s.llm, d); err != nil {
+		// The background refresh picks it up.
+		s.log.Printf("embedding %s failed: %v", d.ID, err)
+		s.embeds.kick()
+	}
+}
+
+// keepOriginal publishes the original text in both languages if the
+// pipeline did not translate it.
+func (run *pipelineRun) keepOriginal() {
+	if run.titleEn == "" && run.titleZh == "" {
+		title := cmp.Or(run.req.Title, "Untitled")
+		run.titleEn, run.titleZh = title, title
+		run.contentEn, run.contentZh = run.req.Content, run.req.Content
+	}
+}
+
+// runMarkdown returns the idea file of a run, dated date and with the
+// given slug.
+func (s *service) runMarkdown(run *pipelineRun, date time.Time, slug string) string {
+	req := run.req
+	var draftLine string
+	if req.Draft {
+		us, _ := s.userSite(req.user)
+		draftLine = us.site.draftFrontMatter()
+	}
+	categories := run.categories
+	if categories == nil {
+		categories = normalizeTags(req.Tags)
+		if s.tax != nil {
+			categories = s.tax.categorize(req.Tags)
+		}
+	}
+	return buildMarkdown(bilingualContent{
+		date:         date,
+		slug:         slug,
+		titleEn:      run.titleEn,
+		titleZh:      run.titleZh,
+		contentEn:    run.contentEn,
+		contentZh:    run.contentZh,
+		augmentedEn:  run.augmentedEn,
+		augmentedZh:  run.augmentedZh,
+		llmGenerated: req.Augmented == "" && run.augmented != "",
+		draftLine:    draftLine,
+		tags:         normalizeTags(req.Tags),
+		categories:   categories,
+	})
 }
 
 type bilingualContent struct {
@@ -242,8 +651,17 @@ type bilingualContent struct {
 	augmentedEn  string
 	augmentedZh  string
 	llmGenerated bool
+	draftLine    string // front matter line marking a draft, empty if published
+	tags         []string
+	categories   []string
 }
 
+// Disclaimers prepended to LLM-generated augmentations.
+const (
+	disclaimerEn = "*The following content is generated by LLMs and may contain inaccuracies.*\n\n"
+	disclaimerZh = "*以下内容由 LLM 生成，可能包含不准确之处。*\n\n"
+)
+
 func buildMarkdown(c bilingualContent) string {
 	var b strings.Builder
 	b.WriteString("---\n")
@@ -251,6 +669,13 @@ func buildMarkdown(c bilingualContent) string {
 	b.WriteString(fmt.Sprintf("slug: %q\n", c.slug))
 	b.WriteString(fmt.Sprintf("title: %q\n", c.titleEn))
 	b.WriteString(fmt.Sprintf("title_zh: %q\n", c.titleZh))
+	b.WriteString(c.draftLine)
+	if len(c.tags) > 0 {
+		b.WriteString(fmt.Sprintf("tags: %s\n", yamlList(c.tags)))
+	}
+	if len(c.categories) > 0 {
+		b.WriteString(fmt.Sprintf("categories: %s\n", yamlList(c.categories)))
+	}
 	b.WriteString("---\n\n")
 
 	// English block.
@@ -259,7 +684,7 @@ func buildMarkdown(c bilingualContent) string {
 	if c.augmentedEn != "" {
 		b.WriteString("\n\n{{% augmented %}}\n")
 		if c.llmGenerated {
-			b.WriteString("*The following content is generated by LLMs and may contain inaccuracies.*\n\n")
+			b.WriteString(disclaimerEn)
 		}
 		b.WriteString(c.augmentedEn)
 		b.WriteString("\n{{% /augmented %}}\n")
@@ -272,7 +697,7 @@ func buildMarkdown(c bilingualContent) string {
 	if c.augmentedZh != "" {
 		b.WriteString("\n\n{{% augmented %}}\n")
 		if c.llmGenerated {
-			b.WriteString("*以下内容由 LLM 生成，可能包含不准确之处。*\n\n")
+			b.WriteString(disclaimerZh)
 		}
 		b.WriteString(c.augmentedZh)
 		b.WriteString("\n{{% /augmented %}}\n")
@@ -282,6 +707,23 @@ func buildMarkdown(c bilingualContent) string {
 	return b.String()
 }
 
+// otherLanguage returns the language ideas in lang are translated to.
+func otherLanguage(lang string) string {
+	if lang == "zh" {
+		return "en"
+	}
+	return "zh"
+}
+
+// yamlList formats items as a YAML flow sequence of quoted strings.
+func yamlList(items []string) string {
+	quoted := make([]string, len(items))
+	for i, item := range items {
+		quoted[i] = fmt.Sprintf("%q", item)
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
+
 // detectLang guesses whether text is primarily Chinese or English
 // by checking if more than half the non-space runes are CJK.
 func detectLang(s string) string {
@@ -327,48 +769,81 @@ func extractURLs(s string) []string {
 }
 
 func fetchURL(ctx context.Context, url string) (string, error) {
+	_, text, err := fetchPage(ctx, url)
+	return text, err
+}
+
+// fetchPage fetches a web page and returns its title and a rough
+// plain-text extraction of its content.
+func fetchPage(ctx context.Context, url string) (title, text string, err error) {
 	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
 	defer cancel()
 
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
-		return "", err
+		return "", "", err
 	}
 	req.Header.Set("User-Agent", "ChangkunIdeasBot/1.0")
 
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
-		return "", err
+		return "", "", err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("HTTP %d", resp.StatusCode)
+		return "", "", fmt.Errorf("HTTP %d", resp.StatusCode)
 	}
 
 	body, err := io.ReadAll(io.LimitReader(resp.Body, 32*1024)) // 32KB max
 	if err != nil {
-		return "", err
+		return "", "", err
+	}
+
+	if m := htmlTitleRe.FindStringSubmatch(string(body)); m != nil {
+		title = html.UnescapeString(strings.Join(strings.Fields(m[1]), " "))
 	}
 
 	// Strip HTML tags for a rough plain-text extraction.
-	text := stripHTMLTags(string(body))
+	text = stripHTMLTags(string(body))
 	// Collapse whitespace.
 	text = strings.Join(strings.Fields(text), " ")
 	if len(text) > 4096 {
 		text = text[:4096]
 	}
-	return text, nil
+	return title, text, nil
 }
 
-var htmlTagRe = regexp.MustCompile(`<[^>]*>`)
+var (
+	htmlTagRe   = regexp.MustCompile(`<[^>]*>`)
+	htmlTitleRe = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+)
 
 func stripHTMLTags(s string) string {
 	return htmlTagRe.ReplaceAllString(s, " ")
 }
 
-func (s *service) jsonError(w http.ResponseWriter, msg string, code int) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(code)
-	json.NewEncoder(w).Encode(ideaResponse{OK: false, Message: msg})
+// defaultMaxBody is the size limit of idea request bodies unless
+// IDEAS_MAX_BODY_BYTES sets another.
+const defaultMaxBody = 256 << 10
+
+// readIdea decodes an idea request body into v, capped at maxBody so
+// that huge inputs reach neither the LLM nor the repository. If it
+// cannot, it responds with 413 or 400 and reports false.
+func (s *service) readIdea(w http.ResponseWriter, r *http.Request, v any) bool {
+	limit := cmp.Or(s.maxBody, defaultMaxBody)
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, limit)).Decode(v)
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge):
+		writeError(w, http.StatusRequestEntityTooLarge, errorResponse{
+			Message:  fmt.Sprintf("request body too large, max %d bytes", limit),
+			MaxBytes: limit,
+		})
+		return false
+	case err != nil:
+		s.jsonError(w, "invalid request body", http.StatusBadRequest)
+		return false
+	}
+	return true
 }
@@ -1,6 +1,73 @@
 package main
 
-import "testing"
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHandlePost(t *testing.T) {
+	l := log.New(io.Discard, "", 0)
+	dir := t.TempDir()
+	s := &service{
+		log:       l,
+		jobs:      newJobStore(filepath.Join(dir, "jobs.json"), l),
+		lifecycle: newLifecycleStore(filepath.Join(dir, "lifecycle.json"), l),
+		site:      newSiteConfig("", "", "", ""),
+		// Held for review before publishing, the idea needs no LLM or
+		// repository.
+		pipelines: map[string][]string{"default": {"detect-lang", "publish"}},
+	}
+
+	rec := httptest.NewRecorder()
+	s.handlePost(rec, httptest.NewRequest("POST", "/ideas/post", strings.NewReader(`{"title":"T","content":"An idea.","review":true}`)))
+	var resp ideaResponse
+	json.NewDecoder(rec.Body).Decode(&resp)
+	if rec.Code != http.StatusAccepted || !resp.OK || resp.Job == "" || rec.Header().Get("Location") != "/ideas/admin/jobs/"+resp.Job {
+		t.Fatalf("post = %d %+v, Location %q", rec.Code, resp, rec.Header().Get("Location"))
+	}
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		if j, _ := s.jobs.get(resp.Job); j.Status == jobReview {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the job was not run in the background")
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	s.handlePost(rec, httptest.NewRequest("POST", "/ideas/post", strings.NewReader(`{"content":""}`)))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("empty idea: status = %d, want 400", rec.Code)
+	}
+}
+
+func TestReadIdeaTooLarge(t *testing.T) {
+	s := &service{log: log.New(io.Discard, "", 0), maxBody: 64}
+	for _, tt := range []struct {
+		name string
+		h    http.HandlerFunc
+	}{
+		{"post", s.handlePost},
+		{"improve", s.handleImprove},
+		{"draft", s.handleSaveDraft},
+	} {
+		rec := httptest.NewRecorder()
+		body := `{"content":"` + strings.Repeat("x", 100) + `"}`
+		tt.h(rec, httptest.NewRequest("POST", "/", strings.NewReader(body)))
+		var resp errorResponse
+		json.NewDecoder(rec.Body).Decode(&resp)
+		if rec.Code != http.StatusRequestEntityTooLarge || resp.Code != codeTooLarge || resp.MaxBytes != 64 {
+			t.Errorf("%s: status = %d, body %+v; want 413 with max_bytes", tt.name, rec.Code, resp)
+		}
+	}
+}
 
 func TestDetectLang(t *testing.T) {
 	tests := []struct {
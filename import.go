@@ -0,0 +1,332 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// importedNote is a note parsed from an export of another app.
+type importedNote struct {
+	Title   string    `json:"title"`
+	Content string    `json:"-"`
+	Date    time.Time `json:"date,omitzero"`
+	Tags    []string  `json:"tags,omitempty"`
+}
+
+const (
+	maxImportSize  = 32 << 20
+	maxImportNotes = 500
+)
+
+// parseExport parses a Notion export zip, a zipped Obsidian vault, or
+// Apple Notes HTML into notes. The format is detected from the content.
+func parseExport(data []byte) ([]importedNote, error) {
+	if bytes.HasPrefix(data, []byte("PK\x03\x04")) {
+		return parseExportZip(data)
+	}
+	if looksLikeHTML(data) {
+		n, ok := parseHTMLNote("", string(data))
+		if !ok {
+			return nil, nil
+		}
+		return []importedNote{n}, nil
+	}
+	return []importedNote{parseMarkdownNote("", string(data))}, nil
+}
+
+func parseExportZip(data []byte) ([]importedNote, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("open zip: %w", err)
+	}
+
+	var notes []importedNote
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() || isHiddenPath(f.Name) {
+			continue
+		}
+		ext := strings.ToLower(path.Ext(f.Name))
+		if ext != ".md" && ext != ".html" && ext != ".htm" {
+			continue
+		}
+		if len(notes) == maxImportNotes {
+			return nil, fmt.Errorf("too many notes, at most %d are imported at once", maxImportNotes)
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("open %s: %w", f.Name, err)
+		}
+		content, err := io.ReadAll(io.LimitReader(rc, 1<<20))
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", f.Name, err)
+		}
+
+		var n importedNote
+		if ext == ".md" {
+			n = parseMarkdownNote(f.Name, string(content))
+		} else {
+			var ok bool
+			if n, ok = parseHTMLNote(f.Name, string(content)); !ok {
+				continue
+			}
+		}
+		if n.Date.IsZero() {
+			n.Date = f.Modified
+		}
+		if strings.TrimSpace(n.Content) != "" {
+			notes = append(notes, n)
+		}
+	}
+	return notes, nil
+}
+
+// isHiddenPath reports whether the path is inside an app folder such as
+// .obsidian/ or __MACOSX/.
+func isHiddenPath(name string) bool {
+	for _, part := range strings.Split(name, "/") {
+		if strings.HasPrefix(part, ".") || part == "__MACOSX" {
+			return true
+		}
+	}
+	return false
+}
+
+var notionIDRe = regexp.MustCompile(`\s+[0-9a-f]{32}$`)
+
+// parseMarkdownNote handles both Notion and Obsidian markdown. Notion
+// exports start with a "# Title" heading followed by "Key: value"
+// property lines and carry a 32-digit ID in the file name, which is how
+// they are told apart: in other notes, such lines are content. Obsidian
+// notes use the file name as title, optional YAML front matter, and
+// inline #tags.
+func parseMarkdownNote(name, md string) importedNote {
+	md = strings.ReplaceAll(md, "\r\n", "\n")
+	base := strings.TrimSuffix(path.Base(name), path.Ext(name))
+	notion := notionIDRe.MatchString(base)
+	n := importedNote{Title: notionIDRe.ReplaceAllString(base, "")}
+
+	if fm, body, ok := cutFrontMatter(md); ok {
+		md = body
+		for _, line := range strings.Split(fm, "\n") {
+			key, value, ok := strings.Cut(line, ":")
+			if !ok {
+				continue
+			}
+			value = strings.TrimSpace(value)
+			switch strings.ToLower(strings.TrimSpace(key)) {
+			case "title":
+				n.Title = unquoteYAML(value)
+			case "tags":
+				n.Tags = append(n.Tags, parseTagList(value)...)
+			case "date", "created":
+				if t, ok := parseNoteDate(unquoteYAML(value)); ok {
+					n.Date = t
+				}
+			}
+		}
+	}
+
+	lines := strings.Split(strings.TrimLeft(md, "\n"), "\n")
+	if len(lines) > 0 && strings.HasPrefix(lines[0], "# ") {
+		n.Title = strings.TrimSpace(lines[0][2:])
+		lines = lines[1:]
+
+		// Notion property block right below the title.
+		for notion && len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+			lines = lines[1:]
+		}
+		for notion && len(lines) > 0 {
+			key, value, ok := strings.Cut(lines[0], ": ")
+			if !ok || strings.ContainsAny(key, "#*[`") || len(key) > 32 {
+				break
+			}
+			switch strings.ToLower(key) {
+			case "tags", "tag":
+				n.Tags = append(n.Tags, parseTagList(value)...)
+			case "created", "created time", "date":
+				if t, ok := parseNoteDate(value); ok {
+					n.Date = t
+				}
+			}
+			lines = lines[1:]
+		}
+	}
+
+	n.Content = strings.TrimSpace(strings.Join(lines, "\n"))
+	for _, m := range inlineTagRe.FindAllStringSubmatch(n.Content, -1) {
+		n.Tags = append(n.Tags, m[1])
+	}
+	n.Tags = dedupTags(n.Tags)
+	return n
+}
+
+var inlineTagRe = regexp.MustCompile(`(?:^|\s)#([\p{L}\p{N}_/-]+)`)
+
+func cutFrontMatter(md string) (fm, body string, ok bool) {
+	if !strings.HasPrefix(md, "---\n") {
+		return "", md, false
+	}
+	fm, body, ok = strings.Cut(md[len("---\n"):], "\n---\n")
+	if !ok {
+		return "", md, false
+	}
+	return fm, body, true
+}
+
+// parseTagList parses "a, b", "[a, b]", or "#a #b".
+func parseTagList(s string) []string {
+	s = strings.Trim(strings.TrimSpace(s), "[]")
+	var tags []string
+	for _, t := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' }) {
+		t = strings.Trim(unquoteYAML(strings.TrimSpace(t)), "#")
+		if t != "" {
+			tags = append(tags, t)
+		}
+	}
+	return tags
+}
+
+func dedupTags(tags []string) []string {
+	var out []string
+	seen := map[string]bool{}
+	for _, t := range tags {
+		if k := normalizeTag(t); k != "" && !seen[k] {
+			seen[k] = true
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+var noteDateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"January 2, 2006 3:04 PM", // Notion
+	"January 2, 2006",
+}
+
+func parseNoteDate(s string) (time.Time, bool) {
+	s = strings.TrimSpace(s)
+	for _, layout := range noteDateLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func looksLikeHTML(data []byte) bool {
+	head := strings.ToLower(string(data[:min(len(data), 512)]))
+	return strings.Contains(head, "<html") || strings.Contains(head, "<!doctype html") || strings.Contains(head, "<body")
+}
+
+var (
+	htmlBlockEndRe = regexp.MustCompile(`(?i)<br\s*/?>|</(p|div|li|h[1-6]|tr|blockquote)>`)
+	htmlH1Re       = regexp.MustCompile(`(?is)<h1[^>]*>(.*?)</h1>`)
+	htmlScriptRe   = regexp.MustCompile(`(?is)<(script|style)[^>]*>.*?</(script|style)>`)
+	blankLinesRe   = regexp.MustCompile(`\n{3,}`)
+)
+
+// parseHTMLNote converts an Apple Notes (or Notion) HTML export into a
+// note. It reports false if the page has no text.
+func parseHTMLNote(name, doc string) (importedNote, bool) {
+	n := importedNote{Title: strings.TrimSuffix(path.Base(name), path.Ext(name))}
+	if m := htmlTitleRe.FindStringSubmatch(doc); m != nil {
+		n.Title = cmp.Or(htmlToText(m[1]), n.Title)
+	}
+	if m := htmlH1Re.FindStringSubmatch(doc); m != nil {
+		n.Title = cmp.Or(htmlToText(m[1]), n.Title)
+		doc = strings.Replace(doc, m[0], "", 1)
+	}
+	if _, body, ok := strings.Cut(doc, "<body"); ok {
+		if _, body, ok = strings.Cut(body, ">"); ok {
+			doc = body
+		}
+	}
+	n.Content = htmlToText(doc)
+	return n, n.Content != ""
+}
+
+func htmlToText(s string) string {
+	s = htmlScriptRe.ReplaceAllString(s, "")
+	s = htmlBlockEndRe.ReplaceAllString(s, "\n")
+	s = html.UnescapeString(htmlTagRe.ReplaceAllString(s, ""))
+	lines := strings.Split(s, "\n")
+	for i, l := range lines {
+		lines[i] = strings.Join(strings.Fields(l), " ")
+	}
+	s = strings.Join(lines, "\n")
+	s = blankLinesRe.ReplaceAllString(s, "\n\n")
+	return strings.TrimSpace(s)
+}
+
+type importResponse struct {
+	OK      bool           `json:"ok"`
+	Message string         `json:"message"`
+	Notes   []importedNote `json:"notes"`
+}
+
+// handleImport bulk-publishes an uploaded export. Query parameters:
+// augment=false publishes notes without LLM augmentation, and
+// draft=true imports them as drafts.
+func (s *service) handleImport(w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxImportSize))
+	if err != nil {
+		s.jsonError(w, "export too large or unreadable", http.StatusBadRequest)
+		return
+	}
+	notes, err := parseExport(data)
+	if err != nil {
+		s.jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(notes) == 0 {
+		s.jsonError(w, "no notes found in export", http.StatusBadRequest)
+		return
+	}
+
+	q := r.URL.Query()
+	augment := q.Get("augment") != "false"
+	draft := q.Get("draft") == "true"
+	go func() {
+		for i, n := range notes {
+			s.log.Printf("importing note %d/%d: %s", i+1, len(notes), n.Title)
+			if _, err := s.processIdea(ideaRequest{
+				Title:       n.Title,
+				Content:     n.Content,
+				Tags:        n.Tags,
+				Draft:       draft,
+				Pipeline:    "import",
+				date:        n.Date,
+				skipAugment: !augment,
+			}); err != nil {
+				s.log.Printf("import of %q failed: %v", n.Title, err)
+			}
+		}
+	}()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(importResponse{
+		OK:      true,
+		Message: fmt.Sprintf("%d notes accepted, publishing in background", len(notes)),
+		Notes:   notes,
+	})
+}
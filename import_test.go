@@ -0,0 +1,110 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestParseMarkdownNote(t *testing.T) {
+	tests := []struct {
+		name  string
+		file  string
+		input string
+		want  importedNote
+	}{
+		{
+			name: "notion",
+			file: "Export/Reward hacking 0123456789abcdef0123456789abcdef.md",
+			input: "# Reward hacking\n\nCreated: March 14, 2024 9:26 AM\nTags: AI, safety\n\n" +
+				"Models exploit the reward.",
+			want: importedNote{
+				Title:   "Reward hacking",
+				Content: "Models exploit the reward.",
+				Date:    time.Date(2024, 3, 14, 9, 26, 0, 0, time.Local),
+				Tags:    []string{"AI", "safety"},
+			},
+		},
+		{
+			name:  "obsidian with front matter and inline tags",
+			file:  "vault/inbox/Bayesian optimization.md",
+			input: "---\ntags: [research, \"bo\"]\ncreated: 2024-01-02\n---\nPreference learning #ml and #research.",
+			want: importedNote{
+				Title:   "Bayesian optimization",
+				Content: "Preference learning #ml and #research.",
+				Date:    time.Date(2024, 1, 2, 0, 0, 0, 0, time.Local),
+				Tags:    []string{"research", "bo", "ml"},
+			},
+		},
+		{
+			name:  "markdown with a heading and key-value lines",
+			file:  "vault/Launch.md",
+			input: "# Launch\n\nNote: ship it on Monday\nTODO: write the post\n\nMore.",
+			want: importedNote{
+				Title:   "Launch",
+				Content: "Note: ship it on Monday\nTODO: write the post\n\nMore.",
+			},
+		},
+		{
+			name:  "plain markdown",
+			file:  "note.md",
+			input: "Just a thought.",
+			want:  importedNote{Title: "note", Content: "Just a thought."},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseMarkdownNote(tt.file, tt.input)
+			if got.Title != tt.want.Title || got.Content != tt.want.Content ||
+				!got.Date.Equal(tt.want.Date) || !slices.Equal(got.Tags, tt.want.Tags) {
+				t.Errorf("mismatch\n got: %+v\nwant: %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseHTMLNote(t *testing.T) {
+	doc := `<html><head><title>Ignored</title><style>p{}</style></head><body>
+<h1>Grocery &amp; ideas</h1><div>First line</div><div><br></div><div>Second <b>bold</b> line</div></body></html>`
+	got, ok := parseHTMLNote("Notes/x.html", doc)
+	if !ok {
+		t.Fatal("parseHTMLNote reported no content")
+	}
+	if got.Title != "Grocery & ideas" {
+		t.Errorf("title = %q", got.Title)
+	}
+	if want := "First line\n\nSecond bold line"; got.Content != want {
+		t.Errorf("content = %q, want %q", got.Content, want)
+	}
+}
+
+func TestParseExportZip(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	files := map[string]string{
+		"vault/a.md":                  "Idea A",
+		"vault/.obsidian/config.json": "{}",
+		"vault/.obsidian/x.md":        "hidden",
+		"vault/empty.md":              "   ",
+		"vault/image.png":             "png",
+	}
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(content))
+	}
+	zw.Close()
+
+	notes, err := parseExport(buf.Bytes())
+	if err != nil {
+		t.Fatalf("parseExport: %v", err)
+	}
+	if len(notes) != 1 || notes[0].Title != "a" || notes[0].Content != "Idea A" {
+		t.Errorf("notes = %+v, want only vault/a.md", notes)
+	}
+}
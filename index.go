@@ -0,0 +1,402 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// archiveIndex is a local full-text index of the ideas in the target
+// repository. It is crawled on startup, kept up to date after each
+// publish, and persisted to the data directory so restarts only fetch
+// files whose blob SHA changed.
+type archiveIndex struct {
+	path string // index file
+	log  *log.Logger
+
+	mu       sync.RWMutex
+	docs     map[string]*indexedIdea   // by idea ID
+	postings map[string]map[string]int // term -> idea ID -> weight
+}
+
+// indexedIdea is an idea as stored in the index.
+type indexedIdea struct {
+	ID          string    `json:"id"` // file name without extension
+	Path        string    `json:"path"`
+	SHA         string    `json:"sha"`
+	Date        time.Time `json:"date"`
+	Slug        string    `json:"slug"`
+	Title       string    `json:"title"`
+	TitleZh     string    `json:"title_zh"`
+	Draft       bool      `json:"draft,omitempty"`
+	Tags        []string  `json:"tags,omitempty"`
+	Categories  []string  `json:"categories,omitempty"`
+	ContentEn   string    `json:"content_en"`
+	ContentZh   string    `json:"content_zh"`
+	AugmentedEn string    `json:"augmented_en,omitempty"`
+	AugmentedZh string    `json:"augmented_zh,omitempty"`
+}
+
+func newArchiveIndex(path string, l *log.Logger) *archiveIndex {
+	idx := &archiveIndex{
+		path:     path,
+		log:      l,
+		docs:     map[string]*indexedIdea{},
+		postings: map[string]map[string]int{},
+	}
+	var docs []*indexedIdea
+	if err := readJSONFile(path, &docs); err != nil {
+		l.Printf("cannot load index, rebuilding: %v", err)
+	}
+	for _, d := range docs {
+		idx.add(d)
+	}
+	return idx
+}
+
+// ideaID returns the ID of an idea from its repository path.
+func ideaID(p string) string {
+	return strings.TrimSuffix(path.Base(p), ".md")
+}
+
+// put parses and indexes the markdown of a freshly written idea file.
+func (idx *archiveIndex) put(p, sha, md string) {
+	doc, err := parseIdea(md)
+	if err != nil {
+		idx.log.Printf("cannot index %s: %v", p, err)
+		return
+	}
+	idx.mu.Lock()
+	idx.add(newIndexedIdea(p, sha, doc))
+	idx.mu.Unlock()
+	idx.save()
+}
+
+func newIndexedIdea(p, sha string, doc *ideaDoc) *indexedIdea {
+	return &indexedIdea{
+		ID:          ideaID(p),
+		Path:        p,
+		SHA:         sha,
+		Date:        doc.Date,
+		Slug:        doc.Slug,
+		Title:       doc.Title,
+		TitleZh:     doc.TitleZh,
+		Draft:       doc.Draft,
+		Tags:        doc.Tags,
+		Categories:  doc.Categories,
+		ContentEn:   doc.ContentEn,
+		ContentZh:   doc.ContentZh,
+		AugmentedEn: doc.AugmentedEn,
+		AugmentedZh: doc.AugmentedZh,
+	}
+}
+
+// add indexes d, replacing any previous version. Callers hold mu.
+func (idx *archiveIndex) add(d *indexedIdea) {
+	idx.remove(d.ID)
+	idx.docs[d.ID] = d
+	for term, w := range d.terms() {
+		if idx.postings[term] == nil {
+			idx.postings[term] = map[string]int{}
+		}
+		idx.postings[term][d.ID] = w
+	}
+}
+
+// remove drops the idea from the index. Callers hold mu.
+func (idx *archiveIndex) remove(id string) {
+	old, ok := idx.docs[id]
+	if !ok {
+		return
+	}
+	for term := range old.terms() {
+		delete(idx.postings[term], id)
+		if len(idx.postings[term]) == 0 {
+			delete(idx.postings, term)
+		}
+	}
+	delete(idx.docs, id)
+}
+
+// terms returns the weighted terms of an idea. Title matches count more
+// than body matches.
+func (d *indexedIdea) terms() map[string]int {
+	terms := map[string]int{}
+	for _, t := range tokenize(d.Title + " " + d.TitleZh) {
+		terms[t] += 3
+	}
+	for _, t := range tokenize(d.ContentEn + " " + d.ContentZh + " " + d.AugmentedEn + " " + d.AugmentedZh) {
+		terms[t]++
+	}
+	for _, c := range slices.Concat(d.Tags, d.Categories) {
+		terms[strings.ToLower(c)] += 2
+	}
+	return terms
+}
+
+// tokenize splits text into lowercase words. Runs of Han characters are
+// split into overlapping bigrams, which works reasonably well for
+// Chinese without a dictionary.
+func tokenize(s string) []string {
+	var tokens []string
+	var word, han []rune
+	flushWord := func() {
+		if len(word) > 1 {
+			tokens = append(tokens, string(word))
+		}
+		word = word[:0]
+	}
+	flushHan := func() {
+		if len(han) == 1 {
+			tokens = append(tokens, string(han))
+		}
+		for i := 0; i+1 < len(han); i++ {
+			tokens = append(tokens, string(han[i:i+2]))
+		}
+		han = han[:0]
+	}
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			flushWord()
+			han = append(han, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			flushHan()
+			word = append(word, r)
+		default:
+			flushWord()
+			flushHan()
+		}
+	}
+	flushWord()
+	flushHan()
+	return tokens
+}
+
+// hasTag reports whether the idea is tagged tag. Ideas published before
+// tags were written on their own have them as categories.
+func (d *indexedIdea) hasTag(tag string) bool {
+	tag = normalizeTag(tag)
+	return slices.Contains(d.Tags, tag) || slices.ContainsFunc(d.Categories, func(c string) bool { return normalizeTag(c) == tag })
+}
+
+// get returns the indexed idea with the given ID.
+func (idx *archiveIndex) get(id string) (*indexedIdea, bool) {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	d, ok := idx.docs[id]
+	return d, ok
+}
+
+// all returns all indexed ideas, newest first.
+func (idx *archiveIndex) all() []*indexedIdea {
+	idx.mu.RLock()
+	docs := make([]*indexedIdea, 0, len(idx.docs))
+	for _, d := range idx.docs {
+		docs = append(docs, d)
+	}
+	idx.mu.RUnlock()
+
+	slices.SortFunc(docs, func(a, b *indexedIdea) int { return strings.Compare(b.ID, a.ID) })
+	return docs
+}
+
+// indexHit is a search result.
+type indexHit struct {
+	Idea  *indexedIdea
+	Score float64
+}
+
+// search ranks ideas matching all query terms by TF-IDF.
+func (idx *archiveIndex) search(query string, limit int) []indexHit {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	terms := tokenize(query)
+	if len(terms) == 0 {
+		return nil
+	}
+	scores := map[string]float64{}
+	for i, term := range terms {
+		ids := idx.postings[term]
+		idf := math.Log(1 + float64(len(idx.docs))/float64(len(ids)+1))
+		next := map[string]float64{}
+		for id, w := range ids {
+			if _, ok := scores[id]; i == 0 || ok {
+				next[id] = scores[id] + float64(w)*idf
+			}
+		}
+		scores = next
+	}
+
+	hits := make([]indexHit, 0, len(scores))
+	for id, score := range scores {
+		hits = append(hits, indexHit{Idea: idx.docs[id], Score: score})
+	}
+	slices.SortFunc(hits, func(a, b indexHit) int {
+		if a.Score != b.Score {
+			if a.Score > b.Score {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(b.Idea.ID, a.Idea.ID)
+	})
+	if limit > 0 && len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits
+}
+
+// snippet returns an excerpt of at most width runes of text around the
+// first match of any of the query's terms, or else its start. Words cut
+// at either end are dropped.
+func snippet(text, query string, width int) string {
+	rs := []rune(strings.Join(strings.Fields(text), " "))
+	lower := make([]rune, len(rs))
+	for i, r := range rs {
+		lower[i] = unicode.ToLower(r)
+	}
+	at := -1
+	for _, term := range tokenize(query) {
+		if i := indexRunes(lower, []rune(term)); i >= 0 && (at < 0 || i < at) {
+			at = i
+		}
+	}
+	if len(rs) <= width {
+		return string(rs)
+	}
+	start := 0
+	if at > width/3 {
+		start = min(at-width/3, len(rs)-width)
+	}
+	end := start + width
+	if start > 0 && rs[start-1] != ' ' {
+		if i := slices.Index(rs[start:max(at, start)], ' '); i >= 0 {
+			start += i + 1
+		}
+	}
+	if end < len(rs) && rs[end] != ' ' {
+		for i := end - 1; i > max(at, start); i-- {
+			if rs[i] == ' ' {
+				end = i
+				break
+			}
+		}
+	}
+	s := string(rs[start:end])
+	if start > 0 {
+		s = "…" + s
+	}
+	if end < len(rs) {
+		s += "…"
+	}
+	return s
+}
+
+// indexRunes returns the index of the first sub in rs, or -1.
+func indexRunes(rs, sub []rune) int {
+	for i := 0; i+len(sub) <= len(rs); i++ {
+		if slices.Equal(rs[i:i+len(sub)], sub) {
+			return i
+		}
+	}
+	return -1
+}
+
+// sync crawls the given repository directories and updates the index
+// with added, changed, and deleted ideas.
+func (idx *archiveIndex) sync(ctx context.Context, gh *githubClient, dirs ...string) error {
+	present := map[string]bool{}
+	var fetched int
+	for _, dir := range dirs {
+		files, err := gh.listDir(ctx, dir)
+		if errors.Is(err, errDirNotFound) {
+			continue // e.g. no drafts yet
+		}
+		if err != nil {
+			return err
+		}
+		for _, f := range files {
+			if !strings.HasSuffix(f.Path, ".md") {
+				continue
+			}
+			id := ideaID(f.Path)
+			present[id] = true
+
+			idx.mu.RLock()
+			d, ok := idx.docs[id]
+			idx.mu.RUnlock()
+			if ok && d.SHA == f.SHA {
+				continue
+			}
+
+			md, _, err := gh.getFile(ctx, f.Path)
+			if err != nil {
+				return err
+			}
+			doc, err := parseIdea(md)
+			if err != nil {
+				idx.log.Printf("skipping unparsable idea %s: %v", f.Path, err)
+				continue
+			}
+			idx.mu.Lock()
+			idx.add(newIndexedIdea(f.Path, f.SHA, doc))
+			idx.mu.Unlock()
+			fetched++
+		}
+	}
+
+	idx.mu.Lock()
+	var removed int
+	for id := range idx.docs {
+		if !present[id] {
+			idx.remove(id)
+			removed++
+		}
+	}
+	total := len(idx.docs)
+	idx.mu.Unlock()
+
+	if fetched > 0 || removed > 0 {
+		idx.save()
+	}
+	idx.log.Printf("index synced: %d ideas, %d updated, %d removed", total, fetched, removed)
+	return nil
+}
+
+// run syncs the index now and then periodically, picking up edits made
+// directly in the repository.
+func (idx *archiveIndex) run(ctx context.Context, gh *githubClient, interval time.Duration, dirs ...string) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		if err := idx.sync(ctx, gh, dirs...); err != nil {
+			idx.log.Printf("index sync failed: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+func (idx *archiveIndex) save() {
+	docs := idx.all()
+	slices.Reverse(docs)
+	if err := writeJSONFile(idx.path, docs); err != nil {
+		idx.log.Printf("cannot save index: %v", err)
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"io"
+	"log"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{input: "Hello, World! a 42", want: []string{"hello", "world", "42"}},
+		{input: "语言模型", want: []string{"语言", "言模", "模型"}},
+		{input: "Go语言", want: []string{"go", "语言"}},
+		{input: "单", want: []string{"单"}},
+		{input: "", want: nil},
+	}
+	for _, tt := range tests {
+		got := tokenize(tt.input)
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("tokenize(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestArchiveIndexSearch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "index.json")
+	idx := newArchiveIndex(path, log.New(io.Discard, "", 0))
+	idx.put("content/ideas/2025-01-01-a.md", "sha-a", "---\ntitle: \"Reward hacking\"\n---\n\n{{% en %}}\nModels exploit rewards.\n{{% /en %}}\n\n{{% zh %}}\n模型利用奖励。\n{{% /zh %}}\n")
+	idx.put("content/ideas/2025-01-02-b.md", "sha-b", "---\ntitle: \"Bayesian optimization\"\n---\n\n{{% en %}}\nPreference learning and rewards.\n{{% /en %}}\n\n{{% zh %}}\n偏好学习。\n{{% /zh %}}\n")
+
+	ids := func(hits []indexHit) []string {
+		var ids []string
+		for _, h := range hits {
+			ids = append(ids, h.Idea.ID)
+		}
+		return ids
+	}
+	got := ids(idx.search("rewards", 0))
+	slices.Sort(got)
+	if !slices.Equal(got, []string{"2025-01-01-a", "2025-01-02-b"}) {
+		t.Errorf("search(rewards) = %q, want both ideas", got)
+	}
+	if got := ids(idx.search("reward hacking", 0)); !slices.Equal(got, []string{"2025-01-01-a"}) {
+		t.Errorf("search(reward hacking) = %q", got)
+	}
+	if got := ids(idx.search("偏好", 0)); !slices.Equal(got, []string{"2025-01-02-b"}) {
+		t.Errorf("search(偏好) = %q", got)
+	}
+
+	// Reloading from disk restores the postings.
+	reloaded := newArchiveIndex(path, log.New(io.Discard, "", 0))
+	if got := ids(reloaded.search("bayesian", 0)); !slices.Equal(got, []string{"2025-01-02-b"}) {
+		t.Errorf("reloaded search(bayesian) = %q", got)
+	}
+
+	// Replacing a document drops its old terms.
+	idx.put("content/ideas/2025-01-01-a.md", "sha-a2", "---\ntitle: \"Something else\"\n---\n")
+	if got := ids(idx.search("hacking", 0)); len(got) != 0 {
+		t.Errorf("search(hacking) after update = %q, want none", got)
+	}
+}
+
+func TestSnippet(t *testing.T) {
+	long := strings.Repeat("filler ", 20) + "Models exploit rewards." + strings.Repeat(" more", 20)
+	tests := []struct {
+		text, query string
+		want        string
+	}{
+		{"Short text.", "text", "Short text."},
+		{"Line one.\n\nLine two.", "two", "Line one. Line two."},
+		{long, "REWARDS", "…Models exploit rewards. more more more more more more more…"},
+		{long, "absent", "filler filler filler filler filler filler filler filler filler…"},
+		{"我们发现模型会利用奖励中的漏洞，" + strings.Repeat("然后", 40), "奖励", "我们发现模型会利用奖励中的漏洞，然后然后然后然后然后然后然后然后然后然后然后然后然后然后然后然后然后然后然后然后然后然后然后然后…"},
+	}
+	for _, tt := range tests {
+		if got := snippet(tt.text, tt.query, 64); got != tt.want {
+			t.Errorf("snippet(%q, %q) = %q, want %q", tt.text, tt.query, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,383 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"log"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Job states.
+const (
+	jobRunning   = "running"
+	jobReview    = "review" // held for review before publishing
+	jobDone      = "done"
+	jobFailed    = "failed"
+	jobDiscarded = "discarded" // in review
+	jobQueued    = "queued"    // the commit failed and is retried in the background
+	jobScheduled = "scheduled" // to be committed at its publish_at
+)
+
+// maxJobs is the number of most recent jobs kept in the job log.
+const maxJobs = 200
+
+// jobStore records each run of the publishing pipeline with per-stage
+// timing, so that failures can be inspected and retried.
+type jobStore struct {
+	path string
+	log  *log.Logger
+
+	mu      sync.Mutex
+	jobs    []*job        // oldest first
+	changed chan struct{} // closed and replaced whenever a job changes
+}
+
+type job struct {
+	ID        string      `json:"id"` // same as the lifecycle capture ID
+	Title     string      `json:"title"`
+	Status    string      `json:"status"`
+	Error     string      `json:"error,omitempty"`
+	Path      string      `json:"path,omitempty"` // published file
+	URL       string      `json:"url,omitempty"`
+	CommitURL string      `json:"commit_url,omitempty"`
+	Started   time.Time   `json:"started"`
+	Finished  time.Time   `json:"finished,omitzero"`
+	Stages    []jobStage  `json:"stages"`
+	Events    []jobEvent  `json:"events,omitempty"`
+	Tokens    int         `json:"tokens"`
+	PublishAt time.Time   `json:"publish_at,omitzero"` // if scheduled
+	Retried   bool        `json:"retried,omitempty"`   // a retry job was started
+	Resume    bool        `json:"resume,omitempty"`    // interrupted by shutdown, to run after restart
+	Preview   *jobPreview `json:"preview,omitempty"`   // while in review
+	Held      *savedRun   `json:"held,omitempty"`      // the run to resume once reviewed
+	Request   ideaRequest `json:"request,omitzero"`
+	RequestID string      `json:"request_id,omitempty"` // of the HTTP request that posted it
+	User      string      `json:"user,omitempty"`       // who posted it
+	// The options of Request that clients cannot set, which its JSON
+	// leaves out.
+	Date        time.Time `json:"date,omitzero"` // of the idea, if captured earlier
+	SkipAugment bool      `json:"skip_augment,omitempty"`
+}
+
+// request returns the request of the job, with the options its JSON
+// leaves out.
+func (j *job) request() ideaRequest {
+	req := j.Request
+	req.user, req.date, req.skipAugment = j.User, j.Date, j.SkipAugment
+	return req
+}
+
+type jobStage struct {
+	Name     string        `json:"name"`
+	Started  time.Time     `json:"started"`
+	Duration time.Duration `json:"duration"` // zero while running
+}
+
+// jobEvent is a milestone of a job within its stages, such as
+// "lang-detected" or "committed", with what it found or made.
+type jobEvent struct {
+	Name   string    `json:"name"`
+	Time   time.Time `json:"time"`
+	Detail string    `json:"detail,omitempty"` // e.g. the language, title, or commit URL
+}
+
+// duration is the total run time, or the time so far if still running.
+func (j *job) duration() time.Duration {
+	if j.Finished.IsZero() {
+		return time.Since(j.Started)
+	}
+	return j.Finished.Sub(j.Started)
+}
+
+func newJobStore(path string, l *log.Logger) *jobStore {
+	js := &jobStore{path: path, log: l, changed: make(chan struct{})}
+	if err := readJSONFile(path, &js.jobs); err != nil {
+		l.Printf("cannot load jobs: %v", err)
+	}
+	// Jobs that were running when the service stopped never finish,
+	// unless shutdown interrupted them to resume. Those held for review
+	// wait on, if their run was saved.
+	for _, j := range js.jobs {
+		if j.Status == jobRunning && !j.Resume || j.Status == jobReview && j.Held == nil {
+			j.Status = jobFailed
+			j.Error = "interrupted by service restart"
+		}
+	}
+	return js
+}
+
+// updates returns a channel that is closed when a job next changes.
+func (js *jobStore) updates() <-chan struct{} {
+	js.mu.Lock()
+	defer js.mu.Unlock()
+	return js.changed
+}
+
+// notify wakes those waiting for updates. Callers hold mu.
+func (js *jobStore) notify() {
+	close(js.changed)
+	js.changed = make(chan struct{})
+}
+
+// save persists the store. Callers hold mu.
+func (js *jobStore) save() {
+	if err := writeJSONFile(js.path, js.jobs); err != nil {
+		js.log.Printf("cannot save jobs: %v", err)
+	}
+}
+
+func (js *jobStore) start(id string, req ideaRequest) {
+	js.mu.Lock()
+	defer js.mu.Unlock()
+	js.jobs = append(js.jobs, &job{
+		ID:          id,
+		Title:       req.Title,
+		Status:      jobRunning,
+		Started:     time.Now(),
+		Request:     req,
+		RequestID:   req.requestID,
+		User:        req.user,
+		Date:        req.date,
+		SkipAugment: req.skipAugment,
+	})
+	if len(js.jobs) > maxJobs {
+		js.jobs = slices.Delete(js.jobs, 0, len(js.jobs)-maxJobs)
+	}
+	js.notify()
+	js.save()
+}
+
+// stage ends the current stage of the job and starts the named one.
+func (js *jobStore) stage(id, name string) {
+	js.mu.Lock()
+	defer js.mu.Unlock()
+	j := js.getLocked(id)
+	if j == nil {
+		return
+	}
+	now := time.Now()
+	endStage(j, now)
+	j.Stages = append(j.Stages, jobStage{Name: name, Started: now})
+	js.notify()
+}
+
+// event records a milestone of the job.
+func (js *jobStore) event(id, name, detail string) {
+	js.mu.Lock()
+	defer js.mu.Unlock()
+	if j := js.getLocked(id); j != nil {
+		j.Events = append(j.Events, jobEvent{Name: name, Time: time.Now(), Detail: detail})
+		js.notify()
+	}
+}
+
+// setTitle updates the title shown for the job once it is generated.
+func (js *jobStore) setTitle(id, title string) {
+	js.mu.Lock()
+	defer js.mu.Unlock()
+	if j := js.getLocked(id); j != nil {
+		j.Title = title
+		js.notify()
+	}
+}
+
+// hold marks the job as held for review with the preview of the idea and
+// the run to resume once approved.
+func (js *jobStore) hold(id string, p jobPreview, run *savedRun) {
+	js.mu.Lock()
+	defer js.mu.Unlock()
+	j := js.getLocked(id)
+	if j == nil {
+		return
+	}
+	endStage(j, time.Now())
+	j.Status = jobReview
+	j.Preview, j.Held = &p, run
+	js.notify()
+	js.save()
+}
+
+// resume marks a job held for review as running again, or as discarded.
+// It reports false if the job is not in review.
+func (js *jobStore) resume(id string, discard bool) bool {
+	js.mu.Lock()
+	defer js.mu.Unlock()
+	j := js.getLocked(id)
+	if j == nil || j.Status != jobReview {
+		return false
+	}
+	j.Status, j.Preview, j.Held = jobRunning, nil, nil
+	if discard {
+		j.Status, j.Finished = jobDiscarded, time.Now()
+	}
+	js.notify()
+	js.save()
+	return true
+}
+
+// queue marks the job as waiting for its commit, of the idea to be
+// published at path and url, to be retried after err.
+func (js *jobStore) queue(id, path, url string, err error) {
+	js.mu.Lock()
+	defer js.mu.Unlock()
+	j := js.getLocked(id)
+	if j == nil {
+		return
+	}
+	endStage(j, time.Now())
+	j.Status, j.Path, j.URL, j.Error = jobQueued, path, url, err.Error()
+	js.notify()
+	js.save()
+}
+
+// schedule marks the job as waiting to publish the idea at path and url
+// at the given time.
+func (js *jobStore) schedule(id, path, url string, at time.Time) {
+	js.mu.Lock()
+	defer js.mu.Unlock()
+	j := js.getLocked(id)
+	if j == nil {
+		return
+	}
+	endStage(j, time.Now())
+	j.Status, j.Path, j.URL, j.PublishAt = jobScheduled, path, url, at
+	js.notify()
+	js.save()
+}
+
+// finish marks the job as done with the published idea, or failed if
+// err is non-nil.
+func (js *jobStore) finish(id string, p *published, err error) {
+	js.mu.Lock()
+	defer js.mu.Unlock()
+	j := js.getLocked(id)
+	if j == nil {
+		return
+	}
+	j.Finished = time.Now()
+	endStage(j, j.Finished)
+	if p != nil {
+		j.Path, j.URL, j.CommitURL = p.path, p.url, p.commitURL
+	}
+	j.Status, j.Error = jobDone, ""
+	if err != nil {
+		j.Status = jobFailed
+		j.Error = err.Error()
+	}
+	js.notify()
+	js.save()
+}
+
+func endStage(j *job, now time.Time) {
+	if n := len(j.Stages); n > 0 && j.Stages[n-1].Duration == 0 {
+		j.Stages[n-1].Duration = now.Sub(j.Stages[n-1].Started)
+	}
+}
+
+func (js *jobStore) addTokens(id string, n int) {
+	js.mu.Lock()
+	defer js.mu.Unlock()
+	if j := js.getLocked(id); j != nil {
+		j.Tokens += n
+	}
+}
+
+// interrupt marks a running job to be resumed after restart.
+func (js *jobStore) interrupt(id string) {
+	js.mu.Lock()
+	defer js.mu.Unlock()
+	j := js.getLocked(id)
+	if j == nil {
+		return
+	}
+	endStage(j, time.Now())
+	j.Resume = true
+	j.Events = append(j.Events, jobEvent{Name: "interrupted", Time: time.Now()})
+	js.notify()
+	js.save()
+}
+
+// interrupted returns the jobs interrupted by the last shutdown, no
+// longer marked to be resumed.
+func (js *jobStore) interrupted() []job {
+	js.mu.Lock()
+	defer js.mu.Unlock()
+	var out []job
+	for _, j := range js.jobs {
+		if j.Status == jobRunning && j.Resume {
+			j.Resume = false
+			out = append(out, *j)
+		}
+	}
+	if out != nil {
+		js.save()
+	}
+	return out
+}
+
+// retry marks a failed job as retried and returns its request. It
+// reports false if the job does not exist or cannot be retried.
+func (js *jobStore) retry(id string) (ideaRequest, bool) {
+	js.mu.Lock()
+	defer js.mu.Unlock()
+	j := js.getLocked(id)
+	if j == nil || j.Status != jobFailed || j.Retried {
+		return ideaRequest{}, false
+	}
+	j.Retried = true
+	js.save()
+	return j.request(), true
+}
+
+func (js *jobStore) getLocked(id string) *job {
+	for i := len(js.jobs) - 1; i >= 0; i-- {
+		if js.jobs[i].ID == id {
+			return js.jobs[i]
+		}
+	}
+	return nil
+}
+
+// get returns a copy of the job with the given ID.
+func (js *jobStore) get(id string) (job, bool) {
+	js.mu.Lock()
+	defer js.mu.Unlock()
+	j := js.getLocked(id)
+	if j == nil {
+		return job{}, false
+	}
+	c := *j
+	c.Stages = slices.Clone(j.Stages)
+	c.Events = slices.Clone(j.Events)
+	return c, true
+}
+
+// recent returns copies of the jobs, newest first.
+func (js *jobStore) recent() []job {
+	js.mu.Lock()
+	defer js.mu.Unlock()
+	jobs := make([]job, len(js.jobs))
+	for i, j := range js.jobs {
+		jobs[len(jobs)-1-i] = *j
+		jobs[len(jobs)-1-i].Stages = slices.Clone(j.Stages)
+	}
+	return jobs
+}
+
+type jobIDKey struct{}
+
+// withJobID attributes work done with ctx, such as LLM token usage, to
+// the given job.
+func withJobID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, jobIDKey{}, id)
+}
+
+func jobIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(jobIDKey{}).(string)
+	return id
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJobStore(t *testing.T) {
+	l := log.New(io.Discard, "", 0)
+	path := filepath.Join(t.TempDir(), "jobs.json")
+	js := newJobStore(path, l)
+	m := newUsageMeter(filepath.Join(t.TempDir(), "usage.json"), l, js)
+
+	js.start("a", ideaRequest{Content: "first"})
+	js.stage("a", "title")
+	js.setTitle("a", "First")
+	js.stage("a", "commit")
+	m.record(withJobID(context.Background(), "a"), "model", tokenUsage{PromptTokens: 10, CompletionTokens: 5})
+	js.finish("a", nil, errors.New("commit failed"))
+
+	js.start("b", ideaRequest{Content: "second"})
+
+	jobs := js.recent()
+	if len(jobs) != 2 || jobs[0].ID != "b" {
+		t.Fatalf("recent = %+v, want b before a", jobs)
+	}
+	a := jobs[1]
+	if a.Status != jobFailed || a.Title != "First" || a.Tokens != 15 || len(a.Stages) != 2 {
+		t.Errorf("job a = %+v", a)
+	}
+	for _, s := range a.Stages {
+		if s.Duration <= 0 {
+			t.Errorf("stage %s has no duration", s.Name)
+		}
+	}
+
+	if _, ok := js.retry("b"); ok {
+		t.Error("running job must not be retryable")
+	}
+	req, ok := js.retry("a")
+	if !ok || req.Content != "first" {
+		t.Errorf("retry(a) = %+v, %v", req, ok)
+	}
+	if _, ok := js.retry("a"); ok {
+		t.Error("job must only be retried once")
+	}
+
+	// Running jobs are failed on reload.
+	reloaded := newJobStore(path, l)
+	if got := reloaded.recent()[0]; got.ID != "b" || got.Status != jobFailed {
+		t.Errorf("reloaded job b = %+v, want failed", got)
+	}
+
+	// Options clients cannot set survive a reload, as for an import.
+	date := time.Date(2020, 1, 1, 8, 0, 0, 0, time.UTC)
+	js.start("c", ideaRequest{Content: "imported", date: date, skipAugment: true, user: "alice"})
+	js.finish("c", nil, errors.New("commit failed"))
+	req, ok = newJobStore(path, l).retry("c")
+	if !ok || !req.date.Equal(date) || !req.skipAugment || req.user != "alice" {
+		t.Errorf("retry(c) after reload = %+v, %v", req, ok)
+	}
+
+	if got := m.summary("").Total; got.Requests != 1 || got.Tokens() != 15 {
+		t.Errorf("usage = %+v", got)
+	}
+}
+
+func TestHandleGetJob(t *testing.T) {
+	l := log.New(io.Discard, "", 0)
+	s := &service{log: l, jobs: newJobStore(filepath.Join(t.TempDir(), "jobs.json"), l)}
+	s.jobs.start("a", ideaRequest{Content: "idea"})
+	s.jobs.stage("a", "commit")
+	s.jobs.finish("a", &published{path: "content/ideas/a.md", url: "https://example.com/a", commitURL: "https://github.com/o/r/commit/c"}, nil)
+
+	get := func(id string) (int, job) {
+		r := httptest.NewRequest("GET", "/ideas/admin/jobs/"+id, nil)
+		r.SetPathValue("id", id)
+		rec := httptest.NewRecorder()
+		s.handleGetJob(rec, r)
+		var resp struct {
+			Job job `json:"job"`
+		}
+		json.NewDecoder(rec.Body).Decode(&resp)
+		return rec.Code, resp.Job
+	}
+	code, j := get("a")
+	if code != http.StatusOK || j.Status != jobDone || j.Path != "content/ideas/a.md" || j.CommitURL == "" || len(j.Stages) != 1 {
+		t.Errorf("get = %d %+v", code, j)
+	}
+	if code, _ := get("missing"); code != http.StatusNotFound {
+		t.Errorf("missing job: status = %d, want 404", code)
+	}
+}
+
+func TestHandleJobEvents(t *testing.T) {
+	l := log.New(io.Discard, "", 0)
+	s := &service{log: l, jobs: newJobStore(filepath.Join(t.TempDir(), "jobs.json"), l)}
+	s.jobs.start("a", ideaRequest{Content: "secret"})
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		s.jobs.stage("a", "title")
+		s.jobs.event("a", "title-generated", "T")
+		s.jobs.stage("a", "commit")
+		s.jobs.event("a", "committed", "https://github.com/o/r/commit/c")
+		s.jobs.finish("a", &published{path: "content/ideas/a.md"}, nil)
+	}()
+
+	r := httptest.NewRequest("GET", "/ideas/admin/jobs/a/events", nil)
+	r.SetPathValue("id", "a")
+	rec := httptest.NewRecorder()
+	s.handleJobEvents(rec, r) // returns once the job is done
+
+	var events []job
+	var milestones []jobEvent
+	for block := range strings.SplitSeq(rec.Body.String(), "\n\n") {
+		name, data := "", ""
+		for line := range strings.Lines(block) {
+			if v, ok := strings.CutPrefix(line, "event: "); ok {
+				name = strings.TrimSpace(v)
+			}
+			if v, ok := strings.CutPrefix(line, "data: "); ok {
+				data = v
+			}
+		}
+		switch {
+		case data == "":
+		case name != "":
+			var e jobEvent
+			if err := json.Unmarshal([]byte(data), &e); err != nil || e.Name != name {
+				t.Fatalf("%s event %q: %v", name, data, err)
+			}
+			milestones = append(milestones, e)
+		default:
+			var j job
+			if err := json.Unmarshal([]byte(data), &j); err != nil {
+				t.Fatalf("event %q: %v", data, err)
+			}
+			events = append(events, j)
+		}
+	}
+	if len(milestones) != 2 || milestones[0].Detail != "T" || milestones[1].Name != "committed" {
+		t.Errorf("milestones = %+v", milestones)
+	}
+	if len(events) < 2 {
+		t.Fatalf("got %d events, want the start and the end at least", len(events))
+	}
+	if first := events[0]; first.Status != jobRunning {
+		t.Errorf("first event = %+v, want running", first)
+	}
+	if last := events[len(events)-1]; last.Status != jobDone || last.Path != "content/ideas/a.md" || len(last.Stages) != 2 || len(last.Events) != 2 {
+		t.Errorf("last event = %+v", last)
+	}
+	if strings.Contains(rec.Body.String(), "secret") {
+		t.Error("events must not carry the request")
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+}
+
+func TestAdminTemplate(t *testing.T) {
+	js := newJobStore(filepath.Join(t.TempDir(), "jobs.json"), log.New(io.Discard, "", 0))
+	js.start("a", ideaRequest{Title: "<b>Idea</b>"})
+	js.stage("a", "commit")
+	js.finish("a", nil, errors.New("boom"))
+
+	var b strings.Builder
+	err := adminTmpl.Execute(&b, adminStatus{
+		Jobs:     js.recent(),
+		Failures: js.recent(),
+		Month: usageSummary{
+			Total:   tokenUsage{Requests: 1, PromptTokens: 3},
+			ByModel: map[string]tokenUsage{"m": {Requests: 1, PromptTokens: 3}},
+		},
+		Budget: 100,
+	})
+	if err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	out := b.String()
+	for _, want := range []string{"&lt;b&gt;Idea&lt;/b&gt;", "/ideas/admin/jobs/a/retry", "boom", "3 of 100 tokens"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("dashboard misses %q", want)
+		}
+	}
+}
@@ -0,0 +1,338 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// lifecycleStore tracks each idea through its lifecycle:
+// captured → augmented → published → expanded into a full post.
+type lifecycleStore struct {
+	path string
+	log  *log.Logger
+
+	mu      sync.Mutex
+	records map[string]*lifecycleRecord // by capture ID
+	byIdea  map[string]*lifecycleRecord // of the published, by idea ID
+	scanned map[string]string           // post path -> blob SHA already scanned
+}
+
+type lifecycleRecord struct {
+	CaptureID   string    `json:"capture_id"`
+	IdeaID      string    `json:"idea_id,omitempty"`
+	CapturedAt  time.Time `json:"captured_at"`
+	AugmentedAt time.Time `json:"augmented_at,omitzero"`
+	PublishedAt time.Time `json:"published_at,omitzero"`
+	ExpandedAt  time.Time `json:"expanded_at,omitzero"`
+	ExpandedIn  string    `json:"expanded_in,omitempty"` // post path or URL
+}
+
+type lifecycleFile struct {
+	Records []*lifecycleRecord `json:"records"`
+	Scanned map[string]string  `json:"scanned"`
+}
+
+func newLifecycleStore(path string, l *log.Logger) *lifecycleStore {
+	ls := &lifecycleStore{
+		path:    path,
+		log:     l,
+		records: map[string]*lifecycleRecord{},
+		byIdea:  map[string]*lifecycleRecord{},
+		scanned: map[string]string{},
+	}
+	var f lifecycleFile
+	if err := readJSONFile(path, &f); err != nil {
+		l.Printf("cannot load lifecycle: %v", err)
+	}
+	for _, r := range f.Records {
+		ls.records[r.CaptureID] = r
+		if r.IdeaID != "" {
+			ls.byIdea[r.IdeaID] = r
+		}
+	}
+	if f.Scanned != nil {
+		ls.scanned = f.Scanned
+	}
+	return ls
+}
+
+// save persists the store. Callers hold mu.
+func (ls *lifecycleStore) save() {
+	f := lifecycleFile{Scanned: ls.scanned}
+	for _, r := range ls.records {
+		f.Records = append(f.Records, r)
+	}
+	slices.SortFunc(f.Records, func(a, b *lifecycleRecord) int {
+		return a.CapturedAt.Compare(b.CapturedAt)
+	})
+	if err := writeJSONFile(ls.path, f); err != nil {
+		ls.log.Printf("cannot save lifecycle: %v", err)
+	}
+}
+
+// capture records a newly captured idea and returns its capture ID.
+func (ls *lifecycleStore) capture() string {
+	var b [8]byte
+	rand.Read(b[:])
+	id := hex.EncodeToString(b[:])
+
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	ls.records[id] = &lifecycleRecord{CaptureID: id, CapturedAt: time.Now()}
+	ls.save()
+	return id
+}
+
+func (ls *lifecycleStore) augmented(captureID string) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	if r, ok := ls.records[captureID]; ok {
+		r.AugmentedAt = time.Now()
+		ls.save()
+	}
+}
+
+func (ls *lifecycleStore) published(captureID, ideaID string) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	if r, ok := ls.records[captureID]; ok {
+		r.IdeaID = ideaID
+		r.PublishedAt = time.Now()
+		ls.byIdea[ideaID] = r
+		ls.save()
+	}
+}
+
+// backfill adds records for the published ideas that have none, such as
+// those published before lifecycle tracking or outside the service.
+func (ls *lifecycleStore) backfill(ideas []*indexedIdea) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	n := len(ls.byIdea)
+	for _, idea := range ideas {
+		if !idea.Draft {
+			ls.byIdeaLocked(idea)
+		}
+	}
+	if len(ls.byIdea) != n {
+		ls.save()
+	}
+}
+
+// expanded marks the idea as expanded into the given post. Ideas that
+// were published before lifecycle tracking get a record on demand.
+func (ls *lifecycleStore) expanded(idea *indexedIdea, post string) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	ls.expandedLocked(idea, post)
+	ls.save()
+}
+
+func (ls *lifecycleStore) expandedLocked(idea *indexedIdea, post string) {
+	r := ls.byIdeaLocked(idea)
+	if r.ExpandedIn != "" {
+		return
+	}
+	r.ExpandedAt = time.Now()
+	r.ExpandedIn = post
+}
+
+// byIdeaLocked returns the record of a published idea, creating a
+// backfilled one if needed. Callers hold mu.
+func (ls *lifecycleStore) byIdeaLocked(idea *indexedIdea) *lifecycleRecord {
+	if r, ok := ls.byIdea[idea.ID]; ok {
+		return r
+	}
+	r := &lifecycleRecord{
+		CaptureID:   "backfill-" + idea.ID,
+		IdeaID:      idea.ID,
+		CapturedAt:  idea.Date,
+		PublishedAt: idea.Date,
+	}
+	ls.records[r.CaptureID] = r
+	ls.byIdea[idea.ID] = r
+	return r
+}
+
+// scanExpansions looks for references to ideas in the blog posts and
+// marks referenced ideas as expanded. Only posts whose blob SHA changed
+// since the last scan are fetched.
+func (ls *lifecycleStore) scanExpansions(ctx context.Context, gh *githubClient, postsDir string, ideas []*indexedIdea) error {
+	files, err := gh.listTree(ctx, postsDir)
+	if errors.Is(err, errDirNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, f := range files {
+		if !strings.HasSuffix(f.Path, ".md") {
+			continue
+		}
+		ls.mu.Lock()
+		done := ls.scanned[f.Path] == f.SHA
+		ls.mu.Unlock()
+		if done {
+			continue
+		}
+
+		md, _, err := gh.getFile(ctx, f.Path)
+		if err != nil {
+			return err
+		}
+		ls.mu.Lock()
+		for _, idea := range ideas {
+			if idea.Draft {
+				continue
+			}
+			if mentions(md, "/ideas/"+idea.Slug) || mentions(md, idea.ID) {
+				ls.log.Printf("idea %s expanded in %s", idea.ID, f.Path)
+				ls.expandedLocked(idea, f.Path)
+			}
+		}
+		ls.scanned[f.Path] = f.SHA
+		ls.save()
+		ls.mu.Unlock()
+	}
+	return nil
+}
+
+// mentions reports whether md refers to ref as a whole: not as the start
+// or the end of a longer slug or ID, such as "/ideas/go" in
+// "/ideas/go-generics".
+func mentions(md, ref string) bool {
+	if ref == "" {
+		return false
+	}
+	for i := 0; ; {
+		j := strings.Index(md[i:], ref)
+		if j < 0 {
+			return false
+		}
+		start, end := i+j, i+j+len(ref)
+		if (start == 0 || !isSlugByte(ref[0]) || !isSlugByte(md[start-1])) && (end == len(md) || !isSlugByte(md[end])) {
+			return true
+		}
+		i = start + 1
+	}
+}
+
+func isSlugByte(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '-' || c == '_'
+}
+
+// run backfills records for the ideas in the index and scans the posts
+// for expansions, every interval.
+func (ls *lifecycleStore) run(ctx context.Context, gh *githubClient, idx *archiveIndex, postsDir string, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	ls.backfill(idx.all())
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+		ls.backfill(idx.all())
+		if err := ls.scanExpansions(ctx, gh, postsDir, idx.all()); err != nil {
+			ls.log.Printf("expansion scan failed: %v", err)
+		}
+	}
+}
+
+// lifecycleFunnel summarizes how many ideas reached each stage.
+type lifecycleFunnel struct {
+	Captured  int `json:"captured"`
+	Augmented int `json:"augmented"`
+	Published int `json:"published"`
+	Expanded  int `json:"expanded"`
+	// ExpansionRate is the share of published ideas that became posts.
+	ExpansionRate float64 `json:"expansion_rate"`
+	// MedianDaysToExpand is the median time from publish to expansion.
+	MedianDaysToExpand float64 `json:"median_days_to_expand"`
+}
+
+type expansion struct {
+	IdeaID     string    `json:"idea_id"`
+	ExpandedIn string    `json:"expanded_in"`
+	ExpandedAt time.Time `json:"expanded_at"`
+}
+
+// funnel computes the funnel over the tracked records, which include the
+// published ideas backfilled.
+func (ls *lifecycleStore) funnel() (lifecycleFunnel, []expansion) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	var f lifecycleFunnel
+	var days []float64
+	var exps []expansion
+	for _, r := range ls.records {
+		f.Captured++
+		if !r.AugmentedAt.IsZero() {
+			f.Augmented++
+		}
+		if !r.PublishedAt.IsZero() {
+			f.Published++
+		}
+		if r.ExpandedIn != "" {
+			f.Expanded++
+			days = append(days, r.ExpandedAt.Sub(r.PublishedAt).Hours()/24)
+			exps = append(exps, expansion{IdeaID: r.IdeaID, ExpandedIn: r.ExpandedIn, ExpandedAt: r.ExpandedAt})
+		}
+	}
+	if f.Published > 0 {
+		f.ExpansionRate = float64(f.Expanded) / float64(f.Published)
+	}
+	if len(days) > 0 {
+		slices.Sort(days)
+		f.MedianDaysToExpand = days[len(days)/2]
+	}
+	slices.SortFunc(exps, func(a, b expansion) int { return b.ExpandedAt.Compare(a.ExpandedAt) })
+	return f, exps
+}
+
+// handleLifecycle serves the lifecycle funnel.
+func (s *service) handleLifecycle(w http.ResponseWriter, r *http.Request) {
+	f, exps := s.lifecycle.funnel()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct {
+		OK         bool            `json:"ok"`
+		Funnel     lifecycleFunnel `json:"funnel"`
+		Expansions []expansion     `json:"expansions"`
+	}{OK: true, Funnel: f, Expansions: exps})
+}
+
+// handleExpanded manually links an idea to the post it was expanded into.
+func (s *service) handleExpanded(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Post string `json:"post"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || strings.TrimSpace(req.Post) == "" {
+		s.jsonError(w, "post is required", http.StatusBadRequest)
+		return
+	}
+	idea, ok := s.index.get(r.PathValue("id"))
+	if !ok {
+		s.jsonError(w, "idea not found", http.StatusNotFound)
+		return
+	}
+	s.lifecycle.expanded(idea, strings.TrimSpace(req.Post))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ideaResponse{OK: true, Message: "idea marked as expanded"})
+}
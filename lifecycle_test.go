@@ -0,0 +1,68 @@
+package main
+
+import (
+	"io"
+	"log"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestMentions(t *testing.T) {
+	for _, tt := range []struct {
+		md, ref string
+		want    bool
+	}{
+		{"see [it](https://example.com/ideas/go/)", "/ideas/go", true},
+		{"see /ideas/go.", "/ideas/go", true},
+		{"see /ideas/go", "/ideas/go", true},
+		{"see /ideas/go-generics/", "/ideas/go", false},
+		{"see /ideas/go-generics/ and /ideas/go#top", "/ideas/go", true},
+		{"from 2025-01-01-reward.md", "2025-01-01-reward", true},
+		{"from 2025-01-01-reward-hacking.md", "2025-01-01-reward", false},
+		{"from x2025-01-01-reward", "2025-01-01-reward", false},
+		{"nothing here", "/ideas/go", false},
+	} {
+		if got := mentions(tt.md, tt.ref); got != tt.want {
+			t.Errorf("mentions(%q, %q) = %v, want %v", tt.md, tt.ref, got, tt.want)
+		}
+	}
+}
+
+func TestLifecycleFunnel(t *testing.T) {
+	l := log.New(io.Discard, "", 0)
+	path := filepath.Join(t.TempDir(), "lifecycle.json")
+	ls := newLifecycleStore(path, l)
+	date := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	ideas := []*indexedIdea{
+		{ID: "2025-01-01-a", Slug: "a", Date: date},
+		{ID: "2025-01-02-b", Slug: "b", Date: date},
+		{ID: "2025-01-03-c", Slug: "c", Date: date, Draft: true},
+	}
+
+	a := ls.capture()
+	ls.augmented(a)
+	ls.published(a, "2025-01-01-a")
+	ls.capture() // still in the pipeline
+
+	// Reading the funnel creates no records.
+	if f, _ := ls.funnel(); f.Captured != 2 || f.Published != 1 {
+		t.Errorf("funnel before backfill = %+v", f)
+	}
+	if len(ls.records) != 2 {
+		t.Errorf("funnel created records: %d", len(ls.records))
+	}
+
+	ls.backfill(ideas)
+	ls.expanded(ideas[1], "content/posts/b.md")
+	// Reloaded, the records are found by idea, and not backfilled again.
+	ls = newLifecycleStore(path, l)
+	ls.backfill(ideas)
+	f, exps := ls.funnel()
+	if f.Captured != 3 || f.Augmented != 1 || f.Published != 2 || f.Expanded != 1 || f.ExpansionRate != 0.5 {
+		t.Errorf("funnel = %+v", f)
+	}
+	if len(exps) != 1 || exps[0].IdeaID != "2025-01-02-b" || exps[0].ExpandedIn != "content/posts/b.md" {
+		t.Errorf("expansions = %+v", exps)
+	}
+}
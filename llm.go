@@ -19,10 +19,14 @@ import (
 
 type llmClient struct {
 	baseURL    string // e.g. "https://llm.changkun.de"
-	apiKey     string
+	apiKey     *secret
 	model      string // e.g. "anthropic/claude-sonnet-4-5-20250929"
 	titleModel string // e.g. "anthropic/claude-haiku-4-5-20251001"
-	log        *log.Logger
+	// embeddingModel is used for related-idea lookups,
+	// e.g. "openai/text-embedding-3-small".
+	embeddingModel string
+	log            *log.Logger
+	usage          *usageMeter // optional
 }
 
 type chatRequest struct {
@@ -63,6 +67,7 @@ type chatResponse struct {
 			Content json.RawMessage `json:"content"`
 		} `json:"message"`
 	} `json:"choices"`
+	Usage tokenUsage `json:"usage"`
 	Error *struct {
 		Message string `json:"message"`
 	} `json:"error,omitempty"`
@@ -151,6 +156,45 @@ func (c *llmClient) improveContent(ctx context.Context, content string) (string,
 	return c.complete(ctx, c.titleModel, improvePrompt, content)
 }
 
+const digestPrompt = `You are writing the weekly digest of a researcher's idea stream.
+Given the ideas captured this week, write a short connecting narrative (2-4 paragraphs) in English that surfaces themes, tensions, and links between them.
+Refer to ideas by linking their titles with the given URLs in markdown.
+Where related earlier ideas are listed, point out the connection if it is meaningful.
+Do not invent ideas or links that are not listed. Return only the narrative, no heading.`
+
+func (c *llmClient) writeDigest(ctx context.Context, ideas string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 120*time.Second)
+	defer cancel()
+
+	return c.complete(ctx, c.model, digestPrompt, ideas)
+}
+
+const teaserPrompt = `Write a one- or two-sentence teaser (max 300 characters) for a social media post announcing the following idea.
+Make readers curious about the idea without repeating the title.
+No hashtags, no emojis, no links, no quotes around the text.
+Use the same language as the content. Return only the teaser.`
+
+func (c *llmClient) writeTeaser(ctx context.Context, title, content string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	prompt := fmt.Sprintf("Title: %s\n\nContent:\n%s", title, content)
+	return c.complete(ctx, c.titleModel, teaserPrompt, prompt)
+}
+
+const summarizePagePrompt = `Summarize the following web page in 2-4 sentences.
+Focus on the central claim or contribution, not on navigation or boilerplate.
+Return only the summary, no preamble.
+Use the same language as the page.`
+
+func (c *llmClient) summarizePage(ctx context.Context, title, text string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	prompt := fmt.Sprintf("Title: %s\n\nPage:\n%s", title, text)
+	return c.complete(ctx, c.titleModel, summarizePagePrompt, prompt)
+}
+
 const detectAndTranslatePrompt = `You will be given a title and content. Do the following:
 1. Detect whether the text is primarily English or Chinese.
 2. Polish the original title and content: fix typos, spelling errors, and grammatical mistakes; improve readability and sentence flow; keep it concise and preserve the original thought structure and tone exactly.
@@ -167,11 +211,20 @@ type translateResult struct {
 	TranslatedContent string `json:"translated_content"`
 }
 
-func (c *llmClient) detectAndTranslate(ctx context.Context, title, content string) (*translateResult, error) {
+// detectAndTranslate polishes and translates title and content. lang
+// is the language of the text, "en" or "zh", or empty to detect it.
+func (c *llmClient) detectAndTranslate(ctx context.Context, title, content, lang string) (*translateResult, error) {
 	ctx, cancel := context.WithTimeout(ctx, 90*time.Second)
 	defer cancel()
 
 	prompt := fmt.Sprintf("Title: %s\n\nContent:\n%s", title, content)
+	if lang != "" {
+		langName := "English"
+		if lang == "zh" {
+			langName = "Chinese"
+		}
+		prompt = fmt.Sprintf("Treat the text as %s, even where it mixes languages, and reply with \"lang\":%q.\n\n%s", langName, lang, prompt)
+	}
 	raw, err := c.complete(ctx, c.titleModel, detectAndTranslatePrompt, prompt)
 	if err != nil {
 		return nil, err
@@ -196,6 +249,9 @@ func (c *llmClient) detectAndTranslate(ctx context.Context, title, content strin
 	if result.Lang != "en" && result.Lang != "zh" {
 		return nil, fmt.Errorf("unexpected language: %q", result.Lang)
 	}
+	if lang != "" && result.Lang != lang {
+		return nil, fmt.Errorf("language %q was given but %q was used", lang, result.Lang)
+	}
 	return &result, nil
 }
 
@@ -281,7 +337,8 @@ func (c *llmClient) completeWithOptions(ctx context.Context, model, system, user
 		return "", fmt.Errorf("create request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	req.Header.Set("Authorization", "Bearer "+c.apiKey.value())
+	setRequestID(req)
 
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
@@ -306,6 +363,7 @@ func (c *llmClient) completeWithOptions(ctx context.Context, model, system, user
 	if result.Error != nil {
 		return "", fmt.Errorf("LLM API error: %s", result.Error.Message)
 	}
+	c.usage.record(ctx, model, result.Usage)
 
 	if len(result.Choices) == 0 {
 		return "", fmt.Errorf("empty response from LLM API")
@@ -9,19 +9,29 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	"changkun.de/x/login"
+	"google.golang.org/grpc"
 )
 
 func main() {
 	l := log.New(os.Stdout, "ideas: ", log.LstdFlags|log.Lshortfile|log.Lmsgprefix)
 
+	if len(os.Args) > 1 && os.Args[1] == "restore" {
+		if err := runRestore(l, os.Args[2:]); err != nil {
+			l.Fatalf("restore failed: %v", err)
+		}
+		return
+	}
+
 	if v := os.Getenv("LOGIN_VERIFY_URL"); v != "" {
 		login.VerifyEndpoint = v
 	}
@@ -30,50 +40,417 @@ func main() {
 	if llmBaseURL == "" {
 		l.Fatal("LLM_BASE_URL is required")
 	}
-	llmAPIKey := os.Getenv("LLM_API_KEY")
-	if llmAPIKey == "" {
-		l.Fatal("LLM_API_KEY is required")
+	llmAPIKey, err := envSecret("LLM_API_KEY")
+	if err != nil {
+		l.Fatal(err)
 	}
-	gitToken := os.Getenv("GIT_TOKEN")
-	if gitToken == "" {
-		l.Fatal("GIT_TOKEN is required")
+	if llmAPIKey == nil {
+		l.Fatal("LLM_API_KEY or LLM_API_KEY_FILE is required")
 	}
-
-	gitRepo := cmp.Or(os.Getenv("GIT_REPO"), "changkun/blog")
-	parts := strings.SplitN(gitRepo, "/", 2)
-	if len(parts) != 2 {
-		l.Fatalf("GIT_REPO must be in owner/repo format, got: %s", gitRepo)
+	sttAPIKey, err := envSecret("STT_API_KEY")
+	if err != nil {
+		l.Fatal(err)
+	}
+	gh, err := githubClientFromEnv()
+	if err != nil {
+		l.Fatal(err)
 	}
 
 	svc := &service{
-		log: l,
+		log:     l,
+		dataDir: dataDirFromEnv(),
 		llm: &llmClient{
-			baseURL:    llmBaseURL,
-			apiKey:     llmAPIKey,
-			model:      cmp.Or(os.Getenv("LLM_MODEL"), "anthropic/claude-sonnet-4-5-20250929"),
-			titleModel: cmp.Or(os.Getenv("LLM_TITLE_MODEL"), "anthropic/claude-haiku-4-5-20251001"),
-			log:        l,
+			baseURL:        llmBaseURL,
+			apiKey:         llmAPIKey,
+			model:          cmp.Or(os.Getenv("LLM_MODEL"), "anthropic/claude-sonnet-4-5-20250929"),
+			titleModel:     cmp.Or(os.Getenv("LLM_TITLE_MODEL"), "anthropic/claude-haiku-4-5-20251001"),
+			embeddingModel: cmp.Or(os.Getenv("LLM_EMBEDDING_MODEL"), "openai/text-embedding-3-small"),
+			log:            l,
 		},
-		github: &githubClient{
-			token: gitToken,
-			owner: parts[0],
-			repo:  parts[1],
-			name:  cmp.Or(os.Getenv("GIT_COMMITTER_NAME"), "Changkun Ideas API Server"),
-			email: cmp.Or(os.Getenv("GIT_COMMITTER_EMAIL"), "hi+ideas@changkun.de"),
+		stt: &sttClient{
+			baseURL: cmp.Or(os.Getenv("STT_BASE_URL"), llmBaseURL),
+			apiKey:  cmp.Or(sttAPIKey, llmAPIKey),
+			model:   cmp.Or(os.Getenv("STT_MODEL"), "whisper-1"),
 		},
+		github: gh,
+		site: newSiteConfig(
+			os.Getenv("SITE_GENERATOR"),
+			os.Getenv("GIT_IDEAS_DIR"),
+			os.Getenv("GIT_DRAFTS_DIR"),
+			os.Getenv("IDEAS_SITE_URL"),
+		),
+		feedTitle:  cmp.Or(os.Getenv("IDEAS_FEED_TITLE"), "Ideas"),
+		feedAuthor: cmp.Or(os.Getenv("IDEAS_FEED_AUTHOR"), gh.owner),
+	}
+
+	svc.index = newArchiveIndex(filepath.Join(svc.dataDir, "index.json"), l)
+	svc.embeds = newEmbeddingStore(filepath.Join(svc.dataDir, "embeddings.json"), l)
+	svc.lifecycle = newLifecycleStore(filepath.Join(svc.dataDir, "lifecycle.json"), l)
+	svc.feedback = newFeedbackStore(filepath.Join(svc.dataDir, "feedback.json"), l)
+	svc.suggestions = newSuggestionStore(filepath.Join(svc.dataDir, "suggestions.json"), l)
+	svc.drafts = newDraftStore(filepath.Join(svc.dataDir, "drafts.json"), l)
+	svc.pending = newPendingStore(filepath.Join(svc.dataDir, "pending.json"), l)
+	svc.jobs = newJobStore(filepath.Join(svc.dataDir, "jobs.json"), l)
+	svc.commits = newCommitQueue(filepath.Join(svc.dataDir, "commits.json"), l)
+	svc.apiKeys = newAPIKeyStore(filepath.Join(svc.dataDir, "apikeys.json"), l)
+	svc.audit = newAuditLog(filepath.Join(svc.dataDir, "audit.jsonl"), l)
+	if path := os.Getenv("IDEAS_USERS_FILE"); path != "" {
+		svc.users, err = loadUserSites(path, gh, svc.site)
+		if err != nil {
+			l.Fatal(err)
+		}
+		l.Printf("multi-user mode: %d users", len(svc.users))
+	}
+	// Login tokens without scope or role claims, and login cookies, are
+	// granted these, or ideas:write for the users of the users file.
+	defaultScopes := scopeDefaults{
+		owner: strings.Fields(cmp.Or(os.Getenv("IDEAS_DEFAULT_SCOPES"), scopeAdmin)),
+		users: svc.users,
+	}
+	svc.usage = newUsageMeter(filepath.Join(svc.dataDir, "usage.json"), l, svc.jobs)
+	svc.llm.usage = svc.usage
+	if v := os.Getenv("IDEAS_TOKEN_BUDGET"); v != "" {
+		svc.tokenBudget, err = strconv.Atoi(v)
+		if err != nil {
+			l.Fatalf("invalid IDEAS_TOKEN_BUDGET: %v", err)
+		}
+	}
+
+	svc.duplicateScore = defaultDuplicateScore
+	if v := os.Getenv("IDEAS_DUPLICATE_SCORE"); v != "" {
+		svc.duplicateScore, err = strconv.ParseFloat(v, 64)
+		if err != nil || svc.duplicateScore < 0 || svc.duplicateScore > 1 {
+			l.Fatalf("invalid IDEAS_DUPLICATE_SCORE: must be a number from 0 to 1")
+		}
+	}
+
+	workers, err := strconv.Atoi(cmp.Or(os.Getenv("IDEAS_WORKERS"), "2"))
+	if err != nil || workers < 1 {
+		l.Fatalf("invalid IDEAS_WORKERS: must be a positive number")
+	}
+	depth, err := strconv.Atoi(cmp.Or(os.Getenv("IDEAS_QUEUE_DEPTH"), "50"))
+	if err != nil || depth < 0 {
+		l.Fatalf("invalid IDEAS_QUEUE_DEPTH: must be a number, 0 or more")
+	}
+	svc.pool = newWorkerPool(workers, depth)
+	maxBody, err := strconv.ParseInt(cmp.Or(os.Getenv("IDEAS_MAX_BODY_BYTES"), strconv.Itoa(defaultMaxBody)), 10, 64)
+	if err != nil || maxBody < 1 {
+		l.Fatalf("invalid IDEAS_MAX_BODY_BYTES: must be a positive number")
+	}
+	svc.maxBody = maxBody
+	var haltPipelines context.CancelFunc
+	svc.halt, haltPipelines = context.WithCancel(context.Background())
+
+	if path := os.Getenv("IDEAS_TAXONOMY_FILE"); path != "" {
+		tax, err := loadTaxonomy(path)
+		if err != nil {
+			l.Fatalf("cannot load taxonomy: %v", err)
+		}
+		svc.tax = tax
+	}
+
+	if path := os.Getenv("IDEAS_PIPELINES_FILE"); path != "" {
+		svc.pipelines, err = loadPipelines(path)
+		if err != nil {
+			l.Fatalf("cannot load pipelines: %v", err)
+		}
+	}
+
+	if path := os.Getenv("IDEAS_HOOKS_FILE"); path != "" {
+		svc.hooks, err = loadHooks(path)
+		if err != nil {
+			l.Fatalf("cannot load hooks: %v", err)
+		}
 	}
 
 	r := http.NewServeMux()
 	r.HandleFunc("GET /ideas/ping", func(w http.ResponseWriter, r *http.Request) {
 		fmt.Fprintln(w, "pong")
 	})
+	r.HandleFunc("GET /ideas/healthz", svc.handleHealth)
+	r.Handle("/ideas/debug/pprof/", pprofHandler())
 	r.HandleFunc("POST /ideas/post", svc.handlePost)
+	r.HandleFunc("POST /ideas/draft", svc.handleSaveDraft)
+	r.HandleFunc("GET /ideas/drafts", svc.handleListDrafts)
+	r.HandleFunc("POST /ideas/drafts/{id}/publish", svc.handlePublishDraft)
+	r.HandleFunc("DELETE /ideas/drafts/{id}", svc.handleDeleteDraft)
 	r.HandleFunc("POST /ideas/improve", svc.handleImprove)
+	r.HandleFunc("POST /ideas/voice", svc.handleVoice)
+	r.HandleFunc("POST /ideas/clip", svc.handleClip)
+	// The archive, and the accounts ideas are announced with, are the
+	// server owner's; users with a blog of their own only post to it.
+	r.HandleFunc("POST /ideas/digest", svc.ownerOnly(svc.handleDigest))
+	r.HandleFunc("POST /ideas/import", svc.ownerOnly(svc.handleImport))
+	r.HandleFunc("POST /ideas/mcp", svc.handleMCP)
+	r.HandleFunc("GET /ideas/list", svc.ownerOnly(svc.handleListIdeas))
+	r.HandleFunc("GET /ideas/search", svc.ownerOnly(svc.handleSearchIdeas))
+	r.HandleFunc("GET /ideas/export", svc.ownerOnly(svc.handleExport))
+	r.HandleFunc("GET /ideas/ui", svc.handleUI)
+	r.HandleFunc("GET /ideas/feed.xml", svc.handleFeed)
+	r.HandleFunc("GET /ideas/ws", svc.handleStatusSocket)
+	r.HandleFunc("GET /ideas/{id}", svc.ownerOnly(svc.handleGetIdea))
+	r.HandleFunc("PUT /ideas/{id}", svc.ownerOnly(svc.handleUpdateIdea))
+	r.HandleFunc("GET /ideas/{id}/related", svc.ownerOnly(svc.handleRelated))
+	r.HandleFunc("GET /ideas/{id}/similar", svc.ownerOnly(svc.handleRelated))
+	r.HandleFunc("POST /ideas/{id}/append", svc.ownerOnly(svc.handleAppendIdea))
+	r.HandleFunc("GET /ideas/lifecycle", svc.ownerOnly(svc.handleLifecycle))
+	r.HandleFunc("GET /ideas/stats", svc.ownerOnly(svc.handleStats))
+	r.HandleFunc("POST /ideas/{id}/expanded", svc.ownerOnly(svc.handleExpanded))
+	r.HandleFunc("POST /ideas/{id}/thread", svc.ownerOnly(svc.handleThread))
+	r.HandleFunc("GET /ideas/{id}/feedback", svc.ownerOnly(svc.handleFeedback))
+	r.HandleFunc("GET /ideas/admin", svc.handleAdmin)
+	r.HandleFunc("GET /ideas/admin/jobs/{id}", svc.handleGetJob)
+	r.HandleFunc("GET /ideas/admin/jobs/{id}/events", svc.handleJobEvents)
+	r.HandleFunc("POST /ideas/admin/jobs/{id}/retry", svc.handleRetryJob)
+	r.HandleFunc("POST /ideas/admin/jobs/{id}/approve", svc.handleApproveJob)
+	r.HandleFunc("POST /ideas/admin/jobs/{id}/discard", svc.handleDiscardJob)
+	r.HandleFunc("POST /ideas/admin/suggestions/{id}/approve", svc.handleModerateSuggestion)
+	r.HandleFunc("POST /ideas/admin/suggestions/{id}/reject", svc.handleModerateSuggestion)
+	r.HandleFunc("GET /ideas/admin/keys", svc.handleListAPIKeys)
+	r.HandleFunc("POST /ideas/admin/keys", svc.handleCreateAPIKey)
+	r.HandleFunc("DELETE /ideas/admin/keys/{id}", svc.handleRevokeAPIKey)
+	r.HandleFunc("GET /ideas/admin/audit", svc.handleAudit)
+
+	if key := secretEnv("IDEAS_API_KEY"); key != "" {
+		svc.apiKey = key
+		r.HandleFunc("POST /ideas/quick", svc.handleQuick)
+
+		limit, err := strconv.Atoi(cmp.Or(os.Getenv("IDEAS_BRIDGE_LIMIT"), "10"))
+		if err != nil || limit < 1 {
+			l.Fatalf("invalid IDEAS_BRIDGE_LIMIT: %q", os.Getenv("IDEAS_BRIDGE_LIMIT"))
+		}
+		svc.bridgeLimit = newRateLimiter(limit, time.Hour)
+		r.HandleFunc("GET /ideas/t", svc.handleTextBridge)
+	}
+
+	publicLimit, err := strconv.Atoi(cmp.Or(os.Getenv("IDEAS_PUBLIC_LIMIT"), "600"))
+	if err != nil || publicLimit < 1 {
+		l.Fatalf("invalid IDEAS_PUBLIC_LIMIT: %q", os.Getenv("IDEAS_PUBLIC_LIMIT"))
+	}
+	svc.publicLimit = newRateLimiter(publicLimit, time.Hour)
+
+	if v := os.Getenv("IDEAS_SUGGEST"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 {
+			l.Fatalf("invalid IDEAS_SUGGEST: %q", v)
+		}
+		svc.suggestLimit = newRateLimiter(limit, time.Hour)
+		if secret := secretEnv("TURNSTILE_SECRET"); secret != "" {
+			svc.captcha = &turnstile{secret: secret}
+		}
+		r.HandleFunc("POST /ideas/suggest", svc.handleSuggest)
+	}
+
+	if secret := secretEnv("SLACK_SIGNING_SECRET"); secret != "" {
+		svc.slack = &slackClient{
+			signingSecret: secret,
+			botToken:      secretEnv("SLACK_BOT_TOKEN"),
+			allowedUsers:  map[string]bool{},
+		}
+		for _, u := range splitList(os.Getenv("SLACK_ALLOWED_USERS")) {
+			svc.slack.allowedUsers[u] = true
+		}
+		r.HandleFunc("POST /ideas/slack/command", svc.handleSlackCommand)
+		r.HandleFunc("POST /ideas/slack/events", svc.handleSlackEvents)
+	}
+
+	if token := secretEnv("TELEGRAM_BOT_TOKEN"); token != "" {
+		svc.telegram = &telegramClient{
+			apiURL:       cmp.Or(os.Getenv("TELEGRAM_API_URL"), "https://api.telegram.org"),
+			botToken:     token,
+			secret:       secretEnv("TELEGRAM_WEBHOOK_SECRET"),
+			allowedChats: map[int64]bool{},
+		}
+		if svc.telegram.secret == "" {
+			l.Fatal("TELEGRAM_WEBHOOK_SECRET is required with TELEGRAM_BOT_TOKEN")
+		}
+		for _, c := range splitList(os.Getenv("TELEGRAM_ALLOWED_CHATS")) {
+			id, err := strconv.ParseInt(c, 10, 64)
+			if err != nil {
+				l.Fatalf("invalid TELEGRAM_ALLOWED_CHATS: %q", c)
+			}
+			svc.telegram.allowedChats[id] = true
+		}
+		r.HandleFunc("POST /ideas/telegram", svc.handleTelegram)
+	}
+
+	if secret := secretEnv("GITHUB_WEBHOOK_SECRET"); secret != "" {
+		minWords, err := strconv.Atoi(cmp.Or(os.Getenv("IDEAS_FEEDBACK_MIN_WORDS"), "15"))
+		if err != nil || minWords < 0 {
+			l.Fatalf("invalid IDEAS_FEEDBACK_MIN_WORDS: %q", os.Getenv("IDEAS_FEEDBACK_MIN_WORDS"))
+		}
+		revisit, err := strconv.Atoi(cmp.Or(os.Getenv("IDEAS_FEEDBACK_REVISIT"), "0"))
+		if err != nil || revisit < 0 {
+			l.Fatalf("invalid IDEAS_FEEDBACK_REVISIT: %q", os.Getenv("IDEAS_FEEDBACK_REVISIT"))
+		}
+		svc.comments = &commentWebhook{
+			secret:   secret,
+			minWords: minWords,
+			revisit:  revisit,
+			ignore:   map[string]bool{strings.ToLower(gh.owner): true},
+		}
+		for _, u := range splitList(os.Getenv("IDEAS_FEEDBACK_IGNORE")) {
+			svc.comments.ignore[strings.ToLower(u)] = true
+		}
+		r.HandleFunc("POST /ideas/webhooks/comments", svc.handleCommentWebhook)
+	}
+
+	if server := os.Getenv("MASTODON_SERVER"); server != "" {
+		token := secretEnv("MASTODON_TOKEN")
+		if token == "" {
+			l.Fatal("MASTODON_TOKEN is required when MASTODON_SERVER is set")
+		}
+		svc.mastodon = &mastodonClient{
+			server:     server,
+			token:      token,
+			visibility: cmp.Or(os.Getenv("MASTODON_VISIBILITY"), "public"),
+		}
+	}
+
+	if ntfy, po := os.Getenv("NTFY_URL"), secretEnv("PUSHOVER_TOKEN"); ntfy != "" || po != "" {
+		svc.notifier = &notifier{
+			log:           l,
+			ntfyURL:       ntfy,
+			ntfyToken:     secretEnv("NTFY_TOKEN"),
+			pushoverToken: po,
+			pushoverUser:  os.Getenv("PUSHOVER_USER"),
+		}
+		if po != "" && svc.notifier.pushoverUser == "" {
+			l.Fatal("PUSHOVER_USER is required when PUSHOVER_TOKEN is set")
+		}
+	}
+
+	if token := secretEnv("X_ACCESS_TOKEN"); token != "" {
+		svc.x = &xClient{baseURL: "https://api.x.com", token: token}
+	}
+
+	// Background subsystems run until the service shuts down.
+	bg, stopBg := context.WithCancel(context.Background())
+	defer stopBg()
+
+	indexInterval, err := time.ParseDuration(cmp.Or(os.Getenv("IDEAS_INDEX_INTERVAL"), "1h"))
+	if err != nil {
+		l.Fatalf("invalid IDEAS_INDEX_INTERVAL: %v", err)
+	}
+	indexDirs := []string{svc.site.ideasDir}
+	if svc.site.draftsDir != svc.site.ideasDir {
+		indexDirs = append(indexDirs, svc.site.draftsDir)
+	}
+	go svc.index.run(bg, svc.github, indexInterval, indexDirs...)
+	go svc.embeds.run(bg, svc.llm, svc.index, indexInterval)
+	postsDir := cmp.Or(os.Getenv("GIT_POSTS_DIR"), "content/posts")
+	go svc.lifecycle.run(bg, svc.github, svc.index, postsDir, indexInterval)
+	go svc.runCommitQueue(bg, minCommitBackoff)
+	go svc.runPending(bg, cmp.Or(os.Getenv("GIT_PENDING_DIR"), ".ideas/pending"), indexInterval)
+
+	if feeds := splitList(os.Getenv("IDEAS_FEEDS")); len(feeds) > 0 {
+		interval, err := time.ParseDuration(cmp.Or(os.Getenv("IDEAS_FEED_INTERVAL"), "1h"))
+		if err != nil {
+			l.Fatalf("invalid IDEAS_FEED_INTERVAL: %v", err)
+		}
+		fw := &feedWatcher{
+			s:         svc,
+			urls:      feeds,
+			interval:  interval,
+			statePath: filepath.Join(svc.dataDir, "feeds.json"),
+		}
+		go fw.run(bg)
+	}
+
+	if token := secretEnv("READWISE_TOKEN"); token != "" {
+		interval, err := time.ParseDuration(cmp.Or(os.Getenv("READWISE_INTERVAL"), "1h"))
+		if err != nil {
+			l.Fatalf("invalid READWISE_INTERVAL: %v", err)
+		}
+		rw := &readwiseWatcher{
+			s:         svc,
+			client:    &readwiseClient{baseURL: "https://readwise.io", token: token},
+			interval:  interval,
+			statePath: filepath.Join(svc.dataDir, "readwise.json"),
+		}
+		go rw.run(bg)
+	}
+
+	if v := os.Getenv("IDEAS_DIGEST_WEEKDAY"); v != "" {
+		weekday, err := parseWeekday(v)
+		if err != nil {
+			l.Fatalf("invalid IDEAS_DIGEST_WEEKDAY: %v", err)
+		}
+		hour, err := strconv.Atoi(cmp.Or(os.Getenv("IDEAS_DIGEST_HOUR"), "18"))
+		if err != nil || hour < 0 || hour > 23 {
+			l.Fatalf("invalid IDEAS_DIGEST_HOUR: %q", os.Getenv("IDEAS_DIGEST_HOUR"))
+		}
+		ds := &digestScheduler{
+			s:         svc,
+			weekday:   weekday,
+			hour:      hour,
+			statePath: filepath.Join(svc.dataDir, "digest.json"),
+		}
+		go ds.run(bg)
+	}
+
+	if v := os.Getenv("IDEAS_REMINDER_HOUR"); v != "" {
+		hour, err := strconv.Atoi(v)
+		if err != nil || hour < 0 || hour > 23 {
+			l.Fatalf("invalid IDEAS_REMINDER_HOUR: %q", v)
+		}
+		rm := &reminder{
+			s:         svc,
+			hour:      hour,
+			statePath: filepath.Join(svc.dataDir, "reminder.json"),
+		}
+		if to := os.Getenv("IDEAS_REMINDER_EMAIL"); to != "" {
+			rm.mail = &mailer{
+				addr: os.Getenv("SMTP_ADDR"),
+				user: os.Getenv("SMTP_USER"),
+				pass: secretEnv("SMTP_PASS"),
+				from: cmp.Or(os.Getenv("SMTP_FROM"), os.Getenv("SMTP_USER")),
+				to:   to,
+			}
+			if rm.mail.addr == "" || rm.mail.from == "" {
+				l.Fatal("SMTP_ADDR and SMTP_FROM are required when IDEAS_REMINDER_EMAIL is set")
+			}
+		}
+		if svc.notifier == nil && rm.mail == nil {
+			l.Fatal("IDEAS_REMINDER_HOUR needs ntfy, Pushover, or IDEAS_REMINDER_EMAIL to be configured")
+		}
+		go rm.run(bg)
+	}
+
+	if bdir, bs3 := os.Getenv("IDEAS_BACKUP_DIR"), s3ClientFromEnv(); bdir != "" || bs3 != nil {
+		interval, err := time.ParseDuration(cmp.Or(os.Getenv("IDEAS_BACKUP_INTERVAL"), "24h"))
+		if err != nil {
+			l.Fatalf("invalid IDEAS_BACKUP_INTERVAL: %v", err)
+		}
+		keep, err := strconv.Atoi(cmp.Or(os.Getenv("IDEAS_BACKUP_KEEP"), "7"))
+		if err != nil || keep < 1 {
+			l.Fatalf("invalid IDEAS_BACKUP_KEEP: %q", os.Getenv("IDEAS_BACKUP_KEEP"))
+		}
+		b := &backupper{s: svc, dir: bdir, s3: bs3, keep: keep}
+		go b.run(bg, interval)
+	}
+
+	allowed, err := parseCIDRs(os.Getenv("IDEAS_ALLOW_CIDRS"))
+	if err != nil {
+		l.Fatalf("invalid IDEAS_ALLOW_CIDRS: %v", err)
+	}
+	trusted, err := parseCIDRs(os.Getenv("IDEAS_TRUSTED_PROXIES"))
+	if err != nil {
+		l.Fatalf("invalid IDEAS_TRUSTED_PROXIES: %v", err)
+	}
+
+	// The gRPC interface is served on a port of its own, as it needs
+	// HTTP/2 end to end.
+	var gs *grpc.Server
+	grpcAddr := os.Getenv("IDEAS_GRPC_ADDR")
+	if grpcAddr != "" {
+		gs = newGRPCServer(svc, &grpcGate{log: l, keys: svc.apiKeys, defaultScopes: defaultScopes, allowed: allowed, trusted: trusted, audit: svc.audit})
+	}
 
 	addr := cmp.Or(os.Getenv("IDEAS_ADDR"), "0.0.0.0:80")
 	s := &http.Server{
 		Addr:         addr,
-		Handler:      logging(l)(cors(auth(r))),
+		Handler:      requestID(realIP(trusted)(logging(l)(allowCIDRs(allowed)(cors(auth(svc.apiKeys, defaultScopes)(auditActions(svc.audit)(withPublic(svc.publicRoutes(), r)))))))),
 		ReadTimeout:  30 * time.Second,
 		WriteTimeout: 2 * time.Minute,
 		IdleTimeout:  time.Minute,
@@ -81,20 +458,49 @@ func main() {
 
 	done := make(chan bool)
 	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, os.Interrupt)
+	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
 
 	go func() {
 		<-quit
 		l.Println("ideas service is shutting down...")
+		stopBg()
 		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 		defer cancel()
 		s.SetKeepAlivesEnabled(false)
-		if err := s.Shutdown(ctx); err != nil {
+		shutdown := make(chan error, 1)
+		go func() { shutdown <- s.Shutdown(ctx) }()
+		if gs != nil {
+			go gs.GracefulStop()
+		}
+
+		// Pipelines get most of the time to finish. Those yet to publish
+		// are then interrupted, to be resumed after restart, while those
+		// publishing still complete their commit.
+		drain, cancelDrain := context.WithTimeout(ctx, 20*time.Second)
+		defer cancelDrain()
+		if !svc.pool.wait(drain) {
+			l.Println("interrupting the pipelines yet to publish...")
+			haltPipelines()
+			if !svc.pool.wait(ctx) {
+				l.Println("pipelines still publishing at shutdown")
+			}
+		}
+		if gs != nil {
+			// Ends the calls still watching jobs.
+			gs.Stop()
+		}
+		if err := <-shutdown; err != nil {
 			l.Fatalf("cannot gracefully shutdown: %v", err)
 		}
 		close(done)
 	}()
 
+	// Pipelines interrupted by the last shutdown start over.
+	svc.resumeJobs()
+
+	if gs != nil {
+		go serveGRPC(gs, grpcAddr, l)
+	}
 	l.Printf("ideas service is serving on %s...", addr)
 	if err := s.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 		l.Fatalf("cannot listen on %s, err: %v\n", addr, err)
@@ -111,7 +517,13 @@ func cors(next http.Handler) http.Handler {
 	}
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		origin := r.Header.Get("Origin")
-		if allowed[origin] {
+		if strings.HasPrefix(r.URL.Path, publicPrefix) {
+			// Any site may read the published ideas.
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+			w.Header().Set("Access-Control-Allow-Headers", "If-None-Match")
+			w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+			w.Header().Set("Access-Control-Expose-Headers", "ETag")
+		} else if allowed[origin] {
 			w.Header().Set("Access-Control-Allow-Origin", origin)
 			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
 			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
@@ -124,55 +536,89 @@ func cors(next http.Handler) http.Handler {
 	})
 }
 
-func auth(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if r.URL.Path == "/ideas/ping" {
-			next.ServeHTTP(w, r)
-			return
-		}
-
-		// Try Bearer token from Authorization header.
-		if h := r.Header.Get("Authorization"); strings.HasPrefix(h, "Bearer ") {
-			token := strings.TrimPrefix(h, "Bearer ")
-			if _, err := login.Verify(token); err == nil {
-				next.ServeHTTP(w, r)
-				return
-			}
-		}
-
-		// Fall back to query param / cookie via SDK.
-		if _, err := login.HandleAuth(w, r); err == nil {
-			next.ServeHTTP(w, r)
-			return
-		}
+// publicPaths lists endpoints that are either unauthenticated or
+// verify requests on their own.
+var publicPaths = map[string]bool{
+	"/ideas/ping":              true,
+	"/ideas/healthz":           true,
+	"/ideas/feed.xml":          true,
+	"/ideas/quick":             true,
+	"/ideas/t":                 true,
+	"/ideas/suggest":           true,
+	"/ideas/slack/command":     true,
+	"/ideas/slack/events":      true,
+	"/ideas/telegram":          true,
+	"/ideas/webhooks/comments": true,
+}
 
-		http.Error(w, "unauthorized", http.StatusUnauthorized)
-	})
+// isPublic reports whether the endpoint at path needs no authentication:
+// one of publicPaths, or one serving the published ideas.
+func isPublic(path string) bool {
+	return publicPaths[path] || strings.HasPrefix(path, publicPrefix)
 }
 
 func logging(logger *log.Logger) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			defer func() {
-				logger.Println(readIP(r), r.Method, r.URL.Path)
+				logger.Println(readIP(r), r.Method, r.URL.Path, requestIDFromContext(r.Context()))
 			}()
 			next.ServeHTTP(w, r)
 		})
 	}
 }
 
-func readIP(r *http.Request) string {
-	clientIP := r.Header.Get("X-Forwarded-For")
-	clientIP = strings.TrimSpace(strings.Split(clientIP, ",")[0])
-	if clientIP == "" {
-		clientIP = strings.TrimSpace(r.Header.Get("X-Real-Ip"))
+func dataDirFromEnv() string {
+	return cmp.Or(os.Getenv("IDEAS_DATA_DIR"), "data")
+}
+
+func githubClientFromEnv() (*githubClient, error) {
+	gitToken, err := envSecret("GIT_TOKEN")
+	if err != nil {
+		return nil, err
 	}
-	if clientIP != "" {
-		return clientIP
+	if gitToken == nil {
+		return nil, fmt.Errorf("GIT_TOKEN or GIT_TOKEN_FILE is required")
 	}
-	ip, _, err := net.SplitHostPort(strings.TrimSpace(r.RemoteAddr))
-	if err != nil {
-		return "unknown"
+	gitRepo := cmp.Or(os.Getenv("GIT_REPO"), "changkun/blog")
+	parts := strings.SplitN(gitRepo, "/", 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("GIT_REPO must be in owner/repo format, got: %s", gitRepo)
+	}
+	return &githubClient{
+		token: gitToken,
+		owner: parts[0],
+		repo:  parts[1],
+		name:  cmp.Or(os.Getenv("GIT_COMMITTER_NAME"), "Changkun Ideas API Server"),
+		email: cmp.Or(os.Getenv("GIT_COMMITTER_EMAIL"), "hi+ideas@changkun.de"),
+	}, nil
+}
+
+// s3ClientFromEnv returns the backup bucket client, or nil if no bucket
+// is configured.
+func s3ClientFromEnv() *s3Client {
+	bucket := os.Getenv("IDEAS_BACKUP_S3_BUCKET")
+	if bucket == "" {
+		return nil
+	}
+	region := cmp.Or(os.Getenv("IDEAS_BACKUP_S3_REGION"), "us-east-1")
+	return &s3Client{
+		endpoint:  cmp.Or(os.Getenv("IDEAS_BACKUP_S3_ENDPOINT"), "https://s3."+region+".amazonaws.com"),
+		region:    region,
+		bucket:    bucket,
+		accessKey: secretEnv("IDEAS_BACKUP_S3_ACCESS_KEY"),
+		secretKey: secretEnv("IDEAS_BACKUP_S3_SECRET_KEY"),
+	}
+}
+
+// splitList splits a comma-separated configuration value, dropping
+// empty elements.
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
 	}
-	return ip
+	return items
 }
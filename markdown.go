@@ -0,0 +1,210 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ideaDoc is an idea file parsed back from the markdown produced by
+// buildMarkdown.
+type ideaDoc struct {
+	Date        time.Time
+	Slug        string
+	Title       string
+	TitleZh     string
+	Draft       bool
+	Tags        []string
+	Categories  []string
+	ContentEn   string
+	ContentZh   string
+	AugmentedEn string
+	AugmentedZh string
+}
+
+// parseIdea parses the front matter and the language blocks of an idea
+// markdown file.
+func parseIdea(md string) (*ideaDoc, error) {
+	md = strings.ReplaceAll(md, "\r\n", "\n")
+	if !strings.HasPrefix(md, "---\n") {
+		return nil, fmt.Errorf("missing front matter")
+	}
+	fm, body, ok := strings.Cut(md[len("---\n"):], "\n---\n")
+	if !ok {
+		return nil, fmt.Errorf("unterminated front matter")
+	}
+
+	doc := &ideaDoc{}
+	for _, line := range strings.Split(fm, "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch strings.TrimSpace(key) {
+		case "date":
+			t, err := time.ParseInLocation("2006-01-02T15:04:05", unquoteYAML(value), time.Local)
+			if err != nil {
+				return nil, fmt.Errorf("invalid date: %w", err)
+			}
+			doc.Date = t
+		case "slug":
+			doc.Slug = unquoteYAML(value)
+		case "title":
+			doc.Title = unquoteYAML(value)
+		case "title_zh":
+			doc.TitleZh = unquoteYAML(value)
+		case "draft":
+			doc.Draft = value == "true"
+		case "published":
+			doc.Draft = value == "false"
+		case "tags":
+			doc.Tags = parseYAMLList(value)
+		case "categories":
+			doc.Categories = parseYAMLList(value)
+		}
+	}
+
+	doc.ContentEn, doc.AugmentedEn = langBlock(body, "en")
+	doc.ContentZh, doc.AugmentedZh = langBlock(body, "zh")
+	return doc, nil
+}
+
+// langBlock extracts the content and augmented parts of a language
+// shortcode block such as {{% en %}}...{{% /en %}}.
+func langBlock(body, lang string) (content, augmented string) {
+	_, rest, ok := strings.Cut(body, "{{% "+lang+" %}}\n")
+	if !ok {
+		return "", ""
+	}
+	block, _, _ := strings.Cut(rest, "{{% /"+lang+" %}}")
+	content, aug, ok := strings.Cut(block, "{{% augmented %}}\n")
+	if ok {
+		augmented, _, _ = strings.Cut(aug, "\n{{% /augmented %}}")
+		augmented = strings.TrimPrefix(augmented, disclaimerEn)
+		augmented = strings.TrimPrefix(augmented, disclaimerZh)
+	}
+	return strings.TrimSpace(content), strings.TrimSpace(augmented)
+}
+
+// appendFollowUp appends a follow-up dated date to the content of both
+// language blocks of an idea, after what is there and before the
+// augmentation.
+func appendFollowUp(md string, date time.Time, en, zh string) (string, error) {
+	day := date.Format("2006-01-02")
+	sections := []struct{ lang, heading, text string }{
+		{"en", "### Follow-up (" + day + ")", en},
+		{"zh", "### 后续（" + day + "）", zh},
+	}
+	for _, sec := range sections {
+		i, j, err := contentSpan(md, sec.lang)
+		if err != nil {
+			return "", err
+		}
+		content := strings.TrimRight(md[i:j], "\n")
+		section := "\n\n" + sec.heading + "\n\n" + strings.TrimSpace(sec.text)
+		if strings.TrimSpace(content) == "" {
+			section = strings.TrimPrefix(section, "\n\n")
+		}
+		md = md[:i] + content + section + md[i+len(content):]
+	}
+	return md, nil
+}
+
+// replaceContent replaces the content of both language blocks of an
+// idea, keeping their augmentation.
+func replaceContent(md, en, zh string) (string, error) {
+	for _, sec := range []struct{ lang, text string }{{"en", en}, {"zh", zh}} {
+		i, j, err := contentSpan(md, sec.lang)
+		if err != nil {
+			return "", err
+		}
+		content := strings.TrimRight(md[i:j], "\n")
+		md = md[:i] + strings.TrimSpace(sec.text) + md[i+len(content):]
+	}
+	return md, nil
+}
+
+// contentSpan returns where the content of a language block starts and
+// ends, before the augmentation if there is one.
+func contentSpan(md, lang string) (i, j int, err error) {
+	open, end := "{{% "+lang+" %}}\n", "{{% /"+lang+" %}}"
+	i = strings.Index(md, open)
+	if i < 0 {
+		return 0, 0, fmt.Errorf("missing %s block", lang)
+	}
+	i += len(open)
+	j = strings.Index(md[i:], end)
+	if j < 0 {
+		return 0, 0, fmt.Errorf("unterminated %s block", lang)
+	}
+	j += i
+	if k := strings.Index(md[i:j], "\n\n{{% augmented %}}"); k >= 0 {
+		j = i + k
+	}
+	return i, j, nil
+}
+
+// setFrontMatter sets a string field of an idea's front matter, adding
+// it at the end if it is missing.
+func setFrontMatter(md, key, value string) (string, error) {
+	fm, body, ok := cutFrontMatter(md)
+	if !ok {
+		return "", fmt.Errorf("missing front matter")
+	}
+	line := fmt.Sprintf("%s: %q", key, value)
+	lines := strings.Split(fm, "\n")
+	found := false
+	for i, l := range lines {
+		if k, _, ok := strings.Cut(l, ":"); ok && strings.TrimSpace(k) == key {
+			lines[i], found = line, true
+			break
+		}
+	}
+	if !found {
+		lines = append(lines, line)
+	}
+	return "---\n" + strings.Join(lines, "\n") + "\n---\n" + body, nil
+}
+
+func unquoteYAML(s string) string {
+	if u, err := strconv.Unquote(s); err == nil {
+		return u
+	}
+	return s
+}
+
+// parseYAMLList parses a flow sequence such as ["a", "b"] as written by
+// yamlList.
+func parseYAMLList(s string) []string {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "[") || !strings.HasSuffix(s, "]") {
+		return nil
+	}
+	s = s[1 : len(s)-1]
+
+	var items []string
+	for s = strings.TrimSpace(s); s != ""; s = strings.TrimSpace(s) {
+		var item string
+		if s[0] == '"' {
+			q, err := strconv.QuotedPrefix(s)
+			if err != nil {
+				return items
+			}
+			item = unquoteYAML(q)
+			s = s[len(q):]
+		} else {
+			item, s, _ = strings.Cut(s, ",")
+			item = strings.TrimSpace(item)
+		}
+		items = append(items, item)
+		s = strings.TrimSpace(s)
+		s = strings.TrimPrefix(s, ",")
+	}
+	return items
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseIdeaRoundTrip(t *testing.T) {
+	date := time.Date(2025, 3, 14, 9, 26, 53, 0, time.Local)
+	md := buildMarkdown(bilingualContent{
+		date:         date,
+		slug:         "pi-day",
+		titleEn:      `A "Quoted" Title`,
+		titleZh:      "带引号的标题",
+		contentEn:    "First paragraph.\n\nSecond paragraph.",
+		contentZh:    "第一段。\n\n第二段。",
+		augmentedEn:  "**Context** — more.",
+		augmentedZh:  "**背景** — 更多。",
+		llmGenerated: true,
+		draftLine:    "draft: true\n",
+		tags:         []string{"go", "llm-agents"},
+		categories:   []string{"ai", "other"},
+	})
+
+	got, err := parseIdea(md)
+	if err != nil {
+		t.Fatalf("parseIdea: %v\n%s", err, md)
+	}
+	want := &ideaDoc{
+		Date:        date,
+		Slug:        "pi-day",
+		Title:       `A "Quoted" Title`,
+		TitleZh:     "带引号的标题",
+		Draft:       true,
+		Tags:        []string{"go", "llm-agents"},
+		Categories:  []string{"ai", "other"},
+		ContentEn:   "First paragraph.\n\nSecond paragraph.",
+		ContentZh:   "第一段。\n\n第二段。",
+		AugmentedEn: "**Context** — more.",
+		AugmentedZh: "**背景** — 更多。",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mismatch\n got: %+v\nwant: %+v", got, want)
+	}
+}
+
+func TestParseYAMLList(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{input: `[]`, want: nil},
+		{input: `["a", "b"]`, want: []string{"a", "b"}},
+		{input: `[a, b c]`, want: []string{"a", "b c"}},
+		{input: `["comma, inside", plain]`, want: []string{"comma, inside", "plain"}},
+		{input: `not a list`, want: nil},
+	}
+	for _, tt := range tests {
+		got := parseYAMLList(tt.input)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseYAMLList(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestReplaceContent(t *testing.T) {
+	augmented := buildMarkdown(bilingualContent{
+		date:        time.Date(2025, 1, 3, 0, 0, 0, 0, time.Local),
+		slug:        "s",
+		titleEn:     "T",
+		titleZh:     "T",
+		contentEn:   "First.",
+		contentZh:   "第一。",
+		augmentedEn: "More.",
+		augmentedZh: "更多。",
+	})
+	for _, md := range []string{testIdeaMarkdown, augmented} {
+		got, err := replaceContent(md, "New.\n", "新的。")
+		if err != nil {
+			t.Fatal(err)
+		}
+		doc, _ := parseIdea(got)
+		want, _ := parseIdea(md)
+		if doc.ContentEn != "New." || doc.ContentZh != "新的。" || doc.AugmentedEn != want.AugmentedEn || doc.AugmentedZh != want.AugmentedZh || doc.Title != want.Title {
+			t.Errorf("replaced:\n%s", got)
+		}
+	}
+}
+
+func TestSetFrontMatter(t *testing.T) {
+	md, err := setFrontMatter(testIdeaMarkdown, "title", `Reward "gaming"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if md, err = setFrontMatter(md, "title_zh", "奖励"); err != nil {
+		t.Fatal(err)
+	}
+	doc, _ := parseIdea(md)
+	if doc.Title != `Reward "gaming"` || doc.TitleZh != "奖励" || doc.Slug != "reward" || doc.ContentEn != "Models exploit rewards." {
+		t.Errorf("set front matter:\n%s", md)
+	}
+	if _, err := setFrontMatter("no front matter", "title", "x"); err == nil {
+		t.Error("setting front matter of a file without one succeeded")
+	}
+}
+
+func TestAppendFollowUp(t *testing.T) {
+	date := time.Date(2025, 1, 3, 0, 0, 0, 0, time.Local)
+	augmented := buildMarkdown(bilingualContent{
+		date:        date,
+		slug:        "s",
+		titleEn:     "T",
+		titleZh:     "T",
+		contentEn:   "First.",
+		contentZh:   "第一。",
+		augmentedEn: "More.",
+		augmentedZh: "更多。",
+	})
+
+	tests := []struct {
+		name   string
+		md     string
+		wantEn string
+		wantZh string
+		aug    string
+	}{
+		{
+			name:   "plain",
+			md:     testIdeaMarkdown,
+			wantEn: "Models exploit rewards.\n\n### Follow-up (2025-01-03)\n\nLater.",
+			wantZh: "模型利用奖励。\n\n### 后续（2025-01-03）\n\n后来。",
+		},
+		{
+			name:   "before augmentation",
+			md:     augmented,
+			wantEn: "First.\n\n### Follow-up (2025-01-03)\n\nLater.",
+			wantZh: "第一。\n\n### 后续（2025-01-03）\n\n后来。",
+			aug:    "More.",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			md, err := appendFollowUp(tt.md, date, " Later.\n", "后来。")
+			if err != nil {
+				t.Fatal(err)
+			}
+			doc, err := parseIdea(md)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if doc.ContentEn != tt.wantEn || doc.ContentZh != tt.wantZh || doc.AugmentedEn != tt.aug {
+				t.Errorf("appended:\n%s", md)
+			}
+		})
+	}
+
+	if _, err := appendFollowUp("---\ntitle: x\n---\n\nno blocks\n", date, "a", "b"); err == nil {
+		t.Error("appending to an idea without language blocks succeeded")
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Mastodon counts every URL as 23 characters regardless of its length.
+const (
+	mastodonMaxChars  = 500
+	mastodonURLLength = 23
+)
+
+type mastodonClient struct {
+	server     string // e.g. "https://mastodon.social"
+	token      string // access token with the write:statuses scope
+	visibility string // "public", "unlisted", "private"
+}
+
+type mastodonStatus struct {
+	ID  string `json:"id"`
+	URL string `json:"url"`
+}
+
+// post publishes a status, as a reply if replyTo is a status ID. The
+// idempotency key prevents duplicates when a request is retried.
+func (c *mastodonClient) post(ctx context.Context, status, replyTo, idempotencyKey string) (*mastodonStatus, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	params := map[string]string{
+		"status":     status,
+		"visibility": c.visibility,
+	}
+	if replyTo != "" {
+		params["in_reply_to_id"] = replyTo
+	}
+	body, err := json.Marshal(params)
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", strings.TrimRight(c.server, "/")+"/api/v1/statuses", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Idempotency-Key", idempotencyKey)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("send request: %w", err)
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Mastodon API returned %d: %s", resp.StatusCode, string(respBody))
+	}
+	var result mastodonStatus
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, fmt.Errorf("unmarshal response: %w", err)
+	}
+	return &result, nil
+}
+
+// composeToot formats the status for a published idea, shortening the
+// teaser so that the status fits the character limit.
+func composeToot(title, teaser, url string) string {
+	fixed := utf8.RuneCountInString(title) + mastodonURLLength + len("\n\n\n\n")
+	teaser = strings.TrimSpace(teaser)
+	if room := mastodonMaxChars - fixed; utf8.RuneCountInString(teaser) > room {
+		if room <= 1 {
+			teaser = ""
+		} else {
+			teaser = string([]rune(teaser)[:room-1]) + "…"
+		}
+	}
+	if teaser == "" {
+		return title + "\n\n" + url
+	}
+	return title + "\n\n" + teaser + "\n\n" + url
+}
+
+// crosspost announces a published idea on Mastodon.
+func (s *service) crosspost(ctx context.Context, captureID, title, content, url string) {
+	teaser, err := s.llm.writeTeaser(ctx, title, content)
+	if err != nil {
+		s.log.Printf("teaser generation failed, posting without: %v", err)
+		teaser = ""
+	}
+	st, err := s.mastodon.post(ctx, composeToot(title, teaser, url), "", captureID)
+	if err != nil {
+		s.log.Printf("Mastodon cross-post failed: %v", err)
+		return
+	}
+	s.log.Printf("cross-posted to Mastodon: %s", st.URL)
+}
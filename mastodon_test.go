@@ -0,0 +1,30 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestComposeToot(t *testing.T) {
+	url := "https://changkun.de/ideas/some-rather-long-slug-for-testing/"
+
+	got := composeToot("Reward hacking", "Models exploit their rewards.", url)
+	if want := "Reward hacking\n\nModels exploit their rewards.\n\n" + url; got != want {
+		t.Errorf("composeToot = %q, want %q", got, want)
+	}
+
+	if got := composeToot("Title", "", url); got != "Title\n\n"+url {
+		t.Errorf("composeToot without teaser = %q", got)
+	}
+
+	long := strings.Repeat("思", 600)
+	got = composeToot("Title", long, url)
+	counted := utf8.RuneCountInString(strings.TrimSuffix(got, url)) + mastodonURLLength
+	if counted != mastodonMaxChars {
+		t.Errorf("long toot counts %d characters, want %d", counted, mastodonMaxChars)
+	}
+	if !strings.Contains(got, "…\n\n"+url) {
+		t.Errorf("long teaser is not truncated with an ellipsis: %q", got)
+	}
+}
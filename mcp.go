@@ -0,0 +1,245 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// This file implements a Model Context Protocol server so that agent
+// tools can capture, improve, and search ideas. The HTTP transport is
+// served at /ideas/mcp behind the regular authentication; the stdio
+// transport is provided by "idea mcp", which relays messages to it.
+
+// mcpProtocolVersions are the supported protocol revisions, newest first.
+var mcpProtocolVersions = []string{"2025-06-18", "2025-03-26", "2024-11-05"}
+
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"` // absent for notifications
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// JSON-RPC error codes.
+const (
+	rpcParseError     = -32700
+	rpcInvalidRequest = -32600
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+)
+
+type mcpTool struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	InputSchema map[string]any `json:"inputSchema"`
+}
+
+var mcpTools = []mcpTool{
+	{
+		Name:        "post_idea",
+		Description: "Capture an idea. It is polished, translated, augmented with research context, and published to the ideas stream in the background.",
+		InputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"content": map[string]any{"type": "string", "description": "The idea in markdown"},
+				"title":   map[string]any{"type": "string", "description": "Optional title, generated if empty"},
+				"draft":   map[string]any{"type": "boolean", "description": "Publish as a draft hidden from the live site"},
+				"tags":    map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+			},
+			"required": []string{"content"},
+		},
+	},
+	{
+		Name:        "improve_text",
+		Description: "Fix typos and grammar and improve the flow of a text without changing its meaning. Nothing is published.",
+		InputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"content": map[string]any{"type": "string"},
+			},
+			"required": []string{"content"},
+		},
+	},
+	{
+		Name:        "search_ideas",
+		Description: "Full-text search over previously captured ideas, in English or Chinese.",
+		InputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"query": map[string]any{"type": "string"},
+				"limit": map[string]any{"type": "integer", "description": "Maximum number of results, default 10"},
+			},
+			"required": []string{"query"},
+		},
+	},
+}
+
+// handleMCP serves the MCP Streamable HTTP transport. Each POST carries
+// one JSON-RPC message; responses are returned as plain JSON.
+func (s *service) handleMCP(w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	var req rpcRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		writeRPC(w, rpcResponse{ID: json.RawMessage("null"), Error: &rpcError{rpcParseError, "parse error"}})
+		return
+	}
+	if req.ID == nil {
+		// Notifications and client responses need no reply.
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		writeRPC(w, rpcResponse{ID: req.ID, Error: &rpcError{rpcInvalidRequest, "invalid request"}})
+		return
+	}
+
+	result, rerr := s.mcpCall(r, req.Method, req.Params)
+	writeRPC(w, rpcResponse{ID: req.ID, Result: result, Error: rerr})
+}
+
+func writeRPC(w http.ResponseWriter, resp rpcResponse) {
+	resp.JSONRPC = "2.0"
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (s *service) mcpCall(r *http.Request, method string, params json.RawMessage) (any, *rpcError) {
+	switch method {
+	case "initialize":
+		var p struct {
+			ProtocolVersion string `json:"protocolVersion"`
+		}
+		json.Unmarshal(params, &p)
+		version := mcpProtocolVersions[0]
+		if slices.Contains(mcpProtocolVersions, p.ProtocolVersion) {
+			version = p.ProtocolVersion
+		}
+		return map[string]any{
+			"protocolVersion": version,
+			"capabilities":    map[string]any{"tools": map[string]any{}},
+			"serverInfo":      map[string]any{"name": "ideas", "version": "1.0.0"},
+			"instructions":    "Use post_idea to capture ideas for the user, search_ideas to find earlier ones before posting a duplicate, and improve_text to polish drafts.",
+		}, nil
+	case "ping":
+		return map[string]any{}, nil
+	case "tools/list":
+		return map[string]any{"tools": mcpTools}, nil
+	case "tools/call":
+		var p struct {
+			Name      string          `json:"name"`
+			Arguments json.RawMessage `json:"arguments"`
+		}
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, &rpcError{rpcInvalidParams, "invalid params"}
+		}
+		text, err := s.mcpTool(r, p.Name, p.Arguments)
+		if err != nil {
+			var rerr *rpcError
+			if errors.As(err, &rerr) {
+				return nil, rerr
+			}
+			// Tool failures are reported to the model, not as protocol errors.
+			return mcpToolResult(err.Error(), true), nil
+		}
+		return mcpToolResult(text, false), nil
+	}
+	return nil, &rpcError{rpcMethodNotFound, "method not found: " + method}
+}
+
+func (e *rpcError) Error() string { return e.Message }
+
+func mcpToolResult(text string, isError bool) map[string]any {
+	return map[string]any{
+		"content": []map[string]any{{"type": "text", "text": text}},
+		"isError": isError,
+	}
+}
+
+func (s *service) mcpTool(r *http.Request, name string, args json.RawMessage) (string, error) {
+	switch name {
+	case "post_idea":
+		var req ideaRequest
+		if err := json.Unmarshal(args, &req); err != nil || strings.TrimSpace(req.Content) == "" {
+			return "", &rpcError{rpcInvalidParams, "content is required"}
+		}
+		req.Augmented = "" // always augment on the server
+		req.Review = false
+		req.requestID = requestIDFromContext(r.Context())
+		req.user = requestUser(r)
+		if _, ok := s.enqueueIdea(req); !ok {
+			return "", errBusy
+		}
+		return "Idea accepted, publishing in background.", nil
+
+	case "improve_text":
+		var req struct {
+			Content string `json:"content"`
+		}
+		if err := json.Unmarshal(args, &req); err != nil || strings.TrimSpace(req.Content) == "" {
+			return "", &rpcError{rpcInvalidParams, "content is required"}
+		}
+		improved, err := s.llm.improveContent(r.Context(), req.Content)
+		if err != nil {
+			s.log.Printf("content improvement failed: %v", err)
+			return "", fmt.Errorf("content improvement failed")
+		}
+		return improved, nil
+
+	case "search_ideas":
+		var req struct {
+			Query string `json:"query"`
+			Limit int    `json:"limit"`
+		}
+		if err := json.Unmarshal(args, &req); err != nil || strings.TrimSpace(req.Query) == "" {
+			return "", &rpcError{rpcInvalidParams, "query is required"}
+		}
+		if req.Limit <= 0 || req.Limit > 50 {
+			req.Limit = 10
+		}
+		if !s.isOwner(r.Context()) {
+			// The archive is of the server's own blog.
+			return "No matching ideas.", nil
+		}
+		hits := s.index.search(req.Query, req.Limit)
+		if len(hits) == 0 {
+			return "No matching ideas.", nil
+		}
+		var b strings.Builder
+		for _, h := range hits {
+			d := h.Idea
+			fmt.Fprintf(&b, "## %s\n", d.Title)
+			fmt.Fprintf(&b, "%s · %s", d.Date.Format("2006-01-02"), s.site.url(d.Slug))
+			if d.Draft {
+				b.WriteString(" · draft")
+			}
+			fmt.Fprintf(&b, "\n\n%s\n\n", d.ContentEn)
+		}
+		return strings.TrimSpace(b.String()), nil
+	}
+	return "", &rpcError{rpcInvalidParams, "unknown tool: " + name}
+}
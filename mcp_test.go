@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHandleMCP(t *testing.T) {
+	l := log.New(io.Discard, "", 0)
+	s := &service{
+		log:   l,
+		index: newArchiveIndex(filepath.Join(t.TempDir(), "index.json"), l),
+		site:  newSiteConfig("", "", "", ""),
+	}
+	s.index.put("content/ideas/2025-01-01-reward.md", "sha", "---\ndate: 2025-01-01T00:00:00\nslug: \"reward\"\ntitle: \"Reward hacking\"\n---\n\n{{% en %}}\nModels exploit rewards.\n{{% /en %}}\n\n{{% zh %}}\n模型利用奖励。\n{{% /zh %}}\n")
+
+	call := func(body string) (int, rpcResponse) {
+		rec := httptest.NewRecorder()
+		s.handleMCP(rec, httptest.NewRequest("POST", "/ideas/mcp", strings.NewReader(body)))
+		var resp rpcResponse
+		if rec.Code == http.StatusOK {
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("decode %s: %v", rec.Body, err)
+			}
+		}
+		return rec.Code, resp
+	}
+	result := func(resp rpcResponse) string {
+		b, _ := json.Marshal(resp.Result)
+		return string(b)
+	}
+
+	_, resp := call(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26"}}`)
+	if got := result(resp); !strings.Contains(got, `"protocolVersion":"2025-03-26"`) {
+		t.Errorf("initialize = %s", got)
+	}
+
+	if code, _ := call(`{"jsonrpc":"2.0","method":"notifications/initialized"}`); code != http.StatusAccepted {
+		t.Errorf("notification status = %d, want 202", code)
+	}
+
+	_, resp = call(`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`)
+	for _, name := range []string{"post_idea", "improve_text", "search_ideas"} {
+		if !strings.Contains(result(resp), `"name":"`+name+`"`) {
+			t.Errorf("tools/list misses %s", name)
+		}
+	}
+
+	_, resp = call(`{"jsonrpc":"2.0","id":"3","method":"tools/call","params":{"name":"search_ideas","arguments":{"query":"rewards"}}}`)
+	if got := result(resp); !strings.Contains(got, "Reward hacking") || !strings.Contains(got, "https://changkun.de/ideas/reward/") {
+		t.Errorf("search_ideas = %s", got)
+	}
+	if string(resp.ID) != `"3"` {
+		t.Errorf("id = %s, want \"3\"", resp.ID)
+	}
+
+	_, resp = call(`{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"post_idea","arguments":{}}}`)
+	if resp.Error == nil || resp.Error.Code != rpcInvalidParams {
+		t.Errorf("post_idea without content: error = %+v", resp.Error)
+	}
+
+	_, resp = call(`{"jsonrpc":"2.0","id":5,"method":"resources/list"}`)
+	if resp.Error == nil || resp.Error.Code != rpcMethodNotFound {
+		t.Errorf("unknown method: error = %+v", resp.Error)
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// notifier sends push notifications to ntfy and/or Pushover, so that
+// ideas posted fire-and-forget from a phone still get a confirmation.
+type notifier struct {
+	log *log.Logger
+
+	ntfyURL   string // topic URL, e.g. "https://ntfy.sh/my-ideas"
+	ntfyToken string // access token for protected topics, optional
+
+	pushoverToken string // application token
+	pushoverUser  string // user or group key
+}
+
+type notification struct {
+	title   string
+	message string
+	url     string // opened when the notification is tapped, optional
+	high    bool   // high priority, e.g. for failures
+}
+
+// send delivers n to all configured services. It is safe to call on a
+// nil notifier; errors are logged.
+func (nt *notifier) send(ctx context.Context, n notification) {
+	if nt == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	if nt.ntfyURL != "" {
+		if err := nt.sendNtfy(ctx, n); err != nil {
+			nt.log.Printf("ntfy notification failed: %v", err)
+		}
+	}
+	if nt.pushoverToken != "" {
+		if err := nt.sendPushover(ctx, n); err != nil {
+			nt.log.Printf("Pushover notification failed: %v", err)
+		}
+	}
+}
+
+// sendNtfy publishes n as described in https://docs.ntfy.sh/publish/.
+func (nt *notifier) sendNtfy(ctx context.Context, n notification) error {
+	req, err := http.NewRequestWithContext(ctx, "POST", nt.ntfyURL, strings.NewReader(n.message))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	// Header values must be ASCII; ntfy decodes RFC 2047 encoded words.
+	req.Header.Set("Title", mimeHeader(n.title))
+	req.Header.Set("Tags", "bulb")
+	if n.url != "" {
+		req.Header.Set("Click", n.url)
+	}
+	if n.high {
+		req.Header.Set("Priority", "high")
+		req.Header.Set("Tags", "warning")
+	}
+	if nt.ntfyToken != "" {
+		req.Header.Set("Authorization", "Bearer "+nt.ntfyToken)
+	}
+	return doNotify(req)
+}
+
+// sendPushover sends n as described in https://pushover.net/api.
+func (nt *notifier) sendPushover(ctx context.Context, n notification) error {
+	form := url.Values{
+		"token":   {nt.pushoverToken},
+		"user":    {nt.pushoverUser},
+		"title":   {n.title},
+		"message": {n.message},
+	}
+	if n.url != "" {
+		form.Set("url", n.url)
+	}
+	if n.high {
+		form.Set("priority", "1")
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", "https://api.pushover.net/1/messages.json", strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return doNotify(req)
+}
+
+func doNotify(req *http.Request) error {
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("send request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("%s returned %d: %s", req.URL.Host, resp.StatusCode, string(body))
+	}
+	return nil
+}
+
+// mimeHeader encodes s as an RFC 2047 word if it is not plain ASCII.
+func mimeHeader(s string) string {
+	for _, r := range s {
+		if r > 127 {
+			return mime.BEncoding.Encode("utf-8", s)
+		}
+	}
+	return s
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNotifierNtfy(t *testing.T) {
+	var got *http.Request
+	var body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		got, body = r, string(b)
+	}))
+	defer srv.Close()
+
+	nt := &notifier{log: log.New(io.Discard, "", 0), ntfyURL: srv.URL + "/ideas", ntfyToken: "tk"}
+	nt.send(context.Background(), notification{
+		title:   "Idea published",
+		message: "奖励黑客",
+		url:     "https://changkun.de/ideas/reward/",
+	})
+	if got == nil {
+		t.Fatal("no request sent")
+	}
+	if got.URL.Path != "/ideas" || body != "奖励黑客" {
+		t.Errorf("request = %s %q", got.URL.Path, body)
+	}
+	if got.Header.Get("Click") != "https://changkun.de/ideas/reward/" || got.Header.Get("Authorization") != "Bearer tk" {
+		t.Errorf("headers = %v", got.Header)
+	}
+
+	nt.send(context.Background(), notification{title: "想法", message: "x", high: true})
+	if got.Header.Get("Priority") != "high" || got.Header.Get("Title") != "=?utf-8?b?5oOz5rOV?=" {
+		t.Errorf("headers = %v", got.Header)
+	}
+
+	var nilNotifier *notifier
+	nilNotifier.send(context.Background(), notification{title: "ignored"})
+}
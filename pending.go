@@ -0,0 +1,220 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxPendingAttempts is how often a pending idea is run through its
+// pipeline before it is set aside in the failed/ subdirectory for a
+// person to look at.
+const maxPendingAttempts = 5
+
+// pendingStore remembers, by path, what became of the pending ideas
+// across rounds and restarts, so that one failing is not run through
+// the LLM forever, and one published is not published again when its
+// file could not be removed.
+type pendingStore struct {
+	path string
+	log  *log.Logger
+
+	mu    sync.Mutex
+	ideas map[string]*pendingState
+}
+
+type pendingState struct {
+	Attempts  int    `json:"attempts"`
+	Error     string `json:"error,omitempty"` // of the last attempt
+	Published bool   `json:"published,omitempty"`
+	SetAside  bool   `json:"set_aside,omitempty"` // copied to failed/
+}
+
+func newPendingStore(path string, l *log.Logger) *pendingStore {
+	ps := &pendingStore{path: path, log: l, ideas: map[string]*pendingState{}}
+	if err := readJSONFile(path, &ps.ideas); err != nil {
+		l.Printf("cannot load pending ideas: %v", err)
+	}
+	return ps
+}
+
+// save persists the store. Callers hold mu.
+func (ps *pendingStore) save() {
+	if err := writeJSONFile(ps.path, ps.ideas); err != nil {
+		ps.log.Printf("cannot save pending ideas: %v", err)
+	}
+}
+
+// get returns the state of the pending idea at p.
+func (ps *pendingStore) get(p string) pendingState {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	if st, ok := ps.ideas[p]; ok {
+		return *st
+	}
+	return pendingState{}
+}
+
+// update changes the state of the pending idea at p with f and returns
+// it.
+func (ps *pendingStore) update(p string, f func(*pendingState)) pendingState {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	st, ok := ps.ideas[p]
+	if !ok {
+		st = &pendingState{}
+		ps.ideas[p] = st
+	}
+	f(st)
+	ps.save()
+	return *st
+}
+
+// forget drops the pending idea at p, once its file is gone.
+func (ps *pendingStore) forget(p string) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	if _, ok := ps.ideas[p]; ok {
+		delete(ps.ideas, p)
+		ps.save()
+	}
+}
+
+// pendingIdea is an idea the CLI committed to the repository while the
+// server was unreachable: the request it would have posted, and when it
+// was captured.
+type pendingIdea struct {
+	ideaRequest
+	Captured time.Time `json:"captured"`
+}
+
+// parsePending parses a pending idea file into the request to publish.
+func parsePending(data string) (ideaRequest, error) {
+	var p pendingIdea
+	if err := json.Unmarshal([]byte(data), &p); err != nil {
+		return ideaRequest{}, err
+	}
+	req := p.ideaRequest
+	if strings.TrimSpace(req.Content) == "" {
+		return ideaRequest{}, errors.New("content is required")
+	}
+	switch req.Lang {
+	case "", "en", "zh":
+	case "auto":
+		req.Lang = ""
+	default:
+		return ideaRequest{}, fmt.Errorf("invalid lang %q", req.Lang)
+	}
+	req.date = p.Captured
+	req.Review = false // no one is waiting to review it
+	return req, nil
+}
+
+// runPending publishes the pending ideas in dir at startup and then every
+// interval.
+func (s *service) runPending(ctx context.Context, dir string, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		if err := s.publishPending(ctx, dir); err != nil {
+			s.log.Printf("publishing pending ideas failed: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// publishPending runs every pending idea in dir through its pipeline and
+// removes the file once published. Ideas that fail stay for the next
+// round, up to maxPendingAttempts, and are then moved to dir/failed/,
+// as are files that cannot be published at all.
+func (s *service) publishPending(ctx context.Context, dir string) error {
+	files, err := s.github.listDir(ctx, dir)
+	if errors.Is(err, errDirNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		if path.Ext(f.Path) == ".json" {
+			s.publishPendingFile(ctx, dir, f.Path)
+		}
+	}
+	return nil
+}
+
+// publishPendingFile publishes the pending idea at p in dir, unless it
+// was published before, and removes it.
+func (s *service) publishPendingFile(ctx context.Context, dir, p string) {
+	data, sha, err := s.github.getFile(ctx, p)
+	if err != nil {
+		s.log.Printf("pending idea %s: %v", p, err)
+		return
+	}
+	st := s.pending.get(p)
+	if st.SetAside || st.Attempts >= maxPendingAttempts {
+		s.setAsidePending(ctx, dir, p, data, sha)
+		return
+	}
+	if !st.Published {
+		req, err := parsePending(data)
+		if err == nil && !s.hasPipeline(req.Pipeline) {
+			err = fmt.Errorf("unknown pipeline %q", req.Pipeline)
+		}
+		if err != nil {
+			s.log.Printf("pending idea %s: %v", p, err)
+			s.setAsidePending(ctx, dir, p, data, sha)
+			return
+		}
+		s.log.Printf("publishing pending idea %s", p)
+		if _, err := s.processIdea(req); err != nil {
+			st := s.pending.update(p, func(st *pendingState) { st.Attempts, st.Error = st.Attempts+1, err.Error() })
+			if st.Attempts >= maxPendingAttempts {
+				s.log.Printf("pending idea %s failed %d times, last: %v", p, st.Attempts, err)
+				s.setAsidePending(ctx, dir, p, data, sha)
+			}
+			return
+		}
+		s.pending.update(p, func(st *pendingState) { st.Published = true })
+	}
+	msg := sanitizeCommitMsg("ideas: remove published " + path.Base(p))
+	if err := s.github.deleteFile(ctx, p, sha, msg); err != nil {
+		s.log.Printf("pending idea %s is published but not removed: %v", p, err)
+		return
+	}
+	s.pending.forget(p)
+}
+
+// setAsidePending moves the pending idea at p, whose content is data and
+// blob SHA sha, to the failed/ subdirectory of dir, where it is no longer
+// published.
+func (s *service) setAsidePending(ctx context.Context, dir, p, data, sha string) {
+	failed := path.Join(dir, "failed", path.Base(p))
+	msg := sanitizeCommitMsg("ideas: set aside failed " + path.Base(p))
+	if !s.pending.get(p).SetAside {
+		if _, err := s.github.createFile(ctx, failed, data, msg); err != nil {
+			s.log.Printf("cannot set aside pending idea %s: %v", p, err)
+			return
+		}
+		s.pending.update(p, func(st *pendingState) { st.SetAside = true })
+	}
+	if err := s.github.deleteFile(ctx, p, sha, msg); err != nil {
+		s.log.Printf("cannot remove pending idea %s set aside: %v", p, err)
+		return
+	}
+	s.pending.forget(p)
+}
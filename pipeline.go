@@ -0,0 +1,407 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// pipelineRun is the state of an idea as it passes through the stages
+// of a pipeline.
+type pipelineRun struct {
+	ctx context.Context
+	id  string // capture ID, also the job ID
+	req ideaRequest
+
+	enriched string // content with the text of linked pages
+	lang     string // "en" or "zh"
+
+	titleEn, titleZh     string
+	contentEn, contentZh string
+
+	augmented                string // in the original language
+	augmentedEn, augmentedZh string
+
+	warnings []string // problems worth reporting that did not stop the run
+
+	// Set once the idea was reviewed, with the slug and categories given
+	// in review, if any.
+	reviewed   bool
+	slug       string
+	categories []string
+
+	path      string // repository file, set once published
+	url       string // public URL, set once published
+	commitURL string // set once published
+	commitSHA string // set once published
+
+	rest      []string // stages after the running one
+	committed bool     // by the commit queue, before the run resumed
+}
+
+// savedRun is a pipeline run persisted to be resumed later, with the
+// stages left to run: those after publishing, for an idea whose commit
+// was queued.
+type savedRun struct {
+	Stages      []string    `json:"stages"`
+	Request     ideaRequest `json:"request"`
+	Date        time.Time   `json:"date,omitzero"` // of Request, which its JSON leaves out
+	Lang        string      `json:"lang,omitempty"`
+	TitleEn     string      `json:"title_en"`
+	TitleZh     string      `json:"title_zh"`
+	ContentEn   string      `json:"content_en"`
+	ContentZh   string      `json:"content_zh"`
+	Augmented   string      `json:"augmented,omitempty"`
+	AugmentedEn string      `json:"augmented_en,omitempty"`
+	AugmentedZh string      `json:"augmented_zh,omitempty"`
+	Warnings    []string    `json:"warnings,omitempty"`
+	Reviewed    bool        `json:"reviewed,omitempty"`
+	Slug        string      `json:"slug,omitempty"`
+	Categories  []string    `json:"categories,omitempty"`
+	Path        string      `json:"path,omitempty"`
+	URL         string      `json:"url,omitempty"`
+}
+
+// save returns the run to resume with its remaining stages.
+func (run *pipelineRun) save() *savedRun {
+	return &savedRun{
+		Stages:      run.rest,
+		Request:     run.req,
+		Date:        run.req.date,
+		Lang:        run.lang,
+		TitleEn:     run.titleEn,
+		TitleZh:     run.titleZh,
+		ContentEn:   run.contentEn,
+		ContentZh:   run.contentZh,
+		Augmented:   run.augmented,
+		AugmentedEn: run.augmentedEn,
+		AugmentedZh: run.augmentedZh,
+		Warnings:    run.warnings,
+		Reviewed:    run.reviewed,
+		Slug:        run.slug,
+		Categories:  run.categories,
+		Path:        run.path,
+		URL:         run.url,
+	}
+}
+
+// restore returns the saved run of the job id. The request loses who
+// posted it, which callers set again.
+func (sr *savedRun) restore(id string) *pipelineRun {
+	req := sr.Request
+	req.date = sr.Date
+	return &pipelineRun{
+		id:          id,
+		req:         req,
+		enriched:    sr.Request.Content,
+		lang:        sr.Lang,
+		titleEn:     sr.TitleEn,
+		titleZh:     sr.TitleZh,
+		contentEn:   sr.ContentEn,
+		contentZh:   sr.ContentZh,
+		augmented:   sr.Augmented,
+		augmentedEn: sr.AugmentedEn,
+		augmentedZh: sr.AugmentedZh,
+		warnings:    sr.Warnings,
+		reviewed:    sr.Reviewed,
+		slug:        sr.Slug,
+		categories:  sr.Categories,
+		path:        sr.Path,
+		url:         sr.URL,
+	}
+}
+
+// pipelineStage is a step of a pipeline. Returning an error aborts the
+// run; stages that can degrade gracefully log and carry on instead.
+type pipelineStage func(s *service, run *pipelineRun) error
+
+// pipelineStages are the stages pipelines can be assembled from.
+var pipelineStages = map[string]pipelineStage{
+	"fetch":       (*service).stageFetch,
+	"title":       (*service).stageTitle,
+	"detect-lang": (*service).stageDetectLang,
+	"moderate":    (*service).stageModerate,
+	"translate":   (*service).stageTranslate,
+	"augment":     (*service).stageAugment,
+	"tag":         (*service).stageTag,
+	"link-check":  (*service).stageLinkCheck,
+	"publish":     (*service).stagePublish,
+	"crosspost":   (*service).stageCrosspost,
+	"notify":      (*service).stageNotify,
+}
+
+// postPublishStages need the published idea and must follow "publish".
+var postPublishStages = map[string]bool{"crosspost": true, "notify": true}
+
+// defaultPipeline is the flow used unless configured otherwise.
+var defaultPipeline = []string{"fetch", "title", "translate", "augment", "tag", "publish", "crosspost", "notify"}
+
+// validatePipeline checks that a pipeline only uses known stages, each
+// at most once, and publishes exactly once before the stages that
+// announce the idea.
+func validatePipeline(stages []string) error {
+	published := false
+	seen := map[string]bool{}
+	for _, name := range stages {
+		if _, ok := pipelineStages[name]; !ok {
+			return fmt.Errorf("unknown stage %q", name)
+		}
+		if seen[name] {
+			return fmt.Errorf("stage %q appears twice", name)
+		}
+		seen[name] = true
+		switch {
+		case name == "publish":
+			published = true
+		case postPublishStages[name] && !published:
+			return fmt.Errorf("stage %q must come after publish", name)
+		case !postPublishStages[name] && published:
+			return fmt.Errorf("stage %q must come before publish", name)
+		}
+	}
+	if !published {
+		return fmt.Errorf("pipeline does not publish")
+	}
+	return nil
+}
+
+// loadPipelines reads named pipelines, e.g. one per intake or user:
+//
+//	{
+//	  "default": ["fetch", "title", "translate", "augment", "publish", "crosspost", "notify"],
+//	  "suggest": ["moderate", "title", "translate", "link-check", "publish", "notify"]
+//	}
+//
+// A "default" entry replaces the built-in default pipeline.
+func loadPipelines(path string) (map[string][]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var pipelines map[string][]string
+	if err := json.Unmarshal(data, &pipelines); err != nil {
+		return nil, fmt.Errorf("parse pipelines: %w", err)
+	}
+	for name, stages := range pipelines {
+		if err := validatePipeline(stages); err != nil {
+			return nil, fmt.Errorf("pipeline %q: %w", name, err)
+		}
+	}
+	return pipelines, nil
+}
+
+// stagesFor returns the stages to run for req: its pipeline without the
+// stages the request opts out of.
+func (s *service) stagesFor(req ideaRequest) []string {
+	skip := map[string]bool{
+		"title":     req.NoTitle,
+		"translate": req.NoTranslate,
+		"augment":   req.NoAugment,
+		"tag":       req.NoTags,
+	}
+	var stages []string
+	for _, name := range s.pipeline(req.Pipeline) {
+		if !skip[name] {
+			stages = append(stages, name)
+		}
+	}
+	return stages
+}
+
+// pipeline returns the stages of the named pipeline, or of the default
+// one if name is empty or unknown.
+func (s *service) pipeline(name string) []string {
+	if stages, ok := s.pipelines[name]; ok {
+		return stages
+	}
+	if stages, ok := s.pipelines["default"]; ok {
+		return stages
+	}
+	return defaultPipeline
+}
+
+// hasPipeline reports whether name refers to a configured pipeline.
+func (s *service) hasPipeline(name string) bool {
+	_, ok := s.pipelines[name]
+	return name == "" || name == "default" || ok
+}
+
+// stageDetectLang detects the language of the idea without an LLM,
+// unless the request gave it. The translate stage refines it.
+func (s *service) stageDetectLang(run *pipelineRun) error {
+	s.jobs.stage(run.id, "detect-lang")
+	run.lang = cmp.Or(run.req.Lang, detectLang(run.req.Content))
+	s.jobs.event(run.id, "lang-detected", run.lang)
+	s.log.Printf("detected language: %s", run.lang)
+	return nil
+}
+
+const moderatePrompt = `You review ideas before they are published on a personal research blog.
+Reject content that is spam, advertising, abusive, hateful, sexually explicit, or that discloses private personal data.
+Accept everything else, including opinions you disagree with and rough, unfinished thoughts.
+Reply with ONLY a JSON object in this exact format, no other text:
+{"allowed": true or false, "reason": "short reason if rejected"}`
+
+func (c *llmClient) moderate(ctx context.Context, title, content string) (allowed bool, reason string, err error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	prompt := fmt.Sprintf("Title: %s\n\nContent:\n%s", title, content)
+	raw, err := c.complete(ctx, c.titleModel, moderatePrompt, prompt)
+	if err != nil {
+		return false, "", err
+	}
+	raw = strings.TrimSpace(raw)
+	raw = strings.TrimPrefix(raw, "```json")
+	raw = strings.TrimPrefix(raw, "```")
+	raw = strings.TrimSuffix(raw, "```")
+
+	var result struct {
+		Allowed bool   `json:"allowed"`
+		Reason  string `json:"reason"`
+	}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(raw)), &result); err != nil {
+		return false, "", fmt.Errorf("parse moderation response: %w (raw: %s)", err, raw)
+	}
+	return result.Allowed, result.Reason, nil
+}
+
+// stageModerate stops ideas that should not be published. If the check
+// itself fails, the idea is kept as a draft for a human to look at.
+func (s *service) stageModerate(run *pipelineRun) error {
+	s.jobs.stage(run.id, "moderate")
+	allowed, reason, err := s.llm.moderate(run.ctx, run.req.Title, run.req.Content)
+	if err != nil {
+		s.log.Printf("moderation failed, saving as draft: %v", err)
+		run.req.Draft = true
+		run.warnings = append(run.warnings, "moderation check failed, saved as draft")
+		return nil
+	}
+	if !allowed {
+		return fmt.Errorf("rejected by moderation: %s", reason)
+	}
+	return nil
+}
+
+const tagPrompt = `Suggest 1-3 short topic tags for the following idea, such as "ai", "programming", or "research".
+Use lowercase English words, hyphenated if needed.
+Reply with ONLY the tags separated by commas, nothing else.`
+
+func (c *llmClient) suggestTags(ctx context.Context, title, content string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	prompt := fmt.Sprintf("Title: %s\n\nContent:\n%s", title, content)
+	raw, err := c.complete(ctx, c.titleModel, tagPrompt, prompt)
+	if err != nil {
+		return nil, err
+	}
+	var tags []string
+	for _, t := range strings.Split(raw, ",") {
+		if t = normalizeTag(t); t != "" {
+			tags = append(tags, t)
+		}
+	}
+	if len(tags) == 0 {
+		return nil, fmt.Errorf("no tags suggested")
+	}
+	return tags, nil
+}
+
+// stageTag suggests tags for ideas posted without any. They are written
+// to the front matter, and with a taxonomy decide the categories of the
+// published idea.
+func (s *service) stageTag(run *pipelineRun) error {
+	if len(run.req.Tags) > 0 {
+		return nil
+	}
+	s.jobs.stage(run.id, "tag")
+	tags, err := s.llm.suggestTags(run.ctx, run.req.Title, run.req.Content)
+	if err != nil {
+		s.log.Printf("tag suggestion failed: %v", err)
+		return nil
+	}
+	s.log.Printf("suggested tags: %v", tags)
+	s.jobs.event(run.id, "tagged", strings.Join(tags, ", "))
+	run.req.Tags = tags
+	return nil
+}
+
+// stageLinkCheck reports links in the idea that do not resolve.
+func (s *service) stageLinkCheck(run *pipelineRun) error {
+	urls := extractURLs(run.req.Content)
+	if len(urls) == 0 {
+		return nil
+	}
+	s.jobs.stage(run.id, "link-check")
+	for _, u := range urls {
+		if err := checkLink(run.ctx, u); err != nil {
+			s.log.Printf("broken link %s: %v", u, err)
+			run.warnings = append(run.warnings, fmt.Sprintf("broken link %s: %v", u, err))
+		}
+	}
+	return nil
+}
+
+func checkLink(ctx context.Context, url string) error {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	// Some servers reject HEAD, so fall back to GET.
+	for _, method := range []string{"HEAD", "GET"} {
+		req, err := http.NewRequestWithContext(ctx, method, url, nil)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("User-Agent", "ChangkunIdeasBot/1.0")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode < 400 {
+			return nil
+		}
+		if method == "GET" || !slices.Contains([]int{http.StatusMethodNotAllowed, http.StatusForbidden, http.StatusNotImplemented}, resp.StatusCode) {
+			return fmt.Errorf("HTTP %d", resp.StatusCode)
+		}
+	}
+	return nil
+}
+
+// stageCrosspost announces a published idea on Mastodon. The account is
+// the server owner's, so ideas of other users are not announced.
+func (s *service) stageCrosspost(run *pipelineRun) error {
+	if s.mastodon == nil || run.req.Draft || run.req.NoCrosspost {
+		return nil
+	}
+	if _, own := s.userSite(run.req.user); !own {
+		return nil
+	}
+	s.jobs.stage(run.id, "crosspost")
+	s.crosspost(run.ctx, run.id, run.titleEn, run.contentEn, run.url)
+	return nil
+}
+
+// stageNotify confirms the published idea, along with any warnings.
+func (s *service) stageNotify(run *pipelineRun) error {
+	n := notification{title: "Idea published", message: run.titleEn, url: run.url}
+	if run.req.Draft {
+		n = notification{title: "Draft saved", message: run.titleEn + "\n\n" + run.path}
+	}
+	if len(run.warnings) > 0 {
+		n.message += "\n\n" + strings.Join(run.warnings, "\n")
+	}
+	s.notifier.send(run.ctx, n)
+	return nil
+}
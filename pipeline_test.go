@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestValidatePipeline(t *testing.T) {
+	tests := []struct {
+		stages  []string
+		wantErr string
+	}{
+		{stages: defaultPipeline},
+		{stages: []string{"publish"}},
+		{stages: []string{"detect-lang", "moderate", "tag", "link-check", "publish", "notify"}},
+		{stages: []string{"fetch", "unknown", "publish"}, wantErr: "unknown stage"},
+		{stages: []string{"title", "title", "publish"}, wantErr: "appears twice"},
+		{stages: []string{"notify", "publish"}, wantErr: "must come after publish"},
+		{stages: []string{"publish", "augment"}, wantErr: "must come before publish"},
+		{stages: []string{"fetch", "title"}, wantErr: "does not publish"},
+		{stages: nil, wantErr: "does not publish"},
+	}
+	for _, tt := range tests {
+		err := validatePipeline(tt.stages)
+		if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+			t.Errorf("validatePipeline(%v) = %v, want %q", tt.stages, err, tt.wantErr)
+		}
+	}
+}
+
+func TestLoadPipelines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pipelines.json")
+	os.WriteFile(path, []byte(`{"suggest": ["moderate", "title", "publish", "notify"]}`), 0o644)
+	pipelines, err := loadPipelines(path)
+	if err != nil {
+		t.Fatalf("loadPipelines: %v", err)
+	}
+	s := &service{pipelines: pipelines}
+	if got := s.pipeline("suggest"); !slices.Equal(got, []string{"moderate", "title", "publish", "notify"}) {
+		t.Errorf("pipeline(suggest) = %v", got)
+	}
+	if got := s.pipeline("feed"); !slices.Equal(got, defaultPipeline) {
+		t.Errorf("unconfigured pipeline = %v, want default", got)
+	}
+	if !s.hasPipeline("") || !s.hasPipeline("suggest") || s.hasPipeline("feed") {
+		t.Error("hasPipeline reports wrong pipelines")
+	}
+
+	os.WriteFile(path, []byte(`{"default": ["notify", "publish"]}`), 0o644)
+	if _, err := loadPipelines(path); err == nil || !strings.Contains(err.Error(), `pipeline "default"`) {
+		t.Errorf("invalid pipeline: err = %v", err)
+	}
+}
+
+func TestStageLinkCheck(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/missing":
+			http.NotFound(w, r)
+		case r.URL.Path == "/nohead" && r.Method == "HEAD":
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+	defer srv.Close()
+
+	l := log.New(io.Discard, "", 0)
+	s := &service{log: l, jobs: newJobStore(filepath.Join(t.TempDir(), "jobs.json"), l)}
+	run := &pipelineRun{
+		ctx: context.Background(),
+		req: ideaRequest{Content: "See " + srv.URL + "/ok, " + srv.URL + "/nohead and " + srv.URL + "/missing"},
+	}
+	s.stageLinkCheck(run)
+	if len(run.warnings) != 1 || !strings.Contains(run.warnings[0], "/missing: HTTP 404") {
+		t.Errorf("warnings = %q", run.warnings)
+	}
+}
+
+func TestStageDetectLang(t *testing.T) {
+	l := log.New(io.Discard, "", 0)
+	s := &service{log: l, jobs: newJobStore(filepath.Join(t.TempDir(), "jobs.json"), l)}
+	tests := []struct {
+		req  ideaRequest
+		want string
+	}{
+		{ideaRequest{Content: "只有中文"}, "zh"},
+		{ideaRequest{Content: "mostly English"}, "en"},
+		{ideaRequest{Content: "用 Go 写 benchmark", Lang: "en"}, "en"},
+	}
+	for _, tt := range tests {
+		run := &pipelineRun{req: tt.req}
+		s.stageDetectLang(run)
+		if run.lang != tt.want {
+			t.Errorf("lang of %q (given %q) = %q, want %s", tt.req.Content, tt.req.Lang, run.lang, tt.want)
+		}
+	}
+}
+
+func TestStagesFor(t *testing.T) {
+	s := &service{pipelines: map[string][]string{"short": {"title", "publish", "notify"}}}
+	tests := []struct {
+		name string
+		req  ideaRequest
+		want []string
+	}{
+		{"default", ideaRequest{}, defaultPipeline},
+		{"verbatim", ideaRequest{NoTitle: true, NoTranslate: true, NoAugment: true, NoTags: true}, []string{"fetch", "publish", "crosspost", "notify"}},
+		{"no augment", ideaRequest{NoAugment: true}, []string{"fetch", "title", "translate", "tag", "publish", "crosspost", "notify"}},
+		{"no tags", ideaRequest{NoTags: true}, []string{"fetch", "title", "translate", "augment", "publish", "crosspost", "notify"}},
+		{"named pipeline", ideaRequest{Pipeline: "short", NoTitle: true}, []string{"publish", "notify"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := s.stagesFor(tt.req); !slices.Equal(got, tt.want) {
+				t.Errorf("stagesFor = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+var (
+	// errBusy reports that the worker pool's queue is full.
+	errBusy = errors.New("too many ideas are being published, try again later")
+	// errInterrupted reports that shutdown stopped a pipeline before it
+	// published, to be run again after restart.
+	errInterrupted = errors.New("interrupted by shutdown, resumed after restart")
+)
+
+// workerPool runs publishing pipelines on a fixed number of workers, so
+// that a burst of ideas does not flood the LLM and GitHub APIs. Pipelines
+// wait for a worker in a queue of bounded depth. A nil pool runs each
+// pipeline right away.
+type workerPool struct {
+	queue chan func()
+	slots chan struct{} // one per pipeline running or waiting
+}
+
+func newWorkerPool(workers, depth int) *workerPool {
+	p := &workerPool{
+		queue: make(chan func(), workers+depth),
+		slots: make(chan struct{}, workers+depth),
+	}
+	for range workers {
+		go p.work()
+	}
+	return p
+}
+
+func (p *workerPool) work() {
+	for f := range p.queue {
+		f()
+		<-p.slots
+	}
+}
+
+// reserve takes a place for run, reporting false if all workers are busy
+// and the queue is full.
+func (p *workerPool) reserve() bool {
+	if p == nil {
+		return true
+	}
+	select {
+	case p.slots <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+// run queues f in the place taken by reserve.
+func (p *workerPool) run(f func()) {
+	if p == nil {
+		go f()
+		return
+	}
+	p.queue <- f
+}
+
+// do queues f, waiting for a place if the queue is full, and returns
+// once a worker ran it.
+func (p *workerPool) do(f func()) {
+	if p == nil {
+		f()
+		return
+	}
+	p.slots <- struct{}{}
+	done := make(chan struct{})
+	p.queue <- func() {
+		defer close(done)
+		f()
+	}
+	<-done
+}
+
+// wait waits until no pipeline is running or waiting, or ctx is done,
+// and reports whether all finished.
+func (p *workerPool) wait(ctx context.Context) bool {
+	if p == nil {
+		return true
+	}
+	t := time.NewTicker(100 * time.Millisecond)
+	defer t.Stop()
+	for len(p.slots) > 0 {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-t.C:
+		}
+	}
+	return true
+}
+
+// halted reports whether shutdown interrupts the pipelines.
+func (s *service) halted() bool {
+	return s.halt != nil && s.halt.Err() != nil
+}
+
+// interrupt checkpoints a run stopped by shutdown before publishing, for
+// resumeJobs to run it again from the start.
+func (s *service) interrupt(run *pipelineRun) error {
+	s.log.Printf("job %s interrupted by shutdown", run.id)
+	s.jobs.interrupt(run.id)
+	return errInterrupted
+}
+
+// resumeJobs runs again the pipelines interrupted by the last shutdown.
+func (s *service) resumeJobs() {
+	for _, j := range s.jobs.interrupted() {
+		s.log.Printf("resuming job %s", j.ID)
+		if s.pool != nil {
+			s.jobs.stage(j.ID, "wait")
+		}
+		req := j.request()
+		req.requestID = j.RequestID
+		go s.pool.do(func() { s.runIdea(j.ID, req) })
+	}
+}
+
+// busy responds that the queue is full, for clients to retry later.
+func (s *service) busy(w http.ResponseWriter) {
+	w.Header().Set("Retry-After", "30")
+	s.codeError(w, codeBusy, errBusy.Error(), http.StatusTooManyRequests)
+}
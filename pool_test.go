@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWorkerPool(t *testing.T) {
+	p := newWorkerPool(2, 1)
+	release := make(chan struct{})
+	var running, peak atomic.Int32
+	job := func() {
+		n := running.Add(1)
+		for {
+			m := peak.Load()
+			if n <= m || peak.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		<-release
+		running.Add(-1)
+	}
+
+	// Two run, one waits in the queue, and a fourth finds it full.
+	for range 2 {
+		if !p.reserve() {
+			t.Fatal("reserve failed with idle workers")
+		}
+		p.run(job)
+	}
+	for deadline := time.Now().Add(5 * time.Second); running.Load() < 2; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("workers did not start")
+		}
+	}
+	if !p.reserve() {
+		t.Fatal("reserve failed with an empty queue")
+	}
+	p.run(job)
+	if p.reserve() {
+		t.Fatal("reserve succeeded with a full queue")
+	}
+
+	done := make(chan struct{})
+	go func() {
+		p.do(func() {})
+		close(done)
+	}()
+	close(release)
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("do did not run once there was room")
+	}
+	if peak.Load() != 2 {
+		t.Errorf("peak concurrency = %d, want 2", peak.Load())
+	}
+}
+
+func TestHandlePostBusy(t *testing.T) {
+	l := log.New(io.Discard, "", 0)
+	dir := t.TempDir()
+	s := &service{
+		log:       l,
+		jobs:      newJobStore(filepath.Join(dir, "jobs.json"), l),
+		lifecycle: newLifecycleStore(filepath.Join(dir, "lifecycle.json"), l),
+		// No workers: the first idea takes the one place in the queue.
+		pool: newWorkerPool(0, 1),
+	}
+	var codes []int
+	for range 2 {
+		rec := httptest.NewRecorder()
+		s.handlePost(rec, httptest.NewRequest("POST", "/ideas/post", strings.NewReader(`{"content":"An idea."}`)))
+		codes = append(codes, rec.Code)
+		if rec.Code == http.StatusTooManyRequests && rec.Header().Get("Retry-After") == "" {
+			t.Error("429 without Retry-After")
+		}
+	}
+	if codes[0] != http.StatusAccepted || codes[1] != http.StatusTooManyRequests {
+		t.Errorf("statuses = %v, want [202 429]", codes)
+	}
+	if jobs := s.jobs.recent(); len(jobs) != 1 || jobs[0].Stages[0].Name != "wait" {
+		t.Errorf("jobs = %+v, want one waiting", jobs)
+	}
+}
+
+func TestShutdownInterrupt(t *testing.T) {
+	l := log.New(io.Discard, "", 0)
+	dir := t.TempDir()
+	calls := make(chan struct{}, 2)
+	llm := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		calls <- struct{}{}
+		<-r.Context().Done()
+	}))
+	t.Cleanup(llm.Close)
+	halt, haltPipelines := context.WithCancel(context.Background())
+	s := &service{
+		log:       l,
+		llm:       &llmClient{baseURL: llm.URL, log: l},
+		jobs:      newJobStore(filepath.Join(dir, "jobs.json"), l),
+		lifecycle: newLifecycleStore(filepath.Join(dir, "lifecycle.json"), l),
+		pipelines: map[string][]string{"default": {"title", "publish"}},
+		pool:      newWorkerPool(1, 1),
+		halt:      halt,
+	}
+
+	// One pipeline waits on the LLM, the other for a worker.
+	var ids []string
+	for range 2 {
+		id, ok := s.enqueueIdea(ideaRequest{Content: "An idea."})
+		if !ok {
+			t.Fatal("enqueueIdea found the pool busy")
+		}
+		ids = append(ids, id)
+	}
+	select {
+	case <-calls:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the pipeline did not call the LLM")
+	}
+	drain, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if s.pool.wait(drain) {
+		t.Fatal("wait returned with a pipeline running")
+	}
+
+	haltPipelines()
+	wait, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if !s.pool.wait(wait) {
+		t.Fatal("interrupted pipelines did not stop")
+	}
+	for _, id := range ids {
+		if j, _ := s.jobs.get(id); j.Status != jobRunning || !j.Resume || j.Events[len(j.Events)-1].Name != "interrupted" {
+			t.Errorf("job %s = %+v, want interrupted", id, j)
+		}
+	}
+
+	// After restart, the jobs are resumed once.
+	js := newJobStore(s.jobs.path, l)
+	if got := js.interrupted(); len(got) != 2 || got[0].Request.Content != "An idea." {
+		t.Errorf("interrupted jobs = %+v", got)
+	}
+	if got := js.interrupted(); len(got) != 0 {
+		t.Errorf("jobs resumed twice: %+v", got)
+	}
+}
@@ -0,0 +1,1125 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: ideas/v1/ideas.proto
+
+// Package ideas.v1 is the gRPC interface of the ideas service, served
+// on IDEAS_GRPC_ADDR next to the HTTP API, which it mirrors.
+
+package ideasv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type PostRequest struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Title       string                 `protobuf:"bytes,1,opt,name=title,proto3" json:"title,omitempty"`
+	Content     string                 `protobuf:"bytes,2,opt,name=content,proto3" json:"content,omitempty"`
+	Draft       bool                   `protobuf:"varint,3,opt,name=draft,proto3" json:"draft,omitempty"`
+	Tags        []string               `protobuf:"bytes,4,rep,name=tags,proto3" json:"tags,omitempty"`
+	NoCrosspost bool                   `protobuf:"varint,5,opt,name=no_crosspost,json=noCrosspost,proto3" json:"no_crosspost,omitempty"`
+	NoTitle     bool                   `protobuf:"varint,6,opt,name=no_title,json=noTitle,proto3" json:"no_title,omitempty"`
+	NoTranslate bool                   `protobuf:"varint,7,opt,name=no_translate,json=noTranslate,proto3" json:"no_translate,omitempty"`
+	NoAugment   bool                   `protobuf:"varint,8,opt,name=no_augment,json=noAugment,proto3" json:"no_augment,omitempty"`
+	NoTags      bool                   `protobuf:"varint,9,opt,name=no_tags,json=noTags,proto3" json:"no_tags,omitempty"`
+	Pipeline    string                 `protobuf:"bytes,10,opt,name=pipeline,proto3" json:"pipeline,omitempty"`
+	// "en", "zh", or empty to detect.
+	Lang           string                 `protobuf:"bytes,11,opt,name=lang,proto3" json:"lang,omitempty"`
+	Review         bool                   `protobuf:"varint,12,opt,name=review,proto3" json:"review,omitempty"`
+	PublishAt      *timestamppb.Timestamp `protobuf:"bytes,13,opt,name=publish_at,json=publishAt,proto3" json:"publish_at,omitempty"`
+	AllowSecrets   bool                   `protobuf:"varint,14,opt,name=allow_secrets,json=allowSecrets,proto3" json:"allow_secrets,omitempty"`
+	AllowDuplicate bool                   `protobuf:"varint,15,opt,name=allow_duplicate,json=allowDuplicate,proto3" json:"allow_duplicate,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *PostRequest) Reset() {
+	*x = PostRequest{}
+	mi := &file_ideas_v1_ideas_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PostRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PostRequest) ProtoMessage() {}
+
+func (x *PostRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ideas_v1_ideas_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PostRequest.ProtoReflect.Descriptor instead.
+func (*PostRequest) Descriptor() ([]byte, []int) {
+	return file_ideas_v1_ideas_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *PostRequest) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *PostRequest) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+func (x *PostRequest) GetDraft() bool {
+	if x != nil {
+		return x.Draft
+	}
+	return false
+}
+
+func (x *PostRequest) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *PostRequest) GetNoCrosspost() bool {
+	if x != nil {
+		return x.NoCrosspost
+	}
+	return false
+}
+
+func (x *PostRequest) GetNoTitle() bool {
+	if x != nil {
+		return x.NoTitle
+	}
+	return false
+}
+
+func (x *PostRequest) GetNoTranslate() bool {
+	if x != nil {
+		return x.NoTranslate
+	}
+	return false
+}
+
+func (x *PostRequest) GetNoAugment() bool {
+	if x != nil {
+		return x.NoAugment
+	}
+	return false
+}
+
+func (x *PostRequest) GetNoTags() bool {
+	if x != nil {
+		return x.NoTags
+	}
+	return false
+}
+
+func (x *PostRequest) GetPipeline() string {
+	if x != nil {
+		return x.Pipeline
+	}
+	return ""
+}
+
+func (x *PostRequest) GetLang() string {
+	if x != nil {
+		return x.Lang
+	}
+	return ""
+}
+
+func (x *PostRequest) GetReview() bool {
+	if x != nil {
+		return x.Review
+	}
+	return false
+}
+
+func (x *PostRequest) GetPublishAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.PublishAt
+	}
+	return nil
+}
+
+func (x *PostRequest) GetAllowSecrets() bool {
+	if x != nil {
+		return x.AllowSecrets
+	}
+	return false
+}
+
+func (x *PostRequest) GetAllowDuplicate() bool {
+	if x != nil {
+		return x.AllowDuplicate
+	}
+	return false
+}
+
+type PostResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// ID of the job publishing the idea, to Watch.
+	Job           string `protobuf:"bytes,1,opt,name=job,proto3" json:"job,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PostResponse) Reset() {
+	*x = PostResponse{}
+	mi := &file_ideas_v1_ideas_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PostResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PostResponse) ProtoMessage() {}
+
+func (x *PostResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_ideas_v1_ideas_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PostResponse.ProtoReflect.Descriptor instead.
+func (*PostResponse) Descriptor() ([]byte, []int) {
+	return file_ideas_v1_ideas_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *PostResponse) GetJob() string {
+	if x != nil {
+		return x.Job
+	}
+	return ""
+}
+
+type ImproveRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Content       string                 `protobuf:"bytes,1,opt,name=content,proto3" json:"content,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ImproveRequest) Reset() {
+	*x = ImproveRequest{}
+	mi := &file_ideas_v1_ideas_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ImproveRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ImproveRequest) ProtoMessage() {}
+
+func (x *ImproveRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ideas_v1_ideas_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ImproveRequest.ProtoReflect.Descriptor instead.
+func (*ImproveRequest) Descriptor() ([]byte, []int) {
+	return file_ideas_v1_ideas_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ImproveRequest) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+type ImproveResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Content       string                 `protobuf:"bytes,1,opt,name=content,proto3" json:"content,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ImproveResponse) Reset() {
+	*x = ImproveResponse{}
+	mi := &file_ideas_v1_ideas_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ImproveResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ImproveResponse) ProtoMessage() {}
+
+func (x *ImproveResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_ideas_v1_ideas_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ImproveResponse.ProtoReflect.Descriptor instead.
+func (*ImproveResponse) Descriptor() ([]byte, []int) {
+	return file_ideas_v1_ideas_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ImproveResponse) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+type ListRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// At most 500, 50 if unset.
+	Limit int32 `protobuf:"varint,1,opt,name=limit,proto3" json:"limit,omitempty"`
+	// ID of the idea after which the page starts.
+	Cursor        string `protobuf:"bytes,2,opt,name=cursor,proto3" json:"cursor,omitempty"`
+	Tag           string `protobuf:"bytes,3,opt,name=tag,proto3" json:"tag,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListRequest) Reset() {
+	*x = ListRequest{}
+	mi := &file_ideas_v1_ideas_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListRequest) ProtoMessage() {}
+
+func (x *ListRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ideas_v1_ideas_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListRequest.ProtoReflect.Descriptor instead.
+func (*ListRequest) Descriptor() ([]byte, []int) {
+	return file_ideas_v1_ideas_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ListRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ListRequest) GetCursor() string {
+	if x != nil {
+		return x.Cursor
+	}
+	return ""
+}
+
+func (x *ListRequest) GetTag() string {
+	if x != nil {
+		return x.Tag
+	}
+	return ""
+}
+
+type ListResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Ideas []*IdeaSummary         `protobuf:"bytes,1,rep,name=ideas,proto3" json:"ideas,omitempty"`
+	// Empty on the last page.
+	NextCursor    string `protobuf:"bytes,2,opt,name=next_cursor,json=nextCursor,proto3" json:"next_cursor,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListResponse) Reset() {
+	*x = ListResponse{}
+	mi := &file_ideas_v1_ideas_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListResponse) ProtoMessage() {}
+
+func (x *ListResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_ideas_v1_ideas_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListResponse.ProtoReflect.Descriptor instead.
+func (*ListResponse) Descriptor() ([]byte, []int) {
+	return file_ideas_v1_ideas_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ListResponse) GetIdeas() []*IdeaSummary {
+	if x != nil {
+		return x.Ideas
+	}
+	return nil
+}
+
+func (x *ListResponse) GetNextCursor() string {
+	if x != nil {
+		return x.NextCursor
+	}
+	return ""
+}
+
+type IdeaSummary struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Path          string                 `protobuf:"bytes,2,opt,name=path,proto3" json:"path,omitempty"`
+	Url           string                 `protobuf:"bytes,3,opt,name=url,proto3" json:"url,omitempty"`
+	Date          *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=date,proto3" json:"date,omitempty"`
+	Slug          string                 `protobuf:"bytes,5,opt,name=slug,proto3" json:"slug,omitempty"`
+	Title         string                 `protobuf:"bytes,6,opt,name=title,proto3" json:"title,omitempty"`
+	TitleZh       string                 `protobuf:"bytes,7,opt,name=title_zh,json=titleZh,proto3" json:"title_zh,omitempty"`
+	Languages     []string               `protobuf:"bytes,8,rep,name=languages,proto3" json:"languages,omitempty"`
+	Draft         bool                   `protobuf:"varint,9,opt,name=draft,proto3" json:"draft,omitempty"`
+	Tags          []string               `protobuf:"bytes,10,rep,name=tags,proto3" json:"tags,omitempty"`
+	Categories    []string               `protobuf:"bytes,11,rep,name=categories,proto3" json:"categories,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *IdeaSummary) Reset() {
+	*x = IdeaSummary{}
+	mi := &file_ideas_v1_ideas_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IdeaSummary) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IdeaSummary) ProtoMessage() {}
+
+func (x *IdeaSummary) ProtoReflect() protoreflect.Message {
+	mi := &file_ideas_v1_ideas_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IdeaSummary.ProtoReflect.Descriptor instead.
+func (*IdeaSummary) Descriptor() ([]byte, []int) {
+	return file_ideas_v1_ideas_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *IdeaSummary) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *IdeaSummary) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *IdeaSummary) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *IdeaSummary) GetDate() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Date
+	}
+	return nil
+}
+
+func (x *IdeaSummary) GetSlug() string {
+	if x != nil {
+		return x.Slug
+	}
+	return ""
+}
+
+func (x *IdeaSummary) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *IdeaSummary) GetTitleZh() string {
+	if x != nil {
+		return x.TitleZh
+	}
+	return ""
+}
+
+func (x *IdeaSummary) GetLanguages() []string {
+	if x != nil {
+		return x.Languages
+	}
+	return nil
+}
+
+func (x *IdeaSummary) GetDraft() bool {
+	if x != nil {
+		return x.Draft
+	}
+	return false
+}
+
+func (x *IdeaSummary) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *IdeaSummary) GetCategories() []string {
+	if x != nil {
+		return x.Categories
+	}
+	return nil
+}
+
+type GetRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetRequest) Reset() {
+	*x = GetRequest{}
+	mi := &file_ideas_v1_ideas_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetRequest) ProtoMessage() {}
+
+func (x *GetRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ideas_v1_ideas_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetRequest.ProtoReflect.Descriptor instead.
+func (*GetRequest) Descriptor() ([]byte, []int) {
+	return file_ideas_v1_ideas_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *GetRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type Idea struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Path          string                 `protobuf:"bytes,2,opt,name=path,proto3" json:"path,omitempty"`
+	Sha           string                 `protobuf:"bytes,3,opt,name=sha,proto3" json:"sha,omitempty"`
+	Url           string                 `protobuf:"bytes,4,opt,name=url,proto3" json:"url,omitempty"`
+	GithubUrl     string                 `protobuf:"bytes,5,opt,name=github_url,json=githubUrl,proto3" json:"github_url,omitempty"`
+	Date          *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=date,proto3" json:"date,omitempty"`
+	Slug          string                 `protobuf:"bytes,7,opt,name=slug,proto3" json:"slug,omitempty"`
+	Title         string                 `protobuf:"bytes,8,opt,name=title,proto3" json:"title,omitempty"`
+	TitleZh       string                 `protobuf:"bytes,9,opt,name=title_zh,json=titleZh,proto3" json:"title_zh,omitempty"`
+	Draft         bool                   `protobuf:"varint,10,opt,name=draft,proto3" json:"draft,omitempty"`
+	Tags          []string               `protobuf:"bytes,11,rep,name=tags,proto3" json:"tags,omitempty"`
+	Categories    []string               `protobuf:"bytes,12,rep,name=categories,proto3" json:"categories,omitempty"`
+	FrontMatter   string                 `protobuf:"bytes,13,opt,name=front_matter,json=frontMatter,proto3" json:"front_matter,omitempty"`
+	Markdown      string                 `protobuf:"bytes,14,opt,name=markdown,proto3" json:"markdown,omitempty"`
+	CommitUrl     string                 `protobuf:"bytes,15,opt,name=commit_url,json=commitUrl,proto3" json:"commit_url,omitempty"`
+	Created       *timestamppb.Timestamp `protobuf:"bytes,16,opt,name=created,proto3" json:"created,omitempty"`
+	Updated       *timestamppb.Timestamp `protobuf:"bytes,17,opt,name=updated,proto3" json:"updated,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Idea) Reset() {
+	*x = Idea{}
+	mi := &file_ideas_v1_ideas_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Idea) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Idea) ProtoMessage() {}
+
+func (x *Idea) ProtoReflect() protoreflect.Message {
+	mi := &file_ideas_v1_ideas_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Idea.ProtoReflect.Descriptor instead.
+func (*Idea) Descriptor() ([]byte, []int) {
+	return file_ideas_v1_ideas_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *Idea) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Idea) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *Idea) GetSha() string {
+	if x != nil {
+		return x.Sha
+	}
+	return ""
+}
+
+func (x *Idea) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *Idea) GetGithubUrl() string {
+	if x != nil {
+		return x.GithubUrl
+	}
+	return ""
+}
+
+func (x *Idea) GetDate() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Date
+	}
+	return nil
+}
+
+func (x *Idea) GetSlug() string {
+	if x != nil {
+		return x.Slug
+	}
+	return ""
+}
+
+func (x *Idea) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *Idea) GetTitleZh() string {
+	if x != nil {
+		return x.TitleZh
+	}
+	return ""
+}
+
+func (x *Idea) GetDraft() bool {
+	if x != nil {
+		return x.Draft
+	}
+	return false
+}
+
+func (x *Idea) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *Idea) GetCategories() []string {
+	if x != nil {
+		return x.Categories
+	}
+	return nil
+}
+
+func (x *Idea) GetFrontMatter() string {
+	if x != nil {
+		return x.FrontMatter
+	}
+	return ""
+}
+
+func (x *Idea) GetMarkdown() string {
+	if x != nil {
+		return x.Markdown
+	}
+	return ""
+}
+
+func (x *Idea) GetCommitUrl() string {
+	if x != nil {
+		return x.CommitUrl
+	}
+	return ""
+}
+
+func (x *Idea) GetCreated() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Created
+	}
+	return nil
+}
+
+func (x *Idea) GetUpdated() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Updated
+	}
+	return nil
+}
+
+type WatchRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Job           string                 `protobuf:"bytes,1,opt,name=job,proto3" json:"job,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchRequest) Reset() {
+	*x = WatchRequest{}
+	mi := &file_ideas_v1_ideas_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchRequest) ProtoMessage() {}
+
+func (x *WatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ideas_v1_ideas_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchRequest.ProtoReflect.Descriptor instead.
+func (*WatchRequest) Descriptor() ([]byte, []int) {
+	return file_ideas_v1_ideas_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *WatchRequest) GetJob() string {
+	if x != nil {
+		return x.Job
+	}
+	return ""
+}
+
+type Job struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Title string                 `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
+	// "running", "review", "scheduled", "queued", "done", "failed", or
+	// "discarded".
+	Status    string `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"`
+	Error     string `protobuf:"bytes,4,opt,name=error,proto3" json:"error,omitempty"`
+	Path      string `protobuf:"bytes,5,opt,name=path,proto3" json:"path,omitempty"`
+	Url       string `protobuf:"bytes,6,opt,name=url,proto3" json:"url,omitempty"`
+	CommitUrl string `protobuf:"bytes,7,opt,name=commit_url,json=commitUrl,proto3" json:"commit_url,omitempty"`
+	// Name of the stage running or last run.
+	Stage         string                 `protobuf:"bytes,8,opt,name=stage,proto3" json:"stage,omitempty"`
+	Tokens        int32                  `protobuf:"varint,9,opt,name=tokens,proto3" json:"tokens,omitempty"`
+	Started       *timestamppb.Timestamp `protobuf:"bytes,10,opt,name=started,proto3" json:"started,omitempty"`
+	Finished      *timestamppb.Timestamp `protobuf:"bytes,11,opt,name=finished,proto3" json:"finished,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Job) Reset() {
+	*x = Job{}
+	mi := &file_ideas_v1_ideas_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Job) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Job) ProtoMessage() {}
+
+func (x *Job) ProtoReflect() protoreflect.Message {
+	mi := &file_ideas_v1_ideas_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Job.ProtoReflect.Descriptor instead.
+func (*Job) Descriptor() ([]byte, []int) {
+	return file_ideas_v1_ideas_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *Job) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Job) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *Job) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Job) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *Job) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *Job) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *Job) GetCommitUrl() string {
+	if x != nil {
+		return x.CommitUrl
+	}
+	return ""
+}
+
+func (x *Job) GetStage() string {
+	if x != nil {
+		return x.Stage
+	}
+	return ""
+}
+
+func (x *Job) GetTokens() int32 {
+	if x != nil {
+		return x.Tokens
+	}
+	return 0
+}
+
+func (x *Job) GetStarted() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Started
+	}
+	return nil
+}
+
+func (x *Job) GetFinished() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Finished
+	}
+	return nil
+}
+
+var File_ideas_v1_ideas_proto protoreflect.FileDescriptor
+
+const file_ideas_v1_ideas_proto_rawDesc = "" +
+	"\n" +
+	"\x14ideas/v1/ideas.proto\x12\bideas.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\xd1\x03\n" +
+	"\vPostRequest\x12\x14\n" +
+	"\x05title\x18\x01 \x01(\tR\x05title\x12\x18\n" +
+	"\acontent\x18\x02 \x01(\tR\acontent\x12\x14\n" +
+	"\x05draft\x18\x03 \x01(\bR\x05draft\x12\x12\n" +
+	"\x04tags\x18\x04 \x03(\tR\x04tags\x12!\n" +
+	"\fno_crosspost\x18\x05 \x01(\bR\vnoCrosspost\x12\x19\n" +
+	"\bno_title\x18\x06 \x01(\bR\anoTitle\x12!\n" +
+	"\fno_translate\x18\a \x01(\bR\vnoTranslate\x12\x1d\n" +
+	"\n" +
+	"no_augment\x18\b \x01(\bR\tnoAugment\x12\x17\n" +
+	"\ano_tags\x18\t \x01(\bR\x06noTags\x12\x1a\n" +
+	"\bpipeline\x18\n" +
+	" \x01(\tR\bpipeline\x12\x12\n" +
+	"\x04lang\x18\v \x01(\tR\x04lang\x12\x16\n" +
+	"\x06review\x18\f \x01(\bR\x06review\x129\n" +
+	"\n" +
+	"publish_at\x18\r \x01(\v2\x1a.google.protobuf.TimestampR\tpublishAt\x12#\n" +
+	"\rallow_secrets\x18\x0e \x01(\bR\fallowSecrets\x12'\n" +
+	"\x0fallow_duplicate\x18\x0f \x01(\bR\x0eallowDuplicate\" \n" +
+	"\fPostResponse\x12\x10\n" +
+	"\x03job\x18\x01 \x01(\tR\x03job\"*\n" +
+	"\x0eImproveRequest\x12\x18\n" +
+	"\acontent\x18\x01 \x01(\tR\acontent\"+\n" +
+	"\x0fImproveResponse\x12\x18\n" +
+	"\acontent\x18\x01 \x01(\tR\acontent\"M\n" +
+	"\vListRequest\x12\x14\n" +
+	"\x05limit\x18\x01 \x01(\x05R\x05limit\x12\x16\n" +
+	"\x06cursor\x18\x02 \x01(\tR\x06cursor\x12\x10\n" +
+	"\x03tag\x18\x03 \x01(\tR\x03tag\"\\\n" +
+	"\fListResponse\x12+\n" +
+	"\x05ideas\x18\x01 \x03(\v2\x15.ideas.v1.IdeaSummaryR\x05ideas\x12\x1f\n" +
+	"\vnext_cursor\x18\x02 \x01(\tR\n" +
+	"nextCursor\"\xa0\x02\n" +
+	"\vIdeaSummary\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04path\x18\x02 \x01(\tR\x04path\x12\x10\n" +
+	"\x03url\x18\x03 \x01(\tR\x03url\x12.\n" +
+	"\x04date\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\x04date\x12\x12\n" +
+	"\x04slug\x18\x05 \x01(\tR\x04slug\x12\x14\n" +
+	"\x05title\x18\x06 \x01(\tR\x05title\x12\x19\n" +
+	"\btitle_zh\x18\a \x01(\tR\atitleZh\x12\x1c\n" +
+	"\tlanguages\x18\b \x03(\tR\tlanguages\x12\x14\n" +
+	"\x05draft\x18\t \x01(\bR\x05draft\x12\x12\n" +
+	"\x04tags\x18\n" +
+	" \x03(\tR\x04tags\x12\x1e\n" +
+	"\n" +
+	"categories\x18\v \x03(\tR\n" +
+	"categories\"\x1c\n" +
+	"\n" +
+	"GetRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\xf6\x03\n" +
+	"\x04Idea\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04path\x18\x02 \x01(\tR\x04path\x12\x10\n" +
+	"\x03sha\x18\x03 \x01(\tR\x03sha\x12\x10\n" +
+	"\x03url\x18\x04 \x01(\tR\x03url\x12\x1d\n" +
+	"\n" +
+	"github_url\x18\x05 \x01(\tR\tgithubUrl\x12.\n" +
+	"\x04date\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\x04date\x12\x12\n" +
+	"\x04slug\x18\a \x01(\tR\x04slug\x12\x14\n" +
+	"\x05title\x18\b \x01(\tR\x05title\x12\x19\n" +
+	"\btitle_zh\x18\t \x01(\tR\atitleZh\x12\x14\n" +
+	"\x05draft\x18\n" +
+	" \x01(\bR\x05draft\x12\x12\n" +
+	"\x04tags\x18\v \x03(\tR\x04tags\x12\x1e\n" +
+	"\n" +
+	"categories\x18\f \x03(\tR\n" +
+	"categories\x12!\n" +
+	"\ffront_matter\x18\r \x01(\tR\vfrontMatter\x12\x1a\n" +
+	"\bmarkdown\x18\x0e \x01(\tR\bmarkdown\x12\x1d\n" +
+	"\n" +
+	"commit_url\x18\x0f \x01(\tR\tcommitUrl\x124\n" +
+	"\acreated\x18\x10 \x01(\v2\x1a.google.protobuf.TimestampR\acreated\x124\n" +
+	"\aupdated\x18\x11 \x01(\v2\x1a.google.protobuf.TimestampR\aupdated\" \n" +
+	"\fWatchRequest\x12\x10\n" +
+	"\x03job\x18\x01 \x01(\tR\x03job\"\xba\x02\n" +
+	"\x03Job\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x14\n" +
+	"\x05title\x18\x02 \x01(\tR\x05title\x12\x16\n" +
+	"\x06status\x18\x03 \x01(\tR\x06status\x12\x14\n" +
+	"\x05error\x18\x04 \x01(\tR\x05error\x12\x12\n" +
+	"\x04path\x18\x05 \x01(\tR\x04path\x12\x10\n" +
+	"\x03url\x18\x06 \x01(\tR\x03url\x12\x1d\n" +
+	"\n" +
+	"commit_url\x18\a \x01(\tR\tcommitUrl\x12\x14\n" +
+	"\x05stage\x18\b \x01(\tR\x05stage\x12\x16\n" +
+	"\x06tokens\x18\t \x01(\x05R\x06tokens\x124\n" +
+	"\astarted\x18\n" +
+	" \x01(\v2\x1a.google.protobuf.TimestampR\astarted\x126\n" +
+	"\bfinished\x18\v \x01(\v2\x1a.google.protobuf.TimestampR\bfinished2\x94\x02\n" +
+	"\x05Ideas\x125\n" +
+	"\x04Post\x12\x15.ideas.v1.PostRequest\x1a\x16.ideas.v1.PostResponse\x12>\n" +
+	"\aImprove\x12\x18.ideas.v1.ImproveRequest\x1a\x19.ideas.v1.ImproveResponse\x125\n" +
+	"\x04List\x12\x15.ideas.v1.ListRequest\x1a\x16.ideas.v1.ListResponse\x12+\n" +
+	"\x03Get\x12\x14.ideas.v1.GetRequest\x1a\x0e.ideas.v1.Idea\x120\n" +
+	"\x05Watch\x12\x16.ideas.v1.WatchRequest\x1a\r.ideas.v1.Job0\x01B,Z*changkun.de/x/ideas/proto/ideas/v1;ideasv1b\x06proto3"
+
+var (
+	file_ideas_v1_ideas_proto_rawDescOnce sync.Once
+	file_ideas_v1_ideas_proto_rawDescData []byte
+)
+
+func file_ideas_v1_ideas_proto_rawDescGZIP() []byte {
+	file_ideas_v1_ideas_proto_rawDescOnce.Do(func() {
+		file_ideas_v1_ideas_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_ideas_v1_ideas_proto_rawDesc), len(file_ideas_v1_ideas_proto_rawDesc)))
+	})
+	return file_ideas_v1_ideas_proto_rawDescData
+}
+
+var file_ideas_v1_ideas_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
+var file_ideas_v1_ideas_proto_goTypes = []any{
+	(*PostRequest)(nil),           // 0: ideas.v1.PostRequest
+	(*PostResponse)(nil),          // 1: ideas.v1.PostResponse
+	(*ImproveRequest)(nil),        // 2: ideas.v1.ImproveRequest
+	(*ImproveResponse)(nil),       // 3: ideas.v1.ImproveResponse
+	(*ListRequest)(nil),           // 4: ideas.v1.ListRequest
+	(*ListResponse)(nil),          // 5: ideas.v1.ListResponse
+	(*IdeaSummary)(nil),           // 6: ideas.v1.IdeaSummary
+	(*GetRequest)(nil),            // 7: ideas.v1.GetRequest
+	(*Idea)(nil),                  // 8: ideas.v1.Idea
+	(*WatchRequest)(nil),          // 9: ideas.v1.WatchRequest
+	(*Job)(nil),                   // 10: ideas.v1.Job
+	(*timestamppb.Timestamp)(nil), // 11: google.protobuf.Timestamp
+}
+var file_ideas_v1_ideas_proto_depIdxs = []int32{
+	11, // 0: ideas.v1.PostRequest.publish_at:type_name -> google.protobuf.Timestamp
+	6,  // 1: ideas.v1.ListResponse.ideas:type_name -> ideas.v1.IdeaSummary
+	11, // 2: ideas.v1.IdeaSummary.date:type_name -> google.protobuf.Timestamp
+	11, // 3: ideas.v1.Idea.date:type_name -> google.protobuf.Timestamp
+	11, // 4: ideas.v1.Idea.created:type_name -> google.protobuf.Timestamp
+	11, // 5: ideas.v1.Idea.updated:type_name -> google.protobuf.Timestamp
+	11, // 6: ideas.v1.Job.started:type_name -> google.protobuf.Timestamp
+	11, // 7: ideas.v1.Job.finished:type_name -> google.protobuf.Timestamp
+	0,  // 8: ideas.v1.Ideas.Post:input_type -> ideas.v1.PostRequest
+	2,  // 9: ideas.v1.Ideas.Improve:input_type -> ideas.v1.ImproveRequest
+	4,  // 10: ideas.v1.Ideas.List:input_type -> ideas.v1.ListRequest
+	7,  // 11: ideas.v1.Ideas.Get:input_type -> ideas.v1.GetRequest
+	9,  // 12: ideas.v1.Ideas.Watch:input_type -> ideas.v1.WatchRequest
+	1,  // 13: ideas.v1.Ideas.Post:output_type -> ideas.v1.PostResponse
+	3,  // 14: ideas.v1.Ideas.Improve:output_type -> ideas.v1.ImproveResponse
+	5,  // 15: ideas.v1.Ideas.List:output_type -> ideas.v1.ListResponse
+	8,  // 16: ideas.v1.Ideas.Get:output_type -> ideas.v1.Idea
+	10, // 17: ideas.v1.Ideas.Watch:output_type -> ideas.v1.Job
+	13, // [13:18] is the sub-list for method output_type
+	8,  // [8:13] is the sub-list for method input_type
+	8,  // [8:8] is the sub-list for extension type_name
+	8,  // [8:8] is the sub-list for extension extendee
+	0,  // [0:8] is the sub-list for field type_name
+}
+
+func init() { file_ideas_v1_ideas_proto_init() }
+func file_ideas_v1_ideas_proto_init() {
+	if File_ideas_v1_ideas_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_ideas_v1_ideas_proto_rawDesc), len(file_ideas_v1_ideas_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   11,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_ideas_v1_ideas_proto_goTypes,
+		DependencyIndexes: file_ideas_v1_ideas_proto_depIdxs,
+		MessageInfos:      file_ideas_v1_ideas_proto_msgTypes,
+	}.Build()
+	File_ideas_v1_ideas_proto = out.File
+	file_ideas_v1_ideas_proto_goTypes = nil
+	file_ideas_v1_ideas_proto_depIdxs = nil
+}
@@ -0,0 +1,306 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: ideas/v1/ideas.proto
+
+// Package ideas.v1 is the gRPC interface of the ideas service, served
+// on IDEAS_GRPC_ADDR next to the HTTP API, which it mirrors.
+
+package ideasv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Ideas_Post_FullMethodName    = "/ideas.v1.Ideas/Post"
+	Ideas_Improve_FullMethodName = "/ideas.v1.Ideas/Improve"
+	Ideas_List_FullMethodName    = "/ideas.v1.Ideas/List"
+	Ideas_Get_FullMethodName     = "/ideas.v1.Ideas/Get"
+	Ideas_Watch_FullMethodName   = "/ideas.v1.Ideas/Watch"
+)
+
+// IdeasClient is the client API for Ideas service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Ideas posts, improves, and serves ideas. Calls carry the same
+// credentials as HTTP requests, a login token as "authorization:
+// Bearer <token>" or an API key as "x-api-key", in their metadata.
+type IdeasClient interface {
+	// Post accepts an idea and starts the job publishing it in the
+	// background, as POST /ideas/post.
+	Post(ctx context.Context, in *PostRequest, opts ...grpc.CallOption) (*PostResponse, error)
+	// Improve polishes content without posting it, as POST /ideas/improve.
+	Improve(ctx context.Context, in *ImproveRequest, opts ...grpc.CallOption) (*ImproveResponse, error)
+	// List lists the indexed ideas, newest first, as GET /ideas/list.
+	List(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (*ListResponse, error)
+	// Get returns an idea with its markdown, as GET /ideas/{id}.
+	Get(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*Idea, error)
+	// Watch streams a job, once now and whenever it changes, until it is
+	// no longer running, as GET /ideas/admin/jobs/{id}/events.
+	Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Job], error)
+}
+
+type ideasClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewIdeasClient(cc grpc.ClientConnInterface) IdeasClient {
+	return &ideasClient{cc}
+}
+
+func (c *ideasClient) Post(ctx context.Context, in *PostRequest, opts ...grpc.CallOption) (*PostResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PostResponse)
+	err := c.cc.Invoke(ctx, Ideas_Post_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ideasClient) Improve(ctx context.Context, in *ImproveRequest, opts ...grpc.CallOption) (*ImproveResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ImproveResponse)
+	err := c.cc.Invoke(ctx, Ideas_Improve_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ideasClient) List(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (*ListResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListResponse)
+	err := c.cc.Invoke(ctx, Ideas_List_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ideasClient) Get(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*Idea, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Idea)
+	err := c.cc.Invoke(ctx, Ideas_Get_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ideasClient) Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Job], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Ideas_ServiceDesc.Streams[0], Ideas_Watch_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchRequest, Job]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Ideas_WatchClient = grpc.ServerStreamingClient[Job]
+
+// IdeasServer is the server API for Ideas service.
+// All implementations must embed UnimplementedIdeasServer
+// for forward compatibility.
+//
+// Ideas posts, improves, and serves ideas. Calls carry the same
+// credentials as HTTP requests, a login token as "authorization:
+// Bearer <token>" or an API key as "x-api-key", in their metadata.
+type IdeasServer interface {
+	// Post accepts an idea and starts the job publishing it in the
+	// background, as POST /ideas/post.
+	Post(context.Context, *PostRequest) (*PostResponse, error)
+	// Improve polishes content without posting it, as POST /ideas/improve.
+	Improve(context.Context, *ImproveRequest) (*ImproveResponse, error)
+	// List lists the indexed ideas, newest first, as GET /ideas/list.
+	List(context.Context, *ListRequest) (*ListResponse, error)
+	// Get returns an idea with its markdown, as GET /ideas/{id}.
+	Get(context.Context, *GetRequest) (*Idea, error)
+	// Watch streams a job, once now and whenever it changes, until it is
+	// no longer running, as GET /ideas/admin/jobs/{id}/events.
+	Watch(*WatchRequest, grpc.ServerStreamingServer[Job]) error
+	mustEmbedUnimplementedIdeasServer()
+}
+
+// UnimplementedIdeasServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedIdeasServer struct{}
+
+func (UnimplementedIdeasServer) Post(context.Context, *PostRequest) (*PostResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Post not implemented")
+}
+func (UnimplementedIdeasServer) Improve(context.Context, *ImproveRequest) (*ImproveResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Improve not implemented")
+}
+func (UnimplementedIdeasServer) List(context.Context, *ListRequest) (*ListResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method List not implemented")
+}
+func (UnimplementedIdeasServer) Get(context.Context, *GetRequest) (*Idea, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Get not implemented")
+}
+func (UnimplementedIdeasServer) Watch(*WatchRequest, grpc.ServerStreamingServer[Job]) error {
+	return status.Errorf(codes.Unimplemented, "method Watch not implemented")
+}
+func (UnimplementedIdeasServer) mustEmbedUnimplementedIdeasServer() {}
+func (UnimplementedIdeasServer) testEmbeddedByValue()               {}
+
+// UnsafeIdeasServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to IdeasServer will
+// result in compilation errors.
+type UnsafeIdeasServer interface {
+	mustEmbedUnimplementedIdeasServer()
+}
+
+func RegisterIdeasServer(s grpc.ServiceRegistrar, srv IdeasServer) {
+	// If the following call pancis, it indicates UnimplementedIdeasServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Ideas_ServiceDesc, srv)
+}
+
+func _Ideas_Post_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PostRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IdeasServer).Post(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Ideas_Post_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IdeasServer).Post(ctx, req.(*PostRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Ideas_Improve_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ImproveRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IdeasServer).Improve(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Ideas_Improve_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IdeasServer).Improve(ctx, req.(*ImproveRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Ideas_List_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IdeasServer).List(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Ideas_List_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IdeasServer).List(ctx, req.(*ListRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Ideas_Get_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IdeasServer).Get(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Ideas_Get_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IdeasServer).Get(ctx, req.(*GetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Ideas_Watch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(IdeasServer).Watch(m, &grpc.GenericServerStream[WatchRequest, Job]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Ideas_WatchServer = grpc.ServerStreamingServer[Job]
+
+// Ideas_ServiceDesc is the grpc.ServiceDesc for Ideas service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Ideas_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "ideas.v1.Ideas",
+	HandlerType: (*IdeasServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Post",
+			Handler:    _Ideas_Post_Handler,
+		},
+		{
+			MethodName: "Improve",
+			Handler:    _Ideas_Improve_Handler,
+		},
+		{
+			MethodName: "List",
+			Handler:    _Ideas_List_Handler,
+		},
+		{
+			MethodName: "Get",
+			Handler:    _Ideas_Get_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Watch",
+			Handler:       _Ideas_Watch_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "ideas/v1/ideas.proto",
+}
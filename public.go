@@ -0,0 +1,156 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// publicPrefix is the path of the endpoints that serve the published
+// ideas to anyone, such as the blog rendering them in the browser.
+const publicPrefix = "/ideas/public/"
+
+// publicMaxAge is how long clients and caches may keep public responses.
+const publicMaxAge = time.Minute
+
+// publicIdea is a published idea as served to anyone. Drafts, paths in
+// the repository, and file contents are left out.
+type publicIdea struct {
+	ID         string    `json:"id"`
+	URL        string    `json:"url"`
+	Date       time.Time `json:"date"`
+	Slug       string    `json:"slug"`
+	Title      string    `json:"title"`
+	TitleZh    string    `json:"title_zh"`
+	Languages  []string  `json:"languages"`
+	Tags       []string  `json:"tags,omitempty"`
+	Categories []string  `json:"categories,omitempty"`
+	ContentEn  string    `json:"content_en"`
+	ContentZh  string    `json:"content_zh"`
+}
+
+func (s *service) publicIdea(d *indexedIdea) publicIdea {
+	return publicIdea{
+		ID:         d.ID,
+		URL:        s.site.url(d.Slug),
+		Date:       d.Date,
+		Slug:       d.Slug,
+		Title:      d.Title,
+		TitleZh:    d.TitleZh,
+		Languages:  ideaLanguages(d),
+		Tags:       d.Tags,
+		Categories: d.Categories,
+		ContentEn:  d.ContentEn,
+		ContentZh:  d.ContentZh,
+	}
+}
+
+// publicRoutes returns the handler of the public endpoints. They have a
+// mux of their own, as /ideas/public/{id} overlaps the routes under
+// /ideas/{id}.
+func (s *service) publicRoutes() http.Handler {
+	r := http.NewServeMux()
+	r.HandleFunc("GET /ideas/public/list", s.handlePublicList)
+	r.HandleFunc("GET /ideas/public/{id}", s.handlePublicIdea)
+	return r
+}
+
+// withPublic serves the public endpoints with pub, and all others with
+// next.
+func withPublic(pub, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, publicPrefix) {
+			pub.ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// handlePublicList lists the published ideas, newest first, with their
+// text, a page of limit at a time, only those with ?tag if given. Pages
+// are chained by cursor as in handleListIdeas.
+func (s *service) handlePublicList(w http.ResponseWriter, r *http.Request) {
+	if !s.publicLimit.allow(readIP(r)) {
+		s.jsonError(w, "too many requests, try again later", http.StatusTooManyRequests)
+		return
+	}
+	limit := 20
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 100 {
+			s.jsonError(w, "limit must be between 1 and 100", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+	cursor, tag := r.URL.Query().Get("cursor"), r.URL.Query().Get("tag")
+
+	resp := struct {
+		OK         bool         `json:"ok"`
+		Ideas      []publicIdea `json:"ideas"`
+		NextCursor string       `json:"next_cursor,omitempty"`
+	}{OK: true, Ideas: []publicIdea{}}
+	for _, d := range s.index.all() {
+		if d.Draft || cursor != "" && d.ID >= cursor || tag != "" && !d.hasTag(tag) {
+			continue
+		}
+		if len(resp.Ideas) == limit {
+			resp.NextCursor = resp.Ideas[limit-1].ID
+			break
+		}
+		resp.Ideas = append(resp.Ideas, s.publicIdea(d))
+	}
+	writeCacheable(w, r, resp)
+}
+
+// handlePublicIdea serves a published idea with its text and deep dive,
+// from the index rather than the repository.
+func (s *service) handlePublicIdea(w http.ResponseWriter, r *http.Request) {
+	if !s.publicLimit.allow(readIP(r)) {
+		s.jsonError(w, "too many requests, try again later", http.StatusTooManyRequests)
+		return
+	}
+	d, ok := s.index.get(r.PathValue("id"))
+	if !ok || d.Draft {
+		s.jsonError(w, "idea not found", http.StatusNotFound)
+		return
+	}
+	type detail struct {
+		publicIdea
+		AugmentedEn string `json:"augmented_en,omitempty"`
+		AugmentedZh string `json:"augmented_zh,omitempty"`
+	}
+	writeCacheable(w, r, struct {
+		OK   bool   `json:"ok"`
+		Idea detail `json:"idea"`
+	}{OK: true, Idea: detail{s.publicIdea(d), d.AugmentedEn, d.AugmentedZh}})
+}
+
+// writeCacheable responds with v as JSON that caches may keep for
+// publicMaxAge, tagged by its hash so that clients can revalidate it
+// with If-None-Match.
+func writeCacheable(w http.ResponseWriter, r *http.Request, v any) {
+	var body bytes.Buffer
+	json.NewEncoder(&body).Encode(v)
+	sum := sha256.Sum256(body.Bytes())
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	w.Header().Set("Cache-Control", "public, max-age="+strconv.Itoa(int(publicMaxAge.Seconds())))
+	w.Header().Set("ETag", etag)
+	if match := r.Header.Get("If-None-Match"); match != "" && (match == "*" || strings.Contains(match, etag)) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(body.Bytes())
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPublicEndpoints(t *testing.T) {
+	l := log.New(io.Discard, "", 0)
+	s := &service{
+		log:         l,
+		index:       newArchiveIndex(filepath.Join(t.TempDir(), "index.json"), l),
+		site:        newSiteConfig("", "", "", "https://changkun.de/ideas/"),
+		publicLimit: newRateLimiter(4, time.Hour),
+	}
+	s.index.put("content/ideas/2025-01-01-a.md", "sha", testIdeaMarkdown)
+	s.index.put("content/ideas/2025-01-02-draft.md", "sha", strings.Replace(testIdeaMarkdown, "\n---\n", "\ndraft: true\n---\n", 1))
+	// The full chain, as the public endpoints need no token.
+	h := realIP(nil)(cors(auth(newAPIKeyStore(filepath.Join(t.TempDir(), "keys.json"), l), scopeDefaults{})(withPublic(s.publicRoutes(), http.NotFoundHandler()))))
+
+	n := 0
+	get := func(path, etag string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", path, nil)
+		r.Header.Set("Origin", "https://example.com")
+		// A client claiming another address each time is still limited.
+		n++
+		r.Header.Set("X-Forwarded-For", fmt.Sprintf("198.51.100.%d", n))
+		if etag != "" {
+			r.Header.Set("If-None-Match", etag)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, r)
+		return rec
+	}
+
+	rec := get("/ideas/public/list", "")
+	var list struct {
+		Ideas []publicIdea `json:"ideas"`
+	}
+	json.NewDecoder(rec.Body).Decode(&list)
+	if rec.Code != http.StatusOK || len(list.Ideas) != 1 || list.Ideas[0].ID != "2025-01-01-a" || list.Ideas[0].ContentEn != "Models exploit rewards." {
+		t.Errorf("list = %d %+v", rec.Code, list)
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Errorf("Access-Control-Allow-Origin = %q", got)
+	}
+	etag := rec.Header().Get("ETag")
+	if rec := get("/ideas/public/list", etag); etag == "" || rec.Code != http.StatusNotModified {
+		t.Errorf("revalidated list = %d, ETag %q", rec.Code, etag)
+	}
+
+	rec = get("/ideas/public/2025-01-01-a", "")
+	var resp struct {
+		Idea struct {
+			publicIdea
+			AugmentedEn string `json:"augmented_en"`
+		} `json:"idea"`
+	}
+	json.NewDecoder(rec.Body).Decode(&resp)
+	if rec.Code != http.StatusOK || resp.Idea.URL != "https://changkun.de/ideas/reward/" || resp.Idea.Title != "Reward hacking" {
+		t.Errorf("idea = %d %+v", rec.Code, resp.Idea)
+	}
+	if rec := get("/ideas/public/2025-01-02-draft", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("draft = %d, want 404", rec.Code)
+	}
+	if rec := get("/ideas/public/list", ""); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("request over the limit = %d, want 429", rec.Code)
+	}
+}
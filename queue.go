@@ -0,0 +1,211 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"sync"
+	"time"
+)
+
+var (
+	// errCommitQueued reports that committing an idea failed and it was
+	// queued to be committed again in the background.
+	errCommitQueued = errors.New("GitHub commit failed, queued to retry")
+	// errScheduled reports that an idea was queued to be committed at
+	// its publish_at.
+	errScheduled = errors.New("scheduled to be published later")
+)
+
+// Backoff between attempts to commit a queued idea.
+const (
+	minCommitBackoff = time.Minute
+	maxCommitBackoff = time.Hour
+)
+
+// commitQueue keeps processed ideas to be committed to GitHub later:
+// those scheduled to be published at a given time, and those whose
+// commit failed after the LLM stages succeeded, so that the work is not
+// lost, which are committed again with backoff. It is persisted, so ideas
+// survive a restart.
+type commitQueue struct {
+	path string
+	log  *log.Logger
+
+	mu      sync.Mutex
+	commits []*queuedCommit // oldest first
+}
+
+// queuedCommit is an idea file waiting to be committed.
+type queuedCommit struct {
+	Job      string    `json:"job"`
+	Path     string    `json:"path"`
+	Markdown string    `json:"markdown"`
+	Message  string    `json:"message"` // of the commit
+	Title    string    `json:"title"`
+	URL      string    `json:"url"`
+	Draft    bool      `json:"draft,omitempty"`
+	User     string    `json:"user,omitempty"` // whose repository it goes to
+	Queued   time.Time `json:"queued"`
+	Attempts int       `json:"attempts"`      // none yet if scheduled
+	Next     time.Time `json:"next"`          // of the next attempt, or the scheduled time
+	Error    string    `json:"error"`         // of the last attempt
+	Run      *savedRun `json:"run,omitempty"` // to resume after the commit
+}
+
+func newCommitQueue(path string, l *log.Logger) *commitQueue {
+	q := &commitQueue{path: path, log: l}
+	if err := readJSONFile(path, &q.commits); err != nil {
+		l.Printf("cannot load queued commits: %v", err)
+	}
+	return q
+}
+
+// save persists the queue. Callers hold mu.
+func (q *commitQueue) save() {
+	if err := writeJSONFile(q.path, q.commits); err != nil {
+		q.log.Printf("cannot save queued commits: %v", err)
+	}
+}
+
+// add queues c after its first attempt failed.
+func (q *commitQueue) add(c queuedCommit) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	c.Queued = time.Now()
+	c.Attempts = 1
+	c.Next = c.Queued.Add(commitBackoff(c.Attempts))
+	q.commits = append(q.commits, &c)
+	q.save()
+}
+
+// schedule queues c to be committed at.
+func (q *commitQueue) schedule(c queuedCommit, at time.Time) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	c.Queued, c.Next = time.Now(), at
+	q.commits = append(q.commits, &c)
+	q.save()
+}
+
+// list returns the queued commits, oldest first.
+func (q *commitQueue) list() []queuedCommit {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	out := make([]queuedCommit, len(q.commits))
+	for i, c := range q.commits {
+		out[i] = *c
+	}
+	return out
+}
+
+// due returns the commits whose next attempt is due at now.
+func (q *commitQueue) due(now time.Time) []queuedCommit {
+	var due []queuedCommit
+	for _, c := range q.list() {
+		if !c.Next.After(now) {
+			due = append(due, c)
+		}
+	}
+	return due
+}
+
+// failed records another failed attempt of the job's commit and backs
+// off before the next.
+func (q *commitQueue) failed(job string, err error, now time.Time) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, c := range q.commits {
+		if c.Job == job {
+			c.Attempts++
+			c.Next = now.Add(commitBackoff(c.Attempts))
+			c.Error = err.Error()
+		}
+	}
+	q.save()
+}
+
+// remove drops the job's commit once it is done.
+func (q *commitQueue) remove(job string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.commits = slices.DeleteFunc(q.commits, func(c *queuedCommit) bool { return c.Job == job })
+	q.save()
+}
+
+// commitBackoff returns the wait after the given number of failed
+// attempts, doubling from minCommitBackoff up to maxCommitBackoff.
+func commitBackoff(attempts int) time.Duration {
+	d := minCommitBackoff
+	for i := 1; i < attempts && d < maxCommitBackoff; i++ {
+		d *= 2
+	}
+	return min(d, maxCommitBackoff)
+}
+
+// runCommitQueue commits the queued ideas that are due, every interval.
+func (s *service) runCommitQueue(ctx context.Context, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		s.commitQueued(ctx, time.Now())
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// commitQueued commits the queued ideas that are due at now, those
+// scheduled and those tried before. Once committed, an idea is indexed,
+// and its run resumes with the post-publish hooks and the stages after
+// publishing, such as cross-posting.
+func (s *service) commitQueued(ctx context.Context, now time.Time) {
+	for _, c := range s.commits.due(now) {
+		us, own := s.userSite(c.User)
+		fc, err := us.github.createFile(ctx, c.Path, c.Markdown, c.Message)
+		if err != nil {
+			// An attempt whose reply was lost may have committed it.
+			if md, sha, gerr := us.github.getFile(ctx, c.Path); gerr == nil && md == c.Markdown {
+				fc, err = &fileCommit{SHA: sha}, nil
+			}
+		}
+		if err != nil {
+			s.log.Printf("queued commit of %s failed (attempt %d): %v", c.Path, c.Attempts+1, err)
+			s.commits.failed(c.Job, err, now)
+			if c.Attempts == 0 {
+				s.jobs.queue(c.Job, c.Path, c.URL, fmt.Errorf("%w: %w", errCommitQueued, err))
+			}
+			continue
+		}
+		s.commits.remove(c.Job)
+		s.log.Printf("queued idea published: %s", c.Path)
+		s.indexCommitted(withJobID(ctx, c.Job), c.Job, own, c.Path, c.Markdown, fc)
+		if c.Run != nil {
+			run := c.Run.restore(c.Job)
+			run.req.user = c.User
+			if j, ok := s.jobs.get(c.Job); ok {
+				run.req.requestID = j.RequestID
+			}
+			run.committed, run.commitURL, run.commitSHA = true, fc.CommitURL, fc.CommitSHA
+			s.runStages(run, append([]string{"publish"}, c.Run.Stages...))
+			continue
+		}
+		// Queued before runs were saved with their commit.
+		s.jobs.finish(c.Job, &published{path: c.Path, url: c.URL, commitURL: fc.CommitURL}, nil)
+		s.auditPublished(c.Job, ideaRequest{user: c.User}, c.Path, fc.CommitSHA, nil)
+		s.status.broadcast(ideaStatus{Type: statusPublished, Job: c.Job, Title: c.Title, Draft: c.Draft, Path: c.Path, URL: c.URL, user: c.User})
+		n := notification{title: "Idea published", message: c.Title, url: c.URL}
+		if c.Draft {
+			n = notification{title: "Draft saved", message: c.Title + "\n\n" + c.Path}
+		}
+		s.notifier.send(ctx, n)
+	}
+}
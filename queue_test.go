@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCommitBackoff(t *testing.T) {
+	for _, tt := range []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, time.Minute},
+		{2, 2 * time.Minute},
+		{4, 8 * time.Minute},
+		{7, time.Hour},
+		{100, time.Hour},
+	} {
+		if got := commitBackoff(tt.attempts); got != tt.want {
+			t.Errorf("commitBackoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+// commitTestService returns a service committing to a fake GitHub that
+// fails while down is set.
+func commitTestService(t *testing.T, files map[string]string, down *atomic.Bool) *service {
+	l := log.New(io.Discard, "", 0)
+	dir := t.TempDir()
+	gh := fakeGitHub(t, files)
+	target, _ := url.Parse(gh.apiURL)
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if down.Load() {
+			http.Error(w, "unavailable", http.StatusBadGateway)
+			return
+		}
+		proxy.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	gh.apiURL = srv.URL
+
+	noLLM := httptest.NewServer(http.NotFoundHandler())
+	t.Cleanup(noLLM.Close)
+	return &service{
+		log:       l,
+		llm:       &llmClient{baseURL: noLLM.URL, log: l},
+		github:    gh,
+		index:     newArchiveIndex(filepath.Join(dir, "index.json"), l),
+		embeds:    newEmbeddingStore(filepath.Join(dir, "embeddings.json"), l),
+		lifecycle: newLifecycleStore(filepath.Join(dir, "lifecycle.json"), l),
+		jobs:      newJobStore(filepath.Join(dir, "jobs.json"), l),
+		commits:   newCommitQueue(filepath.Join(dir, "commits.json"), l),
+		site:      newSiteConfig("", "content/ideas", "", "https://example.com/ideas"),
+	}
+}
+
+func TestCommitQueued(t *testing.T) {
+	files := map[string]string{}
+	var down atomic.Bool
+	down.Store(true)
+	s := commitTestService(t, files, &down)
+	var hooked []hookIdea
+	hookSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var in hookIdea
+		json.NewDecoder(r.Body).Decode(&in)
+		hooked = append(hooked, in)
+	}))
+	defer hookSrv.Close()
+	s.hooks = []*hook{{Name: "index", When: hookPostPublish, URL: hookSrv.URL, OnFailure: hookIgnore, timeout: time.Second}}
+
+	req := ideaRequest{Title: "Reward hacking", Content: "Models exploit rewards.", date: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+	id := s.startJob(req)
+	run := &pipelineRun{id: id, req: req, slug: "reward"}
+	p, err := s.runStages(run, []string{"publish", "notify"})
+	if err != nil || p == nil || !p.queued {
+		t.Fatalf("runStages = %+v, %v; want queued", p, err)
+	}
+	if j, _ := s.jobs.get(id); j.Status != jobQueued || j.Path != "content/ideas/2025-01-01-reward.md" || j.Error == "" {
+		t.Fatalf("job = %+v", j)
+	}
+	if q := newCommitQueue(s.commits.path, s.log).list(); len(q) != 1 || q[0].Job != id || q[0].Attempts != 1 ||
+		q[0].Run == nil || !slices.Equal(q[0].Run.Stages, []string{"notify"}) {
+		t.Fatalf("persisted queue = %+v", q)
+	}
+	if len(hooked) != 0 {
+		t.Fatalf("post-publish hook ran before the commit: %+v", hooked)
+	}
+
+	// Not due yet, and then failing again.
+	now := time.Now()
+	s.commitQueued(context.Background(), now)
+	if q := s.commits.list(); q[0].Attempts != 1 {
+		t.Fatalf("attempted before due: %+v", q)
+	}
+	s.commitQueued(context.Background(), now.Add(time.Minute))
+	if q := s.commits.list(); q[0].Attempts != 2 || !q[0].Next.Equal(now.Add(3*time.Minute)) {
+		t.Fatalf("after a failed attempt: %+v", q)
+	}
+
+	down.Store(false)
+	s.commitQueued(context.Background(), now.Add(3*time.Minute))
+	if q := s.commits.list(); len(q) != 0 {
+		t.Fatalf("queue after commit = %+v", q)
+	}
+	j, _ := s.jobs.get(id)
+	if j.Status != jobDone || j.Error != "" || j.URL != "https://example.com/ideas/reward/" || j.CommitURL == "" {
+		t.Errorf("job after commit = %+v", j)
+	}
+	if _, ok := files["/repos/o/r/contents/content/ideas/2025-01-01-reward.md"]; !ok {
+		t.Error("the idea was not committed")
+	}
+	if _, ok := s.index.get("2025-01-01-reward"); !ok {
+		t.Error("the committed idea was not indexed")
+	}
+	if len(hooked) != 1 || hooked[0].Event != hookPostPublish || hooked[0].URL != "https://example.com/ideas/reward/" || hooked[0].TitleEn != "Reward hacking" {
+		t.Errorf("post-publish hook got %+v after the commit", hooked)
+	}
+}
+
+func TestScheduledPublish(t *testing.T) {
+	files := map[string]string{}
+	var down atomic.Bool
+	s := commitTestService(t, files, &down)
+
+	at := time.Now().Add(time.Hour).Truncate(time.Second)
+	req := ideaRequest{Title: "Reward hacking", Content: "Models exploit rewards.", PublishAt: at}
+	id := s.startJob(req)
+	p, err := s.runStages(&pipelineRun{id: id, req: req, slug: "reward"}, []string{"publish", "notify"})
+	if err != nil || p == nil || !p.queued {
+		t.Fatalf("runStages = %+v, %v; want scheduled", p, err)
+	}
+	path := "content/ideas/" + at.Format("2006-01-02") + "-reward.md"
+	if j, _ := s.jobs.get(id); j.Status != jobScheduled || j.Path != path || !j.PublishAt.Equal(at) {
+		t.Fatalf("job = %+v", j)
+	}
+	q := s.commits.list()
+	if len(q) != 1 || q[0].Attempts != 0 || !q[0].Next.Equal(at) || !strings.Contains(q[0].Markdown, "date: "+at.Local().Format("2006-01-02T15:04:05")) {
+		t.Fatalf("queue = %+v", q)
+	}
+
+	s.commitQueued(context.Background(), at.Add(-time.Minute))
+	if len(files) != 0 {
+		t.Fatal("committed before its time")
+	}
+	// A failed first attempt leaves it queued for retries.
+	down.Store(true)
+	s.commitQueued(context.Background(), at)
+	if j, _ := s.jobs.get(id); j.Status != jobQueued {
+		t.Fatalf("job after a failed attempt = %+v", j)
+	}
+	down.Store(false)
+	s.commitQueued(context.Background(), at.Add(time.Minute))
+	if j, _ := s.jobs.get(id); j.Status != jobDone || files["/repos/o/r/contents/"+path] == "" {
+		t.Fatalf("job at its time = %+v", j)
+	}
+
+	rec := httptest.NewRecorder()
+	s.handlePost(rec, httptest.NewRequest("POST", "/ideas/post", strings.NewReader(`{"content":"x","publish_at":"2020-01-01T00:00:00Z"}`)))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("publish_at in the past: status = %d, want 400", rec.Code)
+	}
+}
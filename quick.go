@@ -0,0 +1,115 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+// handleQuick accepts a plain-text idea authenticated by an API key,
+// so that an iOS Shortcut or a curl one-liner can post without JSON:
+//
+//	curl -H "X-Api-Key: $KEY" --data-binary @note.txt https://api.changkun.de/ideas/quick
+func (s *service) handleQuick(w http.ResponseWriter, r *http.Request) {
+	p, ok := s.checkAPIKey(r.Header.Get("X-Api-Key"))
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r.Body, 64*1024))
+	if err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	content := strings.TrimSpace(string(data))
+	if content == "" {
+		http.Error(w, "content is required", http.StatusBadRequest)
+		return
+	}
+
+	id, ok := s.enqueueIdea(ideaRequest{
+		Title:     strings.TrimSpace(r.URL.Query().Get("title")),
+		Content:   content,
+		requestID: requestIDFromContext(r.Context()),
+		user:      p.User,
+	})
+	noteAudit(r.Context(), func(e *auditEntry) { e.User, e.APIKey, e.Job = p.User, p.APIKey, id })
+	if !ok {
+		w.Header().Set("Retry-After", "30")
+		http.Error(w, errBusy.Error(), http.StatusTooManyRequests)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprintln(w, "idea accepted, publishing in background")
+}
+
+// maxBridgeText caps the length of ideas sent through the GET bridge.
+const maxBridgeText = 1000
+
+// handleTextBridge captures an idea from a single GET request for
+// clients that cannot send a body or headers, such as watches and e-ink
+// devices:
+//
+//	GET /ideas/t?k=<key>&text=<idea>
+//
+// The key travels in the URL, so the endpoint is rate-limited per client
+// before the key is checked and only accepts short texts.
+func (s *service) handleTextBridge(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	if !s.bridgeLimit.allow(readIP(r)) {
+		http.Error(w, "too many requests", http.StatusTooManyRequests)
+		return
+	}
+	q := r.URL.Query()
+	p, ok := s.checkAPIKey(q.Get("k"))
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	text := strings.TrimSpace(q.Get("text"))
+	if text == "" {
+		http.Error(w, "text is required", http.StatusBadRequest)
+		return
+	}
+	if utf8.RuneCountInString(text) > maxBridgeText {
+		http.Error(w, fmt.Sprintf("text too long, max %d characters", maxBridgeText), http.StatusBadRequest)
+		return
+	}
+
+	id, ok := s.enqueueIdea(ideaRequest{Content: text, requestID: requestIDFromContext(r.Context()), user: p.User})
+	noteAudit(r.Context(), func(e *auditEntry) { e.User, e.APIKey, e.Job = p.User, p.APIKey, id })
+	if !ok {
+		w.Header().Set("Retry-After", "30")
+		http.Error(w, errBusy.Error(), http.StatusTooManyRequests)
+		return
+	}
+	fmt.Fprintln(w, "ok")
+}
+
+// sharedAPIKey stands for IDEAS_API_KEY where the ID of a managed key
+// would be.
+const sharedAPIKey = "IDEAS_API_KEY"
+
+// checkAPIKey reports whether key may post ideas, returning who it acts
+// for: the user of a managed key, or no one for the shared one.
+func (s *service) checkAPIKey(key string) (principal, bool) {
+	if k, ok := s.apiKeys.verify(key); ok {
+		p := k.principal()
+		return p, p.can(scopeWrite)
+	}
+	if s.apiKey == "" || key == "" {
+		return principal{}, false
+	}
+	ok := subtle.ConstantTimeCompare([]byte(key), []byte(s.apiKey)) == 1
+	return principal{APIKey: sharedAPIKey, Scopes: []string{scopeWrite}}, ok
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckAPIKey(t *testing.T) {
+	l := log.New(io.Discard, "", 0)
+	s := &service{
+		log:     l,
+		apiKeys: newAPIKeyStore(filepath.Join(t.TempDir(), "apikeys.json"), l),
+		apiKey:  "shared",
+	}
+	writer, wk := s.apiKeys.create("phone", "changkun", []string{scopeWrite})
+	reader, _ := s.apiKeys.create("reader", "changkun", []string{scopeRead})
+
+	tests := []struct {
+		name     string
+		key      string
+		wantOK   bool
+		wantUser string
+		wantKey  string
+	}{
+		{name: "managed write key", key: writer, wantOK: true, wantUser: "changkun", wantKey: wk.ID},
+		{name: "managed read key", key: reader},
+		{name: "shared key", key: "shared", wantOK: true, wantKey: sharedAPIKey},
+		{name: "wrong key", key: "guess"},
+		{name: "no key", key: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, ok := s.checkAPIKey(tt.key)
+			if ok != tt.wantOK || ok && (p.User != tt.wantUser || p.APIKey != tt.wantKey) {
+				t.Errorf("checkAPIKey() = %+v, %v", p, ok)
+			}
+		})
+	}
+
+	// Without a shared key, an empty key is not taken for it.
+	s.apiKey = ""
+	if _, ok := s.checkAPIKey(""); ok {
+		t.Error("checkAPIKey(\"\") without a shared key = true")
+	}
+}
+
+func TestHandleQuick(t *testing.T) {
+	l := log.New(io.Discard, "", 0)
+	dir := t.TempDir()
+	s := &service{
+		log:       l,
+		apiKeys:   newAPIKeyStore(filepath.Join(dir, "apikeys.json"), l),
+		apiKey:    "shared",
+		jobs:      newJobStore(filepath.Join(dir, "jobs.json"), l),
+		lifecycle: newLifecycleStore(filepath.Join(dir, "lifecycle.json"), l),
+		// No workers: accepted ideas wait in the queue.
+		pool: newWorkerPool(0, 1),
+	}
+	key, _ := s.apiKeys.create("phone", "changkun", []string{scopeWrite})
+	post := func(key, target, body string) (int, string) {
+		r := httptest.NewRequest("POST", target, strings.NewReader(body))
+		r.Header.Set("Content-Type", "text/plain")
+		if key != "" {
+			r.Header.Set("X-Api-Key", key)
+		}
+		rec := httptest.NewRecorder()
+		s.handleQuick(rec, r)
+		return rec.Code, rec.Body.String()
+	}
+
+	tests := []struct {
+		name   string
+		key    string
+		target string
+		body   string
+		want   int
+	}{
+		{name: "no key", target: "/ideas/quick", body: "An idea.", want: http.StatusUnauthorized},
+		{name: "wrong key", key: "guess", target: "/ideas/quick", body: "An idea.", want: http.StatusUnauthorized},
+		{name: "empty", key: key, target: "/ideas/quick", body: " \n ", want: http.StatusBadRequest},
+		{name: "accepted", key: key, target: "/ideas/quick?title=Phones", body: "  An idea from the share sheet.\n", want: http.StatusOK},
+		{name: "queue full", key: "shared", target: "/ideas/quick", body: "Another idea.", want: http.StatusTooManyRequests},
+	}
+	for _, tt := range tests {
+		if code, body := post(tt.key, tt.target, tt.body); code != tt.want {
+			t.Errorf("%s: status = %d %q, want %d", tt.name, code, body, tt.want)
+		}
+	}
+
+	jobs := s.jobs.recent()
+	if len(jobs) != 1 {
+		t.Fatalf("jobs = %+v, want one", jobs)
+	}
+	if req := jobs[0].Request; req.Title != "Phones" || req.Content != "An idea from the share sheet." || req.user != "changkun" {
+		t.Errorf("queued request = %+v", req)
+	}
+}
+
+func TestTextBridgeLimit(t *testing.T) {
+	l := log.New(io.Discard, "", 0)
+	s := &service{
+		log:         l,
+		apiKeys:     newAPIKeyStore(filepath.Join(t.TempDir(), "apikeys.json"), l),
+		apiKey:      "secret",
+		bridgeLimit: newRateLimiter(2, time.Hour),
+	}
+	h := realIP(nil)(http.HandlerFunc(s.handleTextBridge))
+	// Guessing keys from one client is limited however it claims to be
+	// forwarded.
+	for i, want := range []int{http.StatusUnauthorized, http.StatusUnauthorized, http.StatusTooManyRequests} {
+		r := httptest.NewRequest("GET", "/ideas/t?k=guess&text=hi", nil)
+		r.RemoteAddr = "203.0.113.1:4000"
+		r.Header.Set("X-Forwarded-For", fmt.Sprintf("198.51.100.%d", i))
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, r)
+		if rec.Code != want {
+			t.Errorf("attempt %d = %d, want %d", i, rec.Code, want)
+		}
+	}
+}
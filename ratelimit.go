@@ -0,0 +1,50 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// rateLimiter allows at most limit events per key within a sliding
+// window. Keys are typically client IPs.
+type rateLimiter struct {
+	limit  int
+	window time.Duration
+
+	mu     sync.Mutex
+	events map[string][]time.Time
+}
+
+func newRateLimiter(limit int, window time.Duration) *rateLimiter {
+	return &rateLimiter{limit: limit, window: window, events: map[string][]time.Time{}}
+}
+
+// allow records an event for key and reports whether it is within the
+// limit. Rejected events are not recorded.
+func (rl *rateLimiter) allow(key string) bool {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	now := time.Now()
+	cutoff := now.Add(-rl.window)
+	for k, ts := range rl.events {
+		for len(ts) > 0 && ts[0].Before(cutoff) {
+			ts = ts[1:]
+		}
+		if len(ts) == 0 {
+			delete(rl.events, k)
+		} else {
+			rl.events[k] = ts
+		}
+	}
+
+	if len(rl.events[key]) >= rl.limit {
+		return false
+	}
+	rl.events[key] = append(rl.events[key], now)
+	return true
+}
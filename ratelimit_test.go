@@ -0,0 +1,23 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	rl := newRateLimiter(2, 50*time.Millisecond)
+	if !rl.allow("a") || !rl.allow("a") {
+		t.Fatal("first two events must be allowed")
+	}
+	if rl.allow("a") {
+		t.Error("third event within the window must be rejected")
+	}
+	if !rl.allow("b") {
+		t.Error("other keys have their own budget")
+	}
+	time.Sleep(60 * time.Millisecond)
+	if !rl.allow("a") {
+		t.Error("events must be allowed again after the window")
+	}
+}
@@ -0,0 +1,312 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// readwiseClient reads highlights and saved articles from Readwise and
+// its Reader app. Pocket and Omnivore were shut down, so Readwise is the
+// read-later service we pull from; it also imports from Kindle, Instapaper
+// and others.
+type readwiseClient struct {
+	baseURL string // e.g. "https://readwise.io"
+	token   string // access token from https://readwise.io/access_token
+}
+
+type readwiseBook struct {
+	ID          int64               `json:"user_book_id"`
+	Title       string              `json:"title"`
+	Author      string              `json:"author"`
+	SourceURL   string              `json:"source_url"`
+	ReadwiseURL string              `json:"readwise_url"`
+	Highlights  []readwiseHighlight `json:"highlights"`
+}
+
+type readwiseHighlight struct {
+	ID        int64  `json:"id"`
+	Text      string `json:"text"`
+	Note      string `json:"note"`
+	IsDeleted bool   `json:"is_deleted"`
+}
+
+// readerDocument is a document saved to Readwise Reader.
+type readerDocument struct {
+	ID       string  `json:"id"`
+	Title    string  `json:"title"`
+	Author   string  `json:"author"`
+	URL      string  `json:"url"`
+	Source   string  `json:"source_url"`
+	Summary  string  `json:"summary"`
+	Category string  `json:"category"`
+	ParentID *string `json:"parent_id"`
+}
+
+// highlights returns the books with highlights updated after the given
+// time, following all result pages.
+func (c *readwiseClient) highlights(ctx context.Context, after time.Time) ([]readwiseBook, error) {
+	var books []readwiseBook
+	q := url.Values{}
+	if !after.IsZero() {
+		q.Set("updatedAfter", after.UTC().Format(time.RFC3339))
+	}
+	for {
+		var page struct {
+			Results []readwiseBook `json:"results"`
+			Next    *string        `json:"nextPageCursor"`
+		}
+		if err := c.get(ctx, "/api/v2/export/", q, &page); err != nil {
+			return nil, err
+		}
+		books = append(books, page.Results...)
+		if page.Next == nil || *page.Next == "" {
+			return books, nil
+		}
+		q.Set("pageCursor", *page.Next)
+	}
+}
+
+// saved returns the documents in the Reader "later" list updated after
+// the given time, without highlights and notes which Reader also lists.
+func (c *readwiseClient) saved(ctx context.Context, after time.Time) ([]readerDocument, error) {
+	var docs []readerDocument
+	q := url.Values{"location": {"later"}}
+	if !after.IsZero() {
+		q.Set("updatedAfter", after.UTC().Format(time.RFC3339))
+	}
+	for {
+		var page struct {
+			Results []readerDocument `json:"results"`
+			Next    *string          `json:"nextPageCursor"`
+		}
+		if err := c.get(ctx, "/api/v3/list/", q, &page); err != nil {
+			return nil, err
+		}
+		for _, d := range page.Results {
+			if d.ParentID == nil && d.Category != "highlight" && d.Category != "note" {
+				docs = append(docs, d)
+			}
+		}
+		if page.Next == nil || *page.Next == "" {
+			return docs, nil
+		}
+		q.Set("pageCursor", *page.Next)
+	}
+}
+
+func (c *readwiseClient) get(ctx context.Context, path string, q url.Values, v any) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	u := strings.TrimRight(c.baseURL, "/") + path + "?" + q.Encode()
+	req, err := http.NewRequestWithContext(ctx, "GET", u, nil)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Token "+c.token)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("send request: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 16<<20))
+	if err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Readwise API returned %d: %s", resp.StatusCode, string(body))
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("unmarshal response: %w", err)
+	}
+	return nil
+}
+
+// readwiseWatcher periodically turns new Readwise highlights and saved
+// articles into draft ideas, so that reading feeds the writing habit.
+type readwiseWatcher struct {
+	s         *service
+	client    *readwiseClient
+	interval  time.Duration
+	statePath string
+}
+
+type readwiseState struct {
+	// Start of the last successful poll; nothing is drafted on the
+	// first poll, otherwise connecting an account would flood the drafts.
+	Highlights time.Time `json:"highlights,omitzero"`
+	Saved      time.Time `json:"saved,omitzero"`
+	// Seen remembers drafted highlight and document IDs, as edits make
+	// an item show up again.
+	Seen []string `json:"seen"`
+}
+
+// maxReadwiseSeen bounds the remembered highlight and document IDs.
+const maxReadwiseSeen = 2000
+
+func (rw *readwiseWatcher) run(ctx context.Context) {
+	t := time.NewTicker(rw.interval)
+	defer t.Stop()
+	for {
+		if err := rw.poll(ctx); err != nil {
+			rw.s.log.Printf("readwise: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// readwiseDraft is a draft to create from new Readwise items, and the
+// IDs of the items.
+type readwiseDraft struct {
+	req   ideaRequest
+	ids   []string
+	saved bool // of a saved article rather than highlights
+}
+
+func (rw *readwiseWatcher) poll(ctx context.Context) error {
+	var st readwiseState
+	if err := readJSONFile(rw.statePath, &st); err != nil {
+		return fmt.Errorf("load state: %w", err)
+	}
+	seen := map[string]bool{}
+	for _, id := range st.Seen {
+		seen[id] = true
+	}
+	remember := func(ids ...string) {
+		for _, id := range ids {
+			if !seen[id] {
+				seen[id] = true
+				st.Seen = append(st.Seen, id)
+			}
+		}
+		if len(st.Seen) > maxReadwiseSeen {
+			st.Seen = st.Seen[len(st.Seen)-maxReadwiseSeen:]
+		}
+	}
+	var drafts []readwiseDraft
+
+	highlightsAt := time.Now()
+	books, err := rw.client.highlights(ctx, st.Highlights)
+	if err != nil {
+		return fmt.Errorf("fetch highlights: %w", err)
+	}
+	initial := st.Highlights.IsZero()
+	for _, b := range books {
+		var (
+			fresh []readwiseHighlight
+			ids   []string
+		)
+		for _, h := range b.Highlights {
+			if id := fmt.Sprintf("h%d", h.ID); !h.IsDeleted && strings.TrimSpace(h.Text) != "" && !seen[id] {
+				fresh = append(fresh, h)
+				ids = append(ids, id)
+			}
+		}
+		switch {
+		case len(fresh) == 0:
+		case initial:
+			remember(ids...)
+		default:
+			drafts = append(drafts, readwiseDraft{req: highlightDraft(b, fresh), ids: ids})
+		}
+	}
+
+	savedAt := time.Now()
+	docs, err := rw.client.saved(ctx, st.Saved)
+	if err != nil {
+		// Reader is optional, highlights alone are still useful.
+		rw.s.log.Printf("readwise: fetch saved articles: %v", err)
+		savedAt = time.Time{}
+	}
+	for _, d := range docs {
+		id := "d" + d.ID
+		switch {
+		case seen[id]:
+		case st.Saved.IsZero():
+			remember(id)
+		default:
+			req := feedDraft(feedEntry{
+				title:   d.Title,
+				link:    cmp.Or(d.Source, d.URL),
+				summary: plainText(d.Summary),
+			})
+			req.Pipeline = "readwise"
+			drafts = append(drafts, readwiseDraft{req: req, ids: []string{id}, saved: true})
+		}
+	}
+	if initial {
+		rw.s.log.Printf("readwise: connected, skipped %d existing books", len(books))
+	}
+
+	// Items are only remembered once drafted, and the cursors only move
+	// past items that all were, so that failed ones are fetched again.
+	for _, d := range drafts {
+		rw.s.log.Printf("new Readwise item, creating draft: %s", d.req.Title)
+		if _, err := rw.s.processIdea(d.req); err != nil {
+			rw.s.log.Printf("readwise draft failed: %v", err)
+			if d.saved {
+				savedAt = time.Time{}
+			} else {
+				highlightsAt = time.Time{}
+			}
+			continue
+		}
+		remember(d.ids...)
+		if err := writeJSONFile(rw.statePath, st); err != nil {
+			return fmt.Errorf("save state: %w", err)
+		}
+	}
+	if !highlightsAt.IsZero() {
+		st.Highlights = highlightsAt
+	}
+	if !savedAt.IsZero() {
+		st.Saved = savedAt
+	}
+	if err := writeJSONFile(rw.statePath, st); err != nil {
+		return fmt.Errorf("save state: %w", err)
+	}
+	return nil
+}
+
+// highlightDraft turns the new highlights of a book or article into a
+// draft idea that quotes them and prompts a reaction.
+func highlightDraft(b readwiseBook, hs []readwiseHighlight) ideaRequest {
+	var sb strings.Builder
+	source := b.Title
+	if link := cmp.Or(b.SourceURL, b.ReadwiseURL); link != "" {
+		source = fmt.Sprintf("[%s](%s)", cmp.Or(b.Title, link), link)
+	}
+	if b.Author != "" {
+		source += " by " + b.Author
+	}
+	fmt.Fprintf(&sb, "React to what I highlighted in %s: do I agree, and what does it remind me of?", source)
+	for _, h := range hs {
+		sb.WriteString("\n\n> ")
+		sb.WriteString(strings.ReplaceAll(strings.TrimSpace(h.Text), "\n", "\n> "))
+		if note := strings.TrimSpace(h.Note); note != "" {
+			fmt.Fprintf(&sb, "\n\nMy note: %s", note)
+		}
+	}
+	return ideaRequest{
+		Title:    b.Title,
+		Content:  sb.String(),
+		Draft:    true,
+		Pipeline: "readwise",
+	}
+}
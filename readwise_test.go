@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestReadwiseHighlights(t *testing.T) {
+	var queries []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Token tk" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		queries = append(queries, r.URL.RawQuery)
+		if r.URL.Query().Get("pageCursor") == "" {
+			fmt.Fprint(w, `{"results":[{"user_book_id":1,"title":"A","highlights":[{"id":10,"text":"x"}]}],"nextPageCursor":"2"}`)
+			return
+		}
+		fmt.Fprint(w, `{"results":[{"user_book_id":2,"title":"B","highlights":[]}],"nextPageCursor":null}`)
+	}))
+	defer srv.Close()
+
+	c := &readwiseClient{baseURL: srv.URL, token: "tk"}
+	after := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	books, err := c.highlights(context.Background(), after)
+	if err != nil {
+		t.Fatalf("highlights: %v", err)
+	}
+	if len(books) != 2 || books[0].Highlights[0].ID != 10 {
+		t.Errorf("books = %+v", books)
+	}
+	want := []string{"updatedAfter=2025-03-01T12%3A00%3A00Z", "pageCursor=2&updatedAfter=2025-03-01T12%3A00%3A00Z"}
+	if fmt.Sprint(queries) != fmt.Sprint(want) {
+		t.Errorf("queries = %q, want %q", queries, want)
+	}
+
+	c.token = "wrong"
+	if _, err := c.highlights(context.Background(), after); err == nil {
+		t.Error("highlights with a wrong token succeeded")
+	}
+}
+
+func TestHighlightDraft(t *testing.T) {
+	tests := []struct {
+		name string
+		book readwiseBook
+		hs   []readwiseHighlight
+		want string
+	}{
+		{
+			name: "with source and note",
+			book: readwiseBook{Title: "Essay", Author: "Ann", SourceURL: "https://example.com/essay"},
+			hs: []readwiseHighlight{
+				{Text: "First line\nsecond line"},
+				{Text: "Another", Note: "Really?"},
+			},
+			want: "React to what I highlighted in [Essay](https://example.com/essay) by Ann: do I agree, and what does it remind me of?" +
+				"\n\n> First line\n> second line\n\n> Another\n\nMy note: Really?",
+		},
+		{
+			name: "without link",
+			book: readwiseBook{Title: "Kindle Book"},
+			hs:   []readwiseHighlight{{Text: "Quote"}},
+			want: "React to what I highlighted in Kindle Book: do I agree, and what does it remind me of?\n\n> Quote",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := highlightDraft(tt.book, tt.hs)
+			if got.Content != tt.want {
+				t.Errorf("content\n got: %q\nwant: %q", got.Content, tt.want)
+			}
+			if !got.Draft || got.Title != tt.book.Title {
+				t.Errorf("request = %+v", got)
+			}
+		})
+	}
+}
+
+func TestReadwisePoll(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v2/export/":
+			fmt.Fprint(w, `{"results":[{"user_book_id":1,"title":"A","highlights":[{"id":10,"text":"x"}]}]}`)
+		case "/api/v3/list/":
+			fmt.Fprint(w, `{"results":[{"id":"a","title":"Saved","url":"https://example.com/a","category":"article"}]}`)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	var reject atomic.Bool
+	var calls atomic.Int32
+	llm := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		allowed := `{\"allowed\": true}`
+		if reject.Load() {
+			allowed = `{\"allowed\": false, \"reason\": \"spam\"}`
+		}
+		io.WriteString(w, `{"choices":[{"message":{"content":"`+allowed+`"}}]}`)
+	}))
+	t.Cleanup(llm.Close)
+
+	l := log.New(io.Discard, "", 0)
+	dir := t.TempDir()
+	rw := &readwiseWatcher{
+		s: &service{
+			log:       l,
+			llm:       &llmClient{baseURL: llm.URL, log: l},
+			jobs:      newJobStore(filepath.Join(dir, "jobs.json"), l),
+			lifecycle: newLifecycleStore(filepath.Join(dir, "lifecycle.json"), l),
+			pipelines: map[string][]string{"readwise": {"moderate"}},
+		},
+		client:    &readwiseClient{baseURL: srv.URL},
+		statePath: filepath.Join(dir, "readwise.json"),
+	}
+	connected := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	writeJSONFile(rw.statePath, readwiseState{Highlights: connected, Saved: connected})
+	state := func() readwiseState {
+		var st readwiseState
+		readJSONFile(rw.statePath, &st)
+		return st
+	}
+
+	// Failed drafts leave the items unseen and the cursors where they were.
+	reject.Store(true)
+	if err := rw.poll(t.Context()); err != nil || calls.Load() != 2 {
+		t.Fatalf("failing poll: %v, %d calls", err, calls.Load())
+	}
+	if st := state(); len(st.Seen) != 0 || !st.Highlights.Equal(connected) || !st.Saved.Equal(connected) {
+		t.Errorf("state after failed drafts = %+v", st)
+	}
+
+	reject.Store(false)
+	for range 2 {
+		if err := rw.poll(t.Context()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if calls.Load() != 4 {
+		t.Errorf("%d calls, want the two items retried once", calls.Load())
+	}
+	if st := state(); !slices.Equal(st.Seen, []string{"h10", "da"}) || !st.Highlights.After(connected) || !st.Saved.After(connected) {
+		t.Errorf("state after drafts = %+v", st)
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRequestID(t *testing.T) {
+	for _, tt := range []struct {
+		name, header string
+		keep         bool
+	}{
+		{"none", "", false},
+		{"client", "cli-7f3a.1", true},
+		{"too long", strings.Repeat("a", 65), false},
+		{"unsafe", "a b\nc", false},
+	} {
+		var got string
+		h := requestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got = requestIDFromContext(r.Context())
+		}))
+		r := httptest.NewRequest("GET", "/ideas/ping", nil)
+		if tt.header != "" {
+			r.Header.Set("X-Request-Id", tt.header)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, r)
+		if got == "" || rec.Header().Get("X-Request-Id") != got {
+			t.Errorf("%s: context ID %q, response ID %q", tt.name, got, rec.Header().Get("X-Request-Id"))
+		}
+		if (got == tt.header) != tt.keep {
+			t.Errorf("%s: ID = %q, keep client's = %v", tt.name, got, tt.keep)
+		}
+	}
+}
+
+func TestRequestIDForwarded(t *testing.T) {
+	var got string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get("X-Request-Id")
+		io.WriteString(w, `{"choices":[{"message":{"content":"A title"}}]}`)
+	}))
+	defer srv.Close()
+
+	llm := &llmClient{baseURL: srv.URL, log: log.New(io.Discard, "", 0)}
+	if _, err := llm.generateTitle(withRequestID(context.Background(), "req-1"), "An idea."); err != nil {
+		t.Fatal(err)
+	}
+	if got != "req-1" {
+		t.Errorf("LLM request ID = %q, want req-1", got)
+	}
+
+	gh := &githubClient{apiURL: srv.URL, owner: "o", repo: "r"}
+	req, _ := gh.newRequest(withRequestID(context.Background(), "req-2"), "GET", "/contents/x.md", nil)
+	if id := req.Header.Get("X-Request-Id"); id != "req-2" {
+		t.Errorf("GitHub request ID = %q, want req-2", id)
+	}
+}
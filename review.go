@@ -0,0 +1,174 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// jobPreview is an idea held for review, as it would be published.
+type jobPreview struct {
+	Title   string `json:"title"`
+	TitleZh string `json:"title_zh"`
+	Lang    string `json:"lang,omitempty"` // of the original text
+	// Markdown is the file to be committed. Its slug is generated on
+	// publishing unless one is filled in.
+	Markdown string `json:"markdown"`
+}
+
+// holdForReview holds a run before the given stages, the first of which
+// publishes, and shows the idea as it would be published on its job. The
+// run is saved with the job, so it survives a restart.
+func (s *service) holdForReview(run *pipelineRun, stages []string) {
+	run.keepOriginal()
+	held := run.save()
+	held.Stages = stages
+	s.jobs.hold(run.id, jobPreview{
+		Title:    run.titleEn,
+		TitleZh:  run.titleZh,
+		Lang:     run.lang,
+		Markdown: s.runMarkdown(run, cmp.Or(run.req.date, time.Now()), run.slug),
+	}, held)
+	s.log.Printf("idea %q held for review", run.titleEn)
+}
+
+// applyReview replaces what a held run publishes with the reviewed
+// markdown: titles, text, deep dive, draft state, tags, categories, and,
+// if given, date and slug.
+func applyReview(run *pipelineRun, md string) error {
+	doc, err := parseIdea(md)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(doc.ContentEn+doc.ContentZh) == "" {
+		return errors.New("the idea has no content")
+	}
+	run.titleEn = cmp.Or(doc.Title, doc.TitleZh, "Untitled")
+	run.titleZh = cmp.Or(doc.TitleZh, run.titleEn)
+	run.contentEn = cmp.Or(doc.ContentEn, doc.ContentZh)
+	run.contentZh = cmp.Or(doc.ContentZh, doc.ContentEn)
+	run.augmentedEn, run.augmentedZh = doc.AugmentedEn, doc.AugmentedZh
+	run.req.Draft = doc.Draft
+	run.req.Tags = doc.Tags
+	run.categories = doc.Categories
+	if run.categories == nil {
+		run.categories = []string{}
+	}
+	run.slug = ""
+	if doc.Slug != "" {
+		run.slug = slugify(doc.Slug)
+	}
+	if !doc.Date.IsZero() {
+		run.req.date = doc.Date
+	}
+	return nil
+}
+
+// retitle replaces the titles of a held run with title, given in the
+// idea's language and translated to the other one when there is an LLM
+// and the idea is translated at all.
+func (s *service) retitle(ctx context.Context, run *pipelineRun, title string) {
+	translated := run.titleEn != run.titleZh
+	run.titleEn, run.titleZh = title, title
+	if s.llm != nil && translated {
+		other := "zh"
+		if run.lang == "zh" {
+			other = "en"
+		}
+		t, err := s.llm.translateContent(ctx, title, other)
+		if err != nil {
+			s.log.Printf("title translation failed: %v", err)
+			t = title
+		}
+		if other == "zh" {
+			run.titleZh = t
+		} else {
+			run.titleEn = t
+		}
+	}
+	s.jobs.setTitle(run.id, run.titleEn)
+}
+
+// handleApproveJob publishes an idea held for review, as previewed or
+// with the markdown given in its place. A title given replaces the
+// previewed one. Browsers submit the markdown from the dashboard as a
+// form field, and are sent back to it.
+func (s *service) handleApproveJob(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Markdown string `json:"markdown"`
+		Title    string `json:"title"` // in the idea's language
+	}
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+				s.jsonError(w, "invalid request body", http.StatusBadRequest)
+				return
+			}
+		}
+	} else {
+		req.Markdown = r.FormValue("markdown")
+		req.Title = r.FormValue("title")
+	}
+	req.Title = strings.TrimSpace(req.Title)
+
+	id := r.PathValue("id")
+	j, ok := s.requestedJob(r)
+	if !ok || j.Status != jobReview || j.Held == nil {
+		s.jsonError(w, "job not found or not in review", http.StatusNotFound)
+		return
+	}
+	run := j.Held.restore(id)
+	run.req.requestID, run.req.user = j.RequestID, j.User
+	if req.Markdown != "" {
+		if err := applyReview(run, req.Markdown); err != nil {
+			s.jsonError(w, "invalid idea: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	// Of concurrent approvals, only the first resumes the job.
+	if !s.jobs.resume(id, false) {
+		s.jsonError(w, "job not found or not in review", http.StatusNotFound)
+		return
+	}
+	run.reviewed = true
+	go s.pool.do(func() {
+		if req.Title != "" {
+			s.retitle(withJobID(context.Background(), id), run, req.Title)
+		}
+		s.runStages(run, j.Held.Stages)
+	})
+	s.reviewed(w, r, "publishing")
+}
+
+// handleDiscardJob drops an idea held for review.
+func (s *service) handleDiscardJob(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if _, ok := s.requestedJob(r); !ok {
+		s.jsonError(w, "job not found or not in review", http.StatusNotFound)
+		return
+	}
+	if !s.jobs.resume(id, true) {
+		s.jsonError(w, "job not found or not in review", http.StatusNotFound)
+		return
+	}
+	s.reviewed(w, r, "discarded")
+}
+
+// reviewed responds to a review decision.
+func (s *service) reviewed(w http.ResponseWriter, r *http.Request, msg string) {
+	if r.Header.Get("Accept") != "application/json" {
+		http.Redirect(w, r, "/ideas/admin", http.StatusSeeOther)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ideaResponse{OK: true, Message: fmt.Sprintf("idea %s", msg), Job: r.PathValue("id")})
+}
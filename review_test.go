@@ -0,0 +1,159 @@
+package main
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReviewHold(t *testing.T) {
+	l := log.New(io.Discard, "", 0)
+	path := filepath.Join(t.TempDir(), "jobs.json")
+	s := &service{
+		log:       l,
+		jobs:      newJobStore(path, l),
+		site:      newSiteConfig("", "", "", ""),
+		pipelines: map[string][]string{"plain": {"detect-lang", "publish"}},
+	}
+	req := ideaRequest{Title: "Held", Content: "Some thought", Pipeline: "plain", Review: true}
+	const id = "a"
+	s.jobs.start(id, req)
+	if p, err := s.runIdea(id, req); p != nil || err != nil {
+		t.Fatalf("runIdea = %v, %v; want the idea held", p, err)
+	}
+	j, _ := s.jobs.get(id)
+	if j.Status != jobReview || j.Preview == nil || j.Preview.Title != "Held" || !strings.Contains(j.Preview.Markdown, "Some thought") {
+		t.Fatalf("held job = %+v", j)
+	}
+
+	decide := func(action, md string) int {
+		r := httptest.NewRequest("POST", "/ideas/admin/jobs/"+id+"/"+action, strings.NewReader(url.Values{"markdown": {md}}.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		r.Header.Set("Accept", "application/json")
+		r.SetPathValue("id", id)
+		rec := httptest.NewRecorder()
+		if action == "approve" {
+			s.handleApproveJob(rec, r)
+		} else {
+			s.handleDiscardJob(rec, r)
+		}
+		return rec.Code
+	}
+	if code := decide("approve", "no front matter"); code != http.StatusBadRequest {
+		t.Errorf("approve invalid markdown: status = %d, want 400", code)
+	}
+	if j, _ := s.jobs.get(id); j.Status != jobReview {
+		t.Errorf("after a rejected approval, status = %s, want still in review", j.Status)
+	}
+
+	// A restart keeps the run held.
+	s.jobs = newJobStore(path, l)
+	j, _ = s.jobs.get(id)
+	if j.Status != jobReview || j.Held == nil || !slices.Equal(j.Held.Stages, []string{"publish"}) || j.Held.TitleEn != "Held" {
+		t.Fatalf("held job after restart = %+v", j)
+	}
+	if code := decide("discard", ""); code != http.StatusOK {
+		t.Errorf("discard: status = %d", code)
+	}
+	if j, _ := s.jobs.get(id); j.Status != jobDiscarded || j.Preview != nil {
+		t.Errorf("discarded job = %+v", j)
+	}
+	if code := decide("approve", ""); code != http.StatusNotFound {
+		t.Errorf("approve discarded: status = %d, want 404", code)
+	}
+}
+
+func TestApplyReview(t *testing.T) {
+	date := time.Date(2025, 2, 1, 10, 0, 0, 0, time.Local)
+	md := buildMarkdown(bilingualContent{
+		date:        date,
+		slug:        "Better Slug",
+		titleEn:     "Edited",
+		titleZh:     "编辑过",
+		contentEn:   "Text.",
+		contentZh:   "文本。",
+		augmentedEn: "More.",
+		draftLine:   "draft: true\n",
+		categories:  []string{"ai"},
+	})
+
+	tests := []struct {
+		name    string
+		md      string
+		check   func(run *pipelineRun) bool
+		wantErr bool
+	}{
+		{
+			name: "edited",
+			md:   md,
+			check: func(run *pipelineRun) bool {
+				return run.titleEn == "Edited" && run.titleZh == "编辑过" && run.contentZh == "文本。" &&
+					run.augmentedEn == "More." && run.augmentedZh == "" && run.req.Draft &&
+					len(run.categories) == 1 && run.slug == "better-slug" && run.req.date.Equal(date)
+			},
+		},
+		{
+			name: "slug left empty",
+			md:   strings.Replace(md, `slug: "Better Slug"`, `slug: ""`, 1),
+			check: func(run *pipelineRun) bool {
+				return run.slug == ""
+			},
+		},
+		{
+			name: "categories removed",
+			md:   strings.Replace(md, "categories: [\"ai\"]\n", "", 1),
+			check: func(run *pipelineRun) bool {
+				return run.categories != nil && len(run.categories) == 0
+			},
+		},
+		{name: "no content", md: "---\ntitle: \"x\"\n---\n\n{{% en %}}\n{{% /en %}}\n", wantErr: true},
+		{name: "no front matter", md: "text", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			run := &pipelineRun{titleEn: "Generated", slug: "old"}
+			err := applyReview(run, tt.md)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !tt.check(run) {
+				t.Errorf("run = %+v", run)
+			}
+		})
+	}
+}
+
+func TestRetitle(t *testing.T) {
+	l := log.New(io.Discard, "", 0)
+	s := &service{log: l, jobs: newJobStore(filepath.Join(t.TempDir(), "jobs.json"), l)}
+	s.jobs.start("a", ideaRequest{})
+
+	tests := []struct {
+		name           string
+		run            pipelineRun
+		wantEn, wantZh string
+	}{
+		{"untranslated", pipelineRun{id: "a", lang: "en", titleEn: "Old", titleZh: "Old"}, "New", "New"},
+		// Without an LLM, the title stands in for its translation.
+		{"translated", pipelineRun{id: "a", lang: "zh", titleEn: "Old", titleZh: "旧"}, "New", "New"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			run := tt.run
+			s.retitle(t.Context(), &run, "New")
+			if run.titleEn != tt.wantEn || run.titleZh != tt.wantZh {
+				t.Errorf("titles = %q, %q; want %q, %q", run.titleEn, run.titleZh, tt.wantEn, tt.wantZh)
+			}
+			if j, _ := s.jobs.get("a"); j.Title != tt.wantEn {
+				t.Errorf("job title = %q, want %q", j.Title, tt.wantEn)
+			}
+		})
+	}
+}
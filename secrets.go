@@ -0,0 +1,94 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// secretCheckInterval is how often a secret file is checked for a new
+// value.
+const secretCheckInterval = 30 * time.Second
+
+// secret is a credential from the environment, or from a file such as a
+// mounted Docker or Kubernetes secret. A file is read again once it
+// changes, so that rotating the secret needs no restart.
+type secret struct {
+	path string // empty if from the environment
+
+	mu      sync.Mutex
+	val     string
+	mod     time.Time // of the file when read
+	checked time.Time
+}
+
+// envSecret returns the secret in the file named by the environment
+// variable name_FILE, or else in the variable name. It returns nil if
+// neither is set.
+func envSecret(name string) (*secret, error) {
+	if path := os.Getenv(name + "_FILE"); path != "" {
+		s := &secret{path: path}
+		if err := s.read(); err != nil {
+			return nil, fmt.Errorf("%s_FILE: %w", name, err)
+		}
+		return s, nil
+	}
+	if v := os.Getenv(name); v != "" {
+		return &secret{val: v}, nil
+	}
+	return nil, nil
+}
+
+// secretEnv returns the value of envSecret(name), exiting if its file
+// cannot be read. It is for secrets read once, at startup.
+func secretEnv(name string) string {
+	s, err := envSecret(name)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return s.value()
+}
+
+// read reads the secret from its file. Callers hold mu, or own s.
+func (s *secret) read() error {
+	fi, err := os.Stat(s.path)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return err
+	}
+	s.val = strings.TrimSpace(string(data))
+	s.mod = fi.ModTime()
+	s.checked = time.Now()
+	return nil
+}
+
+// value returns the secret, or "" for a nil secret. If the file changed
+// since it was read, it is read again; if it cannot be, the last value
+// is kept.
+func (s *secret) value() string {
+	if s == nil {
+		return ""
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.path == "" || time.Since(s.checked) < secretCheckInterval {
+		return s.val
+	}
+	s.checked = time.Now()
+	if fi, err := os.Stat(s.path); err == nil && !fi.ModTime().Equal(s.mod) {
+		if err := s.read(); err != nil {
+			log.Printf("cannot read secret %s: %v", s.path, err)
+		}
+	}
+	return s.val
+}
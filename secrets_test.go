@@ -0,0 +1,50 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestEnvSecret(t *testing.T) {
+	t.Setenv("TEST_SECRET", "from-env")
+	s, err := envSecret("TEST_SECRET")
+	if err != nil || s.value() != "from-env" {
+		t.Errorf("envSecret from the environment = %q, %v", s.value(), err)
+	}
+	if s, err := envSecret("TEST_NO_SECRET"); s != nil || err != nil || s.value() != "" {
+		t.Errorf("envSecret of an unset variable = %+v, %v", s, err)
+	}
+
+	t.Setenv("TEST_SECRET_FILE", filepath.Join(t.TempDir(), "missing"))
+	if _, err := envSecret("TEST_SECRET"); err == nil {
+		t.Error("envSecret of a missing file succeeds")
+	}
+
+	// The file takes precedence over the variable, and is read again
+	// once it changes.
+	path := filepath.Join(t.TempDir(), "secret")
+	os.WriteFile(path, []byte("first\n"), 0o600)
+	t.Setenv("TEST_SECRET_FILE", path)
+	s, err = envSecret("TEST_SECRET")
+	if err != nil || s.value() != "first" {
+		t.Fatalf("envSecret from a file = %q, %v", s.value(), err)
+	}
+	os.WriteFile(path, []byte("second\n"), 0o600)
+	os.Chtimes(path, time.Now(), s.mod.Add(time.Minute))
+	if got := s.value(); got != "first" {
+		t.Errorf("value before the check interval = %q, want first", got)
+	}
+	s.checked = time.Now().Add(-secretCheckInterval)
+	if got := s.value(); got != "second" {
+		t.Errorf("value after rotation = %q, want second", got)
+	}
+
+	// A file that went away keeps the last value.
+	os.Remove(path)
+	s.checked = time.Now().Add(-secretCheckInterval)
+	if got := s.value(); got != "second" {
+		t.Errorf("value after removal = %q, want second", got)
+	}
+}
@@ -0,0 +1,60 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// errSecretFound reports that an idea seems to contain a credential and
+// was not published.
+var errSecretFound = errors.New("the idea seems to contain a secret")
+
+// secretPatterns match credentials that must never be published, along
+// with what they are. They aim at obvious, well-formed keys rather than
+// at every string that looks random.
+var secretPatterns = []struct {
+	kind string
+	re   *regexp.Regexp
+}{
+	{"AWS access key", regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"private key", regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY( BLOCK)?-----`)},
+	{"GitHub token", regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36}|github_pat_[A-Za-z0-9_]{22,})\b`)},
+	{"Slack token", regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}`)},
+	{"API key", regexp.MustCompile(`\bsk-[A-Za-z0-9_-]{20,}`)},
+	{"ideas API key", regexp.MustCompile(`\b` + apiKeyPrefix + `[0-9a-f]{48}\b`)},
+	{"JSON web token", regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}`)},
+	{"bearer token", regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9._~+/-]{20,}`)},
+}
+
+// checkSecrets refuses texts about to be published if they seem to
+// contain a credential, unless allow is set.
+func checkSecrets(allow bool, texts ...string) *requestError {
+	found := findSecrets(texts...)
+	if len(found) == 0 || allow {
+		return nil
+	}
+	msg := fmt.Sprintf("%v (%s); remove it, or set allow_secrets to publish it anyway", errSecretFound, strings.Join(found, ", "))
+	return &requestError{http.StatusUnprocessableEntity, errorResponse{Code: codeSecretDetected, Message: msg}}
+}
+
+// findSecrets returns the kinds of credentials found in texts, in the
+// order of secretPatterns.
+func findSecrets(texts ...string) []string {
+	var kinds []string
+	for _, p := range secretPatterns {
+		for _, t := range texts {
+			if p.re.MatchString(t) {
+				kinds = append(kinds, p.kind)
+				break
+			}
+		}
+	}
+	return kinds
+}
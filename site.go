@@ -0,0 +1,63 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"path"
+	"strings"
+)
+
+// siteConfig describes how ideas are laid out in the target repository
+// for the configured static site generator.
+type siteConfig struct {
+	generator string // "hugo" or "jekyll"
+	ideasDir  string // e.g. "content/ideas"
+	draftsDir string // e.g. "content/ideas" for hugo, "_drafts" for jekyll
+	baseURL   string // e.g. "https://changkun.de/ideas/"
+}
+
+func newSiteConfig(generator, ideasDir, draftsDir, baseURL string) siteConfig {
+	c := siteConfig{generator: generator, ideasDir: ideasDir, draftsDir: draftsDir, baseURL: baseURL}
+	if c.generator != "jekyll" {
+		c.generator = "hugo"
+	}
+	if c.ideasDir == "" {
+		c.ideasDir = "content/ideas"
+	}
+	if c.baseURL == "" {
+		c.baseURL = "https://changkun.de/ideas/"
+	}
+	if c.draftsDir == "" {
+		// Hugo keeps drafts next to published content and hides them
+		// via front matter, Jekyll expects them in a separate folder.
+		c.draftsDir = c.ideasDir
+		if c.generator == "jekyll" {
+			c.draftsDir = "_drafts"
+		}
+	}
+	return c
+}
+
+// filePath returns the repository path for the given idea file name.
+func (c siteConfig) filePath(filename string, draft bool) string {
+	if draft {
+		return path.Join(c.draftsDir, filename)
+	}
+	return path.Join(c.ideasDir, filename)
+}
+
+// url returns the public URL of the idea with the given slug.
+func (c siteConfig) url(slug string) string {
+	return strings.TrimRight(c.baseURL, "/") + "/" + slug + "/"
+}
+
+// draftFrontMatter returns the front matter line that keeps a draft
+// off the live site.
+func (c siteConfig) draftFrontMatter() string {
+	if c.generator == "jekyll" {
+		return "published: false\n"
+	}
+	return "draft: true\n"
+}
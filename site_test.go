@@ -0,0 +1,45 @@
+package main
+
+import "testing"
+
+func TestSiteConfigFilePath(t *testing.T) {
+	tests := []struct {
+		name  string
+		site  siteConfig
+		draft bool
+		want  string
+	}{
+		{
+			name: "hugo published",
+			site: newSiteConfig("", "", "", ""),
+			want: "content/ideas/2025-01-01-idea.md",
+		},
+		{
+			name:  "hugo draft stays in place",
+			site:  newSiteConfig("hugo", "", "", ""),
+			draft: true,
+			want:  "content/ideas/2025-01-01-idea.md",
+		},
+		{
+			name:  "jekyll draft",
+			site:  newSiteConfig("jekyll", "_posts", "", ""),
+			draft: true,
+			want:  "_drafts/2025-01-01-idea.md",
+		},
+		{
+			name:  "custom drafts dir",
+			site:  newSiteConfig("hugo", "content/ideas", "content/drafts/", ""),
+			draft: true,
+			want:  "content/drafts/2025-01-01-idea.md",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.site.filePath("2025-01-01-idea.md", tt.draft)
+			if got != tt.want {
+				t.Errorf("filePath() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,238 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type slackClient struct {
+	signingSecret string
+	botToken      string          // for replying to DMs, optional
+	allowedUsers  map[string]bool // Slack user IDs, empty allows everyone
+}
+
+// verify checks the Slack request signature as described in
+// https://api.slack.com/authentication/verifying-requests-from-slack.
+func (c *slackClient) verify(r *http.Request, body []byte) error {
+	ts := r.Header.Get("X-Slack-Request-Timestamp")
+	sec, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid timestamp: %q", ts)
+	}
+	if d := time.Since(time.Unix(sec, 0)); d > 5*time.Minute || d < -5*time.Minute {
+		return fmt.Errorf("stale timestamp: %s", ts)
+	}
+
+	mac := hmac.New(sha256.New, []byte(c.signingSecret))
+	fmt.Fprintf(mac, "v0:%s:", ts)
+	mac.Write(body)
+	want := "v0=" + hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(want), []byte(r.Header.Get("X-Slack-Signature"))) {
+		return fmt.Errorf("signature mismatch")
+	}
+	return nil
+}
+
+func (c *slackClient) allowed(user string) bool {
+	return len(c.allowedUsers) == 0 || c.allowedUsers[user]
+}
+
+// readSlackRequest reads the request body and verifies its Slack signature.
+func (s *service) readSlackRequest(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return nil, false
+	}
+	if err := s.slack.verify(r, body); err != nil {
+		s.log.Printf("slack verification failed: %v", err)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return nil, false
+	}
+	return body, true
+}
+
+// handleSlackCommand handles the /idea slash command.
+func (s *service) handleSlackCommand(w http.ResponseWriter, r *http.Request) {
+	body, ok := s.readSlackRequest(w, r)
+	if !ok {
+		return
+	}
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		http.Error(w, "invalid form", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if !s.slack.allowed(form.Get("user_id")) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"response_type": "ephemeral",
+			"text":          "You are not allowed to post ideas.",
+		})
+		return
+	}
+	text := strings.TrimSpace(form.Get("text"))
+	if text == "" {
+		json.NewEncoder(w).Encode(map[string]string{
+			"response_type": "ephemeral",
+			"text":          "Usage: /idea <your idea>",
+		})
+		return
+	}
+
+	responseURL := form.Get("response_url")
+	go func() {
+		msg := s.publishForSlack(ideaRequest{Content: text})
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if err := postSlackJSON(ctx, responseURL, "", map[string]string{
+			"response_type": "ephemeral",
+			"text":          msg,
+		}); err != nil {
+			s.log.Printf("slack response failed: %v", err)
+		}
+	}()
+
+	json.NewEncoder(w).Encode(map[string]string{
+		"response_type": "ephemeral",
+		"text":          "Idea accepted, publishing in background...",
+	})
+}
+
+type slackEnvelope struct {
+	Type      string `json:"type"`
+	Challenge string `json:"challenge"`
+	Event     struct {
+		Type        string `json:"type"`
+		Subtype     string `json:"subtype"`
+		ChannelType string `json:"channel_type"`
+		Channel     string `json:"channel"`
+		User        string `json:"user"`
+		BotID       string `json:"bot_id"`
+		Text        string `json:"text"`
+		TS          string `json:"ts"`
+	} `json:"event"`
+}
+
+// handleSlackEvents handles direct messages sent to the bot via the
+// Events API and replies in-thread once the idea is published.
+func (s *service) handleSlackEvents(w http.ResponseWriter, r *http.Request) {
+	body, ok := s.readSlackRequest(w, r)
+	if !ok {
+		return
+	}
+	var env slackEnvelope
+	if err := json.Unmarshal(body, &env); err != nil {
+		http.Error(w, "invalid event", http.StatusBadRequest)
+		return
+	}
+
+	switch env.Type {
+	case "url_verification":
+		w.Header().Set("Content-Type", "text/plain")
+		io.WriteString(w, env.Challenge)
+		return
+	case "event_callback":
+	default:
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	// Slack retries events that were not acknowledged quickly enough.
+	// The first delivery already triggered publishing.
+	if r.Header.Get("X-Slack-Retry-Num") != "" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	ev := env.Event
+	if ev.Type != "message" || ev.ChannelType != "im" || ev.Subtype != "" || ev.BotID != "" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	text := strings.TrimSpace(ev.Text)
+	if text == "" || !s.slack.allowed(ev.User) {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	go func() {
+		msg := s.publishForSlack(ideaRequest{Content: text})
+		if s.slack.botToken == "" {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if err := postSlackJSON(ctx, "https://slack.com/api/chat.postMessage", s.slack.botToken, map[string]string{
+			"channel":   ev.Channel,
+			"thread_ts": ev.TS,
+			"text":      msg,
+		}); err != nil {
+			s.log.Printf("slack reply failed: %v", err)
+		}
+	}()
+	w.WriteHeader(http.StatusOK)
+}
+
+// publishForSlack publishes the idea and returns a reply message.
+func (s *service) publishForSlack(req ideaRequest) string {
+	p, err := s.processIdea(req)
+	if err != nil {
+		return fmt.Sprintf("Publishing failed: %v", err)
+	}
+	if p.queued {
+		return fmt.Sprintf("Committing to GitHub failed, retrying in the background: %s", p.url)
+	}
+	return fmt.Sprintf("Published: %s", p.url)
+}
+
+func postSlackJSON(ctx context.Context, url, token string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("marshal request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Slack API returned %d: %s", resp.StatusCode, string(respBody))
+	}
+	// Web API methods report errors in the body with a 200 status.
+	var result struct {
+		OK    *bool  `json:"ok"`
+		Error string `json:"error"`
+	}
+	if json.Unmarshal(respBody, &result) == nil && result.OK != nil && !*result.OK {
+		return fmt.Errorf("Slack API error: %s", result.Error)
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestSlackVerify(t *testing.T) {
+	c := &slackClient{signingSecret: "secret"}
+	body := []byte("text=hello&user_id=U1")
+	sign := func(ts string) string {
+		mac := hmac.New(sha256.New, []byte("secret"))
+		mac.Write([]byte("v0:" + ts + ":" + string(body)))
+		return "v0=" + hex.EncodeToString(mac.Sum(nil))
+	}
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	old := strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+
+	tests := []struct {
+		name    string
+		ts      string
+		sig     string
+		wantErr bool
+	}{
+		{name: "valid", ts: now, sig: sign(now)},
+		{name: "wrong signature", ts: now, sig: "v0=deadbeef", wantErr: true},
+		{name: "stale timestamp", ts: old, sig: sign(old), wantErr: true},
+		{name: "missing timestamp", ts: "", sig: sign(""), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/ideas/slack/command", nil)
+			r.Header.Set("X-Slack-Request-Timestamp", tt.ts)
+			r.Header.Set("X-Slack-Signature", tt.sig)
+			err := c.verify(r, body)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("verify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"strings"
+	"time"
+)
+
+// archiveStats summarizes the indexed ideas.
+type archiveStats struct {
+	Total  int          `json:"total"`
+	Months []monthStats `json:"months"` // oldest first, including empty months
+	// Languages counts the ideas with text in each language; bilingual
+	// ideas count for both.
+	Languages map[string]int `json:"languages"`
+	// AverageWords is the average length of the text in each language,
+	// in words, where a CJK character counts as a word.
+	AverageWords map[string]int `json:"average_words"`
+}
+
+type monthStats struct {
+	Month string `json:"month"` // 2006-01
+	Ideas int    `json:"ideas"`
+}
+
+// computeArchiveStats computes the statistics of ideas over the given
+// number of months up to now. Weekly digests are not ideas and are left
+// out.
+func computeArchiveStats(ideas []*indexedIdea, now time.Time, months int) archiveStats {
+	st := archiveStats{Languages: map[string]int{}, AverageWords: map[string]int{}}
+	first := firstMonth(now, months)
+	perMonth := map[string]int{}
+	words := map[string]int{}
+	for _, d := range ideas {
+		if strings.Contains(d.ID, "-weekly-digest") {
+			continue
+		}
+		st.Total++
+		if !d.Date.Before(first) {
+			perMonth[d.Date.Format("2006-01")]++
+		}
+		for lang, text := range map[string]string{"en": d.ContentEn, "zh": d.ContentZh} {
+			if strings.TrimSpace(text) == "" {
+				continue
+			}
+			st.Languages[lang]++
+			words[lang] += countWords(text)
+		}
+	}
+	for m := first; !m.After(now); m = m.AddDate(0, 1, 0) {
+		month := m.Format("2006-01")
+		st.Months = append(st.Months, monthStats{Month: month, Ideas: perMonth[month]})
+	}
+	for lang, n := range st.Languages {
+		st.AverageWords[lang] = words[lang] / n
+	}
+	return st
+}
+
+// firstMonth returns the start of the first of the given number of
+// months up to now.
+func firstMonth(now time.Time, months int) time.Time {
+	return time.Date(now.Year(), now.Month()-time.Month(months-1), 1, 0, 0, 0, 0, now.Location())
+}
+
+// activityStats summarizes the work of the service: the LLM tokens used,
+// which are kept for usageRetention, and the pipelines that failed, of
+// those in the job log.
+type activityStats struct {
+	Usage  tokenUsage `json:"usage"`
+	Failed int        `json:"failed"`
+	// FailedStages counts the failed pipelines by the stage that failed.
+	FailedStages map[string]int  `json:"failed_stages"`
+	Months       []monthActivity `json:"months"` // oldest first, including empty months
+}
+
+type monthActivity struct {
+	Month  string     `json:"month"` // 2006-01
+	Usage  tokenUsage `json:"usage"`
+	Failed int        `json:"failed"`
+}
+
+// computeActivityStats computes the activity over the given number of
+// months up to now from the token usage and the jobs. A nil meter has no
+// usage.
+func computeActivityStats(usage *usageMeter, jobs []job, now time.Time, months int) activityStats {
+	st := activityStats{FailedStages: map[string]int{}}
+	first := firstMonth(now, months)
+	perMonth := map[string]int{}
+	for _, j := range jobs {
+		if j.Status != jobFailed || j.Started.Before(first) {
+			continue
+		}
+		st.Failed++
+		perMonth[j.Started.Format("2006-01")]++
+		if n := len(j.Stages); n > 0 {
+			st.FailedStages[j.Stages[n-1].Name]++
+		}
+	}
+	for m := first; !m.After(now); m = m.AddDate(0, 1, 0) {
+		ma := monthActivity{Month: m.Format("2006-01"), Failed: perMonth[m.Format("2006-01")]}
+		if usage != nil {
+			ma.Usage = usage.summary(ma.Month).Total
+		}
+		st.Usage.add(ma.Usage)
+		st.Months = append(st.Months, ma)
+	}
+	return st
+}
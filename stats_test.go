@@ -0,0 +1,79 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestComputeArchiveStats(t *testing.T) {
+	now := time.Date(2025, 3, 10, 20, 0, 0, 0, time.Local)
+	day := func(s string) time.Time {
+		d, _ := time.ParseInLocation(time.DateOnly, s, time.Local)
+		return d
+	}
+	ideas := []*indexedIdea{
+		{ID: "2025-03-01-a", Date: day("2025-03-01"), ContentEn: "one two three", ContentZh: "一二三四五"},
+		{ID: "2025-03-02-b", Date: day("2025-03-02"), ContentEn: "one"},
+		{ID: "2025-01-31-c", Date: day("2025-01-31"), ContentZh: "一"},
+		{ID: "2024-06-01-d", Date: day("2024-06-01"), ContentEn: "one two"},
+		{ID: "2025-03-09-weekly-digest", Date: day("2025-03-09"), ContentEn: "digest"},
+	}
+
+	got := computeArchiveStats(ideas, now, 3)
+	want := archiveStats{
+		Total: 4,
+		Months: []monthStats{
+			{Month: "2025-01", Ideas: 1},
+			{Month: "2025-02", Ideas: 0},
+			{Month: "2025-03", Ideas: 2},
+		},
+		Languages:    map[string]int{"en": 3, "zh": 2},
+		AverageWords: map[string]int{"en": 2, "zh": 3},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("computeArchiveStats = %+v, want %+v", got, want)
+	}
+
+	if got := computeArchiveStats(nil, now, 1); len(got.Months) != 1 || got.Total != 0 || len(got.AverageWords) != 0 {
+		t.Errorf("no ideas: %+v", got)
+	}
+}
+
+func TestComputeActivityStats(t *testing.T) {
+	now := time.Date(2025, 3, 10, 20, 0, 0, 0, time.Local)
+	usage := &usageMeter{days: map[string]map[string]*tokenUsage{
+		"2025-03-01": {"m1": {Requests: 2, PromptTokens: 100, CompletionTokens: 50}, "m2": {Requests: 1, PromptTokens: 10}},
+		"2025-01-15": {"m1": {Requests: 1, PromptTokens: 20, CompletionTokens: 5}},
+		"2024-12-31": {"m1": {Requests: 9, PromptTokens: 900}},
+	}}
+	failed := func(started time.Time, stage string) job {
+		return job{Status: jobFailed, Started: started, Stages: []jobStage{{Name: "title"}, {Name: stage}}}
+	}
+	jobs := []job{
+		failed(time.Date(2025, 3, 2, 0, 0, 0, 0, time.Local), "commit"),
+		failed(time.Date(2025, 3, 5, 0, 0, 0, 0, time.Local), "augment"),
+		failed(time.Date(2025, 1, 5, 0, 0, 0, 0, time.Local), "commit"),
+		failed(time.Date(2024, 12, 5, 0, 0, 0, 0, time.Local), "commit"),
+		{Status: jobDone, Started: time.Date(2025, 3, 3, 0, 0, 0, 0, time.Local)},
+	}
+
+	got := computeActivityStats(usage, jobs, now, 3)
+	want := activityStats{
+		Usage:        tokenUsage{Requests: 4, PromptTokens: 130, CompletionTokens: 55},
+		Failed:       3,
+		FailedStages: map[string]int{"commit": 2, "augment": 1},
+		Months: []monthActivity{
+			{Month: "2025-01", Usage: tokenUsage{Requests: 1, PromptTokens: 20, CompletionTokens: 5}, Failed: 1},
+			{Month: "2025-02"},
+			{Month: "2025-03", Usage: tokenUsage{Requests: 3, PromptTokens: 110, CompletionTokens: 50}, Failed: 2},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("computeActivityStats = %+v, want %+v", got, want)
+	}
+
+	if got := computeActivityStats(nil, nil, now, 1); len(got.Months) != 1 || got.Usage.Tokens() != 0 || got.Failed != 0 {
+		t.Errorf("no activity: %+v", got)
+	}
+}
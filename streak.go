@@ -0,0 +1,176 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// streakInfo describes the run of consecutive days with at least one idea.
+type streakInfo struct {
+	Current int    `json:"current"`  // days, including today or yesterday
+	Longest int    `json:"longest"`  // days
+	LastDay string `json:"last_day"` // last day with an idea, 2006-01-02
+	Today   bool   `json:"today"`    // whether today already has an idea
+}
+
+// computeStreak computes the streak from the set of days with ideas. A
+// streak that ended yesterday is still current, as today is not over.
+func computeStreak(days map[string]bool, now time.Time) streakInfo {
+	var st streakInfo
+	for day := range days {
+		if day > st.LastDay {
+			st.LastDay = day
+		}
+		// Count each run once, from its first day.
+		t, err := time.Parse(time.DateOnly, day)
+		if err != nil || days[t.AddDate(0, 0, -1).Format(time.DateOnly)] {
+			continue
+		}
+		n := 1
+		for days[t.AddDate(0, 0, n).Format(time.DateOnly)] {
+			n++
+		}
+		st.Longest = max(st.Longest, n)
+	}
+
+	today := now.Format(time.DateOnly)
+	st.Today = days[today]
+	d := now
+	if !st.Today {
+		d = now.AddDate(0, 0, -1)
+	}
+	for days[d.Format(time.DateOnly)] {
+		st.Current++
+		d = d.AddDate(0, 0, -1)
+	}
+	return st
+}
+
+// streak computes the current streak from the indexed ideas.
+func (s *service) streak(now time.Time) streakInfo {
+	days := map[string]bool{}
+	for _, d := range s.index.all() {
+		if !d.Date.IsZero() && !strings.Contains(d.ID, "-weekly-digest") {
+			days[d.Date.Format(time.DateOnly)] = true
+		}
+	}
+	return computeStreak(days, now)
+}
+
+// handleStats serves statistics about the idea stream: the streak and
+// the archive over the last ?months (default 12).
+func (s *service) handleStats(w http.ResponseWriter, r *http.Request) {
+	months := 12
+	if v := r.URL.Query().Get("months"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 120 {
+			s.jsonError(w, "months must be between 1 and 120", http.StatusBadRequest)
+			return
+		}
+		months = n
+	}
+	now := time.Now()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct {
+		OK       bool          `json:"ok"`
+		Streak   streakInfo    `json:"streak"`
+		Archive  archiveStats  `json:"archive"`
+		Activity activityStats `json:"activity"`
+	}{
+		OK:       true,
+		Streak:   s.streak(now),
+		Archive:  computeArchiveStats(s.index.all(), now, months),
+		Activity: computeActivityStats(s.usage, s.jobs.recent(), now, months),
+	})
+}
+
+// mailer sends plain-text email through an SMTP server with STARTTLS.
+type mailer struct {
+	addr string // host:port
+	user string
+	pass string
+	from string
+	to   string
+}
+
+func (m *mailer) send(subject, body string) error {
+	host, _, err := net.SplitHostPort(m.addr)
+	if err != nil {
+		return fmt.Errorf("invalid SMTP address: %w", err)
+	}
+	var auth smtp.Auth
+	if m.user != "" {
+		auth = smtp.PlainAuth("", m.user, m.pass, host)
+	}
+	msg := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n%s\r\n",
+		m.from, m.to, mimeHeader(subject), strings.ReplaceAll(body, "\n", "\r\n"))
+	return smtp.SendMail(m.addr, auth, m.from, []string{m.to}, []byte(msg))
+}
+
+// reminder sends an evening reminder when no idea was captured today and
+// the current streak is about to break.
+type reminder struct {
+	s         *service
+	hour      int
+	mail      *mailer // optional
+	statePath string
+}
+
+type reminderState struct {
+	Last string `json:"last"` // date of the last reminder, 2006-01-02
+}
+
+func (rm *reminder) run(ctx context.Context) {
+	t := time.NewTicker(15 * time.Minute)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+
+		now := time.Now()
+		if now.Hour() < rm.hour {
+			continue
+		}
+		var st reminderState
+		if err := readJSONFile(rm.statePath, &st); err != nil {
+			rm.s.log.Printf("cannot load reminder state: %v", err)
+			continue
+		}
+		today := now.Format(time.DateOnly)
+		if st.Last == today {
+			continue
+		}
+		if streak := rm.s.streak(now); !streak.Today && streak.Current > 0 {
+			rm.remind(ctx, streak)
+		}
+		if err := writeJSONFile(rm.statePath, reminderState{Last: today}); err != nil {
+			rm.s.log.Printf("cannot save reminder state: %v", err)
+		}
+	}
+}
+
+func (rm *reminder) remind(ctx context.Context, streak streakInfo) {
+	title := fmt.Sprintf("Keep your %d-day streak", streak.Current)
+	msg := "No idea captured today yet. One thought is enough to keep the streak going."
+	rm.s.notifier.send(ctx, notification{title: title, message: msg})
+	if rm.mail != nil {
+		if err := rm.mail.send(title, msg); err != nil {
+			rm.s.log.Printf("reminder email failed: %v", err)
+		}
+	}
+	rm.s.log.Printf("streak reminder sent (%d days)", streak.Current)
+}
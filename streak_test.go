@@ -0,0 +1,51 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestComputeStreak(t *testing.T) {
+	now := time.Date(2025, 3, 10, 20, 0, 0, 0, time.Local)
+	set := func(days ...string) map[string]bool {
+		m := map[string]bool{}
+		for _, d := range days {
+			m[d] = true
+		}
+		return m
+	}
+
+	tests := []struct {
+		name string
+		days map[string]bool
+		want streakInfo
+	}{
+		{
+			name: "none",
+			days: set(),
+			want: streakInfo{},
+		},
+		{
+			name: "includes today",
+			days: set("2025-03-08", "2025-03-09", "2025-03-10"),
+			want: streakInfo{Current: 3, Longest: 3, LastDay: "2025-03-10", Today: true},
+		},
+		{
+			name: "ended yesterday is still current",
+			days: set("2025-03-01", "2025-03-02", "2025-03-03", "2025-03-04", "2025-03-08", "2025-03-09"),
+			want: streakInfo{Current: 2, Longest: 4, LastDay: "2025-03-09"},
+		},
+		{
+			name: "broken",
+			days: set("2025-02-27", "2025-02-28", "2025-03-01", "2025-03-08"),
+			want: streakInfo{Current: 0, Longest: 3, LastDay: "2025-03-08"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := computeStreak(tt.days, now); got != tt.want {
+				t.Errorf("computeStreak = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// sttClient transcribes audio using a Whisper-compatible
+// /audio/transcriptions endpoint.
+type sttClient struct {
+	baseURL string
+	apiKey  *secret
+	model   string // e.g. "whisper-1"
+}
+
+// maxAudioSize is the upload limit of the Whisper API.
+const maxAudioSize = 25 << 20
+
+func (c *sttClient) transcribe(ctx context.Context, filename string, audio io.Reader) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 90*time.Second)
+	defer cancel()
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	if err := mw.WriteField("model", c.model); err != nil {
+		return "", fmt.Errorf("write model field: %w", err)
+	}
+	fw, err := mw.CreateFormFile("file", filename)
+	if err != nil {
+		return "", fmt.Errorf("create file field: %w", err)
+	}
+	if _, err := io.Copy(fw, audio); err != nil {
+		return "", fmt.Errorf("copy audio: %w", err)
+	}
+	if err := mw.Close(); err != nil {
+		return "", fmt.Errorf("close multipart: %w", err)
+	}
+
+	url := strings.TrimRight(c.baseURL, "/") + "/audio/transcriptions"
+	req, err := http.NewRequestWithContext(ctx, "POST", url, &body)
+	if err != nil {
+		return "", fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+c.apiKey.value())
+	setRequestID(req)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("STT API returned %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	var result struct {
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return "", fmt.Errorf("unmarshal response: %w", err)
+	}
+	text := strings.TrimSpace(result.Text)
+	if text == "" {
+		return "", fmt.Errorf("empty transcript")
+	}
+	return text, nil
+}
+
+// handleVoice accepts a multipart upload with an "audio" file and an
+// optional "title" field, transcribes it, and publishes the transcript.
+func (s *service) handleVoice(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxAudioSize+1<<20)
+	if err := r.ParseMultipartForm(1 << 20); err != nil {
+		s.jsonError(w, "invalid multipart body", http.StatusBadRequest)
+		return
+	}
+	f, hdr, err := r.FormFile("audio")
+	if err != nil {
+		s.jsonError(w, "audio file is required", http.StatusBadRequest)
+		return
+	}
+	defer f.Close()
+
+	transcript, err := s.stt.transcribe(r.Context(), hdr.Filename, f)
+	if err != nil {
+		s.log.Printf("transcription failed: %v", err)
+		s.llmError(w, "transcription failed", err)
+		return
+	}
+	s.log.Printf("transcribed %s (%d bytes)", hdr.Filename, hdr.Size)
+
+	if _, ok := s.enqueueIdea(ideaRequest{
+		Title:     strings.TrimSpace(r.FormValue("title")),
+		Content:   transcript,
+		requestID: requestIDFromContext(r.Context()),
+		user:      requestUser(r),
+	}); !ok {
+		s.busy(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ideaResponse{
+		OK:      true,
+		Message: "voice memo transcribed, publishing in background",
+		Content: transcript,
+	})
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeSTT serves a Whisper-compatible transcription API that returns
+// text for every upload, or fails if text is empty.
+func fakeSTT(t *testing.T, text string) *sttClient {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/audio/transcriptions" || r.Header.Get("Authorization") != "Bearer key" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		f, _, err := r.FormFile("file")
+		if err != nil || r.FormValue("model") != "whisper-1" {
+			http.Error(w, "invalid upload", http.StatusBadRequest)
+			return
+		}
+		audio, _ := io.ReadAll(f)
+		if text == "" {
+			http.Error(w, "cannot decode "+string(audio), http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"text": " " + text + "\n"})
+	}))
+	t.Cleanup(srv.Close)
+	return &sttClient{baseURL: srv.URL + "/", apiKey: &secret{val: "key"}, model: "whisper-1"}
+}
+
+func TestTranscribe(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		want    string
+		wantErr bool
+	}{
+		{name: "transcript", text: "An idea I had on the way.", want: "An idea I had on the way."},
+		{name: "API error", wantErr: true},
+		{name: "empty transcript", text: " ", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := fakeSTT(t, tt.text).transcribe(t.Context(), "memo.m4a", strings.NewReader("audio"))
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("transcribe() = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleVoice(t *testing.T) {
+	l := log.New(io.Discard, "", 0)
+	dir := t.TempDir()
+	s := &service{
+		log:       l,
+		jobs:      newJobStore(filepath.Join(dir, "jobs.json"), l),
+		lifecycle: newLifecycleStore(filepath.Join(dir, "lifecycle.json"), l),
+		// No workers: accepted ideas wait in the queue.
+		pool: newWorkerPool(0, 1),
+	}
+	upload := func(field string) *http.Request {
+		var body bytes.Buffer
+		mw := multipart.NewWriter(&body)
+		mw.WriteField("title", " Commute ")
+		fw, _ := mw.CreateFormFile(field, "memo.m4a")
+		fw.Write([]byte("audio"))
+		mw.Close()
+		r := httptest.NewRequest("POST", "/ideas/voice", &body)
+		r.Header.Set("Content-Type", mw.FormDataContentType())
+		return r
+	}
+
+	tests := []struct {
+		name  string
+		stt   string
+		field string
+		want  int
+	}{
+		{name: "no audio", stt: "An idea.", field: "file", want: http.StatusBadRequest},
+		{name: "transcription failed", field: "audio", want: http.StatusInternalServerError},
+		{name: "queued", stt: "An idea I had on the way.", field: "audio", want: http.StatusOK},
+		{name: "queue full", stt: "Another idea.", field: "audio", want: http.StatusTooManyRequests},
+	}
+	for _, tt := range tests {
+		s.stt = fakeSTT(t, tt.stt)
+		rec := httptest.NewRecorder()
+		s.handleVoice(rec, upload(tt.field))
+		if rec.Code != tt.want {
+			t.Errorf("%s: status = %d %s, want %d", tt.name, rec.Code, rec.Body, tt.want)
+		}
+	}
+
+	jobs := s.jobs.recent()
+	if len(jobs) != 1 {
+		t.Fatalf("jobs = %+v, want one", jobs)
+	}
+	if req := jobs[0].Request; req.Title != "Commute" || req.Content != "An idea I had on the way." {
+		t.Errorf("queued request = %+v", req)
+	}
+}
@@ -0,0 +1,280 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Suggestion states.
+const (
+	suggestionPending  = "pending"
+	suggestionApproved = "approved"
+	suggestionRejected = "rejected"
+)
+
+// Limits of reader suggestions.
+const (
+	maxSuggestions       = 500 // kept in the store, pending ones are never dropped
+	maxSuggestionTitle   = 200
+	maxSuggestionContent = 5000
+)
+
+// suggestionStore is the moderation queue of ideas suggested by readers.
+type suggestionStore struct {
+	path string
+	log  *log.Logger
+
+	mu    sync.Mutex
+	items []*suggestion // oldest first
+}
+
+type suggestion struct {
+	ID      string    `json:"id"`
+	Title   string    `json:"title,omitempty"`
+	Content string    `json:"content"`
+	Name    string    `json:"name,omitempty"` // how the reader wants to be credited
+	Status  string    `json:"status"`
+	Created time.Time `json:"created"`
+	Decided time.Time `json:"decided,omitzero"`
+}
+
+func newSuggestionStore(path string, l *log.Logger) *suggestionStore {
+	ss := &suggestionStore{path: path, log: l}
+	if err := readJSONFile(path, &ss.items); err != nil {
+		l.Printf("cannot load suggestions: %v", err)
+	}
+	return ss
+}
+
+// save persists the store. Callers hold mu.
+func (ss *suggestionStore) save() {
+	if err := writeJSONFile(ss.path, ss.items); err != nil {
+		ss.log.Printf("cannot save suggestions: %v", err)
+	}
+}
+
+func (ss *suggestionStore) add(title, content, name string) *suggestion {
+	var b [8]byte
+	rand.Read(b[:])
+	sg := &suggestion{
+		ID:      hex.EncodeToString(b[:]),
+		Title:   title,
+		Content: content,
+		Name:    name,
+		Status:  suggestionPending,
+		Created: time.Now(),
+	}
+
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	ss.items = append(ss.items, sg)
+	for i := 0; len(ss.items) > maxSuggestions && i < len(ss.items); {
+		if ss.items[i].Status == suggestionPending {
+			i++
+			continue
+		}
+		ss.items = slices.Delete(ss.items, i, i+1)
+	}
+	ss.save()
+	return sg
+}
+
+// pending returns the suggestions awaiting moderation, oldest first.
+func (ss *suggestionStore) pending() []suggestion {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	var out []suggestion
+	for _, sg := range ss.items {
+		if sg.Status == suggestionPending {
+			out = append(out, *sg)
+		}
+	}
+	return out
+}
+
+// decide moves a pending suggestion to the given state, replacing its
+// title and content with the moderated version if not empty.
+func (ss *suggestionStore) decide(id, status, title, content string) (suggestion, bool) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	for _, sg := range ss.items {
+		if sg.ID != id || sg.Status != suggestionPending {
+			continue
+		}
+		sg.Status = status
+		sg.Decided = time.Now()
+		if title != "" {
+			sg.Title = title
+		}
+		if content != "" {
+			sg.Content = content
+		}
+		ss.save()
+		return *sg, true
+	}
+	return suggestion{}, false
+}
+
+// turnstile verifies Cloudflare Turnstile captcha tokens.
+type turnstile struct {
+	secret string
+}
+
+// verify checks a token as described in
+// https://developers.cloudflare.com/turnstile/get-started/server-side-validation/.
+func (t *turnstile) verify(ctx context.Context, token, ip string) error {
+	if token == "" {
+		return fmt.Errorf("captcha token missing")
+	}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	form := url.Values{"secret": {t.secret}, "response": {token}, "remoteip": {ip}}
+	req, err := http.NewRequestWithContext(ctx, "POST", "https://challenges.cloudflare.com/turnstile/v0/siteverify", strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("send request: %w", err)
+	}
+	defer resp.Body.Close()
+	var result struct {
+		Success bool     `json:"success"`
+		Errors  []string `json:"error-codes"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	if !result.Success {
+		return fmt.Errorf("captcha rejected: %v", result.Errors)
+	}
+	return nil
+}
+
+// handleSuggest lets readers suggest an idea. It accepts JSON or a plain
+// HTML form post, so it can back a form on the blog:
+//
+//	{"title": "...", "content": "...", "name": "...", "captcha": "<turnstile token>"}
+//
+// Suggestions are never published directly; they wait in the moderation
+// queue of the dashboard.
+func (s *service) handleSuggest(w http.ResponseWriter, r *http.Request) {
+	if !s.suggestLimit.allow(readIP(r)) {
+		s.jsonError(w, "too many suggestions, try again later", http.StatusTooManyRequests)
+		return
+	}
+
+	var req struct {
+		Title   string `json:"title"`
+		Content string `json:"content"`
+		Name    string `json:"name"`
+		Captcha string `json:"captcha"`
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, 64*1024)
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			s.jsonError(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+	} else {
+		req.Title = r.FormValue("title")
+		req.Content = r.FormValue("content")
+		req.Name = r.FormValue("name")
+		req.Captcha = r.FormValue("cf-turnstile-response")
+	}
+	req.Title = strings.TrimSpace(req.Title)
+	req.Content = strings.TrimSpace(req.Content)
+	req.Name = strings.TrimSpace(req.Name)
+	switch {
+	case req.Content == "":
+		s.jsonError(w, "content is required", http.StatusBadRequest)
+		return
+	case utf8.RuneCountInString(req.Content) > maxSuggestionContent:
+		s.jsonError(w, fmt.Sprintf("content too long, max %d characters", maxSuggestionContent), http.StatusBadRequest)
+		return
+	case utf8.RuneCountInString(req.Title) > maxSuggestionTitle || utf8.RuneCountInString(req.Name) > maxSuggestionTitle:
+		s.jsonError(w, fmt.Sprintf("title and name are limited to %d characters", maxSuggestionTitle), http.StatusBadRequest)
+		return
+	}
+	if s.captcha != nil {
+		if err := s.captcha.verify(r.Context(), req.Captcha, readIP(r)); err != nil {
+			s.log.Printf("suggestion captcha failed: %v", err)
+			s.jsonError(w, "captcha verification failed", http.StatusForbidden)
+			return
+		}
+	}
+
+	sg := s.suggestions.add(req.Title, req.Content, req.Name)
+	s.log.Printf("new suggestion %s from %q", sg.ID, sg.Name)
+	go s.notifier.send(context.Background(), notification{
+		title:   "New idea suggestion",
+		message: sg.Content,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ideaResponse{OK: true, Message: "thanks, your suggestion will be reviewed"})
+}
+
+// handleModerateSuggestion approves or rejects a suggestion from the
+// dashboard. Approving publishes the possibly edited title and content,
+// or saves them as a draft with draft=true.
+func (s *service) handleModerateSuggestion(w http.ResponseWriter, r *http.Request) {
+	status := suggestionRejected
+	if strings.HasSuffix(r.URL.Path, "/approve") {
+		status = suggestionApproved
+	}
+	title := strings.TrimSpace(r.FormValue("title"))
+	content := strings.TrimSpace(r.FormValue("content"))
+	sg, ok := s.suggestions.decide(r.PathValue("id"), status, title, content)
+	if !ok {
+		s.jsonError(w, "suggestion not found or already moderated", http.StatusNotFound)
+		return
+	}
+	msg := "suggestion rejected"
+	if status == suggestionApproved {
+		req := suggestionIdea(sg, r.FormValue("draft") == "true")
+		req.requestID = requestIDFromContext(r.Context())
+		go s.processIdea(req)
+		msg = "suggestion accepted, publishing in background"
+	}
+
+	// Browsers submit the dashboard form; send them back to it.
+	if r.Header.Get("Accept") != "application/json" {
+		http.Redirect(w, r, "/ideas/admin", http.StatusSeeOther)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ideaResponse{OK: true, Message: msg})
+}
+
+// suggestionIdea turns an approved suggestion into an idea that credits
+// the reader.
+func suggestionIdea(sg suggestion, draft bool) ideaRequest {
+	content := sg.Content
+	if sg.Name != "" {
+		content += "\n\nSuggested by " + sg.Name + "."
+	}
+	return ideaRequest{
+		Title:    sg.Title,
+		Content:  content,
+		Draft:    draft,
+		Pipeline: "suggest",
+	}
+}
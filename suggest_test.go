@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHandleSuggest(t *testing.T) {
+	l := log.New(io.Discard, "", 0)
+	s := &service{
+		log:          l,
+		suggestions:  newSuggestionStore(filepath.Join(t.TempDir(), "suggestions.json"), l),
+		suggestLimit: newRateLimiter(3, time.Hour),
+	}
+	n := 0
+	suggest := func(contentType, body string) int {
+		r := httptest.NewRequest("POST", "/ideas/suggest", strings.NewReader(body))
+		r.Header.Set("Content-Type", contentType)
+		// A client claiming another address each time is still limited.
+		n++
+		r.Header.Set("X-Forwarded-For", fmt.Sprintf("198.51.100.%d", n))
+		rec := httptest.NewRecorder()
+		realIP(nil)(http.HandlerFunc(s.handleSuggest)).ServeHTTP(rec, r)
+		return rec.Code
+	}
+
+	if code := suggest("application/json", `{"title":"Idea","content":"  An idea  ","name":"Ann"}`); code != http.StatusOK {
+		t.Errorf("json: status = %d", code)
+	}
+	if code := suggest("application/x-www-form-urlencoded", "content=From+a+form"); code != http.StatusOK {
+		t.Errorf("form: status = %d", code)
+	}
+	if code := suggest("application/json", `{"content":""}`); code != http.StatusBadRequest {
+		t.Errorf("empty: status = %d, want 400", code)
+	}
+	if code := suggest("application/json", `{"content":"one more"}`); code != http.StatusTooManyRequests {
+		t.Errorf("over limit: status = %d, want 429", code)
+	}
+
+	pending := s.suggestions.pending()
+	if len(pending) != 2 || pending[0].Content != "An idea" || pending[0].Name != "Ann" || pending[1].Content != "From a form" {
+		t.Fatalf("pending = %+v", pending)
+	}
+
+	r := httptest.NewRequest("POST", "/ideas/admin/suggestions/"+pending[1].ID+"/reject", nil)
+	r.SetPathValue("id", pending[1].ID)
+	rec := httptest.NewRecorder()
+	s.handleModerateSuggestion(rec, r)
+	if rec.Code != http.StatusSeeOther {
+		t.Errorf("reject: status = %d, want 303", rec.Code)
+	}
+	if _, ok := s.suggestions.decide(pending[1].ID, suggestionApproved, "", ""); ok {
+		t.Error("a rejected suggestion must not be approved again")
+	}
+
+	sg, ok := s.suggestions.decide(pending[0].ID, suggestionApproved, "Edited", "")
+	if !ok || sg.Title != "Edited" || sg.Content != "An idea" {
+		t.Fatalf("decide = %+v, %v", sg, ok)
+	}
+	if req := suggestionIdea(sg, true); req.Content != "An idea\n\nSuggested by Ann." || req.Title != "Edited" || !req.Draft {
+		t.Errorf("suggestionIdea = %+v", req)
+	}
+	if got := s.suggestions.pending(); len(got) != 0 {
+		t.Errorf("pending after moderation = %+v", got)
+	}
+}
+
+func TestAdminTemplateSuggestions(t *testing.T) {
+	var b strings.Builder
+	err := adminTmpl.Execute(&b, adminStatus{
+		Suggested: []suggestion{{ID: "s1", Content: "<i>idea</i>", Name: "Ann", Created: time.Now()}},
+	})
+	if err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	out := b.String()
+	for _, want := range []string{"/ideas/admin/suggestions/s1/approve", "/ideas/admin/suggestions/s1/reject", "&lt;i&gt;idea&lt;/i&gt;", "by Ann"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("dashboard misses %q", want)
+		}
+	}
+	if strings.Contains(out, `http-equiv="refresh"`) {
+		t.Error("dashboard must not auto-refresh while suggestions are being edited")
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// taxonomy maps free-form tags to the fixed set of categories known by
+// the blog theme. Tags that match nothing land in the "other" bucket.
+type taxonomy struct {
+	categories map[string]bool
+	aliases    map[string]string // normalized tag -> category
+	other      string
+}
+
+type taxonomyFile struct {
+	Categories []string            `json:"categories"`
+	Aliases    map[string][]string `json:"aliases"` // category -> tags
+	Other      string              `json:"other"`
+}
+
+// loadTaxonomy reads a taxonomy definition such as:
+//
+//	{
+//	  "categories": ["ai", "programming", "research"],
+//	  "aliases": {"programming": ["go", "golang", "rust"]},
+//	  "other": "other"
+//	}
+func loadTaxonomy(path string) (*taxonomy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var f taxonomyFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("parse taxonomy: %w", err)
+	}
+	if len(f.Categories) == 0 {
+		return nil, fmt.Errorf("taxonomy has no categories")
+	}
+
+	t := &taxonomy{
+		categories: map[string]bool{},
+		aliases:    map[string]string{},
+		other:      cmp.Or(normalizeTag(f.Other), "other"),
+	}
+	for _, c := range f.Categories {
+		t.categories[normalizeTag(c)] = true
+	}
+	for c, tags := range f.Aliases {
+		c = normalizeTag(c)
+		if !t.categories[c] {
+			return nil, fmt.Errorf("alias target %q is not a category", c)
+		}
+		for _, tag := range tags {
+			t.aliases[normalizeTag(tag)] = c
+		}
+	}
+	return t, nil
+}
+
+// categorize maps tags to valid categories, preserving order and
+// dropping duplicates. The result is never empty.
+func (t *taxonomy) categorize(tags []string) []string {
+	var cats []string
+	seen := map[string]bool{}
+	for _, tag := range tags {
+		tag = normalizeTag(tag)
+		if tag == "" {
+			continue
+		}
+		c, ok := t.aliases[tag]
+		if !ok {
+			c = t.other
+			if t.categories[tag] {
+				c = tag
+			}
+		}
+		if !seen[c] {
+			seen[c] = true
+			cats = append(cats, c)
+		}
+	}
+	if len(cats) == 0 {
+		cats = append(cats, t.other)
+	}
+	return cats
+}
+
+// normalizeTags normalizes tags and drops empty and repeated ones. They
+// become the categories of an idea when there is no taxonomy.
+func normalizeTags(tags []string) []string {
+	var out []string
+	for _, tag := range tags {
+		if tag = normalizeTag(tag); tag != "" && !slices.Contains(out, tag) {
+			out = append(out, tag)
+		}
+	}
+	return out
+}
+
+func normalizeTag(s string) string {
+	s = strings.ToLower(strings.TrimSpace(s))
+	s = strings.TrimPrefix(s, "#")
+	return strings.Join(strings.Fields(s), "-")
+}
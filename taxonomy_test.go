@@ -0,0 +1,60 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestTaxonomyCategorize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "taxonomy.json")
+	def := `{
+		"categories": ["ai", "programming", "research"],
+		"aliases": {"programming": ["Go", "golang"], "ai": ["llm", "machine learning"]}
+	}`
+	if err := os.WriteFile(path, []byte(def), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tax, err := loadTaxonomy(path)
+	if err != nil {
+		t.Fatalf("loadTaxonomy: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		tags []string
+		want []string
+	}{
+		{name: "no tags", tags: nil, want: []string{"other"}},
+		{name: "direct category", tags: []string{"Research"}, want: []string{"research"}},
+		{name: "aliases", tags: []string{"golang", "#LLM"}, want: []string{"programming", "ai"}},
+		{name: "multi-word alias", tags: []string{"Machine  Learning"}, want: []string{"ai"}},
+		{name: "unknown goes to other", tags: []string{"cooking"}, want: []string{"other"}},
+		{name: "deduplicated", tags: []string{"go", "golang", "programming"}, want: []string{"programming"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tax.categorize(tt.tags)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("categorize(%q) = %q, want %q", tt.tags, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeTags(t *testing.T) {
+	tests := []struct {
+		tags []string
+		want []string
+	}{
+		{nil, nil},
+		{[]string{"Go", "#performance", " go ", ""}, []string{"go", "performance"}},
+		{[]string{"Machine  Learning"}, []string{"machine-learning"}},
+	}
+	for _, tt := range tests {
+		if got := normalizeTags(tt.tags); !slices.Equal(got, tt.want) {
+			t.Errorf("normalizeTags(%q) = %q, want %q", tt.tags, got, tt.want)
+		}
+	}
+}
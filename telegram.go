@@ -0,0 +1,186 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// telegramClient receives voice notes sent to a Telegram bot, whose
+// webhook is set to /ideas/telegram with a secret token as described in
+// https://core.telegram.org/bots/api#setwebhook.
+type telegramClient struct {
+	apiURL       string // https://api.telegram.org, or a local Bot API server
+	botToken     string
+	secret       string         // secret_token the webhook was set with
+	allowedChats map[int64]bool // chats whose voice notes are posted
+}
+
+type telegramFile struct {
+	FileID   string `json:"file_id"`
+	FileName string `json:"file_name"` // of audio files, not voice notes
+	FileSize int64  `json:"file_size"`
+}
+
+type telegramUpdate struct {
+	Message *struct {
+		MessageID int64 `json:"message_id"`
+		Chat      struct {
+			ID int64 `json:"id"`
+		} `json:"chat"`
+		Caption string        `json:"caption"`
+		Voice   *telegramFile `json:"voice"`
+		Audio   *telegramFile `json:"audio"`
+	} `json:"message"`
+}
+
+// call requests path of the Bot API, posting payload as JSON unless it
+// is nil, and returns the response body.
+func (c *telegramClient) call(ctx context.Context, path string, payload any) ([]byte, error) {
+	httpMethod, body := "GET", io.Reader(nil)
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return nil, fmt.Errorf("marshal request: %w", err)
+		}
+		httpMethod, body = "POST", bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, httpMethod, strings.TrimRight(c.apiURL, "/")+path, body)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		// The URL holds the bot token, so it is left out.
+		if ue := (*url.Error)(nil); errors.As(err, &ue) {
+			err = ue.Err
+		}
+		return nil, fmt.Errorf("send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxAudioSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Telegram API returned %d: %s", resp.StatusCode, string(data))
+	}
+	if len(data) > maxAudioSize {
+		return nil, fmt.Errorf("file larger than %d bytes", maxAudioSize)
+	}
+	return data, nil
+}
+
+// download fetches a file sent to the bot.
+func (c *telegramClient) download(ctx context.Context, fileID string) ([]byte, error) {
+	data, err := c.call(ctx, "/bot"+c.botToken+"/getFile?file_id="+url.QueryEscape(fileID), nil)
+	if err != nil {
+		return nil, err
+	}
+	var result struct {
+		Result struct {
+			FilePath string `json:"file_path"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("unmarshal response: %w", err)
+	}
+	if result.Result.FilePath == "" {
+		return nil, fmt.Errorf("no file path for %s", fileID)
+	}
+	return c.call(ctx, "/file/bot"+c.botToken+"/"+result.Result.FilePath, nil)
+}
+
+// reply sends text to a chat in reply to one of its messages.
+func (c *telegramClient) reply(ctx context.Context, chat, message int64, text string) error {
+	_, err := c.call(ctx, "/bot"+c.botToken+"/sendMessage", map[string]any{
+		"chat_id":          chat,
+		"text":             text,
+		"reply_parameters": map[string]int64{"message_id": message},
+	})
+	return err
+}
+
+// handleTelegram handles updates of the Telegram bot. Voice notes and
+// audio files from the allowed chats are transcribed and posted like
+// those uploaded to /ideas/voice, with the caption as the title. Other
+// updates are acknowledged and ignored.
+func (s *service) handleTelegram(w http.ResponseWriter, r *http.Request) {
+	token := r.Header.Get("X-Telegram-Bot-Api-Secret-Token")
+	if subtle.ConstantTimeCompare([]byte(token), []byte(s.telegram.secret)) != 1 {
+		s.log.Printf("telegram webhook verification failed")
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	var u telegramUpdate
+	if err := json.NewDecoder(io.LimitReader(r.Body, 1<<20)).Decode(&u); err != nil {
+		http.Error(w, "invalid update", http.StatusBadRequest)
+		return
+	}
+	m := u.Message
+	if m == nil || !s.telegram.allowedChats[m.Chat.ID] {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	f := cmp.Or(m.Voice, m.Audio)
+	if f == nil {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	// Telegram redelivers updates that are not acknowledged quickly, so
+	// the note is transcribed after responding.
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+		defer cancel()
+		msg := s.postTelegramVoice(ctx, f, strings.TrimSpace(m.Caption))
+		if err := s.telegram.reply(ctx, m.Chat.ID, m.MessageID, msg); err != nil {
+			s.log.Printf("telegram reply failed: %v", err)
+		}
+	}()
+	w.WriteHeader(http.StatusOK)
+}
+
+// postTelegramVoice transcribes a voice note and queues the transcript
+// for publishing, returning a reply message.
+func (s *service) postTelegramVoice(ctx context.Context, f *telegramFile, title string) string {
+	if f.FileSize > maxAudioSize {
+		return fmt.Sprintf("The voice note is too large, the limit is %d MB.", maxAudioSize>>20)
+	}
+	audio, err := s.telegram.download(ctx, f.FileID)
+	if err != nil {
+		s.log.Printf("telegram download failed: %v", err)
+		return "Downloading the voice note failed."
+	}
+	// Voice notes are Ogg Opus files, named .oga by Telegram, which
+	// Whisper only knows as .ogg.
+	name := cmp.Or(f.FileName, "voice.ogg")
+	transcript, err := s.stt.transcribe(ctx, name, bytes.NewReader(audio))
+	if err != nil {
+		s.log.Printf("transcription failed: %v", err)
+		return "Transcription failed."
+	}
+	s.log.Printf("transcribed %s from telegram (%d bytes)", name, len(audio))
+	if _, ok := s.enqueueIdea(ideaRequest{Title: title, Content: transcript}); !ok {
+		return "Too many ideas are being published, try again later."
+	}
+	return "Transcribed, publishing in background:\n\n" + transcript
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHandleTelegram(t *testing.T) {
+	replies := make(chan map[string]any, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/bottoken/getFile":
+			if r.URL.Query().Get("file_id") != "f1" {
+				http.Error(w, `{"ok":false}`, http.StatusBadRequest)
+				return
+			}
+			io.WriteString(w, `{"ok":true,"result":{"file_id":"f1","file_path":"voice/file_1.oga"}}`)
+		case "/file/bottoken/voice/file_1.oga":
+			io.WriteString(w, "audio")
+		case "/bottoken/sendMessage":
+			var msg map[string]any
+			json.NewDecoder(r.Body).Decode(&msg)
+			replies <- msg
+			io.WriteString(w, `{"ok":true}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	l := log.New(io.Discard, "", 0)
+	dir := t.TempDir()
+	s := &service{
+		log:       l,
+		stt:       fakeSTT(t, "An idea I had on the way."),
+		jobs:      newJobStore(filepath.Join(dir, "jobs.json"), l),
+		lifecycle: newLifecycleStore(filepath.Join(dir, "lifecycle.json"), l),
+		pool:      newWorkerPool(0, 1),
+		telegram: &telegramClient{
+			apiURL:       srv.URL,
+			botToken:     "token",
+			secret:       "secret",
+			allowedChats: map[int64]bool{42: true},
+		},
+	}
+	send := func(secret, update string) int {
+		r := httptest.NewRequest("POST", "/ideas/telegram", strings.NewReader(update))
+		r.Header.Set("X-Telegram-Bot-Api-Secret-Token", secret)
+		rec := httptest.NewRecorder()
+		s.handleTelegram(rec, r)
+		return rec.Code
+	}
+	voice := func(chat string) string {
+		return `{"update_id":1,"message":{"message_id":7,"chat":{"id":` + chat + `},"caption":" Commute ",` +
+			`"voice":{"file_id":"f1","duration":3,"mime_type":"audio/ogg","file_size":5}}}`
+	}
+
+	if code := send("guess", voice("42")); code != http.StatusUnauthorized {
+		t.Errorf("wrong secret: status = %d, want 401", code)
+	}
+	if code := send("secret", `{"update_id":2,"message":{"message_id":8,"chat":{"id":42},"text":"hi"}}`); code != http.StatusOK {
+		t.Errorf("text message: status = %d, want 200", code)
+	}
+	if code := send("secret", voice("13")); code != http.StatusOK {
+		t.Errorf("other chat: status = %d, want 200", code)
+	}
+	if code := send("secret", voice("42")); code != http.StatusOK {
+		t.Errorf("voice note: status = %d, want 200", code)
+	}
+
+	select {
+	case msg := <-replies:
+		reply, _ := msg["reply_parameters"].(map[string]any)
+		if msg["chat_id"] != 42.0 || reply["message_id"] != 7.0 || !strings.HasSuffix(msg["text"].(string), "An idea I had on the way.") {
+			t.Errorf("reply = %v", msg)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the voice note was not answered")
+	}
+	jobs := s.jobs.recent()
+	if len(jobs) != 1 {
+		t.Fatalf("jobs = %+v, want only the voice note of the allowed chat", jobs)
+	}
+	if req := jobs[0].Request; req.Title != "Commute" || req.Content != "An idea I had on the way." {
+		t.Errorf("queued request = %+v", req)
+	}
+}
@@ -0,0 +1,275 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// threadPostLimit is the maximum weighted length of a post on X, which
+// counts every URL as xURLLength characters.
+const (
+	threadPostLimit = 280
+	xURLLength      = 23
+)
+
+// xClient posts to X (Twitter) with an OAuth 2.0 user access token.
+type xClient struct {
+	baseURL string // e.g. "https://api.x.com"
+	token   string // user access token with the tweet.write scope
+}
+
+// post publishes a post, as a reply if replyTo is a post ID, and returns
+// its ID.
+func (c *xClient) post(ctx context.Context, text, replyTo string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	params := map[string]any{"text": text}
+	if replyTo != "" {
+		params["reply"] = map[string]string{"in_reply_to_tweet_id": replyTo}
+	}
+	body, err := json.Marshal(params)
+	if err != nil {
+		return "", fmt.Errorf("marshal request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", strings.TrimRight(c.baseURL, "/")+"/2/tweets", bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.token)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("send request: %w", err)
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", fmt.Errorf("read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("X API returned %d: %s", resp.StatusCode, string(respBody))
+	}
+	var result struct {
+		Data struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return "", fmt.Errorf("unmarshal response: %w", err)
+	}
+	return result.Data.ID, nil
+}
+
+// postLength returns the length of a post as X counts it: URLs count as
+// 23 characters and CJK characters count double.
+func postLength(s string) int {
+	n := len(urlRe.FindAllString(s, -1)) * xURLLength
+	for _, r := range urlRe.ReplaceAllString(s, "") {
+		if unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) {
+			n += 2
+		} else {
+			n++
+		}
+	}
+	return n
+}
+
+// buildThread splits posts that are too long, appends the link to the
+// idea, and numbers the posts as "1/n".
+func buildThread(posts []string, url string) []string {
+	// Leave room for the longest numbering prefix, "99/99 ".
+	const max = threadPostLimit - len("99/99 ")
+
+	var thread []string
+	for _, p := range posts {
+		if p = strings.TrimSpace(p); p != "" {
+			thread = append(thread, splitPost(p, max)...)
+		}
+	}
+	if n := len(thread); n > 0 && postLength(thread[n-1]+"\n\n"+url) <= max {
+		thread[n-1] += "\n\n" + url
+	} else {
+		thread = append(thread, url)
+	}
+	for i := range thread {
+		thread[i] = fmt.Sprintf("%d/%d %s", i+1, len(thread), thread[i])
+	}
+	return thread
+}
+
+// splitPost splits s into parts of at most max weighted characters,
+// preferably at sentence ends, otherwise at spaces.
+func splitPost(s string, max int) []string {
+	var parts []string
+	for postLength(s) > max {
+		cut := cutPoint(s, max)
+		parts = append(parts, strings.TrimSpace(s[:cut]))
+		s = strings.TrimSpace(s[cut:])
+	}
+	if s != "" {
+		parts = append(parts, s)
+	}
+	return parts
+}
+
+// cutPoint returns the byte offset at which to split s, which is longer
+// than max.
+func cutPoint(s string, max int) int {
+	fit := 0 // longest prefix that fits
+	for i := range s {
+		if postLength(s[:i]) > max {
+			break
+		}
+		fit = i
+	}
+	if fit == 0 {
+		_, size := utf8.DecodeRuneInString(s)
+		return size // a single character does not fit
+	}
+	head := s[:fit]
+	best := -1
+	for _, sep := range []string{". ", "! ", "? ", "。", "！", "？", "\n"} {
+		if i := strings.LastIndex(head, sep); i >= 0 && i+len(sep) > best {
+			best = i + len(sep)
+		}
+	}
+	if best > fit/2 {
+		return best
+	}
+	if i := strings.LastIndex(head, " "); i > fit/3 {
+		return i + 1
+	}
+	return fit
+}
+
+const threadPrompt = `Split the following idea into a thread of short posts for X (Twitter).
+The first post is a hook that states the core idea; each following post carries one thought and stands on its own.
+Each post must be at most 250 characters. Keep links that matter.
+Do not number the posts, and do not use hashtags or emojis.
+Use the same language as the content.
+Reply with ONLY a JSON array of strings, no other text.`
+
+func (c *llmClient) splitThread(ctx context.Context, title, content string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 90*time.Second)
+	defer cancel()
+
+	prompt := fmt.Sprintf("Title: %s\n\nContent:\n%s", title, content)
+	raw, err := c.complete(ctx, c.titleModel, threadPrompt, prompt)
+	if err != nil {
+		return nil, err
+	}
+	raw = strings.TrimSpace(raw)
+	raw = strings.TrimPrefix(raw, "```json")
+	raw = strings.TrimPrefix(raw, "```")
+	raw = strings.TrimSuffix(raw, "```")
+
+	var posts []string
+	if err := json.Unmarshal([]byte(strings.TrimSpace(raw)), &posts); err != nil {
+		return nil, fmt.Errorf("parse thread response: %w (raw: %s)", err, raw)
+	}
+	if len(posts) == 0 {
+		return nil, fmt.Errorf("empty thread generated")
+	}
+	return posts, nil
+}
+
+type threadResponse struct {
+	OK     bool     `json:"ok"`
+	Posts  []string `json:"posts"`
+	Posted []string `json:"posted,omitempty"` // URLs of the posted thread
+}
+
+// handleThread turns a published idea into a numbered thread. With
+// {"post": "x"} or {"post": "mastodon"} the thread is also posted.
+func (s *service) handleThread(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Post string `json:"post"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+			s.jsonError(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+	switch {
+	case req.Post == "x" && s.x == nil:
+		s.jsonError(w, "posting to X is not configured", http.StatusBadRequest)
+		return
+	case req.Post == "mastodon" && s.mastodon == nil:
+		s.jsonError(w, "posting to Mastodon is not configured", http.StatusBadRequest)
+		return
+	case req.Post != "" && req.Post != "x" && req.Post != "mastodon":
+		s.jsonError(w, "post must be x or mastodon", http.StatusBadRequest)
+		return
+	}
+
+	idea, ok := s.index.get(r.PathValue("id"))
+	if !ok {
+		s.jsonError(w, "idea not found", http.StatusNotFound)
+		return
+	}
+	if idea.Draft && req.Post != "" {
+		s.jsonError(w, "drafts cannot be posted", http.StatusBadRequest)
+		return
+	}
+
+	content := idea.ContentEn
+	if idea.AugmentedEn != "" {
+		content += "\n\n" + strings.TrimPrefix(idea.AugmentedEn, disclaimerEn)
+	}
+	posts, err := s.llm.splitThread(r.Context(), idea.Title, content)
+	if err != nil {
+		s.log.Printf("thread generation failed: %v", err)
+		s.llmError(w, "thread generation failed", err)
+		return
+	}
+	resp := threadResponse{OK: true, Posts: buildThread(posts, s.site.url(idea.Slug))}
+
+	var replyTo string
+	for i, p := range resp.Posts {
+		var err error
+		switch req.Post {
+		case "x":
+			var id string
+			id, err = s.x.post(r.Context(), p, replyTo)
+			if err == nil {
+				replyTo = id
+				resp.Posted = append(resp.Posted, "https://x.com/i/status/"+id)
+			}
+		case "mastodon":
+			var st *mastodonStatus
+			st, err = s.mastodon.post(r.Context(), p, replyTo, fmt.Sprintf("%s-thread-%d", idea.ID, i))
+			if err == nil {
+				replyTo = st.ID
+				resp.Posted = append(resp.Posted, st.URL)
+			}
+		}
+		if err != nil {
+			s.log.Printf("posting thread of %s failed at %d/%d: %v", idea.ID, i+1, len(resp.Posts), err)
+			// The posts already made stay up, so the client is told
+			// where the thread ends.
+			writeError(w, http.StatusBadGateway, errorResponse{
+				Message: fmt.Sprintf("posting failed after %d of %d posts", i, len(resp.Posts)),
+				Posted:  resp.Posted,
+			})
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
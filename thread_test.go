@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPostLength(t *testing.T) {
+	tests := []struct {
+		input string
+		want  int
+	}{
+		{"hello", 5},
+		{"see https://example.com/a/very/long/path/that/is/shortened", 4 + xURLLength},
+		{"语言模型", 8},
+	}
+	for _, tt := range tests {
+		if got := postLength(tt.input); got != tt.want {
+			t.Errorf("postLength(%q) = %d, want %d", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestBuildThread(t *testing.T) {
+	url := "https://changkun.de/ideas/reward/"
+
+	got := buildThread([]string{"Hook.", " Second thought. "}, url)
+	want := []string{"1/2 Hook.", "2/2 Second thought.\n\n" + url}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("buildThread = %q, want %q", got, want)
+	}
+
+	long := strings.Repeat("This sentence is long enough to matter. ", 12)
+	got = buildThread([]string{long, strings.Repeat("模型", 200)}, url)
+	if len(got) < 4 {
+		t.Fatalf("long posts were not split: %q", got)
+	}
+	for _, p := range got {
+		if n := postLength(p); n > threadPostLimit {
+			t.Errorf("post has length %d > %d: %q", n, threadPostLimit, p)
+		}
+	}
+	if !strings.HasSuffix(got[0], "matter.") {
+		t.Errorf("first post is not split at a sentence end: %q", got[0])
+	}
+}
+
+func TestHandleThreadPartial(t *testing.T) {
+	llm := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"choices":[{"message":{"content":"[\"One.\", \"Two.\", \"Three.\"]"}}]}`)
+	}))
+	t.Cleanup(llm.Close)
+	var statuses int
+	mastodon := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		statuses++
+		if statuses > 1 {
+			http.Error(w, "rate limited", http.StatusTooManyRequests)
+			return
+		}
+		io.WriteString(w, `{"id":"1","url":"https://mastodon.example/@o/1"}`)
+	}))
+	t.Cleanup(mastodon.Close)
+
+	l := log.New(io.Discard, "", 0)
+	s := &service{
+		log:      l,
+		llm:      &llmClient{baseURL: llm.URL, log: l},
+		mastodon: &mastodonClient{server: mastodon.URL, token: "token", visibility: "public"},
+		index:    newArchiveIndex(filepath.Join(t.TempDir(), "index.json"), l),
+		site:     newSiteConfig("", "", "", ""),
+	}
+	s.index.put("content/ideas/2025-01-01-reward.md", "sha", testIdeaMarkdown)
+
+	r := httptest.NewRequest("POST", "/ideas/2025-01-01-reward/thread", strings.NewReader(`{"post": "mastodon"}`))
+	r.SetPathValue("id", "2025-01-01-reward")
+	rec := httptest.NewRecorder()
+	s.handleThread(rec, r)
+
+	var resp errorResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusBadGateway || len(resp.Posted) != 1 || resp.Posted[0] != "https://mastodon.example/@o/1" {
+		t.Errorf("status = %d, response = %+v; want 502 with the first post", rec.Code, resp)
+	}
+	if statuses != 2 {
+		t.Errorf("posted %d statuses, want 2", statuses)
+	}
+}
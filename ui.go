@@ -0,0 +1,208 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"cmp"
+	"html/template"
+	"maps"
+	"net/http"
+	"slices"
+)
+
+// uiTags is the number of tags offered by the web UI.
+const uiTags = 30
+
+// handleUI serves a page for posting ideas from a browser, such as on a
+// phone where the CLI is not at hand. It posts through the API with the
+// login cookie, and previews the idea through the review of its job.
+func (s *service) handleUI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	if err := uiTmpl.Execute(w, struct{ Tags []string }{popularTags(s.index.all(), uiTags)}); err != nil {
+		s.log.Printf("cannot render web UI: %v", err)
+	}
+}
+
+// popularTags returns the n tags of ideas used most, most used first.
+// Ideas published before tags were written on their own have them as
+// categories.
+func popularTags(ideas []*indexedIdea, n int) []string {
+	count := map[string]int{}
+	for _, d := range ideas {
+		tags := d.Tags
+		if len(tags) == 0 {
+			tags = d.Categories
+		}
+		for _, t := range tags {
+			count[normalizeTag(t)]++
+		}
+	}
+	delete(count, "")
+	tags := slices.SortedFunc(maps.Keys(count), func(a, b string) int {
+		return cmp.Or(count[b]-count[a], cmp.Compare(a, b))
+	})
+	return tags[:min(n, len(tags))]
+}
+
+var uiTmpl = template.Must(template.New("ui").Parse(uiHTML))
+
+const uiHTML = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>New idea</title>
+<style>
+body { font: 16px/1.4 system-ui, sans-serif; margin: 1em auto; padding: 0 1em; max-width: 40em; color: #222; }
+input[type=text], textarea { display: block; box-sizing: border-box; width: 100%; margin-bottom: 8px; font: inherit; padding: 6px; }
+.tags label { display: inline-block; margin: 0 4px 6px 0; padding: 2px 8px; border: 1px solid #ccc; border-radius: 12px; }
+.tags input { margin: 0 4px 0 0; }
+button { font: inherit; padding: 6px 14px; margin: 4px 4px 4px 0; }
+#out { white-space: pre-wrap; }
+.failed { color: #b00; }
+</style>
+</head>
+<body>
+<h1>New idea</h1>
+<form id="idea">
+<input type="text" name="title" placeholder="Title (generated if empty)">
+<textarea name="content" rows="10" placeholder="The idea" required></textarea>
+<div class="tags">
+{{range .Tags}}<label><input type="checkbox" name="tag" value="{{.}}">{{.}}</label>{{end}}
+</div>
+<input type="text" name="more" placeholder="More tags, comma-separated">
+<label><input type="checkbox" name="draft"> Draft</label>
+<div>
+<button name="preview" value="true">Preview</button>
+<button>Post</button>
+</div>
+</form>
+
+<form id="review" hidden>
+<h2 id="preview-title"></h2>
+<textarea name="markdown" rows="20"></textarea>
+<button>Publish</button>
+<button type="button" id="discard">Discard</button>
+</form>
+
+<p id="out"></p>
+
+<script>
+const form = document.getElementById("idea");
+const review = document.getElementById("review");
+const out = document.getElementById("out");
+let job = "";
+let allow = {}; // checks overridden after the server refused the idea
+
+function show(text, failed) {
+  out.textContent = text;
+  out.className = failed ? "failed" : "";
+}
+
+async function api(method, path, body) {
+  const resp = await fetch(path, {
+    method,
+    headers: {"Content-Type": "application/json", "Accept": "application/json"},
+    body: body === undefined ? undefined : JSON.stringify(body),
+  });
+  const data = await resp.json().catch(() => ({ok: false, message: resp.statusText}));
+  if (!data.ok) {
+    const err = new Error(data.message || resp.statusText);
+    err.code = data.code;
+    throw err;
+  }
+  return data;
+}
+
+// follow polls the job until it is held for review or done.
+async function follow() {
+  for (;;) {
+    const {job: j} = await api("GET", "/ideas/admin/jobs/" + job);
+    const stage = j.stages && j.stages.length ? j.stages[j.stages.length - 1].name : "";
+    switch (j.status) {
+    case "review":
+      document.getElementById("preview-title").textContent = j.preview.title;
+      review.elements.markdown.value = j.preview.markdown;
+      review.hidden = false;
+      show("Edit the idea if need be, then publish it.");
+      return;
+    case "done": {
+      show("Published: ");
+      const a = document.createElement("a");
+      a.href = a.textContent = j.url;
+      out.append(a);
+      form.reset();
+      return;
+    }
+    case "scheduled":
+      show("Scheduled for " + new Date(j.publish_at).toLocaleString() + ".");
+      form.reset();
+      return;
+    case "queued":
+      show("The repository is unavailable; the idea is committed once it is back.");
+      form.reset();
+      return;
+    case "failed":
+    case "discarded":
+      show("The idea " + j.status + (j.error ? ": " + j.error : "."), j.status == "failed");
+      return;
+    }
+    show("Working" + (stage ? " (" + stage + ")" : "") + "…");
+    await new Promise(r => setTimeout(r, 2000));
+  }
+}
+
+form.addEventListener("submit", async e => {
+  e.preventDefault();
+  const f = form.elements;
+  const tags = [...form.querySelectorAll("input[name=tag]:checked")].map(t => t.value)
+    .concat(f.more.value.split(",").map(t => t.trim()).filter(t => t));
+  const idea = {
+    title: f.title.value.trim(),
+    content: f.content.value,
+    draft: f.draft.checked,
+    review: e.submitter && e.submitter.name == "preview",
+    ...allow,
+  };
+  if (tags.length) idea.tags = tags;
+  review.hidden = true;
+  show("Posting…");
+  try {
+    job = (await api("POST", "/ideas/post", idea)).job;
+    allow = {};
+    await follow();
+  } catch (err) {
+    if (err.code == "duplicate") allow.allow_duplicate = true;
+    if (err.code == "secret_detected") allow.allow_secrets = true;
+    show(err.message + (err.code in {duplicate: 1, secret_detected: 1} ? "\nSubmit again to post it anyway." : ""), true);
+  }
+});
+
+review.addEventListener("submit", async e => {
+  e.preventDefault();
+  review.hidden = true;
+  try {
+    await api("POST", "/ideas/admin/jobs/" + job + "/approve", {markdown: review.elements.markdown.value});
+    await follow();
+  } catch (err) {
+    review.hidden = false;
+    show(err.message, true);
+  }
+});
+
+document.getElementById("discard").addEventListener("click", async () => {
+  review.hidden = true;
+  try {
+    await api("POST", "/ideas/admin/jobs/" + job + "/discard");
+    show("Discarded.");
+  } catch (err) {
+    show(err.message, true);
+  }
+});
+</script>
+</body>
+</html>
+`
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestPopularTags(t *testing.T) {
+	ideas := []*indexedIdea{
+		{Tags: []string{"go", "ai"}},
+		{Tags: []string{"Go"}},
+		{Categories: []string{"research", "ai"}},
+		{Tags: []string{"rust"}, Categories: []string{"programming"}},
+	}
+	if got, want := popularTags(ideas, 3), []string{"ai", "go", "research"}; !slices.Equal(got, want) {
+		t.Errorf("popularTags = %q, want %q", got, want)
+	}
+	if got := popularTags(nil, 3); len(got) != 0 {
+		t.Errorf("popularTags of no ideas = %q", got)
+	}
+}
+
+func TestHandleUI(t *testing.T) {
+	l := log.New(io.Discard, "", 0)
+	s := &service{log: l, index: newArchiveIndex(filepath.Join(t.TempDir(), "index.json"), l)}
+	s.index.put("content/ideas/2025-01-01-a.md", "sha", strings.Replace(testIdeaMarkdown, "\n---\n", "\ntags: [\"<go>\"]\n---\n", 1))
+	rec := httptest.NewRecorder()
+	s.handleUI(rec, httptest.NewRequest("GET", "/ideas/ui", nil))
+	body := rec.Body.String()
+	if rec.Code != http.StatusOK || !strings.Contains(body, "<textarea name=\"content\"") || !strings.Contains(body, `value="&lt;go&gt;"`) {
+		t.Errorf("ui = %d %s", rec.Code, body)
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// usageRetention is how long daily token usage is kept.
+const usageRetention = 400 * 24 * time.Hour
+
+// usageMeter accounts LLM token usage per day and model. Usage made on
+// behalf of a pipeline job is also attributed to that job.
+type usageMeter struct {
+	path string
+	log  *log.Logger
+	jobs *jobStore // optional
+
+	mu   sync.Mutex
+	days map[string]map[string]*tokenUsage // "2006-01-02" -> model -> usage
+}
+
+type tokenUsage struct {
+	Requests         int `json:"requests"`
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+}
+
+func (u tokenUsage) Tokens() int { return u.PromptTokens + u.CompletionTokens }
+
+func (u *tokenUsage) add(v tokenUsage) {
+	u.Requests += v.Requests
+	u.PromptTokens += v.PromptTokens
+	u.CompletionTokens += v.CompletionTokens
+}
+
+func newUsageMeter(path string, l *log.Logger, jobs *jobStore) *usageMeter {
+	m := &usageMeter{path: path, log: l, jobs: jobs, days: map[string]map[string]*tokenUsage{}}
+	if err := readJSONFile(path, &m.days); err != nil {
+		l.Printf("cannot load token usage: %v", err)
+	}
+	return m
+}
+
+// record adds the usage of one API request. It is safe to call on a
+// nil meter.
+func (m *usageMeter) record(ctx context.Context, model string, u tokenUsage) {
+	if m == nil {
+		return
+	}
+	u.Requests = 1
+	if id := jobIDFromContext(ctx); id != "" && m.jobs != nil {
+		m.jobs.addTokens(id, u.Tokens())
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	day := now.Format(time.DateOnly)
+	if m.days[day] == nil {
+		m.days[day] = map[string]*tokenUsage{}
+	}
+	if m.days[day][model] == nil {
+		m.days[day][model] = &tokenUsage{}
+	}
+	m.days[day][model].add(u)
+
+	cutoff := now.Add(-usageRetention).Format(time.DateOnly)
+	for d := range m.days {
+		if d < cutoff {
+			delete(m.days, d)
+		}
+	}
+	if err := writeJSONFile(m.path, m.days); err != nil {
+		m.log.Printf("cannot save token usage: %v", err)
+	}
+}
+
+// usageSummary is the token usage of a period, total and per model.
+type usageSummary struct {
+	Total   tokenUsage            `json:"total"`
+	ByModel map[string]tokenUsage `json:"by_model"`
+}
+
+// summary sums the usage of all days with the given date prefix, e.g.
+// "2025-06" for a month or "2025-06-01" for a day.
+func (m *usageMeter) summary(prefix string) usageSummary {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s := usageSummary{ByModel: map[string]tokenUsage{}}
+	for day, models := range m.days {
+		if !strings.HasPrefix(day, prefix) {
+			continue
+		}
+		for model, u := range models {
+			s.Total.add(*u)
+			mu := s.ByModel[model]
+			mu.add(*u)
+			s.ByModel[model] = mu
+		}
+	}
+	return s
+}
@@ -0,0 +1,138 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// userConfig is an entry of IDEAS_USERS_FILE, which maps the users of
+// login tokens and API keys to their own blogs. Empty fields take the
+// server's settings. Tokens are not kept in the file, only the name of
+// the variable that holds them.
+type userConfig struct {
+	Repo           string `json:"repo"`      // owner/repo, required
+	TokenEnv       string `json:"token_env"` // defaults to GIT_TOKEN
+	CommitterName  string `json:"committer_name"`
+	CommitterEmail string `json:"committer_email"`
+	Generator      string `json:"generator"`
+	IdeasDir       string `json:"ideas_dir"`
+	DraftsDir      string `json:"drafts_dir"`
+	SiteURL        string `json:"site_url"` // required
+}
+
+// userSite is where the ideas of a user are published.
+type userSite struct {
+	github *githubClient
+	site   siteConfig
+}
+
+// loadUserSites reads the users file at path, filling in what the
+// entries leave out from the server's repository gh and site.
+func loadUserSites(path string, gh *githubClient, site siteConfig) (map[string]userSite, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read users file: %w", err)
+	}
+	var cfg map[string]userConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parse users file: %w", err)
+	}
+	users := make(map[string]userSite, len(cfg))
+	for user, u := range cfg {
+		owner, repo, ok := strings.Cut(u.Repo, "/")
+		if !ok || owner == "" || repo == "" {
+			return nil, fmt.Errorf("user %s: repo must be in owner/repo format, got: %q", user, u.Repo)
+		}
+		if u.SiteURL == "" {
+			return nil, fmt.Errorf("user %s: site_url is required", user)
+		}
+		token := gh.token
+		if u.TokenEnv != "" {
+			if token, err = envSecret(u.TokenEnv); err != nil {
+				return nil, fmt.Errorf("user %s: %w", user, err)
+			}
+			if token == nil {
+				return nil, fmt.Errorf("user %s: %s is not set", user, u.TokenEnv)
+			}
+		}
+		users[user] = userSite{
+			github: &githubClient{
+				token:  token,
+				owner:  owner,
+				repo:   repo,
+				name:   cmp.Or(u.CommitterName, gh.name),
+				email:  cmp.Or(u.CommitterEmail, gh.email),
+				apiURL: gh.apiURL,
+			},
+			site: newSiteConfig(
+				cmp.Or(u.Generator, site.generator),
+				cmp.Or(u.IdeasDir, site.ideasDir),
+				u.DraftsDir,
+				u.SiteURL,
+			),
+		}
+	}
+	return users, nil
+}
+
+// userSite returns where the ideas of user are published, reporting
+// false if it is the server's own repository, as for users not in the
+// users file.
+func (s *service) userSite(user string) (userSite, bool) {
+	if us, ok := s.users[user]; ok && user != "" {
+		return us, false
+	}
+	return userSite{github: s.github, site: s.site}, true
+}
+
+// isOwner reports whether the caller of ctx publishes to the server's
+// own blog, as everyone not in the users file does.
+func (s *service) isOwner(ctx context.Context) bool {
+	p, _ := principalFromContext(ctx)
+	_, own := s.userSite(p.User)
+	return own
+}
+
+// actsFor reports whether the caller of ctx may see and change what
+// user made, such as a draft: they are user, or both publish to the
+// server's own blog.
+func (s *service) actsFor(ctx context.Context, user string) bool {
+	p, _ := principalFromContext(ctx)
+	_, own := s.userSite(user)
+	return p.User == user || own && s.isOwner(ctx)
+}
+
+// canSeeJob reports whether the caller of ctx may follow and act on j:
+// they act for who posted it, or administer the server's own blog, as
+// on the dashboard.
+func (s *service) canSeeJob(ctx context.Context, j job) bool {
+	p, _ := principalFromContext(ctx)
+	return s.actsFor(ctx, j.User) || s.isOwner(ctx) && p.can(scopeAdmin)
+}
+
+// ownerOnly refuses h, which reads or changes the server's own archive
+// or accounts, to the users of the users file.
+func (s *service) ownerOnly(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !s.isOwner(r.Context()) {
+			s.jsonError(w, "only the owner of the server's blog may do this", http.StatusForbidden)
+			return
+		}
+		h(w, r)
+	}
+}
+
+// requestUser returns who made r, or "" for public endpoints.
+func requestUser(r *http.Request) string {
+	p, _ := principalFromContext(r.Context())
+	return p.User
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLoadUserSites(t *testing.T) {
+	gh := &githubClient{token: &secret{val: "server-token"}, owner: "changkun", repo: "blog", name: "Ideas", email: "ideas@example.com"}
+	site := newSiteConfig("hugo", "content/ideas", "", "https://changkun.de/ideas/")
+	t.Setenv("ALICE_TOKEN", "alice-token")
+	for _, tt := range []struct {
+		name    string
+		file    string
+		wantErr string
+	}{
+		{"invalid", `{`, "parse users file"},
+		{"no repo", `{"alice": {"site_url": "https://alice.dev/ideas/"}}`, "owner/repo"},
+		{"no site", `{"alice": {"repo": "alice/site"}}`, "site_url is required"},
+		{"token unset", `{"alice": {"repo": "alice/site", "site_url": "https://alice.dev/", "token_env": "NO_SUCH_TOKEN"}}`, "NO_SUCH_TOKEN is not set"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "users.json")
+			os.WriteFile(path, []byte(tt.file), 0o644)
+			if _, err := loadUserSites(path, gh, site); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("loadUserSites = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	path := filepath.Join(t.TempDir(), "users.json")
+	os.WriteFile(path, []byte(`{
+		"alice": {"repo": "alice/site", "token_env": "ALICE_TOKEN", "committer_name": "Alice", "generator": "jekyll", "ideas_dir": "_ideas", "site_url": "https://alice.dev/ideas/"},
+		"bob": {"repo": "bob/blog", "site_url": "https://bob.dev/ideas/"}
+	}`), 0o644)
+	users, err := loadUserSites(path, gh, site)
+	if err != nil {
+		t.Fatal(err)
+	}
+	alice, bob := users["alice"], users["bob"]
+	if g := alice.github; g.token.value() != "alice-token" || g.owner != "alice" || g.repo != "site" || g.name != "Alice" || g.email != gh.email {
+		t.Errorf("alice's repository = %+v", g)
+	}
+	if alice.site.filePath("a.md", true) != "_drafts/a.md" || alice.site.filePath("a.md", false) != "_ideas/a.md" {
+		t.Errorf("alice's site = %+v", alice.site)
+	}
+	if g := bob.github; g.token != gh.token || g.owner != "bob" || g.name != gh.name {
+		t.Errorf("bob's repository = %+v", g)
+	}
+	if bob.site.url("x") != "https://bob.dev/ideas/x/" || bob.site.filePath("x.md", false) != "content/ideas/x.md" {
+		t.Errorf("bob's site = %+v", bob.site)
+	}
+}
+
+func TestUserPublish(t *testing.T) {
+	// The server's repository is down, so ideas committed there would be
+	// queued.
+	files, aliceFiles := map[string]string{}, map[string]string{}
+	var down atomic.Bool
+	down.Store(true)
+	s := commitTestService(t, files, &down)
+	s.users = map[string]userSite{"alice": {
+		github: fakeGitHub(t, aliceFiles),
+		site:   newSiteConfig("", "ideas", "", "https://alice.dev/ideas/"),
+	}}
+
+	req := ideaRequest{Title: "Reward hacking", Content: "Models exploit rewards.", date: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), user: "alice"}
+	id := s.startJob(req)
+	p, err := s.runStages(&pipelineRun{id: id, req: req, slug: "reward"}, []string{"publish"})
+	if err != nil || p == nil || p.queued {
+		t.Fatalf("runStages = %+v, %v", p, err)
+	}
+	if _, ok := aliceFiles["/repos/o/r/contents/ideas/2025-01-01-reward.md"]; !ok {
+		t.Errorf("not committed to alice's repository: %v", aliceFiles)
+	}
+	if j, _ := s.jobs.get(id); j.URL != "https://alice.dev/ideas/reward/" || j.User != "alice" {
+		t.Errorf("job = %+v", j)
+	}
+	if _, ok := s.index.get("2025-01-01-reward"); ok {
+		t.Error("an idea of another user was indexed")
+	}
+
+	// Retries of queued commits go to the same repository.
+	s.commits.add(queuedCommit{Job: "queued", Path: "ideas/queued.md", Markdown: "queued", User: "alice"})
+	s.commitQueued(context.Background(), time.Now().Add(time.Hour))
+	if _, ok := aliceFiles["/repos/o/r/contents/ideas/queued.md"]; !ok || len(s.commits.list()) != 0 {
+		t.Errorf("queued commit not retried to alice's repository: %v", aliceFiles)
+	}
+}
+
+func TestUserAccess(t *testing.T) {
+	l := log.New(io.Discard, "", 0)
+	dir := t.TempDir()
+	s := &service{
+		log:       l,
+		jobs:      newJobStore(filepath.Join(dir, "jobs.json"), l),
+		lifecycle: newLifecycleStore(filepath.Join(dir, "lifecycle.json"), l),
+		drafts:    newDraftStore(filepath.Join(dir, "drafts.json"), l),
+		users: map[string]userSite{
+			"alice": {site: newSiteConfig("", "", "", "https://alice.dev/ideas/")},
+			"bob":   {site: newSiteConfig("", "", "", "https://bob.dev/ideas/")},
+		},
+	}
+	owner := principal{User: "changkun", Scopes: []string{scopeAdmin}}
+	writer := principal{Scopes: []string{scopeWrite}} // the shared API key
+	alice := principal{User: "alice", Scopes: []string{scopeWrite}}
+	bob := principal{User: "bob", Scopes: []string{scopeAdmin}}
+	as := func(p principal, method, path string) *http.Request {
+		r := httptest.NewRequest(method, path, nil)
+		return r.WithContext(withPrincipal(r.Context(), p))
+	}
+
+	d := scopeDefaults{owner: []string{scopeAdmin}, users: s.users}
+	if got := d.of("alice"); !slices.Equal(got, []string{scopeWrite}) {
+		t.Errorf("default scopes of alice = %v", got)
+	}
+	if got := d.of("changkun"); !slices.Equal(got, []string{scopeAdmin}) {
+		t.Errorf("default scopes of the owner = %v", got)
+	}
+
+	h := s.ownerOnly(func(w http.ResponseWriter, r *http.Request) {})
+	for _, tt := range []struct {
+		p    principal
+		want int
+	}{{owner, http.StatusOK}, {writer, http.StatusOK}, {alice, http.StatusForbidden}, {bob, http.StatusForbidden}} {
+		rec := httptest.NewRecorder()
+		h(rec, as(tt.p, "PUT", "/ideas/2025-01-01-a"))
+		if rec.Code != tt.want {
+			t.Errorf("%q editing the owner's idea = %d, want %d", tt.p.User, rec.Code, tt.want)
+		}
+	}
+
+	ownerJob := s.startJob(ideaRequest{Content: "the owner's"})
+	aliceJob := s.startJob(ideaRequest{Content: "alice's", user: "alice"})
+	for _, tt := range []struct {
+		p    principal
+		id   string
+		want bool
+	}{
+		{owner, ownerJob, true},
+		{writer, ownerJob, true},
+		{alice, ownerJob, false},
+		{alice, aliceJob, true},
+		{bob, aliceJob, false},
+		{owner, aliceJob, true}, // administers the server
+		{writer, aliceJob, false},
+	} {
+		r := as(tt.p, "GET", "/ideas/admin/jobs/"+tt.id)
+		r.SetPathValue("id", tt.id)
+		if _, ok := s.requestedJob(r); ok != tt.want {
+			t.Errorf("%q sees job of %q = %v, want %v", tt.p.User, tt.id, ok, tt.want)
+		}
+	}
+
+	ownerDraft, _ := s.drafts.put("", "changkun", ideaRequest{Content: "the owner's"})
+	aliceDraft, _ := s.drafts.put("", "alice", ideaRequest{Content: "alice's"})
+	list := func(p principal) []string {
+		rec := httptest.NewRecorder()
+		s.handleListDrafts(rec, as(p, "GET", "/ideas/drafts"))
+		var resp struct{ Drafts []savedDraft }
+		json.NewDecoder(rec.Body).Decode(&resp)
+		var ids []string
+		for _, d := range resp.Drafts {
+			ids = append(ids, d.ID)
+		}
+		return ids
+	}
+	if got := list(alice); !slices.Equal(got, []string{aliceDraft.ID}) {
+		t.Errorf("alice's drafts = %v", got)
+	}
+	if got := list(writer); !slices.Equal(got, []string{ownerDraft.ID}) {
+		t.Errorf("the owner's drafts = %v", got)
+	}
+	if got := list(bob); len(got) != 0 {
+		t.Errorf("bob's drafts = %v", got)
+	}
+	r := as(bob, "DELETE", "/ideas/drafts/"+aliceDraft.ID)
+	r.SetPathValue("id", aliceDraft.ID)
+	rec := httptest.NewRecorder()
+	s.handleDeleteDraft(rec, r)
+	if _, ok := s.drafts.get(aliceDraft.ID); rec.Code != http.StatusNotFound || !ok {
+		t.Errorf("bob deleting alice's draft = %d", rec.Code)
+	}
+}
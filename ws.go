@@ -0,0 +1,276 @@
+// Copyright 2025 Changkun Ou. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Types of idea status messages.
+const (
+	statusPublished = "published"
+	statusUpdated   = "updated"
+	statusFailed    = "failed"
+)
+
+// ideaStatus is pushed to the clients of /ideas/ws when an idea is
+// published, updated, or fails to publish.
+type ideaStatus struct {
+	Type  string    `json:"type"`
+	Job   string    `json:"job,omitempty"` // published or failed
+	ID    string    `json:"id,omitempty"`  // updated
+	Title string    `json:"title,omitempty"`
+	Draft bool      `json:"draft,omitempty"`
+	Path  string    `json:"path,omitempty"`
+	URL   string    `json:"url,omitempty"`
+	Error string    `json:"error,omitempty"`
+	Time  time.Time `json:"time"`
+
+	user string // who posted the idea, "" for the server's own archive
+}
+
+// statusHub fans idea status messages out to the connected clients. The
+// zero value has no clients.
+type statusHub struct {
+	mu   sync.Mutex
+	subs map[chan ideaStatus]struct{}
+}
+
+// subscribe returns a channel of the messages broadcast from now on, and
+// a function to stop receiving them.
+func (h *statusHub) subscribe() (<-chan ideaStatus, func()) {
+	ch := make(chan ideaStatus, 16)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.subs == nil {
+		h.subs = map[chan ideaStatus]struct{}{}
+	}
+	h.subs[ch] = struct{}{}
+	return ch, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		delete(h.subs, ch)
+	}
+}
+
+// broadcast sends st to every client. Clients too slow to keep up miss
+// it rather than hold up the pipeline.
+func (h *statusHub) broadcast(st ideaStatus) {
+	if st.Time.IsZero() {
+		st.Time = time.Now()
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subs {
+		select {
+		case ch <- st:
+		default:
+		}
+	}
+}
+
+// WebSocket opcodes, RFC 6455 section 5.2.
+const (
+	wsText  = 0x1
+	wsClose = 0x8
+	wsPing  = 0x9
+	wsPong  = 0xa
+)
+
+// wsGUID is appended to the client's key for the accept header.
+const wsGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// wsMaxFrame bounds the frames read from clients, which only send
+// control frames.
+const wsMaxFrame = 4096
+
+// wsWriteTimeout bounds writing a frame, so that a client that stopped
+// reading is dropped rather than blocking its handler forever.
+const wsWriteTimeout = 10 * time.Second
+
+// handleStatusSocket upgrades to a WebSocket and pushes an ideaStatus as
+// a JSON text message whenever an idea is published, updated, or fails,
+// until the client goes away. Clients only get the messages of the jobs
+// they may see, as on the dashboard. Browsers may only connect from this
+// host.
+func (s *service) handleStatusSocket(w http.ResponseWriter, r *http.Request) {
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if !headerHas(r.Header, "Connection", "upgrade") || !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") || key == "" {
+		s.jsonError(w, "expected a WebSocket upgrade", http.StatusBadRequest)
+		return
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		s.jsonError(w, "unsupported WebSocket version", http.StatusUpgradeRequired)
+		return
+	}
+	if origin := r.Header.Get("Origin"); origin != "" {
+		if u, err := url.Parse(origin); err != nil || u.Host != r.Host {
+			s.jsonError(w, "cross-origin WebSocket not allowed", http.StatusForbidden)
+			return
+		}
+	}
+	conn, rw, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		s.jsonError(w, "cannot upgrade the connection", http.StatusInternalServerError)
+		return
+	}
+	defer conn.Close()
+	// Reads wait for the client as long as it is connected; writes do not.
+	conn.SetDeadline(time.Time{})
+	conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+
+	// Subscribe before the handshake completes, so that the client
+	// misses nothing once it is connected.
+	updates, cancel := s.status.subscribe()
+	defer cancel()
+	fmt.Fprintf(rw, "HTTP/1.1 101 Switching Protocols\r\n"+
+		"Upgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: %s\r\n\r\n", wsAccept(key))
+	if err := rw.Flush(); err != nil {
+		return
+	}
+
+	// Frames are written here only; the reader hands pongs and the
+	// closing handshake over.
+	control := make(chan []byte, 1)
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			op, payload, err := readWSFrame(rw.Reader, true)
+			if err != nil {
+				return
+			}
+			switch op {
+			case wsPing:
+				select {
+				case control <- payload:
+				default:
+				}
+			case wsClose:
+				return
+			}
+		}
+	}()
+
+	write := func(op byte, payload []byte) error {
+		conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+		return writeWSFrame(rw.Writer, op, payload)
+	}
+	ping := time.NewTicker(30 * time.Second)
+	defer ping.Stop()
+	for {
+		var err error
+		select {
+		case st := <-updates:
+			if !s.canSeeJob(r.Context(), job{User: st.user}) {
+				continue
+			}
+			data, _ := json.Marshal(st)
+			err = write(wsText, data)
+		case payload := <-control:
+			err = write(wsPong, payload)
+		case <-ping.C:
+			err = write(wsPing, nil)
+		case <-closed:
+			write(wsClose, nil)
+			return
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// headerHas reports whether the comma-separated header contains token,
+// ignoring case.
+func headerHas(h http.Header, name, token string) bool {
+	for _, v := range h.Values(name) {
+		for _, t := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// wsAccept returns the Sec-WebSocket-Accept for the client's key.
+func wsAccept(key string) string {
+	sum := sha1.Sum([]byte(key + wsGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// writeWSFrame writes an unmasked, unfragmented frame and flushes it.
+func writeWSFrame(w *bufio.Writer, op byte, payload []byte) error {
+	w.WriteByte(0x80 | op)
+	switch n := len(payload); {
+	case n < 126:
+		w.WriteByte(byte(n))
+	case n <= 0xffff:
+		w.WriteByte(126)
+		binary.Write(w, binary.BigEndian, uint16(n))
+	default:
+		w.WriteByte(127)
+		binary.Write(w, binary.BigEndian, uint64(n))
+	}
+	w.Write(payload)
+	return w.Flush()
+}
+
+// readWSFrame reads a frame, unmasking its payload. With masked, as for
+// frames from clients, unmasked frames are an error; so are frames
+// larger than wsMaxFrame.
+func readWSFrame(r *bufio.Reader, masked bool) (op byte, payload []byte, err error) {
+	var h [2]byte
+	if _, err := io.ReadFull(r, h[:]); err != nil {
+		return 0, nil, err
+	}
+	op = h[0] & 0x0f
+	if h[1]&0x80 == 0 && masked {
+		return 0, nil, errors.New("unmasked client frame")
+	}
+	n := uint64(h[1] & 0x7f)
+	switch n {
+	case 126:
+		var l uint16
+		err = binary.Read(r, binary.BigEndian, &l)
+		n = uint64(l)
+	case 127:
+		err = binary.Read(r, binary.BigEndian, &n)
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+	if n > wsMaxFrame {
+		return 0, nil, fmt.Errorf("frame of %d bytes is too large", n)
+	}
+	var mask [4]byte
+	if h[1]&0x80 != 0 {
+		if _, err := io.ReadFull(r, mask[:]); err != nil {
+			return 0, nil, err
+		}
+	}
+	payload = make([]byte, n)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return op, payload, nil
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHandleStatusSocket(t *testing.T) {
+	s := &service{log: log.New(io.Discard, "", 0)}
+	srv := httptest.NewServer(http.HandlerFunc(s.handleStatusSocket))
+	defer srv.Close()
+	host := strings.TrimPrefix(srv.URL, "http://")
+
+	for _, tt := range []struct {
+		name    string
+		headers string
+		want    int
+	}{
+		{"no upgrade", "", http.StatusBadRequest},
+		{"old version", "Upgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Key: a2V5\r\nSec-WebSocket-Version: 8\r\n", http.StatusUpgradeRequired},
+		{"cross origin", "Upgrade: websocket\r\nConnection: keep-alive, Upgrade\r\nSec-WebSocket-Key: a2V5\r\nSec-WebSocket-Version: 13\r\nOrigin: https://evil.example\r\n", http.StatusForbidden},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", srv.URL, nil)
+			for _, h := range strings.Split(strings.TrimSpace(tt.headers), "\r\n") {
+				if k, v, ok := strings.Cut(h, ": "); ok {
+					req.Header.Set(k, v)
+				}
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.want {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.want)
+			}
+		})
+	}
+
+	conn, err := net.Dial("tcp", host)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	key := "dGhlIHNhbXBsZSBub25jZQ=="
+	io.WriteString(conn, "GET /ideas/ws HTTP/1.1\r\nHost: "+host+"\r\nOrigin: "+srv.URL+
+		"\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Key: "+key+"\r\nSec-WebSocket-Version: 13\r\n\r\n")
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The accept value of the key in RFC 6455, section 1.3.
+	if resp.StatusCode != http.StatusSwitchingProtocols || resp.Header.Get("Sec-WebSocket-Accept") != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("handshake = %d %v", resp.StatusCode, resp.Header)
+	}
+
+	s.status.broadcast(ideaStatus{Type: statusPublished, Job: "j1", Title: "Reward hacking", URL: "https://example.com/ideas/reward/"})
+	op, payload, err := readWSFrame(br, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var st ideaStatus
+	if err := json.Unmarshal(payload, &st); op != wsText || err != nil {
+		t.Fatalf("frame %#x %q: %v", op, payload, err)
+	}
+	if st.Type != statusPublished || st.Job != "j1" || st.Title != "Reward hacking" || st.Time.IsZero() {
+		t.Errorf("status = %+v", st)
+	}
+
+	// A masked ping is answered with a pong of the same payload.
+	mask := []byte{1, 2, 3, 4}
+	frame := []byte{0x80 | wsPing, 0x80 | 2}
+	frame = append(frame, mask...)
+	frame = append(frame, 'h'^mask[0], 'i'^mask[1])
+	conn.Write(frame)
+	op, payload, err = readWSFrame(br, false)
+	if err != nil || op != wsPong || string(payload) != "hi" {
+		t.Fatalf("pong = %#x %q, %v", op, payload, err)
+	}
+
+	// Closing unsubscribes the client.
+	conn.Write(append([]byte{0x80 | wsClose, 0x80}, mask...))
+	if op, _, err := readWSFrame(br, false); err != nil || op != wsClose {
+		t.Fatalf("close = %#x, %v", op, err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for {
+		s.status.mu.Lock()
+		n := len(s.status.subs)
+		s.status.mu.Unlock()
+		if n == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d clients still subscribed", n)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestStatusSocketUsers(t *testing.T) {
+	s := &service{
+		log: log.New(io.Discard, "", 0),
+		users: map[string]userSite{
+			"alice": {site: newSiteConfig("", "", "", "https://alice.dev/ideas/")},
+			"bob":   {site: newSiteConfig("", "", "", "https://bob.dev/ideas/")},
+		},
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p := principal{User: r.URL.Query().Get("user"), Scopes: []string{scopeRead}}
+		s.handleStatusSocket(w, r.WithContext(withPrincipal(r.Context(), p)))
+	}))
+	defer srv.Close()
+	host := strings.TrimPrefix(srv.URL, "http://")
+	dial := func(user string) *bufio.Reader {
+		conn, err := net.Dial("tcp", host)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { conn.Close() })
+		conn.SetDeadline(time.Now().Add(5 * time.Second))
+		io.WriteString(conn, "GET /ideas/ws?user="+user+" HTTP/1.1\r\nHost: "+host+
+			"\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Key: a2V5\r\nSec-WebSocket-Version: 13\r\n\r\n")
+		br := bufio.NewReader(conn)
+		if resp, err := http.ReadResponse(br, nil); err != nil || resp.StatusCode != http.StatusSwitchingProtocols {
+			t.Fatalf("handshake of %s = %v, %v", user, resp, err)
+		}
+		return br
+	}
+	next := func(br *bufio.Reader) string {
+		t.Helper()
+		_, payload, err := readWSFrame(br, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var st ideaStatus
+		json.Unmarshal(payload, &st)
+		return st.Job
+	}
+
+	alice, bob := dial("alice"), dial("bob")
+	s.status.broadcast(ideaStatus{Type: statusPublished, Job: "a1", user: "alice"})
+	s.status.broadcast(ideaStatus{Type: statusFailed, Job: "b1", Error: "boom", user: "bob"})
+	s.status.broadcast(ideaStatus{Type: statusUpdated, ID: "2025-01-01-a"})
+	s.status.broadcast(ideaStatus{Type: statusPublished, Job: "a2", user: "alice"})
+	if a, b := next(alice), next(bob); a != "a1" || b != "b1" {
+		t.Errorf("first messages = %q, %q; want each user's own job", a, b)
+	}
+	if a := next(alice); a != "a2" {
+		t.Errorf("alice got %q after her job, want only her next one", a)
+	}
+}